@@ -3,20 +3,26 @@ package main
 import (
 	"log"
 	"os"
+	"path/filepath"
 
 	"github.com/joho/godotenv"
+	"github.com/sazardev/go-money/internal/apperrors"
 	"github.com/sazardev/go-money/internal/cmd"
+	"github.com/sazardev/go-money/internal/paths"
 )
 
 func main() {
-	// Load environment variables from .env file
-	if err := godotenv.Load(); err != nil {
+	// Load environment variables from the .env file of the working
+	// directory, then from the one in the configuration directory, which
+	// GM_CONFIG_DIR set in the first may point to. Variables already set win.
+	errLocal := godotenv.Load()
+	errConfig := godotenv.Load(filepath.Join(paths.Default().Config, ".env"))
+	if errLocal != nil && errConfig != nil {
 		log.Println("No .env file found, using system environment variables")
 	}
 
-	// Execute root command
+	// Execute root command; errors are already reported by Execute
 	if err := cmd.Execute(); err != nil {
-		log.Fatalf("Error executing command: %v", err)
-		os.Exit(1)
+		os.Exit(apperrors.ExitCode(err))
 	}
 }
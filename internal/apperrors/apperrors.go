@@ -0,0 +1,102 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors shared across packages. Wrap them with fmt.Errorf("%w")
+// so callers can classify failures with errors.Is.
+var (
+	// ErrAuthRequired means there is no usable OAuth token and the user must log in
+	ErrAuthRequired = errors.New("authentication required")
+	// ErrTrackerConfig means the user's tracker-mails.json is missing or malformed
+	ErrTrackerConfig = errors.New("invalid tracker configuration")
+	// ErrQuotaExceeded means the Gmail API rejected a request due to rate limits or quota
+	ErrQuotaExceeded = errors.New("gmail API quota exceeded")
+	// ErrInvalidInput means a flag or argument could not be parsed
+	ErrInvalidInput = errors.New("invalid input")
+
+	// ErrTokenRevoked means the saved refresh token was refused
+	// (invalid_grant), because it expired or access was revoked
+	ErrTokenRevoked = fmt.Errorf("%w: the saved sign-in has expired or was revoked", ErrAuthRequired)
+	// ErrInsufficientScope means the token doesn't grant read access to Gmail
+	ErrInsufficientScope = fmt.Errorf("%w: the Google sign-in doesn't allow reading Gmail", ErrAuthRequired)
+	// ErrAPIDisabled means the Gmail API isn't enabled in the Google Cloud
+	// project the OAuth client belongs to
+	ErrAPIDisabled = errors.New("the Gmail API is not enabled for this Google Cloud project")
+)
+
+// Process exit codes returned by the gm binary
+const (
+	ExitOK            = 0
+	ExitFailure       = 1
+	ExitInvalidInput  = 2
+	ExitAuthRequired  = 3
+	ExitTrackerConfig = 4
+	ExitQuotaExceeded = 5
+)
+
+// ExitCode maps an error to the process exit code scripts can react to
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrInvalidInput):
+		return ExitInvalidInput
+	case errors.Is(err, ErrAuthRequired):
+		return ExitAuthRequired
+	case errors.Is(err, ErrTrackerConfig):
+		return ExitTrackerConfig
+	case errors.Is(err, ErrQuotaExceeded):
+		return ExitQuotaExceeded
+	default:
+		return ExitFailure
+	}
+}
+
+// Code returns a stable, machine-readable identifier for an error
+func Code(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrInvalidInput):
+		return "invalid_input"
+	case errors.Is(err, ErrTokenRevoked):
+		return "token_revoked"
+	case errors.Is(err, ErrInsufficientScope):
+		return "insufficient_scope"
+	case errors.Is(err, ErrAPIDisabled):
+		return "api_disabled"
+	case errors.Is(err, ErrAuthRequired):
+		return "auth_required"
+	case errors.Is(err, ErrTrackerConfig):
+		return "tracker_config"
+	case errors.Is(err, ErrQuotaExceeded):
+		return "quota_exceeded"
+	default:
+		return "error"
+	}
+}
+
+// Hint returns a short suggestion on how to fix an error, if one is known
+func Hint(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidInput):
+		return "Check the command flags with --help"
+	case errors.Is(err, ErrTokenRevoked):
+		return "Run 'gm auth login' to sign in again. Google OAuth clients whose consent screen is in Testing mode must sign in again every 7 days; publish the app to avoid this"
+	case errors.Is(err, ErrInsufficientScope):
+		return "Run 'gm auth login' and tick the box allowing gm to read your email on Google's consent screen"
+	case errors.Is(err, ErrAPIDisabled):
+		return "Enable the Gmail API at https://console.cloud.google.com/apis/library/gmail.googleapis.com for the project of your OAuth client, wait a few minutes and try again"
+	case errors.Is(err, ErrAuthRequired):
+		return "Run 'gm auth login' to authenticate"
+	case errors.Is(err, ErrTrackerConfig):
+		return "Make sure the tracker-mails.json given with --tracker or GM_TRACKER, or the one in the configuration directory, exists and is valid JSON"
+	case errors.Is(err, ErrQuotaExceeded):
+		return "Wait a few minutes and try again, or narrow the date range"
+	default:
+		return ""
+	}
+}
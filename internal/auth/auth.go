@@ -1,18 +1,27 @@
 package auth
 
 import (
+	"bufio"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io/fs"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
 
+	"github.com/sazardev/go-money/internal/apperrors"
 	"github.com/sazardev/go-money/internal/config"
+	"github.com/sazardev/go-money/internal/credentials"
+	"github.com/sazardev/go-money/internal/paths"
 	"github.com/sazardev/go-money/pkg/logger"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
@@ -22,6 +31,22 @@ type Authenticator struct {
 	config       *config.Config
 	oauth2Config *oauth2.Config
 	log          logger.Logger
+	provider     string                  // Account name shown to the user, such as "Google"
+	tokenFile    string                  // Name of the token in the credentials store
+	credentials  credentials.Backend     // Where the token is kept, see GM_CREDENTIALS
+	pkce         bool                    // Use PKCE, as Microsoft requires for desktop apps
+	authOptions  []oauth2.AuthCodeOption // Extra parameters for the authorization URL
+	revokeURL    string                  // Token revocation endpoint, if the provider has one
+	appsURL      string                  // Page where the user can remove the app's access
+	tokenInfoURL string                  // Endpoint describing an access token, if the provider has one
+	noBrowser    bool                    // Sign in from another device instead of a local browser
+}
+
+// microsoftEndpoint returns the Microsoft identity platform endpoint of a
+// tenant ("common" accepts both personal and work accounts)
+func microsoftEndpoint(tenant string) oauth2.Endpoint {
+	base := "https://login.microsoftonline.com/" + tenant + "/oauth2/v2.0"
+	return oauth2.Endpoint{AuthURL: base + "/authorize", TokenURL: base + "/token", DeviceAuthURL: base + "/devicecode"}
 }
 
 // NewAuthenticator creates a new Authenticator instance
@@ -43,25 +68,278 @@ func NewAuthenticator() *Authenticator {
 		config:       cfg,
 		oauth2Config: oauthConfig,
 		log:          log,
+		provider:     "Google",
+		tokenFile:    "token.json",
+		credentials:  credentials.Open(cfg.Credentials, paths.Credentials(), cfg.CredentialsPassphrase),
+		authOptions:  []oauth2.AuthCodeOption{oauth2.AccessTypeOffline},
+		revokeURL:    "https://oauth2.googleapis.com/revoke",
+		appsURL:      "https://myaccount.google.com/permissions",
+		tokenInfoURL: "https://oauth2.googleapis.com/tokeninfo",
 	}
 }
 
-// GetToken retrieves a valid OAuth2 token
+// NewMicrosoftAuthenticator creates an Authenticator for Outlook.com and
+// Microsoft 365 mailboxes, with read-only access to mail
+func NewMicrosoftAuthenticator() *Authenticator {
+	cfg := config.LoadConfig()
+
+	oauthConfig := &oauth2.Config{
+		ClientID:     cfg.MicrosoftClientID,
+		ClientSecret: cfg.MicrosoftClientSecret,
+		RedirectURL:  cfg.MicrosoftRedirectURI,
+		Scopes: []string{
+			"offline_access",
+			"https://graph.microsoft.com/Mail.Read",
+		},
+		Endpoint: microsoftEndpoint(cfg.MicrosoftTenant),
+	}
+
+	return &Authenticator{
+		config:       cfg,
+		oauth2Config: oauthConfig,
+		log:          logger.GetLogger(),
+		provider:     "Microsoft",
+		tokenFile:    "outlook-token.json",
+		credentials:  credentials.Open(cfg.Credentials, paths.Credentials(), cfg.CredentialsPassphrase),
+		pkce:         true,
+		appsURL:      "https://account.microsoft.com/privacy/app-access",
+	}
+}
+
+// GetToken retrieves a valid OAuth2 token, refreshing the saved one when it
+// has expired. A saved token that can no longer be refreshed because it
+// was revoked is deleted and the login flow starts again.
 func (a *Authenticator) GetToken(ctx context.Context) (*oauth2.Token, error) {
 	// Try to load from file first
 	token, err := a.loadTokenFromFile()
+	if err == nil && !token.Valid() && token.RefreshToken != "" {
+		token, err = a.TokenSource(ctx, token).Token()
+	}
 	if err == nil && token.Valid() {
 		a.log.Info("Using cached token")
 		return token, nil
 	}
+	if IsRevoked(err) {
+		a.log.Warn(fmt.Sprintf("The saved %s sign-in has expired or was revoked, signing in again", a.provider))
+		if err := a.DeleteToken(); err != nil {
+			a.log.Error(fmt.Sprintf("Failed to delete revoked token: %v", err))
+		}
+	}
 
 	// If no valid token, request a new one
 	a.log.Info("Requesting new token from user...")
-	return a.requestNewToken(ctx)
+	token, err = a.requestNewToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", apperrors.ErrAuthRequired, err)
+	}
+
+	return token, nil
+}
+
+// TokenSource returns the tokens to authorize requests with, starting from
+// token. Expired access tokens are refreshed with its refresh token, and
+// every refreshed token is saved so later commands start from it; a refresh
+// token refused as revoked fails with apperrors.ErrTokenRevoked.
+func (a *Authenticator) TokenSource(ctx context.Context, token *oauth2.Token) oauth2.TokenSource {
+	saving := &savingTokenSource{
+		auth:   a,
+		base:   a.oauth2Config.TokenSource(ctx, token),
+		access: token.AccessToken,
+	}
+	return oauth2.ReuseTokenSource(token, saving)
+}
+
+// savingTokenSource saves the tokens its base source refreshes
+type savingTokenSource struct {
+	auth   *Authenticator
+	base   oauth2.TokenSource
+	access string // Access token saved last
+}
+
+// Token returns a valid token, saving it when it was just refreshed
+func (s *savingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.base.Token()
+	if IsRevoked(err) {
+		return nil, fmt.Errorf("refreshing the %s sign-in: %w (%w)", s.auth.provider, apperrors.ErrTokenRevoked, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if token.AccessToken != s.access {
+		s.access = token.AccessToken
+		if err := s.auth.saveTokenToFile(token); err != nil {
+			s.auth.log.Warn(fmt.Sprintf("Failed to save refreshed token: %v", err))
+		}
+	}
+	return token, nil
+}
+
+// IsRevoked reports whether err is the token endpoint refusing a refresh
+// token (invalid_grant), which only a new login fixes
+func IsRevoked(err error) bool {
+	var retrieveErr *oauth2.RetrieveError
+	return errors.As(err, &retrieveErr) && retrieveErr.ErrorCode == "invalid_grant"
+}
+
+// DeleteToken removes the saved token, so the next command asks to sign in
+// again instead of failing with it
+func (a *Authenticator) DeleteToken() error {
+	return a.credentials.Delete(a.tokenFile)
+}
+
+// Revoke signs the app out: the saved token is revoked with the provider,
+// when it has a revocation endpoint, and deleted. The token is deleted even
+// when revoking it fails, and the error is returned so the user can remove
+// the access at AppsURL instead. It reports whether a token was saved.
+func (a *Authenticator) Revoke(ctx context.Context) (bool, error) {
+	token, err := a.loadTokenFromFile()
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+
+	var revokeErr error
+	if err == nil && a.revokeURL != "" {
+		revokeErr = a.revokeToken(ctx, token)
+	}
+	if err := a.DeleteToken(); err != nil {
+		return true, err
+	}
+	return true, revokeErr
+}
+
+// revokeToken asks the provider to invalidate token. Revoking the refresh
+// token also revokes the access tokens issued with it.
+func (a *Authenticator) revokeToken(ctx context.Context, token *oauth2.Token) error {
+	value := token.RefreshToken
+	if value == "" {
+		value = token.AccessToken
+	}
+	form := url.Values{"token": {value}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.revokeURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("revoking the %s sign-in: %w", a.provider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	// A token that was already revoked or expired is refused as invalid
+	var body struct {
+		Error string `json:"error"`
+	}
+	if json.NewDecoder(resp.Body).Decode(&body) == nil && body.Error == "invalid_token" {
+		return nil
+	}
+	return fmt.Errorf("revoking the %s sign-in: unexpected status %s", a.provider, resp.Status)
+}
+
+// Revocable reports whether Revoke invalidates the token with the provider,
+// rather than only deleting it
+func (a *Authenticator) Revocable() bool {
+	return a.revokeURL != ""
+}
+
+// Scopes returns the permissions granted to token. Google tells them for an
+// access token; Microsoft doesn't, so the scopes requested at sign-in are
+// returned instead, as it grants all of them or none.
+func (a *Authenticator) Scopes(ctx context.Context, token *oauth2.Token) ([]string, error) {
+	if a.tokenInfoURL == "" {
+		return a.oauth2Config.Scopes, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.tokenInfoURL+"?"+url.Values{"access_token": {token.AccessToken}}.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("reading the %s token scopes: %w", a.provider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reading the %s token scopes: unexpected status %s", a.provider, resp.Status)
+	}
+	var info struct {
+		Scope string `json:"scope"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("reading the %s token scopes: %w", a.provider, err)
+	}
+	return strings.Fields(info.Scope), nil
+}
+
+// AppsURL returns the page where the user can remove the app's access to
+// the account
+func (a *Authenticator) AppsURL() string {
+	return a.appsURL
+}
+
+// Provider returns the account name shown to the user, such as "Google"
+func (a *Authenticator) Provider() string {
+	return a.provider
+}
+
+// ForAccount returns an Authenticator that keeps the token of a named
+// account from accounts.json in its own file; "" keeps the default file
+func (a *Authenticator) ForAccount(name string) *Authenticator {
+	if name == "" {
+		return a
+	}
+	account := *a
+	account.tokenFile = strings.TrimSuffix(a.tokenFile, ".json") + "-" + name + ".json"
+	return &account
+}
+
+// WithoutBrowser returns an Authenticator that signs in from another
+// device, for machines without a browser or a reachable port 8080: with a
+// device code when the provider supports it for mail access (Microsoft),
+// or else by pasting back the address the browser was sent to (Google)
+func (a *Authenticator) WithoutBrowser() *Authenticator {
+	headless := *a
+	headless.noBrowser = true
+	return &headless
+}
+
+// CachedToken returns the saved token without starting a login, for
+// accounts syncing at once that can't share the login page. Expired access
+// tokens are refreshed and saved by the HTTP client.
+func (a *Authenticator) CachedToken() (*oauth2.Token, error) {
+	token, err := a.loadTokenFromFile()
+	if err != nil {
+		return nil, fmt.Errorf("%w: no saved %s token: %w", apperrors.ErrAuthRequired, a.provider, err)
+	}
+	return token, nil
 }
 
 // requestNewToken initiates OAuth2 flow with automatic browser and code capture
 func (a *Authenticator) requestNewToken(ctx context.Context) (*oauth2.Token, error) {
+	if a.noBrowser {
+		var token *oauth2.Token
+		var err error
+		if a.oauth2Config.Endpoint.DeviceAuthURL != "" {
+			token, err = a.requestDeviceToken(ctx)
+		} else {
+			token, err = a.requestPastedToken(ctx)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := a.saveTokenToFile(token); err != nil {
+			a.log.Error(fmt.Sprintf("Failed to save token: %v", err))
+			return nil, err
+		}
+		return token, nil
+	}
+
 	// Start local HTTP server to capture the authorization code
 	codeChan := make(chan string)
 	errChan := make(chan error)
@@ -87,7 +365,7 @@ func (a *Authenticator) requestNewToken(ctx context.Context) (*oauth2.Token, err
 
 			// Send success response to browser
 			w.Header().Set("Content-Type", "text/html; charset=utf-8")
-			fmt.Fprint(w, `
+			fmt.Fprintf(w, `
 <!DOCTYPE html>
 <html>
 <head>
@@ -101,10 +379,10 @@ func (a *Authenticator) requestNewToken(ctx context.Context) (*oauth2.Token, err
 <body>
     <div class="success">✅ Authorization Successful!</div>
     <p class="message">You can close this window and return to the terminal.</p>
-    <p class="message">GO Money is now authenticated with your Google account.</p>
+    <p class="message">GO Money is now authenticated with your %s account.</p>
 </body>
 </html>
-			`)
+			`, a.provider)
 
 			// Send code to channel
 			codeChan <- code
@@ -115,7 +393,14 @@ func (a *Authenticator) requestNewToken(ctx context.Context) (*oauth2.Token, err
 	}()
 
 	// Generate authorization URL
-	authURL := a.oauth2Config.AuthCodeURL("state", oauth2.AccessTypeOffline)
+	authOptions := a.authOptions
+	var exchangeOptions []oauth2.AuthCodeOption
+	if a.pkce {
+		verifier := oauth2.GenerateVerifier()
+		authOptions = append(authOptions, oauth2.S256ChallengeOption(verifier))
+		exchangeOptions = append(exchangeOptions, oauth2.VerifierOption(verifier))
+	}
+	authURL := a.oauth2Config.AuthCodeURL("state", authOptions...)
 	fmt.Printf("🔐 Opening browser for authentication...\n")
 	fmt.Printf("📱 If browser doesn't open, visit: %s\n\n", authURL)
 
@@ -129,7 +414,7 @@ func (a *Authenticator) requestNewToken(ctx context.Context) (*oauth2.Token, err
 		a.log.Info("Authorization code received successfully")
 
 		// Exchange code for token
-		token, err := a.oauth2Config.Exchange(ctx, code)
+		token, err := a.oauth2Config.Exchange(ctx, code, exchangeOptions...)
 		if err != nil {
 			a.log.Error(fmt.Sprintf("Failed to exchange code: %v", err))
 			return nil, err
@@ -154,6 +439,91 @@ func (a *Authenticator) requestNewToken(ctx context.Context) (*oauth2.Token, err
 	}
 }
 
+// requestDeviceToken signs in with the device authorization flow: the user
+// enters a code on any device while the token endpoint is polled
+func (a *Authenticator) requestDeviceToken(ctx context.Context) (*oauth2.Token, error) {
+	device, err := a.oauth2Config.DeviceAuth(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("starting the %s device sign-in: %w", a.provider, err)
+	}
+	fmt.Printf("🔐 On any device, visit %s and enter the code %s\n", device.VerificationURI, device.UserCode)
+	fmt.Printf("⏳ Waiting for you to sign in (the code expires at %s)...\n", device.Expiry.Local().Format("15:04"))
+
+	token, err := a.oauth2Config.DeviceAccessToken(ctx, device)
+	if err != nil {
+		return nil, fmt.Errorf("completing the %s device sign-in: %w", a.provider, err)
+	}
+	return token, nil
+}
+
+// requestPastedToken signs in on another device: its browser ends on the
+// redirect address, which can't load there, and the user pastes that
+// address (or only its code) back into the terminal
+func (a *Authenticator) requestPastedToken(ctx context.Context) (*oauth2.Token, error) {
+	state := make([]byte, 16)
+	if _, err := rand.Read(state); err != nil {
+		return nil, err
+	}
+	authOptions := a.authOptions
+	var exchangeOptions []oauth2.AuthCodeOption
+	if a.pkce {
+		verifier := oauth2.GenerateVerifier()
+		authOptions = append(authOptions, oauth2.S256ChallengeOption(verifier))
+		exchangeOptions = append(exchangeOptions, oauth2.VerifierOption(verifier))
+	}
+	authURL := a.oauth2Config.AuthCodeURL(hex.EncodeToString(state), authOptions...)
+
+	fmt.Printf("🔐 On any device with a browser, visit:\n\n%s\n\n", authURL)
+	fmt.Printf("📋 After signing in, the browser opens %s, which fails to load there.\n", a.oauth2Config.RedirectURL)
+	fmt.Printf("   Copy that whole address from the address bar and paste it here: ")
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && strings.TrimSpace(line) == "" {
+		return nil, fmt.Errorf("reading the pasted address: %w", err)
+	}
+	code, err := pastedCode(strings.TrimSpace(line), hex.EncodeToString(state))
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := a.oauth2Config.Exchange(ctx, code, exchangeOptions...)
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Failed to exchange code: %v", err))
+		return nil, err
+	}
+	return token, nil
+}
+
+// pastedCode returns the authorization code of a pasted redirect address,
+// checking it belongs to this sign-in; a bare code is returned as is
+func pastedCode(pasted, state string) (string, error) {
+	if !strings.Contains(pasted, "=") && !strings.Contains(pasted, "://") {
+		if pasted == "" {
+			return "", fmt.Errorf("no address or code was pasted")
+		}
+		return pasted, nil
+	}
+
+	query := pasted
+	if u, err := url.Parse(pasted); err == nil && u.RawQuery != "" {
+		query = u.RawQuery
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return "", fmt.Errorf("invalid pasted address: %w", err)
+	}
+	if reason := values.Get("error"); reason != "" {
+		return "", fmt.Errorf("the sign-in was refused: %s", reason)
+	}
+	if values.Get("state") != state {
+		return "", fmt.Errorf("the pasted address belongs to another sign-in; paste the one from this login")
+	}
+	if values.Get("code") == "" {
+		return "", fmt.Errorf("the pasted address has no authorization code")
+	}
+	return values.Get("code"), nil
+}
+
 // openBrowser opens the default browser with the given URL
 func openBrowser(url string) {
 	var cmd *exec.Cmd
@@ -176,27 +546,19 @@ func openBrowser(url string) {
 	}
 }
 
-// saveTokenToFile saves the OAuth2 token to a file
+// saveTokenToFile saves the OAuth2 token in the credential store
 func (a *Authenticator) saveTokenToFile(token *oauth2.Token) error {
-	credDir := ".credentials"
-	if err := os.MkdirAll(credDir, 0700); err != nil {
-		return err
-	}
-
-	tokFile := filepath.Join(credDir, "token.json")
-	f, err := os.OpenFile(tokFile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	data, err := json.Marshal(token)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-
-	return json.NewEncoder(f).Encode(token)
+	return a.credentials.Save(a.tokenFile, data)
 }
 
-// loadTokenFromFile loads the OAuth2 token from file
+// loadTokenFromFile loads the OAuth2 token from the credential store. The
+// error wraps fs.ErrNotExist when no token is saved.
 func (a *Authenticator) loadTokenFromFile() (*oauth2.Token, error) {
-	tokFile := filepath.Join(".credentials", "token.json")
-	b, err := ioutil.ReadFile(tokFile)
+	b, err := a.credentials.Load(a.tokenFile)
 	if err != nil {
 		return nil, err
 	}
@@ -209,7 +571,8 @@ func (a *Authenticator) loadTokenFromFile() (*oauth2.Token, error) {
 	return &token, nil
 }
 
-// GetHTTPClient returns an HTTP client with the OAuth2 token
+// GetHTTPClient returns an HTTP client authorized with the OAuth2 token,
+// refreshing and saving it as it expires, see TokenSource
 func (a *Authenticator) GetHTTPClient(ctx context.Context, token *oauth2.Token) *http.Client {
-	return a.oauth2Config.Client(ctx, token)
+	return oauth2.NewClient(ctx, a.TokenSource(ctx, token))
 }
@@ -0,0 +1,71 @@
+package bills
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// Bill is a transaction email with a payment due date
+type Bill struct {
+	ID             string    `json:"id" yaml:"id"`
+	ServiceName    string    `json:"service_name" yaml:"service_name"`
+	Category       string    `json:"category" yaml:"category"`
+	Amount         float64   `json:"amount" yaml:"amount"`
+	Currency       string    `json:"currency" yaml:"currency"`
+	CurrencySymbol string    `json:"currency_symbol" yaml:"currency_symbol"`
+	Subject        string    `json:"subject" yaml:"subject"`
+	DueDate        time.Time `json:"due_date" yaml:"due_date"`
+	DaysLeft       int       `json:"days_left" yaml:"days_left"`
+}
+
+// Upcoming returns the bills due today or later, soonest first
+func Upcoming(transactions []*models.Transaction, now time.Time) []Bill {
+	today := day(now)
+
+	var bills []Bill
+	for _, tx := range transactions {
+		if tx.DueDate.IsZero() {
+			continue
+		}
+
+		due := day(tx.DueDate)
+		if due.Before(today) {
+			continue
+		}
+
+		bills = append(bills, Bill{
+			ID:             tx.ID,
+			ServiceName:    tx.ServiceName,
+			Category:       tx.Category,
+			Amount:         tx.Amount,
+			Currency:       tx.Currency,
+			CurrencySymbol: tx.CurrencySymbol,
+			Subject:        tx.Subject,
+			DueDate:        due,
+			DaysLeft:       int(due.Sub(today).Hours() / 24),
+		})
+	}
+
+	sort.SliceStable(bills, func(i, j int) bool {
+		return bills[i].DueDate.Before(bills[j].DueDate)
+	})
+	return bills
+}
+
+// DueWithin keeps the bills due in the next days days
+func DueWithin(bills []Bill, days int) []Bill {
+	var soon []Bill
+	for _, b := range bills {
+		if b.DaysLeft <= days {
+			soon = append(soon, b)
+		}
+	}
+	return soon
+}
+
+// day truncates t to midnight UTC of its calendar date
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
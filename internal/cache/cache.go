@@ -0,0 +1,164 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Cache keeps the results of external lookups (exchange rates, LLM
+// replies, ...) as JSON files with an expiry, grouped by namespace
+type Cache struct {
+	Dir string
+}
+
+// New returns a cache storing its entries under dir
+func New(dir string) *Cache {
+	return &Cache{Dir: dir}
+}
+
+// entry is the on-disk form of a cached value
+type entry struct {
+	Key       string          `json:"key"`
+	StoredAt  time.Time       `json:"stored_at"`
+	ExpiresAt time.Time       `json:"expires_at"`
+	Value     json.RawMessage `json:"value"`
+}
+
+// Get loads the value cached under namespace and key into v. It reports
+// whether a value was found and whether it is still fresh; expired values
+// are still loaded so callers can fall back to them when a refresh fails.
+func (c *Cache) Get(namespace, key string, v interface{}) (found, fresh bool, err error) {
+	data, err := os.ReadFile(c.path(namespace, key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return false, false, fmt.Errorf("corrupt cache entry: %w", err)
+	}
+	// A hash collision would return another key's value
+	if e.Key != key {
+		return false, false, nil
+	}
+	if err := json.Unmarshal(e.Value, v); err != nil {
+		return false, false, fmt.Errorf("corrupt cache entry: %w", err)
+	}
+
+	return true, time.Now().Before(e.ExpiresAt), nil
+}
+
+// Set caches v under namespace and key for ttl
+func (c *Cache) Set(namespace, key string, v interface{}, ttl time.Duration) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	data, err := json.MarshalIndent(entry{Key: key, StoredAt: now, ExpiresAt: now.Add(ttl), Value: value}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := c.path(namespace, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// Write then rename so readers never see a partial entry
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Clear removes the cached entries of the given namespaces, or of every
+// namespace when none is given, and returns how many were removed. Only the
+// entry files Set writes are removed, and a namespace's directory once it
+// is empty, so a cache directory shared with other files keeps them.
+func (c *Cache) Clear(namespaces ...string) (int, error) {
+	if len(namespaces) == 0 {
+		dirs, err := os.ReadDir(c.Dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		for _, d := range dirs {
+			if d.IsDir() {
+				namespaces = append(namespaces, d.Name())
+			}
+		}
+	}
+
+	removed := 0
+	for _, namespace := range namespaces {
+		if namespace == "" || namespace == "." || namespace == ".." || filepath.Base(namespace) != namespace {
+			return removed, fmt.Errorf("invalid cache namespace %q", namespace)
+		}
+		dir := filepath.Join(c.Dir, namespace)
+		files, err := os.ReadDir(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return removed, err
+		}
+		for _, f := range files {
+			if f.IsDir() || !isEntryFile(f.Name()) {
+				continue
+			}
+			if err := os.Remove(filepath.Join(dir, f.Name())); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+		// Left in place when it holds files gm didn't write
+		os.Remove(dir)
+	}
+	return removed, nil
+}
+
+// isEntryFile reports whether name is an entry file written by Set, or one
+// it was still writing
+func isEntryFile(name string) bool {
+	if strings.HasPrefix(name, ".tmp-") {
+		return true
+	}
+	hash, ok := strings.CutSuffix(name, ".json")
+	if !ok || len(hash) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+// path returns the file holding a key; keys are hashed because they can be
+// long or contain characters that aren't valid in file names
+func (c *Cache) path(namespace, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, namespace, hex.EncodeToString(sum[:16])+".json")
+}
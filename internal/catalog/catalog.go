@@ -0,0 +1,222 @@
+// Package catalog downloads the community service catalog: a versioned
+// tracker file published upstream with an Ed25519 signature, so new
+// merchants reach users between releases of gm
+package catalog
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sazardev/go-money/internal/extractor"
+)
+
+// DefaultURL is where the community catalog is published
+const DefaultURL = "https://raw.githubusercontent.com/sazardev/go-money/main/catalog/catalog.json"
+
+// SignatureExt is appended to the catalog URL to get its signature: the
+// base64 Ed25519 signature of the catalog file's bytes
+const SignatureExt = ".sig"
+
+// maxSize bounds the catalog and signature downloads
+const maxSize = 4 << 20
+
+// DefaultKey is the base64 public key catalogs must be signed with when
+// none is configured, set at build time with
+// -ldflags "-X github.com/sazardev/go-money/internal/catalog.DefaultKey=..."
+var DefaultKey = ""
+
+// Client downloads and checks catalogs
+type Client struct {
+	URL    string
+	Key    ed25519.PublicKey
+	Client *http.Client
+}
+
+// NewClient returns a client for the catalog at rawURL signed with the
+// base64 public key
+func NewClient(rawURL, key string) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("catalog URL %q must be an https:// URL", rawURL)
+	}
+	publicKey, err := ParseKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{URL: rawURL, Key: publicKey, Client: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+// ParseKey decodes a base64 Ed25519 public key
+func ParseKey(key string) (ed25519.PublicKey, error) {
+	if key == "" {
+		return nil, fmt.Errorf("no catalog signing key: set catalog.key (GM_CATALOG_KEY) to the publisher's public key")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil || len(decoded) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid catalog signing key: want the base64 of a %d-byte Ed25519 public key", ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(decoded), nil
+}
+
+// Fetch downloads the catalog and its signature, and returns the catalog
+// with its file once the signature, schema and services check out
+func (c *Client) Fetch(ctx context.Context) (*extractor.Catalog, []byte, error) {
+	data, err := c.get(ctx, c.URL)
+	if err != nil {
+		return nil, nil, err
+	}
+	signature, err := c.get(ctx, c.URL+SignatureExt)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := Verify(data, signature, c.Key); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", c.URL, err)
+	}
+	catalog, err := extractor.ParseCatalog(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", c.URL, err)
+	}
+	return catalog, data, nil
+}
+
+func (c *Client) get(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status %s", rawURL, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", rawURL, err)
+	}
+	if len(data) > maxSize {
+		return nil, fmt.Errorf("%s: larger than %d bytes", rawURL, maxSize)
+	}
+	return data, nil
+}
+
+// Verify checks the base64 signature of data against key
+func Verify(data, signature []byte, key ed25519.PublicKey) error {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || !ed25519.Verify(key, data, decoded) {
+		return fmt.Errorf("the catalog signature doesn't match the signing key")
+	}
+	return nil
+}
+
+// Sign returns the base64 signature of data made with the private key
+func Sign(data []byte, key ed25519.PrivateKey) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, data)) + "\n")
+}
+
+// LoadPrivateKey reads the base64 seed of a signing key from path,
+// generating and saving one when create is set and the file doesn't exist
+func LoadPrivateKey(path string, create bool) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) && create {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		seed := base64.StdEncoding.EncodeToString(key.Seed()) + "\n"
+		if err := os.WriteFile(path, []byte(seed), 0600); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("%s isn't a catalog signing key", path)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// PublicKey returns the base64 public key of a signing key, the form
+// catalog.key takes
+func PublicKey(key ed25519.PrivateKey) string {
+	return base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+}
+
+// Install saves the file of a fetched catalog where the extractor reads
+// it, replacing the installed one at once
+func Install(data []byte) error {
+	path := extractor.CatalogPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), extractor.CatalogFile+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Changes are the services a catalog adds, changes and drops compared with
+// the installed one
+type Changes struct {
+	Added   []string `json:"added,omitempty" yaml:"added,omitempty"`
+	Changed []string `json:"changed,omitempty" yaml:"changed,omitempty"`
+	Removed []string `json:"removed,omitempty" yaml:"removed,omitempty"`
+}
+
+// Compare lists the service IDs next adds, changes and drops compared with
+// installed, which is nil when no catalog is installed
+func Compare(installed, next *extractor.Catalog) Changes {
+	before := make(map[string][]byte)
+	if installed != nil {
+		for _, service := range installed.Services {
+			before[service.ID], _ = json.Marshal(service)
+		}
+	}
+
+	var changes Changes
+	for _, service := range next.Services {
+		data, _ := json.Marshal(service)
+		previous, ok := before[service.ID]
+		switch {
+		case !ok:
+			changes.Added = append(changes.Added, service.ID)
+		case string(previous) != string(data):
+			changes.Changed = append(changes.Changed, service.ID)
+		}
+		delete(before, service.ID)
+	}
+	for id := range before {
+		changes.Removed = append(changes.Removed, id)
+	}
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Changed)
+	sort.Strings(changes.Removed)
+	return changes
+}
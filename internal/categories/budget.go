@@ -0,0 +1,32 @@
+package categories
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Budget is a monthly spending limit for a category
+type Budget struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency,omitempty"` // Only transactions in this currency count; empty counts all
+}
+
+// Validate checks that the budget can be tracked
+func (b Budget) Validate() error {
+	if b.Amount <= 0 {
+		return fmt.Errorf("budget amount must be positive, got %.2f", b.Amount)
+	}
+	if b.Currency != "" && (len(b.Currency) != 3 || strings.ToUpper(b.Currency) != b.Currency) {
+		return fmt.Errorf("budget currency must be an upper-case ISO code, got %q", b.Currency)
+	}
+	return nil
+}
+
+// MergeBudgets returns the budget of two categories merged into one, which
+// is their sum; false when they count different currencies
+func MergeBudgets(a, b Budget) (Budget, bool) {
+	if a.Currency != b.Currency {
+		return Budget{}, false
+	}
+	return Budget{Amount: a.Amount + b.Amount, Currency: a.Currency}, true
+}
@@ -0,0 +1,227 @@
+package categories
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/pkg/utils"
+)
+
+// DefaultFile is where user categorization choices are stored
+const DefaultFile = "category-rules.json"
+
+// Other is the catch-all category for transactions that need review
+const Other = "Other"
+
+// Rule assigns a category to every transaction sent from a domain
+type Rule struct {
+	SenderDomain string `json:"sender_domain"`
+	Category     string `json:"category"`
+}
+
+// Overrides holds the categories chosen by the user: rules that apply to
+// whole senders and explicit choices for single transactions, plus tags,
+// excluded transactions, display styles, budgets and household members
+type Overrides struct {
+	Transactions map[string]string   `json:"transactions"` // transaction ID → category
+	Rules        []Rule              `json:"rules"`
+	Tags         map[string][]string `json:"tags,omitempty"`     // transaction ID → tags
+	Excluded     []string            `json:"excluded,omitempty"` // IDs of transactions left out of spending
+	Styles       map[string]Style    `json:"styles,omitempty"`   // category → display metadata
+	Budgets      map[string]Budget   `json:"budgets,omitempty"`  // category → monthly limit
+	Members      []Member            `json:"members,omitempty"`  // household members spending is attributed to
+
+	// Category preset applied with gm categories apply-preset
+	Remap             map[string]string `json:"remap,omitempty"`              // tracker category → category
+	ServiceCategories map[string]string `json:"service_categories,omitempty"` // service ID → category
+
+	path string
+}
+
+// Load reads overrides from path. A missing file yields empty overrides.
+func Load(path string) (*Overrides, error) {
+	overrides := &Overrides{
+		Transactions: make(map[string]string),
+		Tags:         make(map[string][]string),
+		path:         path,
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return overrides, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if overrides.Transactions == nil {
+		overrides.Transactions = make(map[string]string)
+	}
+	if overrides.Tags == nil {
+		overrides.Tags = make(map[string][]string)
+	}
+	for category, style := range overrides.Styles {
+		if style.Color == "" {
+			continue
+		}
+		if _, _, _, err := style.RGB(); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: category %q: %w", path, category, err)
+		}
+	}
+	for category, budget := range overrides.Budgets {
+		if err := budget.Validate(); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: category %q: %w", path, category, err)
+		}
+	}
+	if err := validateMembers(overrides.Members); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return overrides, nil
+}
+
+// Save writes the overrides back to the file they were loaded from
+func (o *Overrides) Save() error {
+	data, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(o.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(o.path, data, 0644)
+}
+
+// SetCategory records an explicit category for a single transaction
+func (o *Overrides) SetCategory(transactionID, category string) {
+	o.Transactions[transactionID] = category
+}
+
+// AddRule assigns category to all transactions from domain, replacing any
+// existing rule for that domain
+func (o *Overrides) AddRule(domain, category string) {
+	domain = strings.ToLower(domain)
+	for i, rule := range o.Rules {
+		if rule.SenderDomain == domain {
+			o.Rules[i].Category = category
+			return
+		}
+	}
+	o.Rules = append(o.Rules, Rule{SenderDomain: domain, Category: category})
+}
+
+// AddTags tags a transaction, ignoring tags it already has
+func (o *Overrides) AddTags(transactionID string, tags ...string) {
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !hasTag(o.Tags[transactionID], tag) {
+			o.Tags[transactionID] = append(o.Tags[transactionID], tag)
+		}
+	}
+}
+
+// RemoveTags removes tags from a transaction
+func (o *Overrides) RemoveTags(transactionID string, tags ...string) {
+	var kept []string
+	for _, tag := range o.Tags[transactionID] {
+		if !hasTag(tags, tag) {
+			kept = append(kept, tag)
+		}
+	}
+
+	if len(kept) == 0 {
+		delete(o.Tags, transactionID)
+		return
+	}
+	o.Tags[transactionID] = kept
+}
+
+// SetExcluded leaves a transaction out of spending, or counts it again
+func (o *Overrides) SetExcluded(transactionID string, excluded bool) {
+	o.Excluded = slices.DeleteFunc(o.Excluded, func(id string) bool { return id == transactionID })
+	if excluded {
+		o.Excluded = append(o.Excluded, transactionID)
+	}
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply updates the category of each transaction: the category preset
+// first, then sender rules, then explicit per-transaction choices, which
+// always win. It also attaches the user's tags and exclusions and
+// attributes the transaction to a household member.
+func (o *Overrides) Apply(transactions []*models.Transaction) {
+	for _, tx := range transactions {
+		if category, ok := o.ServiceCategories[tx.ServiceID]; ok {
+			tx.Category = category
+		} else if category, ok := o.Remap[tx.Category]; ok {
+			tx.Category = category
+		}
+
+		domain := SenderDomain(tx)
+		for _, rule := range o.Rules {
+			if domain != "" && domain == rule.SenderDomain {
+				tx.Category = rule.Category
+				break
+			}
+		}
+
+		if category, ok := o.Transactions[tx.ID]; ok {
+			tx.Category = category
+		}
+		tx.Tags = o.Tags[tx.ID]
+		tx.Excluded = slices.Contains(o.Excluded, tx.ID)
+		tx.Member = o.memberOf(tx.Tags, tx.Account)
+	}
+}
+
+// SenderDomain returns the lower-cased domain of the transaction's sender
+func SenderDomain(tx *models.Transaction) string {
+	return strings.ToLower(utils.ExtractDomain(utils.ExtractEmail(tx.Email)))
+}
+
+// NeedsReview reports whether a transaction has no meaningful category
+func NeedsReview(tx *models.Transaction) bool {
+	return tx.Category == "" || strings.EqualFold(tx.Category, Other)
+}
+
+// List returns the distinct categories of the given transactions and
+// known categories, sorted, with Other last
+func List(known []string, transactions []*models.Transaction) []string {
+	seen := map[string]bool{Other: true}
+	var list []string
+	add := func(category string) {
+		if category != "" && !seen[category] {
+			seen[category] = true
+			list = append(list, category)
+		}
+	}
+
+	for _, category := range known {
+		add(category)
+	}
+	for _, tx := range transactions {
+		add(tx.Category)
+	}
+
+	sort.Strings(list)
+	return append(list, Other)
+}
@@ -0,0 +1,63 @@
+package categories
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SharedTag marks transactions whose cost is split between household members
+const SharedTag = "shared"
+
+// Member is a person in a shared household. Transactions are attributed to
+// a member when they carry one of the member's tags or were sent to one of
+// the member's email accounts.
+type Member struct {
+	Name     string   `json:"name"`
+	Accounts []string `json:"accounts,omitempty"` // Addresses receipts are sent to
+	Tags     []string `json:"tags,omitempty"`
+	Share    float64  `json:"share,omitempty"` // Weight of shared expenses paid by this member; equal split when no member has one
+}
+
+// validateMembers checks that every member can be told apart
+func validateMembers(members []Member) error {
+	seen := make(map[string]bool)
+	for _, member := range members {
+		if strings.TrimSpace(member.Name) == "" {
+			return fmt.Errorf("household members need a name")
+		}
+		if seen[strings.ToLower(member.Name)] {
+			return fmt.Errorf("household member %q is listed twice", member.Name)
+		}
+		seen[strings.ToLower(member.Name)] = true
+
+		if member.Share < 0 {
+			return fmt.Errorf("household member %q has a negative share", member.Name)
+		}
+	}
+	return nil
+}
+
+// memberOf returns the member a transaction with the given tags and
+// account is attributed to. Tags are explicit choices, so they win over
+// accounts; within each, members are tried in order.
+func (o *Overrides) memberOf(tags []string, account string) string {
+	for _, member := range o.Members {
+		for _, tag := range member.Tags {
+			if hasTag(tags, tag) {
+				return member.Name
+			}
+		}
+	}
+
+	if account == "" {
+		return ""
+	}
+	for _, member := range o.Members {
+		for _, a := range member.Accounts {
+			if strings.EqualFold(a, account) {
+				return member.Name
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,180 @@
+package categories
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Preset is a ready-made set of categories replacing the ones of
+// tracker-mails.json, for users who budget with a method of their own
+type Preset struct {
+	Name        string            `json:"name" yaml:"name"`
+	Title       string            `json:"title" yaml:"title"`
+	Description string            `json:"description" yaml:"description"`
+	Categories  map[string]string `json:"categories" yaml:"categories"`                 // tracker category → category
+	Services    map[string]string `json:"services,omitempty" yaml:"services,omitempty"` // service ID → category, winning over Categories
+}
+
+// presets are the packs gm categories apply-preset offers
+var presets = []Preset{
+	{
+		Name:        "ynab",
+		Title:       "YNAB-style",
+		Description: "Category groups of You Need A Budget: bills, frequent, non-monthly and just for fun",
+		Categories: map[string]string{
+			"Transportation":         "Frequent: Transportation",
+			"Food Delivery":          "Frequent: Eating Out",
+			"E-commerce":             "Non-Monthly: Stuff I Forgot to Budget For",
+			"Subscription":           "Bills: TV Streaming",
+			"Travel & Accommodation": "Non-Monthly: Vacation",
+			"Financial Services":     "Non-Monthly: Stuff I Forgot to Budget For",
+			"Cinema":                 "Just for Fun: Fun Money",
+			"Video Game Platforms":   "Just for Fun: Hobbies",
+			"Clothing & Retail":      "Non-Monthly: Clothing",
+		},
+		Services: map[string]string{
+			"spotify":      "Bills: Music",
+			"applemusic":   "Bills: Music",
+			"youtubemusic": "Bills: Music",
+		},
+	},
+	{
+		Name:        "mexican-household",
+		Title:       "Mexican household",
+		Description: "Spanish categories of a Mexican household budget, with supermarkets as groceries (despensa)",
+		Categories: map[string]string{
+			"Transportation":         "Transporte",
+			"Food Delivery":          "Comida fuera",
+			"E-commerce":             "Compras en línea",
+			"Subscription":           "Suscripciones",
+			"Travel & Accommodation": "Viajes",
+			"Financial Services":     "Pagos y transferencias",
+			"Cinema":                 "Entretenimiento",
+			"Video Game Platforms":   "Entretenimiento",
+			"Clothing & Retail":      "Ropa y calzado",
+		},
+		Services: map[string]string{
+			"walmart":  "Despensa",
+			"samsclub": "Despensa",
+		},
+	},
+	{
+		Name:        "freelancer",
+		Title:       "Freelancer",
+		Description: "Business expenses apart from personal ones, as self-employed tax returns need them",
+		Categories: map[string]string{
+			"Transportation":         "Business: Travel",
+			"Food Delivery":          "Business: Meals",
+			"E-commerce":             "Business: Equipment & Supplies",
+			"Subscription":           "Personal: Entertainment",
+			"Travel & Accommodation": "Business: Travel",
+			"Financial Services":     "Business: Payment Fees",
+			"Cinema":                 "Personal: Entertainment",
+			"Video Game Platforms":   "Personal: Entertainment",
+			"Clothing & Retail":      "Personal: Clothing",
+		},
+	},
+}
+
+// Presets returns the category presets, by name
+func Presets() []Preset {
+	list := append([]Preset(nil), presets...)
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// FindPreset returns the preset with the given name or title, ignoring case
+func FindPreset(name string) (Preset, bool) {
+	for _, preset := range presets {
+		if strings.EqualFold(preset.Name, name) || strings.EqualFold(preset.Title, name) {
+			return preset, true
+		}
+	}
+	return Preset{}, false
+}
+
+// Known returns the categories transactions can have: those of
+// tracker-mails.json, as the applied preset renames them
+func (o *Overrides) Known(tracker []string) []string {
+	var known []string
+	for _, category := range tracker {
+		if to, ok := o.Remap[category]; ok {
+			category = to
+		}
+		known = append(known, category)
+	}
+	return slices.AppendSeq(known, maps.Values(o.ServiceCategories))
+}
+
+// ApplyPreset makes preset the mapping of tracker categories, replacing any
+// earlier one. Sender rules, per-transaction choices, styles and budgets
+// naming a remapped category, or its name in the earlier preset, are moved
+// to its new name, so they keep applying. It returns the categories moved,
+// old name → new name, for budgets kept elsewhere to follow.
+func (o *Overrides) ApplyPreset(preset Preset) map[string]string {
+	rename := make(map[string]string)
+	for from, to := range preset.Categories {
+		rename[from] = to
+	}
+	// Categories an earlier preset merged and this one splits stay as they
+	// are, since there is no telling which of the new ones they meant
+	ambiguous := make(map[string]bool)
+	for from, earlier := range o.Remap {
+		to, ok := preset.Categories[from]
+		if !ok || to == earlier {
+			continue
+		}
+		if other, seen := rename[earlier]; seen && other != to {
+			ambiguous[earlier] = true
+		}
+		rename[earlier] = to
+	}
+	for category := range ambiguous {
+		delete(rename, category)
+	}
+
+	o.Remap = make(map[string]string, len(preset.Categories))
+	for from, to := range preset.Categories {
+		o.Remap[from] = to
+	}
+	o.ServiceCategories = make(map[string]string, len(preset.Services))
+	for service, to := range preset.Services {
+		o.ServiceCategories[service] = to
+	}
+
+	for i, rule := range o.Rules {
+		if to, ok := rename[rule.Category]; ok {
+			o.Rules[i].Category = to
+		}
+	}
+	for id, category := range o.Transactions {
+		if to, ok := rename[category]; ok {
+			o.Transactions[id] = to
+		}
+	}
+	for _, from := range slices.Sorted(maps.Keys(o.Styles)) {
+		if to, ok := rename[from]; ok {
+			if _, taken := o.Styles[to]; !taken {
+				o.Styles[to] = o.Styles[from]
+			}
+			delete(o.Styles, from)
+		}
+	}
+	for _, from := range slices.Sorted(maps.Keys(o.Budgets)) {
+		to, ok := rename[from]
+		if !ok {
+			continue
+		}
+		budget := o.Budgets[from]
+		if taken, ok := o.Budgets[to]; ok {
+			if budget, ok = MergeBudgets(taken, budget); !ok {
+				continue // Left for the user to move
+			}
+		}
+		o.Budgets[to] = budget
+		delete(o.Budgets, from)
+	}
+	return rename
+}
@@ -0,0 +1,85 @@
+package categories
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// Style is how a category is displayed in terminal output and reports
+type Style struct {
+	Emoji string `json:"emoji,omitempty"`
+	Color string `json:"color,omitempty"` // #rrggbb
+}
+
+// defaultStyles covers the categories used in tracker-mails.json
+var defaultStyles = map[string]Style{
+	"Cinema":                 {Emoji: "🎬", Color: "#9467bd"},
+	"Clothing & Retail":      {Emoji: "👕", Color: "#e377c2"},
+	"E-commerce":             {Emoji: "📦", Color: "#ff7f0e"},
+	"Financial Services":     {Emoji: "🏦", Color: "#2ca02c"},
+	"Food Delivery":          {Emoji: "🍔", Color: "#d62728"},
+	"Subscription":           {Emoji: "🔁", Color: "#17becf"},
+	"Transportation":         {Emoji: "🚗", Color: "#1f77b4"},
+	"Travel & Accommodation": {Emoji: "✈️", Color: "#bcbd22"},
+	"Video Game Platforms":   {Emoji: "🎮", Color: "#8c564b"},
+	Other:                    {Emoji: "❔", Color: "#7f7f7f"},
+}
+
+// fallbackEmoji marks categories without a configured emoji
+const fallbackEmoji = "🏷️"
+
+// fallbackPalette gives unconfigured categories a stable color
+var fallbackPalette = []string{
+	"#393b79", "#637939", "#8c6d31", "#843c39", "#7b4173",
+	"#3182bd", "#e6550d", "#31a354", "#756bb1", "#636363",
+}
+
+// Style returns the display style of category: the user's configured
+// emoji and color, falling back to the built-in defaults
+func (o *Overrides) Style(category string) Style {
+	style := defaultStyles[category]
+	if custom, ok := o.Styles[category]; ok {
+		if custom.Emoji != "" {
+			style.Emoji = custom.Emoji
+		}
+		if custom.Color != "" {
+			style.Color = custom.Color
+		}
+	}
+
+	if style.Emoji == "" {
+		style.Emoji = fallbackEmoji
+	}
+	if style.Color == "" {
+		h := fnv.New32a()
+		h.Write([]byte(category))
+		style.Color = fallbackPalette[h.Sum32()%uint32(len(fallbackPalette))]
+	}
+
+	return style
+}
+
+// RGB returns the components of the style's color
+func (s Style) RGB() (r, g, b uint8, err error) {
+	hex := strings.TrimPrefix(s.Color, "#")
+	if len(hex) != 6 {
+		return 0, 0, 0, fmt.Errorf("invalid color %q (use #rrggbb)", s.Color)
+	}
+
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid color %q (use #rrggbb)", s.Color)
+	}
+	return uint8(v >> 16), uint8(v >> 8), uint8(v), nil
+}
+
+// Paint wraps text in the ANSI escape codes for the style's color
+func (s Style) Paint(text string) string {
+	r, g, b, err := s.RGB()
+	if err != nil {
+		return text
+	}
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm%s\x1b[0m", r, g, b, text)
+}
@@ -0,0 +1,154 @@
+package charts
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/categories"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+)
+
+// Image size of rendered charts, in pixels
+const (
+	chartWidth  = 1024
+	chartHeight = 640
+)
+
+// timelineColor fills the monthly spending bars
+const timelineColor = "#1f77b4"
+
+// Line colors of the daily trend chart
+const (
+	dailyColor = "#c7c7c7"
+	weekColor  = "#1f77b4"
+	monthColor = "#d62728"
+)
+
+// Format selects the image format charts are rendered in
+type Format = chart.RendererProvider
+
+// FormatForPath returns the image format matching a file's extension
+func FormatForPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		return chart.PNG, nil
+	case ".svg":
+		return chart.SVG, nil
+	default:
+		return nil, fmt.Errorf("unsupported chart file %q (use .png or .svg)", path)
+	}
+}
+
+// WriteCategoryPie renders a pie chart of spending by category, using each
+// category's display color
+func WriteCategoryPie(w io.Writer, format Format, transactions []*models.Transaction, styles *categories.Overrides) error {
+	groups := summary.GroupBy(transactions, summary.ByCategory)
+	if len(groups) == 0 {
+		return fmt.Errorf("no transactions to chart")
+	}
+
+	values := make([]chart.Value, 0, len(groups))
+	for _, group := range groups {
+		values = append(values, chart.Value{
+			Label: fmt.Sprintf("%s (%.0f%%)", group.Key, group.Percent),
+			Value: group.Total,
+			Style: chart.Style{FillColor: color(styles.Style(group.Key).Color)},
+		})
+	}
+
+	pie := chart.PieChart{
+		Title:  "Spending by category",
+		Width:  chartWidth,
+		Height: chartHeight,
+		Values: values,
+	}
+	return pie.Render(format, w)
+}
+
+// WriteMonthlyTimeline renders a bar chart of total spending per month,
+// oldest first
+func WriteMonthlyTimeline(w io.Writer, format Format, transactions []*models.Transaction) error {
+	groups := summary.GroupBy(transactions, summary.ByMonth)
+	if len(groups) == 0 {
+		return fmt.Errorf("no transactions to chart")
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+
+	bars := make([]chart.Value, 0, len(groups))
+	for _, group := range groups {
+		bars = append(bars, chart.Value{
+			Label: group.Key,
+			Value: group.Total,
+			Style: chart.Style{FillColor: color(timelineColor), StrokeColor: color(timelineColor)},
+		})
+	}
+
+	timeline := chart.BarChart{
+		Title:  "Monthly spending",
+		Width:  chartWidth,
+		Height: chartHeight,
+		Background: chart.Style{
+			Padding: chart.Box{Top: 40},
+		},
+		BarWidth: barWidth(len(bars)),
+		Bars:     bars,
+	}
+	return timeline.Render(format, w)
+}
+
+// WriteDailyTrend renders daily spending as a line chart, with its 7-day
+// and 30-day rolling averages drawn over it
+func WriteDailyTrend(w io.Writer, format Format, transactions []*models.Transaction) error {
+	days, totals := summary.Daily(transactions)
+	if len(days) < 2 {
+		return fmt.Errorf("not enough days to chart a trend")
+	}
+
+	line := func(name, hex string, width float64, values []float64) chart.TimeSeries {
+		return chart.TimeSeries{
+			Name:    name,
+			Style:   chart.Style{StrokeColor: color(hex), StrokeWidth: width},
+			XValues: days,
+			YValues: values,
+		}
+	}
+
+	trend := chart.Chart{
+		Title:  "Daily spending",
+		Width:  chartWidth,
+		Height: chartHeight,
+		Background: chart.Style{
+			Padding: chart.Box{Top: 40, Left: 20},
+		},
+		XAxis: chart.XAxis{ValueFormatter: chart.TimeValueFormatterWithFormat("2006-01-02")},
+		Series: []chart.Series{
+			line("Daily", dailyColor, 1, totals),
+			line("7-day average", weekColor, 2, summary.RollingAverage(totals, summary.WeekWindow)),
+			line("30-day average", monthColor, 2, summary.RollingAverage(totals, summary.MonthWindow)),
+		},
+	}
+	trend.Elements = []chart.Renderable{chart.Legend(&trend)}
+	return trend.Render(format, w)
+}
+
+// barWidth fits the bars of a timeline into the chart width
+func barWidth(n int) int {
+	width := chartWidth / (2 * n)
+	if width > 60 {
+		return 60
+	}
+	if width < 4 {
+		return 4
+	}
+	return width
+}
+
+func color(hex string) drawing.Color {
+	return drawing.ColorFromHex(strings.TrimPrefix(hex, "#"))
+}
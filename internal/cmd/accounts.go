@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/config"
+	"github.com/sazardev/go-money/internal/mail"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/paths"
+	"github.com/sazardev/go-money/internal/store"
+)
+
+// accountFlag limits syncing to one account from accounts.json
+var accountFlag string
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&accountFlag, "account", "", "Only sync this account from "+config.DefaultAccountsFile)
+}
+
+// syncOutput prints the status of one mailbox's sync. When several
+// accounts sync at once, lines are prefixed with the account name, live
+// progress is replaced by a line per finished stage and logins can't be
+// started.
+type syncOutput struct {
+	prefix string
+}
+
+// concurrent reports whether other accounts are syncing at the same time
+func (o syncOutput) concurrent() bool {
+	return o.prefix != ""
+}
+
+// statusf prints a status line like statusf
+func (o syncOutput) statusf(format string, a ...interface{}) {
+	statusf(o.format(format), a...)
+}
+
+// failuref prints a failure line like printFailure
+func (o syncOutput) failuref(format string, a ...interface{}) {
+	printFailure(o.format(format), a...)
+}
+
+// format prefixes a line, dropping the blank lines that separate steps of a
+// single sync but would split up interleaved ones
+func (o syncOutput) format(format string) string {
+	if !o.concurrent() {
+		return format
+	}
+	return o.prefix + strings.TrimLeft(format, "\n")
+}
+
+// progress returns the progress callback for the mailbox's provider
+func (o syncOutput) progress() func(mail.Progress) {
+	if !o.concurrent() {
+		return printProgress
+	}
+	return func(p mail.Progress) {
+		if !p.Finished {
+			return
+		}
+		switch p.Stage {
+		case mail.StageListing:
+			o.statusf("   🔎 %q: %d emails\n", p.Query, p.Done)
+		case mail.StageScreening:
+			o.statusf("   🧹 Screened %d emails\n", p.Total)
+		case mail.StageDownloading:
+			o.statusf("   📥 Downloaded %d emails\n", p.Total)
+		case mail.StageAttachments:
+			o.statusf("   📎 Downloaded %d attachments\n", p.Total)
+		}
+	}
+}
+
+// selectedAccounts returns the accounts to sync: every one in
+// accounts.json, or only the one named with --account. No accounts means
+// the single mailbox chosen with --provider.
+func selectedAccounts() ([]config.Account, error) {
+	accounts, err := config.LoadAccounts(paths.Config(config.DefaultAccountsFile))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", apperrors.ErrInvalidInput, err)
+	}
+	if accountFlag == "" {
+		return accounts, nil
+	}
+	for _, account := range accounts {
+		if account.Name == accountFlag {
+			return []config.Account{account}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no account named %q in %s", apperrors.ErrInvalidInput, accountFlag, config.DefaultAccountsFile)
+}
+
+// syncAccounts syncs several accounts concurrently into st. An account that
+// fails is reported and skipped, so the others still sync; it only fails
+// when every account does.
+func syncAccounts(ctx context.Context, st store.Store, accounts []config.Account) ([]*models.Message, error) {
+	width := 0
+	for _, account := range accounts {
+		width = max(width, len(account.Name))
+	}
+
+	statusf("\n🔄 Syncing %d accounts...\n", len(accounts))
+	results := make([][]*models.Message, len(accounts))
+	errs := make([]error, len(accounts))
+	var wg sync.WaitGroup
+	for i, account := range accounts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			out := syncOutput{prefix: fmt.Sprintf("[%-*s] ", width, account.Name)}
+			results[i], errs[i] = syncMailbox(ctx, st, account, out)
+		}()
+	}
+	wg.Wait()
+
+	var messages []*models.Message
+	var failed []string
+	for i, account := range accounts {
+		if errs[i] != nil {
+			failed = append(failed, account.Name)
+			errs[i] = fmt.Errorf("account %s: %w", account.Name, errs[i])
+			continue
+		}
+		messages = append(messages, results[i]...)
+	}
+	if len(failed) == len(accounts) {
+		return nil, errors.Join(errs...)
+	}
+	if len(failed) > 0 {
+		statusf("⚠️  %d of %d accounts failed to sync (%s); their earlier transactions are still included\n",
+			len(failed), len(accounts), strings.Join(failed, ", "))
+	}
+	return messages, nil
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/auth"
+	"github.com/sazardev/go-money/internal/config"
+	"github.com/sazardev/go-money/internal/gmail"
+	"github.com/sazardev/go-money/internal/mail"
+	"github.com/sazardev/go-money/internal/outlook"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	authCmd.AddCommand(authStatusCmd)
+}
+
+// signInStatus is the state of one saved sign-in, as reported by gm auth
+// status
+type signInStatus struct {
+	Account   string     `json:"account,omitempty" yaml:"account,omitempty"`
+	Provider  string     `json:"provider" yaml:"provider"`
+	SignedIn  bool       `json:"signed_in" yaml:"signed_in"`
+	Email     string     `json:"email,omitempty" yaml:"email,omitempty"`
+	Expiry    *time.Time `json:"expiry,omitempty" yaml:"expiry,omitempty"` // Of the access token
+	Renewable bool       `json:"renewable" yaml:"renewable"`               // Refreshed without a new login
+	Scopes    []string   `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	Error     string     `json:"error,omitempty" yaml:"error,omitempty"`
+	Login     string     `json:"login,omitempty" yaml:"login,omitempty"` // Command that fixes the sign-in
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which accounts are signed in, and to which address",
+	Long: `Status checks every saved sign-in without opening a login: it renews the
+access token if needed, then shows the email address of the mailbox, when
+the token expires and the permissions it grants. Accounts from
+` + config.DefaultAccountsFile + ` are always listed; the default Google and Microsoft
+sign-ins when they are saved. Use --account to check one account.
+
+It fails when a listed account isn't signed in, so scripts can check the
+setup before running gm calculate.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		accounts, err := signInAccounts()
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		statuses := make([]signInStatus, 0, len(accounts))
+		failed := 0
+		for _, account := range accounts {
+			status := checkSignIn(ctx, account)
+			if status.Error != "" {
+				failed++
+			}
+			statuses = append(statuses, status)
+		}
+
+		switch outputFormat {
+		case outputJSON:
+			err = summary.WriteJSON(os.Stdout, statuses)
+		case outputYAML:
+			err = summary.WriteYAML(os.Stdout, statuses)
+		default:
+			printSignIns(statuses)
+		}
+		if err != nil {
+			return err
+		}
+		if failed > 0 {
+			return fmt.Errorf("%w: %d of %d sign-ins need attention", apperrors.ErrAuthRequired, failed, len(statuses))
+		}
+		return nil
+	},
+}
+
+// signInAccounts returns the accounts gm auth status checks: the --account
+// one, or every configured account and the saved default sign-ins. The
+// default sign-in of the --provider mailbox is checked when no account is
+// configured, so a missing login is reported.
+func signInAccounts() ([]config.Account, error) {
+	configured, err := selectedAccounts()
+	if err != nil {
+		return nil, err
+	}
+	var accounts []config.Account
+	for _, account := range configured {
+		if accountProvider(account) != "imap" {
+			accounts = append(accounts, account)
+		}
+	}
+	if accountFlag != "" {
+		if len(accounts) == 0 {
+			statusf("💡 IMAP accounts keep no sign-in; gm reads their password from the variable set in %s instead\n", config.DefaultAccountsFile)
+		}
+		return accounts, nil
+	}
+
+	for _, provider := range []string{"gmail", "outlook"} {
+		if _, err := providerAuthenticator(provider).CachedToken(); err == nil || (len(accounts) == 0 && selectedProvider() == provider) {
+			accounts = append(accounts, config.Account{Provider: provider})
+		}
+	}
+	if len(accounts) == 0 {
+		statusf("💡 IMAP keeps no sign-in; gm reads GM_IMAP_HOST, GM_IMAP_USERNAME and GM_IMAP_PASSWORD instead\n")
+	}
+	return accounts, nil
+}
+
+// checkSignIn renews the saved token of an account and asks the provider
+// which address and permissions it has
+func checkSignIn(ctx context.Context, account config.Account) signInStatus {
+	provider := strings.ToLower(account.Provider)
+	authenticator := providerAuthenticator(provider).ForAccount(account.Name)
+	status := signInStatus{Account: account.Name, Provider: authenticator.Provider()}
+	fail := func(err error) signInStatus {
+		status.Error = err.Error()
+		if errors.Is(err, apperrors.ErrAuthRequired) {
+			status.SignedIn = false
+			status.Login = loginCommand(account)
+		}
+		return status
+	}
+
+	token, err := authenticator.CachedToken()
+	if err != nil {
+		return fail(fmt.Errorf("%w: not signed in", apperrors.ErrAuthRequired))
+	}
+	status.SignedIn = true
+	token, err = authenticator.TokenSource(ctx, token).Token()
+	if err != nil {
+		return fail(err)
+	}
+	status.Expiry = &token.Expiry
+	status.Renewable = token.RefreshToken != ""
+
+	if status.Scopes, err = authenticator.Scopes(ctx, token); err != nil {
+		return fail(err)
+	}
+
+	var mailbox mail.Identifier
+	if provider == "outlook" {
+		mailbox = outlook.NewOutlookService(ctx, token)
+	} else if mailbox, err = gmail.NewGmailService(ctx, token); err != nil {
+		return fail(err)
+	}
+	if status.Email, err = mailbox.Address(ctx); err != nil {
+		return fail(err)
+	}
+	return status
+}
+
+// printSignIns prints the sign-ins checked by gm auth status
+func printSignIns(statuses []signInStatus) {
+	if len(statuses) == 0 {
+		return
+	}
+	fmt.Println("\n🔐 Sign-ins:")
+	fmt.Println("─────────────────────────────────────────────────────────────────────────")
+	for _, status := range statuses {
+		name := status.Provider
+		if status.Account != "" {
+			name += " (" + status.Account + ")"
+		}
+		if status.Error != "" {
+			fmt.Printf("❌ %s: %s\n", name, status.Error)
+			if status.Login != "" {
+				fmt.Printf("   💡 Run '%s' to sign in\n", status.Login)
+			}
+			continue
+		}
+
+		fmt.Printf("✅ %s: %s\n", name, status.Email)
+		expiry := status.Expiry.Local().Format("2006-01-02 15:04")
+		if status.Renewable {
+			fmt.Printf("   Access token valid until %s, renewed automatically\n", expiry)
+		} else {
+			fmt.Printf("   Access token valid until %s; sign in again after that\n", expiry)
+		}
+		fmt.Printf("   Scopes: %s\n", strings.Join(status.Scopes, ", "))
+	}
+}
+
+// loginCommand returns the command that signs in to an account, or to the
+// default sign-in of its provider
+func loginCommand(account config.Account) string {
+	switch {
+	case account.Name != "":
+		return "gm auth login --account " + account.Name
+	case strings.ToLower(account.Provider) == "outlook":
+		return "gm auth login --provider outlook"
+	}
+	return "gm auth login"
+}
+
+// providerAuthenticator returns the authenticator of a mail provider's
+// default sign-in
+func providerAuthenticator(provider string) *auth.Authenticator {
+	if provider == "outlook" {
+		return auth.NewMicrosoftAuthenticator()
+	}
+	return auth.NewAuthenticator()
+}
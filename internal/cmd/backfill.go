@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/config"
+	"github.com/sazardev/go-money/internal/mail"
+	"github.com/sazardev/go-money/internal/store"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// backfillDateFormat is how checkpoint dates are stored
+	backfillDateFormat = "2006-01-02"
+	// quotaRetries is how many times a chunk is retried after hitting the quota
+	quotaRetries = 5
+	// quotaBackoff is the first wait after hitting the quota; it doubles per retry
+	quotaBackoff = 30 * time.Second
+)
+
+func init() {
+	rootCmd.AddCommand(backfillCmd)
+
+	backfillCmd.Flags().String("from", "", "Import receipts since this date (YYYY-MM-DD, required)")
+	backfillCmd.Flags().String("to", "", "Import receipts up to this date (YYYY-MM-DD, default today)")
+	backfillCmd.Flags().Duration("pace", 2*time.Second, "Pause between months to stay under the Gmail quota")
+	backfillCmd.Flags().Bool("restart", false, "Ignore the saved checkpoint and start again from --from")
+}
+
+var backfillCmd = &cobra.Command{
+	Use:   "backfill",
+	Short: "Import years of receipts month by month",
+	Long: `Backfill walks the mailbox one month at a time, saving a checkpoint after
+each month so an interrupted import resumes where it stopped. It pauses
+between months and backs off when the Gmail quota is exceeded.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fromStr, _ := cmd.Flags().GetString("from")
+		toStr, _ := cmd.Flags().GetString("to")
+		pace, _ := cmd.Flags().GetDuration("pace")
+		restart, _ := cmd.Flags().GetBool("restart")
+
+		if fromStr == "" {
+			return fmt.Errorf("%w: --from is required", apperrors.ErrInvalidInput)
+		}
+		from, err := parseDate(fromStr)
+		if err != nil {
+			printFailure("❌ Invalid --from date: %v (use YYYY-MM-DD)\n", err)
+			return fmt.Errorf("%w: --from %q", apperrors.ErrInvalidInput, fromStr)
+		}
+
+		to := time.Now()
+		if toStr != "" {
+			if to, err = parseDate(toStr); err != nil {
+				printFailure("❌ Invalid --to date: %v (use YYYY-MM-DD)\n", err)
+				return fmt.Errorf("%w: --to %q", apperrors.ErrInvalidInput, toStr)
+			}
+		}
+		// The search end is exclusive
+		end := time.Date(to.Year(), to.Month(), to.Day()+1, 0, 0, 0, 0, time.UTC)
+		if !from.Before(end) {
+			return fmt.Errorf("%w: --from must be before --to", apperrors.ErrInvalidInput)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		accounts, err := selectedAccounts()
+		if err != nil {
+			return err
+		}
+		if len(accounts) > 1 {
+			return fmt.Errorf("%w: backfill one account at a time with --account", apperrors.ErrInvalidInput)
+		}
+		var account config.Account
+		if len(accounts) == 1 {
+			account = accounts[0]
+		}
+
+		st, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		start := from
+		if !restart {
+			if start, err = backfillCheckpoint(ctx, st, account.Name, from); err != nil {
+				return err
+			}
+			if start.After(from) {
+				statusf("⏩ Resuming from checkpoint %s\n", start.Format(backfillDateFormat))
+			}
+		}
+		if !start.Before(end) {
+			statusf("✅ Backfill from %s is already complete (use --restart to run it again)\n", fromStr)
+			return nil
+		}
+
+		provider, err := connectProvider(ctx, account, syncOutput{})
+		if err != nil {
+			return err
+		}
+		defer provider.Close()
+
+		total := monthsBetween(from, end)
+		emails, found := 0, 0
+
+		statusf("\n📦 Backfilling %s to %s (%d months)...\n", from.Format(backfillDateFormat), to.Format(backfillDateFormat), total)
+		for chunk := start; chunk.Before(end); chunk = nextMonth(chunk) {
+			chunkEnd := nextMonth(chunk)
+			if chunkEnd.After(end) {
+				chunkEnd = end
+			}
+
+			query := mail.Query{Terms: transactionQueries, After: chunk, Before: chunkEnd, All: true}
+
+			var messages, transactions int
+			err := withQuotaBackoff(ctx, func() error {
+				ids, err := provider.Search(ctx, query)
+				if err != nil {
+					return err
+				}
+				fetched, txs, err := syncMessages(ctx, st, provider, ids, syncOutput{})
+				messages, transactions = len(fetched), len(txs)
+				return err
+			})
+			if err != nil {
+				if ctx.Err() != nil {
+					statusf("\n⏸️  Interrupted; run the same command again to resume from %s\n", chunk.Format(backfillDateFormat))
+				}
+				forgetRevokedToken(err, account, syncOutput{})
+				return err
+			}
+
+			if err := saveBackfillCheckpoint(ctx, st, account.Name, from, chunkEnd); err != nil {
+				return err
+			}
+
+			emails += messages
+			found += transactions
+			done := monthsBetween(from, chunkEnd)
+			statusf("[%*d/%d] %s  %4d new emails, %4d transactions  (%3.0f%%)\n",
+				len(fmt.Sprint(total)), done, total, chunk.Format("2006-01"), messages, transactions, 100*float64(done)/float64(total))
+
+			if chunkEnd.Before(end) && !sleepCtx(ctx, pace) {
+				statusf("\n⏸️  Interrupted; run the same command again to resume from %s\n", chunkEnd.Format(backfillDateFormat))
+				return ctx.Err()
+			}
+		}
+
+		if err := st.SetSyncState(ctx, store.LastSyncKey, time.Now().UTC().Format(time.RFC3339)); err != nil {
+			return err
+		}
+
+		statusf("\n✅ Backfill complete: %d new emails, %d transactions\n", emails, found)
+		return nil
+	},
+}
+
+// backfillCheckpoint returns where a backfill from the given date should
+// start. A checkpoint left by a backfill with a different --from is ignored.
+func backfillCheckpoint(ctx context.Context, st store.Store, account string, from time.Time) (time.Time, error) {
+	savedFrom, err := st.SyncState(ctx, store.AccountKey(store.BackfillFromKey, account))
+	if err != nil || savedFrom != from.Format(backfillDateFormat) {
+		return from, err
+	}
+
+	cursor, err := st.SyncState(ctx, store.AccountKey(store.BackfillCursorKey, account))
+	if err != nil || cursor == "" {
+		return from, err
+	}
+
+	next, err := time.Parse(backfillDateFormat, cursor)
+	if err != nil || next.Before(from) {
+		return from, nil
+	}
+	return next, nil
+}
+
+// saveBackfillCheckpoint records that everything before next has been imported
+func saveBackfillCheckpoint(ctx context.Context, st store.Store, account string, from, next time.Time) error {
+	if err := st.SetSyncState(ctx, store.AccountKey(store.BackfillFromKey, account), from.Format(backfillDateFormat)); err != nil {
+		return err
+	}
+	return st.SetSyncState(ctx, store.AccountKey(store.BackfillCursorKey, account), next.Format(backfillDateFormat))
+}
+
+// withQuotaBackoff runs fn, waiting and retrying with exponential backoff
+// while it fails because the Gmail quota is exceeded
+func withQuotaBackoff(ctx context.Context, fn func() error) error {
+	wait := quotaBackoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || !errors.Is(err, apperrors.ErrQuotaExceeded) || attempt == quotaRetries {
+			return err
+		}
+
+		statusf("⏳ Gmail quota exceeded, waiting %s before retrying...\n", wait)
+		if !sleepCtx(ctx, wait) {
+			return ctx.Err()
+		}
+		wait *= 2
+	}
+}
+
+// sleepCtx waits for d and reports false if ctx was cancelled first
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// nextMonth returns the first day of the month after t
+func nextMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// monthsBetween counts the month chunks needed to cover [from, end)
+func monthsBetween(from, end time.Time) int {
+	n := 0
+	for t := from; t.Before(end); t = nextMonth(t) {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/bills"
+	"github.com/sazardev/go-money/internal/export"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(billsCmd)
+
+	billsCmd.Flags().Bool("due-soon", false, "Only show bills due within --days")
+	billsCmd.Flags().Int("days", 7, "How many days ahead counts as due soon")
+	billsCmd.Flags().String("remind", "", "Write the bills to this .ics file with a reminder before each due date")
+	billsCmd.Flags().Int("remind-days", 3, "How many days before the due date the reminder fires")
+	billsFilters = addFilterFlags(billsCmd)
+}
+
+// billsFilters holds the filter flags of gm bills
+var billsFilters *filterFlags
+
+var billsCmd = &cobra.Command{
+	Use:   "bills",
+	Short: "List upcoming bills found in receipt emails",
+	Long: `Bills lists transaction emails that mention a payment due date, soonest
+first. With --remind, the bills are also written to an iCalendar file whose
+events carry an alarm --remind-days before each due date; import it into
+Google or Apple Calendar to get reminders.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dueSoon, _ := cmd.Flags().GetBool("due-soon")
+		days, _ := cmd.Flags().GetInt("days")
+		remind, _ := cmd.Flags().GetString("remind")
+		remindDays, _ := cmd.Flags().GetInt("remind-days")
+
+		if days < 0 || remindDays < 0 {
+			return fmt.Errorf("%w: --days and --remind-days must not be negative", apperrors.ErrInvalidInput)
+		}
+
+		filters, err := billsFilters.filters()
+		if err != nil {
+			return err
+		}
+
+		result, err := syncTransactions(context.Background())
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		upcoming := bills.Upcoming(summary.Apply(result.Transactions, filters...), now)
+		if dueSoon {
+			upcoming = bills.DueWithin(upcoming, days)
+		}
+
+		if remind != "" {
+			file, err := os.Create(remind)
+			if err != nil {
+				printFailure("❌ Failed to create %s: %v\n", remind, err)
+				return err
+			}
+			defer file.Close()
+
+			if err := export.WriteBillsICal(file, upcoming, remindDays, now); err != nil {
+				printFailure("❌ Failed to write calendar: %v\n", err)
+				return err
+			}
+			statusf("\n📅 %d bill reminders written to %s\n", len(upcoming), remind)
+		}
+
+		if upcoming == nil {
+			upcoming = []bills.Bill{}
+		}
+
+		switch outputFormat {
+		case outputJSON:
+			return summary.WriteJSON(os.Stdout, upcoming)
+		case outputYAML:
+			return summary.WriteYAML(os.Stdout, upcoming)
+		}
+
+		if len(upcoming) == 0 {
+			statusf("\n✅ No upcoming bills\n")
+			return nil
+		}
+
+		fmt.Println("\n🧾 Upcoming bills:")
+		fmt.Println("─────────────────────────────────────────────────")
+		for _, bill := range upcoming {
+			when := fmt.Sprintf("in %d days", bill.DaysLeft)
+			switch bill.DaysLeft {
+			case 0:
+				when = "today"
+			case 1:
+				when = "tomorrow"
+			}
+			fmt.Printf("%s  %-20s %s%.2f %s  (due %s)\n", bill.DueDate.Format("2006-01-02"),
+				truncateString(bill.ServiceName, 20), bill.CurrencySymbol, bill.Amount, bill.Currency, when)
+		}
+
+		return nil
+	},
+}
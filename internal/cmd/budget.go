@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/categories"
+	"github.com/sazardev/go-money/internal/extractor"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/store"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(budgetCmd)
+	budgetCmd.AddCommand(budgetSetCmd, budgetListCmd, budgetRemoveCmd)
+
+	budgetSetCmd.Flags().String("currency", "", "Only count transactions in this currency (default: every currency)")
+}
+
+var budgetCmd = &cobra.Command{
+	Use:   "budget",
+	Short: "Manage monthly spending limits per category",
+	Long: `Budget saves monthly spending limits per category in the local store.
+Spending is counted from the first day of each month, so every budget starts
+over monthly. gm calculate compares this month's spending with each budget
+and warns about categories over or projected over budget; gm report --pivot
+compares every month of the table.
+
+Budgets in ` + categories.DefaultFile + ` still apply; one set here for the
+same category replaces it.`,
+}
+
+var budgetSetCmd = &cobra.Command{
+	Use:   "set <category> <amount>",
+	Short: "Set the monthly budget of a category",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		currency, _ := cmd.Flags().GetString("currency")
+		if err := checkWritable("gm budget set", store.Redacted(storePath)); err != nil {
+			return err
+		}
+		if err := checkStored("gm budget set"); err != nil {
+			return err
+		}
+
+		amount, err := strconv.ParseFloat(args[1], 64)
+		if err != nil {
+			return fmt.Errorf("%w: budget amount %q isn't a number", apperrors.ErrInvalidInput, args[1])
+		}
+		currency = strings.ToUpper(currency)
+		if err := (categories.Budget{Amount: amount, Currency: currency}).Validate(); err != nil {
+			return fmt.Errorf("%w: %w", apperrors.ErrInvalidInput, err)
+		}
+		budget := models.Budget{Category: knownCategory(args[0]), Amount: amount, Currency: currency}
+
+		st, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		if err := st.SetBudget(context.Background(), budget); err != nil {
+			printFailure("❌ Failed to save the budget: %v\n", err)
+			return err
+		}
+		statusf("✅ %s budget set to %.2f %s per month\n", budget.Category, budget.Amount, budgetCurrency(budget.Currency))
+		return nil
+	},
+}
+
+var budgetListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the monthly budgets",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		st, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		budgets, err := loadBudgets(ctx, st)
+		if err != nil {
+			return err
+		}
+		list := make([]models.Budget, 0, len(budgets))
+		for category, b := range budgets {
+			list = append(list, models.Budget{Category: category, Amount: b.Amount, Currency: b.Currency})
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Category < list[j].Category })
+
+		switch outputFormat {
+		case outputJSON:
+			return summary.WriteJSON(os.Stdout, list)
+		case outputYAML:
+			return summary.WriteYAML(os.Stdout, list)
+		}
+
+		if len(list) == 0 {
+			statusf("\n💡 No budgets yet; add one with gm budget set <category> <amount>\n")
+			return nil
+		}
+		styles := loadCategoryStyles()
+		fmt.Println("\n🎯 Monthly budgets:")
+		fmt.Println("─────────────────────────────────────────────────")
+		for _, b := range list {
+			fmt.Printf("%s: %10.2f %s\n", categoryLabel(styles, b.Category, 20), b.Amount, budgetCurrency(b.Currency))
+		}
+		return nil
+	},
+}
+
+var budgetRemoveCmd = &cobra.Command{
+	Use:   "remove <category>",
+	Short: "Remove the budget of a category set with gm budget set",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		if err := checkWritable("gm budget remove", store.Redacted(storePath)); err != nil {
+			return err
+		}
+		if err := checkStored("gm budget remove"); err != nil {
+			return err
+		}
+
+		st, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		saved, err := st.Budgets(ctx)
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, b := range saved {
+			found = found || strings.EqualFold(b.Category, args[0])
+		}
+		if !found {
+			return fmt.Errorf("%w: no budget for %q was set with gm budget set", apperrors.ErrInvalidInput, args[0])
+		}
+
+		if err := st.DeleteBudget(ctx, args[0]); err != nil {
+			printFailure("❌ Failed to remove the budget: %v\n", err)
+			return err
+		}
+		statusf("✅ Removed the %s budget\n", args[0])
+		return nil
+	},
+}
+
+// loadBudgets returns the monthly budgets by category: those of
+// category-rules.json, replaced by the ones saved with gm budget set for
+// the same category, ignoring case
+func loadBudgets(ctx context.Context, st store.Store) (map[string]categories.Budget, error) {
+	budgets := make(map[string]categories.Budget)
+	for category, b := range loadCategoryStyles().Budgets {
+		budgets[category] = b
+	}
+
+	saved, err := st.Budgets(ctx)
+	if err != nil {
+		printFailure("❌ Failed to read budgets: %v\n", err)
+		return nil, err
+	}
+	for _, b := range saved {
+		for category := range budgets {
+			if strings.EqualFold(category, b.Category) {
+				delete(budgets, category)
+			}
+		}
+		budgets[b.Category] = categories.Budget{Amount: b.Amount, Currency: b.Currency}
+	}
+	return budgets, nil
+}
+
+// knownCategory returns the spelling tracker-mails.json uses for category,
+// so "groceries" is saved as "Groceries"; other categories are kept as given
+func knownCategory(category string) string {
+	txExtractor, err := extractor.NewTransactionExtractor()
+	if err != nil {
+		return category
+	}
+	for _, known := range loadCategoryStyles().Known(txExtractor.GetCategories()) {
+		if strings.EqualFold(known, category) {
+			return known
+		}
+	}
+	statusf("⚠️  %q isn't a category of any service in tracker-mails.json or the category preset; only transactions recategorized into it will count\n", category)
+	return category
+}
+
+// budgetCurrency names the currency a budget counts
+func budgetCurrency(currency string) string {
+	if currency == "" {
+		return "(every currency)"
+	}
+	return currency
+}
+
+// budgetBarWidth is the width of the budget bar in the calculate footer
+const budgetBarWidth = 20
+
+// budgetStyles are the marker and ANSI color of each budget level
+var budgetStyles = map[string]struct{ marker, color string }{
+	models.BudgetOnTrack: {"🟢", "32"},
+	models.BudgetAtRisk:  {"🟡", "33"},
+	models.BudgetOver:    {"🔴", "31"},
+}
+
+// paintBudget colors text green, yellow or red by a budget level when the
+// terminal supports it
+func paintBudget(level, text string) string {
+	if !colorEnabled() {
+		return text
+	}
+	return "\x1b[" + budgetStyles[level].color + "m" + text + "\x1b[0m"
+}
+
+// budgetFor returns the status of category's budget among budgets that
+// counts amounts in currency, nil when it has none. An empty currency
+// matches any budget.
+func budgetFor(budgets []models.BudgetStatus, category, currency string) *models.BudgetStatus {
+	for i, b := range budgets {
+		if strings.EqualFold(b.Category, category) && (currency == "" || b.Currency == "" || b.Currency == currency) {
+			return &budgets[i]
+		}
+	}
+	return nil
+}
+
+// printBudgetBars prints a compact bar of how much of the budgets has been
+// spent this month, one per currency they are in, colored by how the
+// combined budget fares
+func printBudgetBars(budgets []models.BudgetStatus) {
+	// Grouped by currency code: several currencies share a symbol, like $
+	var currencies []string
+	combined := make(map[string]*models.BudgetStatus)
+	levels := make(map[string]map[string]int)
+	for _, b := range budgets {
+		total, ok := combined[b.Currency]
+		if !ok {
+			currencies = append(currencies, b.Currency)
+			total = &models.BudgetStatus{Currency: b.Currency, CurrencySymbol: b.CurrencySymbol}
+			combined[b.Currency], levels[b.Currency] = total, make(map[string]int)
+		}
+		total.Budget += b.Budget
+		total.Spent += b.Spent
+		total.Projected += b.Projected
+		levels[b.Currency][b.Level()]++
+	}
+
+	for _, currency := range currencies {
+		total := combined[currency]
+		if total.Budget <= 0 {
+			continue
+		}
+		total.Used = total.Spent / total.Budget * 100
+		if total.Projected > total.Budget {
+			total.Overage = total.Projected - total.Budget
+		}
+		filled := min(max(int(total.Used/100*budgetBarWidth), 0), budgetBarWidth)
+		bar := strings.Repeat("█", filled) + strings.Repeat("░", budgetBarWidth-filled)
+
+		var counts []string
+		for _, level := range []struct{ level, name string }{{models.BudgetOver, "over"}, {models.BudgetAtRisk, "at risk"}} {
+			if n := levels[currency][level.level]; n > 0 {
+				counts = append(counts, fmt.Sprintf("%s %d %s", budgetStyles[level.level].marker, n, level.name))
+			}
+		}
+		detail := ""
+		if len(counts) > 0 {
+			detail = " (" + strings.Join(counts, ", ") + ")"
+		}
+		amount := fmt.Sprintf("%s%.2f", total.CurrencySymbol, total.Budget)
+		if len(currencies) > 1 && currency != "" {
+			amount += " " + currency
+		}
+		fmt.Printf("🎯 Budgets: %s %.0f%% of %s%s\n", paintBudget(total.Level(), bar), total.Used, amount, detail)
+	}
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/sazardev/go-money/internal/cache"
+	"github.com/sazardev/go-money/internal/paths"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of external lookups (exchange rates, LLM replies)",
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear [namespace...]",
+	Short: "Remove cached lookups, or only those of the given namespaces (fx, llm)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		removed, err := cache.New(paths.Cache()).Clear(args...)
+		if err != nil {
+			printFailure("❌ Failed to clear the cache: %v\n", err)
+			return err
+		}
+
+		fmt.Printf("🧹 Removed %d cached entries\n", removed)
+		return nil
+	},
+}
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/catalog"
+	"github.com/sazardev/go-money/internal/config"
+	"github.com/sazardev/go-money/internal/extractor"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	servicesCmd.AddCommand(servicesUpdateCmd, servicesSignCatalogCmd)
+
+	servicesUpdateCmd.Flags().String("url", "", "Catalog to download (default catalog.url, or the upstream one)")
+	servicesUpdateCmd.Flags().Bool("check", false, "Show what the catalog would change without installing it")
+	servicesUpdateCmd.Flags().Bool("force", false, "Install the catalog even if it isn't newer than the installed one")
+
+	servicesSignCatalogCmd.Flags().String("key", "", "File holding the signing key; created with a new key if missing (required)")
+	servicesSignCatalogCmd.MarkFlagRequired("key")
+}
+
+// catalogUpdate is what gm services update found and did
+type catalogUpdate struct {
+	URL             string `json:"url" yaml:"url"`
+	Version         int    `json:"version" yaml:"version"`
+	Updated         string `json:"updated,omitempty" yaml:"updated,omitempty"`
+	PreviousVersion int    `json:"previous_version,omitempty" yaml:"previous_version,omitempty"`
+	catalog.Changes `yaml:",inline"`
+	Kept            []string `json:"kept,omitempty" yaml:"kept,omitempty"` // Services of the catalog the user's tracker file replaces
+	Installed       bool     `json:"installed" yaml:"installed"`
+}
+
+var servicesUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Download the community catalog of services",
+	Long: `Update downloads the service catalog maintained upstream, so merchants
+added between releases, such as new fintechs and streaming services, are
+recognized without upgrading gm. The catalog is a versioned
+tracker-mails.json published with an Ed25519 signature, which is checked
+against catalog.key (GM_CATALOG_KEY) before anything is installed.
+
+Its services replace the built-in ones with the same ID, and your own
+tracker-mails.json still replaces both. Older catalogs than the installed
+one are refused unless --force is given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		check, _ := cmd.Flags().GetBool("check")
+		force, _ := cmd.Flags().GetBool("force")
+		if !check {
+			if err := checkWritable("gm services update", extractor.CatalogPath()); err != nil {
+				return err
+			}
+		}
+
+		cfg := config.LoadConfig()
+		url, _ := cmd.Flags().GetString("url")
+		if url == "" {
+			url = cfg.CatalogURL
+		}
+		if url == "" {
+			url = catalog.DefaultURL
+		}
+		key := cfg.CatalogKey
+		if key == "" {
+			key = catalog.DefaultKey
+		}
+		client, err := catalog.NewClient(url, key)
+		if err != nil {
+			return fmt.Errorf("%w: %w", apperrors.ErrInvalidInput, err)
+		}
+
+		installed, err := extractor.LoadCatalog()
+		if err != nil {
+			statusf("⚠️  Ignoring the installed catalog, which doesn't load: %v\n", err)
+			installed = nil
+		}
+		statusf("🌐 Downloading the service catalog from %s...\n", url)
+		next, data, err := client.Fetch(context.Background())
+		if err != nil {
+			printFailure("❌ Failed to download the service catalog: %v\n", err)
+			return err
+		}
+
+		update := catalogUpdate{URL: url, Version: next.Version, Updated: next.Updated, Changes: catalog.Compare(installed, next)}
+		if installed != nil {
+			update.PreviousVersion = installed.Version
+		}
+		if te, err := extractor.NewTransactionExtractor(); err == nil {
+			for _, service := range next.Services {
+				if current := te.GetServiceByID(service.ID); current != nil && current.Source() != extractor.SourceBuiltIn && current.Source() != extractor.SourceCatalog {
+					update.Kept = append(update.Kept, service.ID)
+				}
+			}
+		}
+
+		switch {
+		case installed != nil && next.Version < installed.Version && !force:
+			return fmt.Errorf("%w: %s offers catalog v%d, older than the installed v%d; --force installs it anyway", apperrors.ErrInvalidInput, url, next.Version, installed.Version)
+		case installed != nil && next.Version == installed.Version && !force:
+		case !check:
+			if err := catalog.Install(data); err != nil {
+				printFailure("❌ Failed to install the service catalog: %v\n", err)
+				return err
+			}
+			// Load the catalog as gm will, so a mistake shows now rather than on the next sync
+			if _, err := extractor.NewTransactionExtractor(); err != nil {
+				printFailure("❌ %s was installed but doesn't load: %v\n", extractor.CatalogPath(), err)
+				return err
+			}
+			update.Installed = true
+		}
+
+		switch outputFormat {
+		case outputJSON:
+			return summary.WriteJSON(os.Stdout, update)
+		case outputYAML:
+			return summary.WriteYAML(os.Stdout, update)
+		}
+		printCatalogUpdate(update, next)
+		return nil
+	},
+}
+
+// printCatalogUpdate describes a catalog download
+func printCatalogUpdate(update catalogUpdate, next *extractor.Catalog) {
+	names := make(map[string]string, len(next.Services))
+	for _, service := range next.Services {
+		names[service.ID] = service.Name
+	}
+	list := func(emoji, title string, ids []string) {
+		if len(ids) == 0 {
+			return
+		}
+		fmt.Printf("%s %s:\n", emoji, title)
+		for _, id := range ids {
+			if name := names[id]; name != "" {
+				fmt.Printf("   %-22s %s\n", id, name)
+			} else {
+				fmt.Printf("   %s\n", id)
+			}
+		}
+	}
+
+	updated := ""
+	if update.Updated != "" {
+		updated = ", published " + update.Updated
+	}
+	switch {
+	case update.PreviousVersion == update.Version && !update.Installed:
+		fmt.Printf("✅ The service catalog is up to date (v%d%s)\n", update.Version, updated)
+		return
+	case update.Installed:
+		fmt.Printf("✅ Installed service catalog v%d%s with %d services\n", update.Version, updated, len(next.Services))
+	default:
+		fmt.Printf("🔎 Service catalog v%d%s has %d services; nothing was installed\n", update.Version, updated, len(next.Services))
+	}
+	list("🆕", "Added", update.Added)
+	list("✏️", "Changed", update.Changed)
+	list("🗑️", "Removed", update.Removed)
+	if len(update.Kept) > 0 {
+		statusf("💡 Your %s keeps your own version of %s\n", extractor.TrackerFile, strings.Join(update.Kept, ", "))
+	}
+}
+
+var servicesSignCatalogCmd = &cobra.Command{
+	Use:    "sign-catalog <catalog.json>",
+	Short:  "Sign a service catalog for publishing",
+	Hidden: true, // For the catalog's maintainers
+	Long: `Sign-catalog checks a catalog and writes its signature next to it, as
+<catalog.json>.sig, to be published along with it. The key file is created
+with a new key if it doesn't exist; keep it secret, and give users its
+public key, printed here, as catalog.key.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keyPath, _ := cmd.Flags().GetString("key")
+		path := args[0]
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		next, err := extractor.ParseCatalog(data)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", apperrors.ErrInvalidInput, path, err)
+		}
+		key, err := catalog.LoadPrivateKey(keyPath, true)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path+catalog.SignatureExt, catalog.Sign(data, key), 0644); err != nil {
+			return err
+		}
+
+		statusf("✅ Signed catalog v%d with %d services: %s\n", next.Version, len(next.Services), path+catalog.SignatureExt)
+		fmt.Printf("🔑 Public key: %s\n", catalog.PublicKey(key))
+		return nil
+	},
+}
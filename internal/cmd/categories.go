@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/categories"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/paths"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(categoriesCmd)
+	categoriesCmd.AddCommand(categoriesPresetsCmd, categoriesApplyPresetCmd)
+}
+
+var categoriesCmd = &cobra.Command{
+	Use:   "categories",
+	Short: "Manage the categories transactions are grouped in",
+}
+
+var categoriesPresetsCmd = &cobra.Command{
+	Use:   "presets",
+	Short: "List the category presets gm categories apply-preset offers",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		presets := categories.Presets()
+		switch outputFormat {
+		case outputJSON:
+			return summary.WriteJSON(os.Stdout, presets)
+		case outputYAML:
+			return summary.WriteYAML(os.Stdout, presets)
+		}
+
+		applied := loadCategoryStyles().Remap
+		fmt.Println("\n🗂️  Category presets:")
+		fmt.Println("─────────────────────────────────────────────────")
+		for _, preset := range presets {
+			marker := ""
+			if len(applied) > 0 && presetApplied(preset, applied) {
+				marker = " (applied)"
+			}
+			fmt.Printf("%-18s %s%s\n", preset.Name, preset.Title, marker)
+			fmt.Printf("%-18s %s\n", "", preset.Description)
+		}
+		statusf("\n💡 Apply one with gm categories apply-preset <name>\n")
+		return nil
+	},
+}
+
+var categoriesApplyPresetCmd = &cobra.Command{
+	Use:   "apply-preset <name>",
+	Short: "Replace the tracker categories with those of a preset",
+	Long: `Apply-preset remaps the categories of tracker-mails.json in bulk to those of
+a preset pack, such as ynab, mexican-household or freelancer; see gm
+categories presets. The mapping is saved to ` + categories.DefaultFile + `, where
+it can be edited, and replaces any preset applied before.
+
+Sender rules, per-transaction choices, styles and budgets naming a remapped
+category are moved to its new name. Budgets of categories merged into one
+add up.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkWritable("gm categories apply-preset", categories.DefaultFile); err != nil {
+			return err
+		}
+		preset, ok := categories.FindPreset(args[0])
+		if !ok {
+			var names []string
+			for _, p := range categories.Presets() {
+				names = append(names, p.Name)
+			}
+			return fmt.Errorf("%w: unknown category preset %q (use %s)", apperrors.ErrInvalidInput, args[0], strings.Join(names, ", "))
+		}
+
+		overrides, err := categories.Load(paths.Config(categories.DefaultFile))
+		if err != nil {
+			return err
+		}
+		renamed := overrides.ApplyPreset(preset)
+		if err := overrides.Save(); err != nil {
+			printFailure("❌ Failed to save %s: %v\n", categories.DefaultFile, err)
+			return err
+		}
+		if !noStore {
+			if err := renameStoredBudgets(renamed); err != nil {
+				return err
+			}
+		}
+
+		statusf("✅ Applied the %s preset to %s\n", preset.Title, categories.DefaultFile)
+		for _, from := range slices.Sorted(maps.Keys(preset.Categories)) {
+			statusf("   %s → %s\n", from, preset.Categories[from])
+		}
+		for _, service := range slices.Sorted(maps.Keys(preset.Services)) {
+			statusf("   %s emails → %s\n", service, preset.Services[service])
+		}
+		return nil
+	},
+}
+
+// presetApplied reports whether remap is the mapping of preset
+func presetApplied(preset categories.Preset, remap map[string]string) bool {
+	if len(remap) != len(preset.Categories) {
+		return false
+	}
+	for from, to := range preset.Categories {
+		if remap[from] != to {
+			return false
+		}
+	}
+	return true
+}
+
+// renameStoredBudgets moves the budgets saved with gm budget set to the new
+// names of renamed categories, adding up budgets merged into one
+func renameStoredBudgets(renamed map[string]string) error {
+	ctx := context.Background()
+	st, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	saved, err := st.Budgets(ctx)
+	if err != nil {
+		printFailure("❌ Failed to read budgets: %v\n", err)
+		return err
+	}
+	byCategory := make(map[string]models.Budget, len(saved))
+	for _, b := range saved {
+		byCategory[strings.ToLower(b.Category)] = b
+	}
+
+	for _, b := range saved {
+		to, ok := renamed[b.Category]
+		if !ok {
+			continue
+		}
+		moved := categories.Budget{Amount: b.Amount, Currency: b.Currency}
+		if taken, ok := byCategory[strings.ToLower(to)]; ok {
+			if moved, ok = categories.MergeBudgets(categories.Budget{Amount: taken.Amount, Currency: taken.Currency}, moved); !ok {
+				statusf("⚠️  Kept the %s budget, since the %s one counts another currency\n", b.Category, to)
+				continue
+			}
+		}
+		budget := models.Budget{Category: to, Amount: moved.Amount, Currency: moved.Currency}
+		if err := st.SetBudget(ctx, budget); err != nil {
+			printFailure("❌ Failed to save the budget: %v\n", err)
+			return err
+		}
+		if err := st.DeleteBudget(ctx, b.Category); err != nil {
+			printFailure("❌ Failed to remove the budget: %v\n", err)
+			return err
+		}
+		byCategory[strings.ToLower(to)] = budget
+	}
+	return nil
+}
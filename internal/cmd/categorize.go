@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/categories"
+	"github.com/sazardev/go-money/internal/extractor"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/paths"
+	"github.com/spf13/cobra"
+)
+
+// categoryKeys are the single keys used to pick a category, in list order
+const categoryKeys = "1234567890"
+
+func init() {
+	rootCmd.AddCommand(categorizeCmd)
+
+	categorizeCmd.Flags().StringP("category", "c", "", "Review transactions in this category instead of uncategorized/Other ones")
+	categorizeCmd.Flags().Bool("all", false, "Review every transaction")
+}
+
+var categorizeCmd = &cobra.Command{
+	Use:   "categorize",
+	Short: "Interactively assign categories to uncategorized transactions",
+	Long: `Walks through uncategorized or "Other" transactions one at a time.
+Press a number key to assign a category, s to skip, r to create a rule
+for every transaction from the same sender, or q to quit.
+Choices are saved to ` + categories.DefaultFile + `.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		reviewCategory, _ := cmd.Flags().GetString("category")
+		all, _ := cmd.Flags().GetBool("all")
+		if err := checkWritable("gm categorize", categories.DefaultFile); err != nil {
+			return err
+		}
+
+		txExtractor, err := extractor.NewTransactionExtractor()
+		if err != nil {
+			printFailure("❌ Failed to initialize transaction extractor: %v\n", err)
+			return err
+		}
+
+		result, err := syncTransactions(ctx)
+		if err != nil {
+			return err
+		}
+		transactions := result.Transactions
+
+		needsReview := func(tx *models.Transaction) bool {
+			switch {
+			case all:
+				return true
+			case reviewCategory != "":
+				return strings.EqualFold(tx.Category, reviewCategory)
+			default:
+				return categories.NeedsReview(tx)
+			}
+		}
+
+		var pending []*models.Transaction
+		for _, tx := range transactions {
+			if needsReview(tx) {
+				pending = append(pending, tx)
+			}
+		}
+
+		if len(pending) == 0 {
+			fmt.Println("\n✅ Nothing to categorize!")
+			return nil
+		}
+
+		overrides, err := categories.Load(paths.Config(categories.DefaultFile))
+		if err != nil {
+			return err
+		}
+
+		choices := categories.List(overrides.Known(txExtractor.GetCategories()), transactions)
+		if len(choices) > len(categoryKeys) {
+			choices = append(choices[:len(categoryKeys)-1], categories.Other)
+		}
+
+		fmt.Println("\n🏷️  Categories:")
+		for i, category := range choices {
+			fmt.Printf("   %c) %s\n", categoryKeys[i], categoryLabel(overrides, category, 0))
+		}
+
+		changed := 0
+		for i := 0; i < len(pending); i++ {
+			tx := pending[i]
+			if !all && !needsReview(tx) {
+				// Already handled by a rule created earlier in this session
+				continue
+			}
+
+			fmt.Printf("\n[%d/%d] %s - %s%.2f %s | %s\n", i+1, len(pending), tx.ServiceName,
+				tx.CurrencySymbol, tx.Amount, tx.Currency, tx.Date.Format("2006-01-02"))
+			fmt.Printf("   Subject: %s\n", tx.Subject)
+			fmt.Printf("   From: %s | Category: %s\n", tx.Email, tx.Category)
+
+			key := readKey("   Category key, s=skip, r=rule, q=quit: ")
+			switch key {
+			case 'q':
+				i = len(pending)
+			case 's', '\n':
+				continue
+			case 'r':
+				domain := categories.SenderDomain(tx)
+				if domain == "" {
+					fmt.Println("   ⚠️  Cannot create a rule: sender has no email domain")
+					i--
+					continue
+				}
+				category, ok := pickCategory(choices, readKey(fmt.Sprintf("   Category for all mail from %s: ", domain)))
+				if !ok {
+					i--
+					continue
+				}
+				overrides.AddRule(domain, category)
+				overrides.Apply(transactions)
+				changed++
+				fmt.Printf("   ✅ Rule added: %s → %s\n", domain, category)
+			default:
+				category, ok := pickCategory(choices, key)
+				if !ok {
+					fmt.Println("   ⚠️  Unknown key")
+					i--
+					continue
+				}
+				overrides.SetCategory(tx.ID, category)
+				tx.Category = category
+				changed++
+			}
+		}
+
+		if changed == 0 {
+			fmt.Println("\nNo changes made.")
+			return nil
+		}
+
+		if err := overrides.Save(); err != nil {
+			printFailure("❌ Failed to save %s: %v\n", categories.DefaultFile, err)
+			return err
+		}
+		fmt.Printf("\n💾 Saved %d change(s) to %s\n", changed, categories.DefaultFile)
+
+		return nil
+	},
+}
+
+// pickCategory maps a category key to its category
+func pickCategory(choices []string, key byte) (string, bool) {
+	idx := strings.IndexByte(categoryKeys, key)
+	if idx < 0 || idx >= len(choices) {
+		return "", false
+	}
+	return choices[idx], true
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/store"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(closeCmd)
+
+	closeCmd.Flags().Bool("list", false, "List the closed months")
+	closeCmd.Flags().Bool("reopen", false, "Reopen the month, so its transactions follow the store and rules again")
+}
+
+var closeCmd = &cobra.Command{
+	Use:   "close <YYYY-MM>",
+	Short: "Freeze a past month so its reports never change",
+	Long: `Close syncs new emails and then saves a snapshot of a past month: its
+transactions, with their categories, tags and statuses, and its summary.
+From then on every command reads the month's transactions from the
+snapshot, so its reports stay the same however category rules, merchant
+parsers or later emails change, and syncs don't change transactions dated
+in it.
+
+Reopen a month with --reopen to let it follow the store again, for
+example to close it again with a correction.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		list, _ := cmd.Flags().GetBool("list")
+		reopen, _ := cmd.Flags().GetBool("reopen")
+
+		if list {
+			if len(args) > 0 || reopen {
+				return fmt.Errorf("%w: --list takes no month", apperrors.ErrInvalidInput)
+			}
+			return listClosedMonths(ctx)
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("%w: give the month to close (YYYY-MM), or --list", apperrors.ErrInvalidInput)
+		}
+		start, err := time.ParseInLocation("2006-01", args[0], time.Local)
+		if err != nil {
+			return fmt.Errorf("%w: invalid month %q (use YYYY-MM)", apperrors.ErrInvalidInput, args[0])
+		}
+		month := start.Format("2006-01")
+		if err := checkWritable("gm close", store.Redacted(storePath)); err != nil {
+			return err
+		}
+		if err := checkStored("gm close"); err != nil {
+			return err
+		}
+
+		if reopen {
+			return reopenMonth(ctx, month)
+		}
+		if start.AddDate(0, 1, 0).After(time.Now()) {
+			return fmt.Errorf("%w: %s hasn't ended yet; only past months can be closed", apperrors.ErrInvalidInput, month)
+		}
+
+		result, err := syncTransactions(ctx)
+		if err != nil {
+			return err
+		}
+
+		st, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		closed, err := st.ClosedMonths(ctx)
+		if err != nil {
+			return err
+		}
+		if closedMonthSet(closed)[month] {
+			return fmt.Errorf("%w: %s is already closed; reopen it first with gm close %s --reopen", apperrors.ErrInvalidInput, month, month)
+		}
+
+		var transactions []*models.Transaction
+		for _, tx := range result.Transactions {
+			if summary.ByMonth(tx) == month {
+				transactions = append(transactions, tx)
+			}
+		}
+		snapshot := models.MonthClose{
+			Month:        month,
+			ClosedAt:     time.Now(),
+			Summary:      summary.Build(summary.Apply(transactions, summary.Counted())),
+			Transactions: transactions,
+		}
+		if err := st.CloseMonth(ctx, snapshot); err != nil {
+			printFailure("❌ Failed to close %s: %v\n", month, err)
+			return err
+		}
+
+		switch outputFormat {
+		case outputJSON:
+			return summary.WriteJSON(os.Stdout, snapshot)
+		case outputYAML:
+			return summary.WriteYAML(os.Stdout, snapshot)
+		}
+		statusf("🔒 Closed %s: %d transactions, %s%.2f counted\n", month, len(transactions), snapshot.Summary.CurrencySymbol, snapshot.Summary.TotalAmount)
+		return nil
+	},
+}
+
+// listClosedMonths prints the closed months and their totals
+func listClosedMonths(ctx context.Context) error {
+	st, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	closed, err := st.ClosedMonths(ctx)
+	if err != nil {
+		printFailure("❌ Failed to read closed months: %v\n", err)
+		return err
+	}
+
+	switch outputFormat {
+	case outputJSON:
+		return summary.WriteJSON(os.Stdout, closed)
+	case outputYAML:
+		return summary.WriteYAML(os.Stdout, closed)
+	}
+
+	if len(closed) == 0 {
+		statusf("\n💡 No closed months yet; close one with gm close <YYYY-MM>\n")
+		return nil
+	}
+	fmt.Println("\n🔒 Closed months:")
+	fmt.Println("─────────────────────────────────────────────────")
+	for _, c := range closed {
+		fmt.Printf("%s  %s%10.2f  %4d transactions  closed %s\n", c.Month, c.Summary.CurrencySymbol, c.Summary.TotalAmount,
+			len(c.Transactions), c.ClosedAt.Local().Format("2006-01-02"))
+	}
+	return nil
+}
+
+// reopenMonth drops the snapshot of a closed month
+func reopenMonth(ctx context.Context, month string) error {
+	st, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	closed, err := st.ClosedMonths(ctx)
+	if err != nil {
+		return err
+	}
+	if !closedMonthSet(closed)[month] {
+		return fmt.Errorf("%w: %s isn't closed", apperrors.ErrInvalidInput, month)
+	}
+	if err := st.ReopenMonth(ctx, month); err != nil {
+		printFailure("❌ Failed to reopen %s: %v\n", month, err)
+		return err
+	}
+	statusf("🔓 Reopened %s\n", month)
+	return nil
+}
+
+// closedMonthSet returns the months (YYYY-MM) of closed
+func closedMonthSet(closed []models.MonthClose) map[string]bool {
+	months := make(map[string]bool, len(closed))
+	for _, c := range closed {
+		months[c.Month] = true
+	}
+	return months
+}
+
+// applyClosedMonths replaces the transactions dated in closed months with
+// the ones saved when each month was closed, keeping them ordered by date
+func applyClosedMonths(transactions []*models.Transaction, closed []models.MonthClose) []*models.Transaction {
+	if len(closed) == 0 {
+		return transactions
+	}
+	result, _ := withoutClosedMonths(transactions, closed)
+	for _, c := range closed {
+		result = append(result, c.Transactions...)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Date.Before(result[j].Date) })
+	return result
+}
+
+// withoutClosedMonths drops the transactions dated in closed months and
+// reports how many were dropped
+func withoutClosedMonths(transactions []*models.Transaction, closed []models.MonthClose) ([]*models.Transaction, int) {
+	months := closedMonthSet(closed)
+	var kept []*models.Transaction
+	for _, tx := range transactions {
+		if !months[summary.ByMonth(tx)] {
+			kept = append(kept, tx)
+		}
+	}
+	return kept, len(transactions) - len(kept)
+}
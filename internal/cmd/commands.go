@@ -9,50 +9,181 @@ import (
 	"strings"
 	"time"
 
+	"github.com/sazardev/go-money/internal/apperrors"
 	"github.com/sazardev/go-money/internal/auth"
+	"github.com/sazardev/go-money/internal/categories"
+	"github.com/sazardev/go-money/internal/config"
 	"github.com/sazardev/go-money/internal/extractor"
-	"github.com/sazardev/go-money/internal/gmail"
+	"github.com/sazardev/go-money/internal/fx"
 	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/paths"
+	"github.com/sazardev/go-money/internal/store"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/sazardev/go-money/pkg/redact"
 	"github.com/spf13/cobra"
 )
 
-var Version = "1.0.0"
+// Version, Commit and BuildDate describe the build; release builds set
+// them with -ldflags "-X github.com/sazardev/go-money/internal/cmd.Commit=..."
+var (
+	Version   = "1.0.0"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// unsafeLogs disables masking sensitive data in logs and debug output
+var unsafeLogs bool
 
 var rootCmd = &cobra.Command{
 	Use:   "gm",
 	Short: "GO Money - CLI for managing expenses from Gmail",
 	Long: `GO Money helps you manage your finances by extracting 
 transaction data from your Gmail account.`,
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Flags parsed fine, so don't dump usage for runtime errors
+		cmd.SilenceUsage = true
+		redact.SetEnabled(!unsafeLogs)
+		if noStore && cmd.Flags().Changed("store") {
+			return fmt.Errorf("%w: --store and --no-store can't be used together", apperrors.ErrInvalidInput)
+		}
+		if err := setupDirs(cmd); err != nil {
+			return err
+		}
+		if err := applySettingsFile(); err != nil {
+			return err
+		}
+		cfg := config.LoadConfig()
+		migrateFiles(!cmd.Flags().Changed("store") && cfg.Store == "" && !noStore)
+		if !cmd.Flags().Changed("store") {
+			if cfg.Store != "" {
+				storePath = cfg.Store
+			} else {
+				storePath = paths.Data(store.DefaultPath)
+			}
+		}
+		if cmd.Flags().Changed("tracker") {
+			extractor.SetTrackerPath(trackerFlag)
+		}
+		if !cmd.Flags().Changed("output") && cfg.Output != "" {
+			outputFormat = cfg.Output
+		}
+		if len(cfg.Queries) > 0 {
+			transactionQueries = cfg.Queries
+		}
+		applyPolite(cmd, cfg)
+		return validateOutputFormat()
+	},
 }
 
+// Directories set with --config-dir, --data-dir and --cache-dir
+var configDir, dataDir, cacheDir string
+
+// profile names the profile set with --profile or GM_PROFILE
+var profile string
+
+// trackerFlag is the tracker file set with --tracker
+var trackerFlag string
+
+// setupDirs selects the directories gm keeps its files in: those of the
+// profile, if one was named
+func setupDirs(cmd *cobra.Command) error {
+	dirs := paths.Default()
+	if cmd.Flags().Changed("config-dir") {
+		dirs.Config = configDir
+	}
+	if cmd.Flags().Changed("data-dir") {
+		dirs.Data = dataDir
+	}
+	if cmd.Flags().Changed("cache-dir") {
+		dirs.Cache = cacheDir
+	}
+	if !cmd.Flags().Changed("profile") {
+		profile = os.Getenv("GM_PROFILE")
+	}
+	if profile != "" {
+		var err error
+		if dirs, err = dirs.ForProfile(profile); err != nil {
+			return fmt.Errorf("%w: %w", apperrors.ErrInvalidInput, err)
+		}
+	}
+	paths.Set(dirs)
+	return nil
+}
+
+// applyPolite makes --polite (or GM_POLITE) override the flags it implies:
+// a single request at a time, no batch requests, which send up to
+// gmail.MaxBatchSize requests at once, and the prefilter, which screens
+// emails by their headers before downloading them
+func applyPolite(cmd *cobra.Command, cfg *config.Config) {
+	if !cmd.Flags().Changed("polite") {
+		polite = cfg.Polite
+	}
+	if polite {
+		concurrency, batchSize, noPrefilter = 1, 0, false
+	}
+}
+
+// migrateFiles moves the files earlier versions kept in the working
+// directory to the directories in use, including the default store with
+// moveStore. Nothing is moved with --read-only; the old files are still
+// used where they are.
+func migrateFiles(moveStore bool) {
+	if readOnly {
+		return
+	}
+	moves, err := paths.Migrate(moveStore)
+	for _, move := range moves {
+		if move.Copied {
+			statusf("📦 Copied %s to %s; edit that copy from now on\n", move.From, move.To)
+		} else {
+			statusf("📦 Moved %s to %s\n", move.From, move.To)
+		}
+	}
+	if err != nil {
+		statusf("⚠️  %v; it is still used from the working directory\n", err)
+	}
+}
+
+// Execute runs the root command and reports any error on stderr
 func Execute() error {
-	return rootCmd.Execute()
+	err := rootCmd.Execute()
+	if err != nil {
+		writeError(os.Stderr, err)
+	}
+	return err
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputText, "Output format (text, json, yaml, csv)")
+	rootCmd.PersistentFlags().BoolVar(&unsafeLogs, "unsafe-logs", false, "Show email addresses, account numbers and tokens in logs and debug output")
+	rootCmd.PersistentFlags().StringVar(&configDir, "config-dir", "", "Directory of tracker-mails.json, category-rules.json and accounts.json (default GM_CONFIG_DIR, or the user's configuration directory)")
+	rootCmd.PersistentFlags().StringVar(&dataDir, "data-dir", "", "Directory of the store and saved sign-ins (default GM_DATA_DIR, or the user's data directory)")
+	rootCmd.PersistentFlags().StringVar(&trackerFlag, "tracker", "", "tracker-mails.json with services to add to or replace the built-in ones (default GM_TRACKER, or the one in the configuration directory)")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "Keep settings, sign-ins, the store and budgets apart under this name, such as personal or business (default GM_PROFILE)")
+	rootCmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", "", "Directory of cached exchange rates and LLM replies (default GM_CACHE_DIR, or the user's cache directory)")
+
+	// Logs can end up in bug reports, so they are redacted unless --unsafe-logs is set
+	log.SetOutput(redact.NewWriter(os.Stderr))
+
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(authCmd)
 	rootCmd.AddCommand(calculateCmd)
-	rootCmd.AddCommand(graphCmd)
 
 	// Add subcommands
-	authCmd.AddCommand(loginCmd)
+	authCmd.AddCommand(loginCmd, logoutCmd)
+	loginCmd.Flags().Bool("no-browser", false, "Sign in from another device, for servers without a browser or a reachable port 8080")
+	logoutCmd.Flags().Bool("all", false, "Sign out of every account in "+config.DefaultAccountsFile+" and of the default Google and Microsoft sign-ins")
 
 	// Add flags to calculateCmd
 	calculateCmd.Flags().BoolP("debug", "d", false, "Enable debug mode")
-	calculateCmd.Flags().StringP("from", "f", "", "Start date (YYYY-MM-DD format)")
-	calculateCmd.Flags().StringP("to", "t", "", "End date (YYYY-MM-DD format)")
-	calculateCmd.Flags().StringP("month", "m", "", "Specific month (YYYY-MM format)")
-	calculateCmd.Flags().StringP("currency", "c", "", "Filter by currency (USD, MXN, EUR, GBP, JPY, CAD)")
+	calculateCmd.Flags().String("base-currency", "", "Convert all amounts to this currency (e.g. USD, EUR) for the totals (default GM_CURRENCY)")
+	calculateCmd.Flags().Float64("fx-fee", 0, "Add an estimated foreign transaction fee of this percent to charges not in your card's currency (--base-currency, or the most used one)")
+	calculateFilters = addFilterFlags(calculateCmd)
 }
 
-var versionCmd = &cobra.Command{
-	Use:   "version",
-	Short: "Show version",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("GO Money v%s\n", Version)
-	},
-}
+// calculateFilters holds the filter flags of gm calculate
+var calculateFilters *filterFlags
 
 var authCmd = &cobra.Command{
 	Use:   "auth",
@@ -61,343 +192,484 @@ var authCmd = &cobra.Command{
 
 var loginCmd = &cobra.Command{
 	Use:   "login",
-	Short: "Login to Google",
+	Short: "Login to Google, or to Microsoft with --provider outlook",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := context.Background()
 
+		// With --account, log in to that account from accounts.json
+		provider, example := selectedProvider(), "gm calculate"
+		var accountName string
+		if accountFlag != "" {
+			accounts, err := selectedAccounts()
+			if err != nil {
+				return err
+			}
+			accountName, provider = accounts[0].Name, accounts[0].Provider
+		} else if provider != "gmail" {
+			example += " --provider " + provider
+		}
+
 		// Create authenticator
 		authenticator := auth.NewAuthenticator()
+		account := "Google"
+		switch provider {
+		case "outlook":
+			authenticator = auth.NewMicrosoftAuthenticator()
+			account = "Microsoft"
+		case "imap":
+			statusf("💡 IMAP needs no login; set GM_IMAP_HOST, GM_IMAP_USERNAME and GM_IMAP_PASSWORD instead\n")
+			return nil
+		}
+		authenticator = authenticator.ForAccount(accountName)
+		if noBrowser, _ := cmd.Flags().GetBool("no-browser"); noBrowser {
+			authenticator = authenticator.WithoutBrowser()
+		}
 
 		// Get token (this will open browser or request manual auth)
 		token, err := authenticator.GetToken(ctx)
 		if err != nil {
-			log.Printf("❌ Authentication failed: %v\n", err)
+			printFailure("❌ Authentication failed: %v\n", err)
 			return err
 		}
 
 		// Success
-		fmt.Println("✅ Successfully authenticated with Google!")
+		fmt.Printf("✅ Successfully authenticated with %s!\n", account)
 		fmt.Printf("📧 Access token obtained. Token expires at: %v\n", token.Expiry)
-		fmt.Println("🎉 You can now use 'gm calculate' to extract your expenses!")
+		fmt.Printf("🎉 You can now use '%s' to extract your expenses!\n", example)
 
 		return nil
 	},
 }
 
-var calculateCmd = &cobra.Command{
-	Use:   "calculate",
-	Short: "Calculate and summarize expenses",
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Revoke the saved sign-in and delete it",
+	Long: `Logout disconnects GO Money from a mailbox: the saved token is revoked with
+Google, so it stops working everywhere, and deleted from the credential
+store.
+Microsoft has no way to revoke a token, so it is only deleted; remove the
+app's access from your Microsoft account to disconnect it there too.
+
+Without flags it signs out of the --provider mailbox; use --account to
+sign out of one account from ` + config.DefaultAccountsFile + `, or --all for every one.`,
+	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
-		debug, _ := cmd.Flags().GetBool("debug")
-		fromStr, _ := cmd.Flags().GetString("from")
-		toStr, _ := cmd.Flags().GetString("to")
-		month, _ := cmd.Flags().GetString("month")
-		currency, _ := cmd.Flags().GetString("currency")
-
-		// Parse date filters
-		var fromDate, toDate time.Time
-		var err error
+		// Revoking and deleting a sign-in changes the credential store
+		if err := checkWritable("gm auth logout", paths.Credentials()); err != nil {
+			return err
+		}
+		all, _ := cmd.Flags().GetBool("all")
+		if all && accountFlag != "" {
+			return fmt.Errorf("%w: use either --all or --account", apperrors.ErrInvalidInput)
+		}
+		if !all && accountFlag == "" && selectedProvider() == "imap" {
+			statusf("💡 IMAP keeps no sign-in; unset GM_IMAP_PASSWORD instead\n")
+			return nil
+		}
 
-		if fromStr != "" {
-			fromDate, err = parseDate(fromStr)
+		accounts := []config.Account{{}}
+		if all || accountFlag != "" {
+			selected, err := selectedAccounts()
 			if err != nil {
-				fmt.Printf("❌ Invalid --from date: %v (use YYYY-MM-DD)\n", err)
-				return nil
+				return err
 			}
+			accounts = selected
 		}
 
-		if toStr != "" {
-			toDate, err = parseDate(toStr)
-			if err != nil {
-				fmt.Printf("❌ Invalid --to date: %v (use YYYY-MM-DD)\n", err)
-				return nil
+		var authenticators []*auth.Authenticator
+		var names []string
+		for _, account := range accounts {
+			authenticator := accountAuthenticator(account)
+			name := authenticator.Provider()
+			if account.Name != "" {
+				name += " (" + account.Name + ")"
 			}
+			authenticators = append(authenticators, authenticator)
+			names = append(names, name)
+		}
+		if all {
+			authenticators = append(authenticators, auth.NewAuthenticator(), auth.NewMicrosoftAuthenticator())
+			names = append(names, "Google", "Microsoft")
 		}
 
-		// Handle month filter (YYYY-MM format)
-		if month != "" {
-			parts := strings.Split(month, "-")
-			if len(parts) == 2 {
-				year := parts[0]
-				monthNum := parts[1]
-				dateStr := year + "-" + monthNum + "-01"
-				if monthDate, err := parseDate(dateStr); err == nil {
-					fromDate = monthDate
-					// Set toDate to last day of month
-					toDate = monthDate.AddDate(0, 1, -1).Add(24*time.Hour - time.Nanosecond)
-				}
+		ctx := context.Background()
+		signedOut := 0
+		var failed error
+		for i, authenticator := range authenticators {
+			saved, err := logout(ctx, authenticator, names[i])
+			if saved {
+				signedOut++
+			}
+			if err != nil && failed == nil {
+				failed = err
 			}
 		}
-
-		// Step 1: Load existing token
-		fmt.Println("📊 Loading your authentication token...")
-		authenticator := auth.NewAuthenticator()
-		token, err := authenticator.GetToken(ctx)
-		if err != nil {
-			fmt.Printf("❌ Failed to load authentication: %v\n", err)
-			fmt.Println("💡 Tip: Run 'gm auth login' first to authenticate")
-			return err
+		if signedOut == 0 && failed == nil {
+			fmt.Println("💡 Not signed in; nothing to sign out of")
 		}
-		fmt.Println("✅ Token loaded successfully!")
+		return failed
+	},
+}
 
-		// Step 2: Connect to Gmail
-		fmt.Println("\n📧 Connecting to Gmail...")
-		gmailService, err := gmail.NewGmailService(ctx, token)
-		if err != nil {
-			fmt.Printf("❌ Failed to connect to Gmail: %v\n", err)
-			return err
-		}
-		fmt.Println("✅ Connected to Gmail!")
+// logout revokes and deletes the saved token of authenticator, reporting
+// whether there was one
+func logout(ctx context.Context, authenticator *auth.Authenticator, name string) (bool, error) {
+	saved, err := authenticator.Revoke(ctx)
+	switch {
+	case !saved && err == nil:
+		return false, nil
+	case !saved:
+		printFailure("❌ Failed to sign out of %s: %v\n", name, err)
+		return false, err
+	case err != nil:
+		// The token is gone locally, but keeps working until it's revoked
+		printFailure("⚠️  Deleted the %s sign-in, but couldn't revoke it: %v\n", name, err)
+		printFailure("💡 Remove GO Money's access at %s\n", authenticator.AppsURL())
+		return true, err
+	}
 
-		// Step 3: Get messages with transaction queries
-		fmt.Println("\n🔍 Searching for transaction emails...")
+	fmt.Printf("✅ Signed out of %s\n", name)
+	if !authenticator.Revocable() {
+		fmt.Printf("💡 The sign-in was deleted; remove GO Money's access at %s to disconnect it there too\n", authenticator.AppsURL())
+	}
+	return true, nil
+}
 
-		// Search queries for common transaction keywords
-		queries := []string{
-			"receipt",
-			"payment",
-			"transaction",
-			"order confirmation",
-			"booking confirmation",
+var calculateCmd = &cobra.Command{
+	Use:   "calculate",
+	Short: "Calculate and summarize expenses",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		debug, _ := cmd.Flags().GetBool("debug")
+		baseCurrency, _ := cmd.Flags().GetString("base-currency")
+		if !cmd.Flags().Changed("base-currency") {
+			baseCurrency = config.LoadConfig().Currency
 		}
-
-		var allMessages []*models.Message
-		for _, query := range queries {
-			messages, err := gmailService.GetMessages(ctx, query)
-			if err != nil {
-				log.Printf("⚠️  Warning: Could not search for '%s': %v\n", query, err)
-				continue
-			}
-			allMessages = append(allMessages, messages...)
+		fxFee, _ := cmd.Flags().GetFloat64("fx-fee")
+		if fxFee < 0 || fxFee > fx.MaxFeePercent {
+			return fmt.Errorf("%w: --fx-fee must be between 0 and %d percent", apperrors.ErrInvalidInput, fx.MaxFeePercent)
 		}
 
-		fmt.Printf("✅ Found %d transaction emails!\n", len(allMessages))
+		filters, err := calculateFilters.filters()
+		if err != nil {
+			return err
+		}
 
-		if len(allMessages) == 0 {
-			fmt.Println("\n⚠️  No transaction emails found.")
-			fmt.Println("💡 Tip: Make sure you have emails from services like Uber, Amazon, Netflix, etc.")
+		// In JSON/YAML mode an empty summary is still printed
+		noResults := func() error {
+			if structuredOutput() {
+				return writeResults(os.Stdout, nil, summary.Build(nil))
+			}
 			return nil
 		}
 
-		// Step 4: Extract transactions
-		fmt.Println("\n💰 Extracting transactions...")
-		txExtractor, err := extractor.NewTransactionExtractor()
+		result, err := syncTransactions(ctx)
 		if err != nil {
-			fmt.Printf("❌ Failed to initialize transaction extractor: %v\n", err)
 			return err
 		}
+		newMessages, transactions := result.Messages, result.Transactions
 
-		transactions := txExtractor.ExtractTransactions(allMessages)
-
-		// Filter by date range if provided
-		if !fromDate.IsZero() || !toDate.IsZero() {
-			var filtered []*models.Transaction
-			for _, tx := range transactions {
-				txDate := tx.Date
-				if !fromDate.IsZero() && txDate.Before(fromDate) {
-					continue
-				}
-				if !toDate.IsZero() && txDate.After(toDate) {
-					continue
-				}
-				filtered = append(filtered, tx)
-			}
-			transactions = filtered
-			if len(transactions) == 0 {
-				fmt.Println("⚠️  No transactions found in the specified date range")
-				return nil
-			}
+		if len(newMessages) == 0 && len(transactions) == 0 {
+			statusf("\n⚠️  No transaction emails found.\n")
+			statusf("💡 Tip: Make sure you have emails from services like Uber, Amazon, Netflix, etc.\n")
+			return noResults()
 		}
 
-		// Filter by currency if provided
-		if currency != "" {
-			var filtered []*models.Transaction
-			for _, tx := range transactions {
-				if strings.EqualFold(tx.Currency, currency) {
-					filtered = append(filtered, tx)
-				}
-			}
-			transactions = filtered
+		if len(filters) > 0 && len(transactions) > 0 {
+			transactions = summary.Apply(transactions, filters...)
 			if len(transactions) == 0 {
-				fmt.Printf("⚠️  No transactions found in %s currency\n", currency)
-				return nil
+				statusf("⚠️  No transactions match the given filters\n")
+				return noResults()
 			}
 		}
 
 		// Show debug information if requested
 		if debug {
-			// Show first 10 emails for debugging
+			// Show first 10 emails fetched in this sync for debugging
 			limit := 10
-			if len(allMessages) < limit {
-				limit = len(allMessages)
+			if len(newMessages) < limit {
+				limit = len(newMessages)
 			}
 
 			for i := 0; i < limit; i++ {
-				msg := allMessages[i]
-				fmt.Printf("\n📧 Email %d:\n", i+1)
-				fmt.Printf("   From: %s\n", msg.From)
-				fmt.Printf("   Subject: %s\n", msg.Subject)
-				fmt.Printf("   Date: %s\n", msg.Date)
-				fmt.Printf("   Body (first 200 chars): %s\n", truncateString(msg.Body, 200))
+				msg := newMessages[i]
+				statusf("\n📧 Email %d:\n", i+1)
+				statusf("   From: %s\n", redact.String(msg.From))
+				statusf("   Subject: %s\n", redact.String(msg.Subject))
+				statusf("   Date: %s\n", msg.Date)
+				statusf("   Body (first 200 chars): %s\n", redact.String(truncateString(msg.Body, 200)))
 			}
 
-			fmt.Println("\n💡 Tip: Check the email domains and keywords. You may need to update tracker-mails.json")
+			statusf("\n💡 Tip: Check the email domains and keywords. You may need to update tracker-mails.json\n")
 		}
 
 		// Step 5: Display results
 		if len(transactions) == 0 {
-			fmt.Println("\n⚠️  No transactions could be extracted from the emails.")
-			fmt.Println("💡 Tip: Some emails might not match the configured services.")
+			statusf("\n⚠️  No transactions could be extracted from the emails.\n")
+			statusf("💡 Tip: Some emails might not match the configured services.\n")
 			if !debug {
-				fmt.Println("💡 Try: gm calculate --debug  (to see unmatched emails)")
+				statusf("💡 Try: gm calculate --debug  (to see unmatched emails)\n")
 			}
-			return nil
+			return noResults()
 		}
 
-		displayExpenseSummary(transactions)
+		if fxFee > 0 {
+			transactions = addFXFees(transactions, baseCurrency, fxFee)
+		}
 
-		// Generate detailed CSV report
-		csvFile := generateTransactionCSV(transactions)
-		fmt.Printf("\n📄 CSV Report generated: %s\n", csvFile)
+		expenseSummary := summary.Build(transactions)
+		if baseCurrency != "" {
+			if expenseSummary, err = buildConvertedSummary(ctx, transactions, baseCurrency); err != nil {
+				return err
+			}
+		}
+		// Budgets track the current month, whatever the filters select
+		expenseSummary.Budgets = summary.BurnDown(result.Transactions, result.Budgets, time.Now())
+		expenseSummary.Warnings = extractionWarnings(newMessages)
+		pendingFilters, err := calculateFilters.pending()
+		if err != nil {
+			return err
+		}
+		if pendingFilters != nil {
+			pending := summary.Apply(result.Transactions, pendingFilters...)
+			expenseSummary.PendingAmount, expenseSummary.PendingCount = summary.Total(pending), len(pending)
+		}
+		if structuredOutput() {
+			return writeResults(os.Stdout, transactions, expenseSummary)
+		}
 
+		displayExpenseSummary(transactions, expenseSummary)
 		return nil
 	},
 }
 
+// addFXFees adds the estimated foreign transaction fees of transactions,
+// charged by a card in base or, when base is empty, in the currency most
+// transactions are in
+func addFXFees(transactions []*models.Transaction, base string, percent float64) []*models.Transaction {
+	primary := strings.ToUpper(base)
+	if primary == "" {
+		most := 0
+		for _, group := range summary.GroupBy(transactions, summary.ByCurrency) {
+			if group.Count > most {
+				primary, most = group.Key, group.Count
+			}
+		}
+	}
+
+	fees := fx.EstimateFees(transactions, primary, percent)
+	if len(fees) > 0 {
+		statusf("💳 Added %.1f%% estimated foreign transaction fees to %d charges not in %s\n", percent, len(fees), primary)
+	}
+	return append(transactions, fees...)
+}
+
+// confidenceMarker flags transactions whose extraction is worth checking
+func confidenceMarker(tx *models.Transaction) string {
+	switch {
+	case tx.Confidence == 0 || tx.Confidence >= 0.8:
+		return ""
+	case tx.Confidence >= 0.6:
+		return fmt.Sprintf("  ❔ confidence %.2f", tx.Confidence)
+	default:
+		return fmt.Sprintf("  ⚠️  low confidence %.2f", tx.Confidence)
+	}
+}
+
+// maxWarningsShown is how many extraction warnings the text summary lists
+const maxWarningsShown = 10
+
+// severityMarker flags an extraction warning by its severity
+func severityMarker(severity string) string {
+	switch severity {
+	case models.SeverityHigh:
+		return "❗"
+	case models.SeverityMedium:
+		return "⚠️ "
+	default:
+		return "ℹ️ "
+	}
+}
+
+// statusMarker flags refunds, whose negative amounts reduce the totals,
+// charges that aren't settled and excluded transactions
+func statusMarker(tx *models.Transaction) string {
+	if tx.Excluded {
+		return "  ⛔ excluded"
+	}
+	if extractor.IsRefund(tx) {
+		return "  ↩️  refund"
+	}
+	switch tx.EffectiveStatus() {
+	case models.StatusPending:
+		return "  ⏳ pending"
+	case models.StatusRefunded:
+		return "  ↩️  refunded"
+	case models.StatusCancelled:
+		return "  🚫 cancelled"
+	}
+	return ""
+}
+
 // displayExpenseSummary displays a formatted expense summary
-func displayExpenseSummary(transactions interface{}) {
-	// For now, show basic info
+func displayExpenseSummary(transactions []*models.Transaction, s *models.ExpenseSummary) {
 	fmt.Println("\n" + "═══════════════════════════════════════════════════")
 	fmt.Println("           💸 EXPENSE SUMMARY 💸")
 	fmt.Println("═══════════════════════════════════════════════════")
 
-	// Display basic info
-	switch t := transactions.(type) {
-	case []*models.Transaction:
-		if len(t) == 0 {
-			fmt.Println("No transactions found")
-			return
-		}
-
-		// Show individual transactions
-		fmt.Println("\n📝 Transactions:")
-		fmt.Println("─────────────────────────────────────────────────")
+	if len(transactions) == 0 {
+		fmt.Println("No transactions found")
+		return
+	}
 
-		totalAmount := 0.0
-		byCategory := make(map[string]float64)
-		byService := make(map[string]float64)
-		currenciesSeen := make(map[string]string)
+	styles := loadCategoryStyles()
 
-		for i, tx := range t {
-			fmt.Printf("%d. %s - %s%.2f %s\n", i+1, tx.ServiceName, tx.CurrencySymbol, tx.Amount, tx.Currency)
-			fmt.Printf("   Category: %s | Date: %s\n", tx.Category, tx.Date.Format("2006-01-02"))
-			fmt.Printf("   Subject: %s\n", tx.Subject)
+	// Show individual transactions
+	fmt.Println("\n📝 Transactions:")
+	fmt.Println("─────────────────────────────────────────────────")
 
-			totalAmount += tx.Amount
-			byCategory[tx.Category] += tx.Amount
-			byService[tx.ServiceName] += tx.Amount
-			currenciesSeen[tx.Currency] = tx.CurrencySymbol
-		}
+	shortIDs := summary.ShortIDs(transactions)
+	for i, tx := range transactions {
+		fmt.Printf("%d. %s - %s%.2f %s%s%s\n", i+1, tx.ServiceName, tx.CurrencySymbol, tx.Amount, tx.Currency, statusMarker(tx), confidenceMarker(tx))
+		fmt.Printf("   Category: %s | Date: %s | ID: %s\n", categoryLabel(styles, tx.Category, 0), tx.Date.Format("2006-01-02"), shortIDs[tx.ID])
+		fmt.Printf("   Subject: %s\n", tx.Subject)
+	}
 
-		// Get symbol for summary (use first found)
-		summarySymbol := "$"
-		for _, sym := range currenciesSeen {
-			summarySymbol = sym
-			break
+	// Amounts in different currencies are never added together: unless they
+	// were converted to a base currency, each currency gets its own totals
+	if s.FXSource != "" || len(s.Currencies) <= 1 {
+		displayTotals(s, styles, "")
+	} else {
+		for _, group := range s.Currencies {
+			currencySummary := summary.Build(summary.Apply(transactions, summary.InCurrency(group.Key)))
+			currencySummary.Budgets = s.Budgets
+			title := group.Key
+			if title == "" {
+				title = "Unknown currency"
+			}
+			displayTotals(currencySummary, styles, title)
 		}
+	}
 
-		// Summary by category
-		fmt.Println("\n📊 Summary by Category:")
+	// Subtotals in each original currency of converted totals
+	if s.FXSource != "" {
+		fmt.Println("\n💱 Summary by Currency:")
 		fmt.Println("─────────────────────────────────────────────────")
-		for category, amount := range byCategory {
-			percentage := (amount / totalAmount) * 100
-			fmt.Printf("%-20s: %s%8.2f (%.1f%%)\n", category, summarySymbol, amount, percentage)
+		for _, group := range s.Currencies {
+			fmt.Printf("%-20s: %11.2f (%d transactions)\n", group.Key, group.Total, group.Count)
 		}
+	}
 
-		// Summary by service
-		fmt.Println("\n🏪 Summary by Service (Top 5):")
+	// Budget burn-down for the current month
+	if len(s.Budgets) > 0 {
+		fmt.Printf("\n🎯 Budgets (%s):\n", s.Budgets[0].Month)
 		fmt.Println("─────────────────────────────────────────────────")
-
-		// Sort services by amount (simple bubble sort for demo)
-		type kv struct {
-			service string
-			amount  float64
-		}
-		var services []kv
-		for k, v := range byService {
-			services = append(services, kv{k, v})
+		for _, b := range s.Budgets {
+			fmt.Printf("%s: %s%8.2f of %s%.2f (%.0f%%), projected %s%.2f\n",
+				categoryLabel(styles, b.Category, 20), b.CurrencySymbol, b.Spent, b.CurrencySymbol, b.Budget, b.Used, b.CurrencySymbol, b.Projected)
 		}
-
-		// Sort descending
-		for i := 0; i < len(services); i++ {
-			for j := i + 1; j < len(services); j++ {
-				if services[j].amount > services[i].amount {
-					services[i], services[j] = services[j], services[i]
-				}
+		for _, b := range s.Budgets {
+			switch {
+			case b.Over():
+				fmt.Printf("❗ %s\n", b.Message())
+			case b.AtRisk():
+				fmt.Printf("⚠️  %s\n", b.Message())
 			}
 		}
+	}
 
-		// Show top 5
-		limit := 5
-		if len(services) < limit {
-			limit = len(services)
-		}
-
-		for i := 0; i < limit; i++ {
-			percentage := (services[i].amount / totalAmount) * 100
-			fmt.Printf("%-20s: %s%8.2f (%.1f%%)\n", services[i].service, summarySymbol, services[i].amount, percentage)
+	// Doubts about the emails extracted in this run
+	if len(s.Warnings) > 0 {
+		fmt.Printf("\n🔎 Extraction warnings (%d):\n", len(s.Warnings))
+		fmt.Println("─────────────────────────────────────────────────")
+		for _, warning := range s.Warnings[:min(len(s.Warnings), maxWarningsShown)] {
+			fmt.Printf("%s %s: %s\n", severityMarker(warning.Severity), truncateString(warning.Subject, 40), warning.Message)
 		}
-
-		// Total
-		fmt.Println("\n═══════════════════════════════════════════════════")
-		fmt.Printf("💰 TOTAL EXPENSES: %s%.2f\n", summarySymbol, totalAmount)
-		fmt.Printf("📈 Number of Transactions: %d\n", len(t))
-		if len(t) > 0 {
-			fmt.Printf("📅 Date Range: %s to %s\n",
-				getEarliestDate(t).Format("2006-01-02"),
-				getLatestDate(t).Format("2006-01-02"))
+		if len(s.Warnings) > maxWarningsShown {
+			fmt.Printf("... and %d more; --output json lists them all\n", len(s.Warnings)-maxWarningsShown)
 		}
-		fmt.Println("═══════════════════════════════════════════════════\n")
+	}
 
-	default:
-		fmt.Println("Unknown transaction type")
+	fmt.Println("\n═══════════════════════════════════════════════════")
+	if s.FXSource != "" || len(s.Currencies) <= 1 {
+		displayRefunds(s)
+		fmt.Printf("💰 TOTAL EXPENSES: %s%.2f\n", s.CurrencySymbol, s.TotalAmount)
+	}
+	printBudgetBars(s.Budgets)
+	if s.FXSource != "" {
+		fmt.Printf("💱 Converted to %s using %s rates of %s\n", s.Currency, s.FXSource, s.FXDate)
 	}
+	fmt.Printf("📈 Number of Transactions: %d\n", s.TotalCount)
+	if s.PendingCount > 0 {
+		fmt.Printf("⏳ Pending: %d authorizations (%.2f) aren't counted until they settle; list them with --status pending\n", s.PendingCount, s.PendingAmount)
+	}
+	fmt.Printf("📅 Date Range: %s to %s\n", s.DateRange[0].Format("2006-01-02"), s.DateRange[1].Format("2006-01-02"))
+	fmt.Println("═══════════════════════════════════════════════════")
+	fmt.Println()
 }
 
-// Helper functions
-func getEarliestDate(transactions []*models.Transaction) time.Time {
-	if len(transactions) == 0 {
-		return time.Now()
+// displayTotals prints the category, service and total blocks of a summary;
+// currency, when set, titles the blocks of one currency among several
+func displayTotals(s *models.ExpenseSummary, styles *categories.Overrides, currency string) {
+	if currency != "" {
+		fmt.Printf("\n💱 %s\n", currency)
+		fmt.Println("═════════")
 	}
-	earliest := transactions[0].Date
-	for _, tx := range transactions {
-		if tx.Date.Before(earliest) {
-			earliest = tx.Date
+
+	// Summary by category
+	fmt.Println("\n📊 Summary by Category:")
+	fmt.Println("─────────────────────────────────────────────────")
+	amountsIn := currency
+	if amountsIn == "" && s.FXSource == "" && len(s.Currencies) == 1 {
+		amountsIn = s.Currencies[0].Key
+	}
+	for _, group := range s.Categories {
+		amount := fmt.Sprintf("%s%8.2f", s.CurrencySymbol, group.Total)
+		// Budgeted categories are colored by how this month's budget fares
+		budget := ""
+		if b := budgetFor(s.Budgets, group.Key, amountsIn); b != nil {
+			amount = paintBudget(b.Level(), amount)
+			budget = fmt.Sprintf("  %s %.0f%% of budget", budgetStyles[b.Level()].marker, b.Used)
 		}
+		fmt.Printf("%s: %s (%.1f%%)%s\n", categoryLabel(styles, group.Key, 20), amount, group.Percent, budget)
 	}
-	return earliest
-}
 
-func getLatestDate(transactions []*models.Transaction) time.Time {
-	if len(transactions) == 0 {
-		return time.Now()
+	// Summary by service
+	fmt.Println("\n🏪 Summary by Service (Top 5):")
+	fmt.Println("─────────────────────────────────────────────────")
+
+	services := s.Services
+	if len(services) > 5 {
+		services = services[:5]
+	}
+	for _, group := range services {
+		fmt.Printf("%-20s: %s%8.2f (%.1f%%)\n", group.Key, s.CurrencySymbol, group.Total, group.Percent)
 	}
-	latest := transactions[0].Date
-	for _, tx := range transactions {
-		if tx.Date.After(latest) {
-			latest = tx.Date
+
+	// Summary by household member
+	if len(s.Members) > 0 {
+		fmt.Println("\n👥 Summary by Member:")
+		fmt.Println("─────────────────────────────────────────────────")
+		for _, group := range s.Members {
+			fmt.Printf("%-20s: %s%8.2f (%.1f%%)\n", group.Key, s.CurrencySymbol, group.Total, group.Percent)
 		}
 	}
-	return latest
+
+	if currency != "" {
+		fmt.Println()
+		displayRefunds(s)
+		fmt.Printf("💰 Total %s: %s%.2f (%d transactions)\n", currency, s.CurrencySymbol, s.TotalAmount, s.TotalCount)
+	}
 }
 
-var graphCmd = &cobra.Command{
-	Use:   "graph",
-	Short: "Generate graph",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("TODO: Implement graph")
-	},
+// displayRefunds prints the gross spend and refunds behind a net total,
+// when there are refunds
+func displayRefunds(s *models.ExpenseSummary) {
+	if s.RefundAmount == 0 {
+		return
+	}
+	fmt.Printf("🧾 Gross spend: %s%.2f\n", s.CurrencySymbol, s.GrossAmount)
+	fmt.Printf("↩️  Refunds: -%s%.2f\n", s.CurrencySymbol, s.RefundAmount)
 }
 
 // Helper function to truncate strings
@@ -412,77 +684,3 @@ func truncateString(s string, maxLen int) string {
 func parseDate(dateStr string) (time.Time, error) {
 	return time.Parse("2006-01-02", dateStr)
 }
-
-// generateTransactionCSV generates a detailed CSV report of all transactions
-func generateTransactionCSV(transactions interface{}) string {
-	txList, ok := transactions.([]*models.Transaction)
-	if !ok {
-		return ""
-	}
-
-	// Create CSV filename with timestamp
-	timestamp := time.Now().Format("2006-01-02_15-04-05")
-	filename := fmt.Sprintf("expenses_%s.csv", timestamp)
-
-	file, err := os.Create(filename)
-	if err != nil {
-		log.Printf("Error creating CSV file: %v", err)
-		return ""
-	}
-	defer file.Close()
-
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-
-	// Write header
-	headers := []string{
-		"Transaction ID",
-		"Service Name",
-		"Service ID",
-		"Category",
-		"Amount",
-		"Currency",
-		"Currency Symbol",
-		"Transaction Date",
-		"Email From",
-		"Email Subject",
-		"Email Body (first 500 chars)",
-		"Raw Amount Text",
-		"Extracted Timestamp",
-	}
-	if err := writer.Write(headers); err != nil {
-		log.Printf("Error writing header: %v", err)
-		return ""
-	}
-
-	// Write transaction rows
-	for _, tx := range txList {
-		// Truncate body to first 500 chars
-		body := tx.Description
-		if len(body) > 500 {
-			body = body[:500] + "..."
-		}
-
-		row := []string{
-			tx.ID,
-			tx.ServiceName,
-			tx.ServiceID,
-			tx.Category,
-			fmt.Sprintf("%.2f", tx.Amount),
-			tx.Currency,
-			tx.CurrencySymbol,
-			tx.Date.Format("2006-01-02 15:04:05"),
-			tx.Email,
-			tx.Subject,
-			body,
-			tx.RawAmount,
-			tx.Timestamp.Format("2006-01-02 15:04:05"),
-		}
-		if err := writer.Write(row); err != nil {
-			log.Printf("Error writing row: %v", err)
-			return ""
-		}
-	}
-
-	return filename
-}
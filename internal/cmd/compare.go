@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(compareCmd)
+
+	compareCmd.Flags().String("a", "", "First period: a month (YYYY-MM) or a year (YYYY)")
+	compareCmd.Flags().String("b", "", "Second period, compared with the first: a month (YYYY-MM) or a year (YYYY)")
+	compareCmd.MarkFlagRequired("a")
+	compareCmd.MarkFlagRequired("b")
+	compareFilters = addFilterFlags(compareCmd)
+}
+
+// compareFilters holds the filter flags of gm compare
+var compareFilters *filterFlags
+
+var compareCmd = &cobra.Command{
+	Use:   "compare --a <period> --b <period>",
+	Short: "Compare category spending between two months or years",
+	Long: `Compare shows the spending of each category in two periods side by side,
+with the change from --a to --b in amount and percent, and lists the
+categories that only have spending in one of them. Periods are months
+(2024-05) or years (2024), and needn't be consecutive:
+
+  gm compare --a 2024-05 --b 2024-06
+  gm compare --a 2023 --b 2024 --currency USD
+
+Use --output json or yaml for the structured comparison.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		aFlag, _ := cmd.Flags().GetString("a")
+		bFlag, _ := cmd.Flags().GetString("b")
+		if outputFormat == outputCSV {
+			return fmt.Errorf("%w: gm compare prints json, yaml or text", apperrors.ErrInvalidInput)
+		}
+		a, err := parsePeriod("--a", aFlag)
+		if err != nil {
+			return err
+		}
+		b, err := parsePeriod("--b", bFlag)
+		if err != nil {
+			return err
+		}
+
+		filters, err := compareFilters.filters()
+		if err != nil {
+			return err
+		}
+
+		result, err := syncTransactions(context.Background())
+		if err != nil {
+			return err
+		}
+
+		transactions := summary.Apply(result.Transactions, filters...)
+		comparison := summary.Compare(transactions, a, b)
+		switch outputFormat {
+		case outputJSON:
+			return summary.WriteJSON(os.Stdout, comparison)
+		case outputYAML:
+			return summary.WriteYAML(os.Stdout, comparison)
+		}
+
+		if len(summary.GroupBy(transactions, summary.ByCurrency)) > 1 {
+			statusf("💡 Tip: Amounts in different currencies are added together; use --currency to compare one\n")
+		}
+		printComparison(comparison)
+		return nil
+	},
+}
+
+// parsePeriod parses the month (YYYY-MM) or year (YYYY) given to flag
+func parsePeriod(flag, value string) (summary.Span, error) {
+	if start, err := time.ParseInLocation("2006-01", value, time.Local); err == nil {
+		return summary.Span{Name: value, From: start, To: start.AddDate(0, 1, 0)}, nil
+	}
+	if start, err := time.ParseInLocation("2006", value, time.Local); err == nil {
+		return summary.Span{Name: value, From: start, To: start.AddDate(1, 0, 0)}, nil
+	}
+	return summary.Span{}, fmt.Errorf("%w: invalid %s %q (use YYYY-MM or YYYY)", apperrors.ErrInvalidInput, flag, value)
+}
+
+// printComparison prints the categories of a comparison side by side
+func printComparison(c *summary.Comparison) {
+	styles := loadCategoryStyles()
+	fmt.Printf("\n⚖️  Spending in %s vs %s:\n", c.B.Name, c.A.Name)
+	fmt.Println(strings.Repeat("─", 67))
+	fmt.Printf("%-23s %10.10s %10.10s %10s %10s\n", "", c.A.Name, c.B.Name, "Change", "%")
+	for _, d := range c.Categories {
+		fmt.Print(categoryLabel(styles, d.Key, 20) + " ")
+		printCompareValues(d)
+	}
+	fmt.Printf("%-23s", "Total")
+	printCompareValues(c.Total)
+
+	if len(c.Appeared) > 0 {
+		fmt.Printf("\n🆕 Only in %s: %s\n", c.B.Name, strings.Join(c.Appeared, ", "))
+	}
+	if len(c.Disappeared) > 0 {
+		fmt.Printf("👋 Only in %s: %s\n", c.A.Name, strings.Join(c.Disappeared, ", "))
+	}
+}
+
+// printCompareValues prints one line of a comparison: the amounts of both
+// periods, the change and the percent change
+func printCompareValues(d summary.Delta) {
+	percent := fmt.Sprintf("%+.1f%%", d.Percent)
+	switch {
+	case d.Previous == 0 && d.Total != 0:
+		percent = "new"
+	case d.Total == 0 && d.Previous != 0:
+		percent = "gone"
+	case d.Change == 0:
+		percent = "-"
+	}
+	fmt.Printf(" %10.2f %10.2f %+10.2f %10s\n", d.Previous, d.Total, d.Change, percent)
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/config"
+	"github.com/sazardev/go-money/internal/paths"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/sazardev/go-money/pkg/redact"
+	"github.com/spf13/cobra"
+)
+
+// Where the value of a setting comes from
+const (
+	sourceFile        = "file"
+	sourceEnvironment = "environment"
+)
+
+// fileSettings are the keys whose values came from the settings file
+var fileSettings []string
+
+// applySettingsFile gives the variables not set in the environment the
+// values of config.yaml in the configuration directory
+func applySettingsFile() error {
+	file, err := config.LoadFile(paths.Config(config.FileName))
+	if err != nil {
+		return fmt.Errorf("%w: %w", apperrors.ErrInvalidInput, err)
+	}
+	fileSettings = file.Apply()
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetCmd, configSetCmd)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show and change the settings of config.yaml",
+	Long: `Config reads and writes config.yaml in the configuration directory, which
+holds the settings otherwise given with environment variables: the OAuth
+clients, the provider, the tracker path, the default currency, the mailbox
+search terms, the output format and more.
+
+Flags win over environment variables, which win over the file, so a
+variable set in the shell or .env still overrides a saved setting.`,
+}
+
+// settingValue is a setting with its value in effect, in structured output
+type settingValue struct {
+	Key    string `json:"key" yaml:"key"`
+	Env    string `json:"env" yaml:"env"`
+	Value  string `json:"value" yaml:"value"`
+	Source string `json:"source,omitempty" yaml:"source,omitempty"`
+}
+
+// currentSetting returns the value in effect of setting and where it comes from
+func currentSetting(setting config.Setting) settingValue {
+	current := settingValue{Key: setting.Key, Env: setting.Env}
+	value, set := os.LookupEnv(setting.Env)
+	if !set {
+		return current
+	}
+	current.Value = value
+	current.Source = sourceEnvironment
+	if slices.Contains(fileSettings, setting.Key) {
+		current.Source = sourceFile
+	}
+	return current
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "Print a setting, or every setting and where it comes from",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 {
+			setting, ok := config.FindSetting(args[0])
+			if !ok {
+				return fmt.Errorf("%w: unknown setting %q; gm config get lists them", apperrors.ErrInvalidInput, args[0])
+			}
+			fmt.Println(currentSetting(setting).Value)
+			return nil
+		}
+
+		values := make([]settingValue, len(config.Settings))
+		for i, setting := range config.Settings {
+			values[i] = currentSetting(setting)
+			if setting.Secret && values[i].Value != "" && redact.Enabled() {
+				values[i].Value = "********"
+			}
+		}
+		switch outputFormat {
+		case outputJSON:
+			return summary.WriteJSON(os.Stdout, values)
+		case outputYAML:
+			return summary.WriteYAML(os.Stdout, values)
+		}
+
+		fmt.Printf("\n⚙️  Settings (%s):\n", paths.Config(config.FileName))
+		fmt.Println("─────────────────────────────────────────────────")
+		for i, setting := range config.Settings {
+			value := values[i].Value
+			if values[i].Source != "" {
+				value += " (" + values[i].Source + ")"
+			} else {
+				value = "-"
+			}
+			fmt.Printf("%-24s %s\n", setting.Key, value)
+			fmt.Printf("%-24s %s, %s\n", "", setting.Description, setting.Env)
+		}
+		statusf("\n💡 Change one with gm config set <key> <value>\n")
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Save a setting in config.yaml; an empty value removes it",
+	Long: `Set saves a setting in config.yaml. Lists, such as queries, are given
+comma-separated. An empty value removes the setting, so its default or
+environment variable applies again.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, value := args[0], strings.TrimSpace(args[1])
+		setting, ok := config.FindSetting(key)
+		if !ok {
+			return fmt.Errorf("%w: unknown setting %q; gm config get lists them", apperrors.ErrInvalidInput, key)
+		}
+
+		file, err := config.LoadFile(paths.Config(config.FileName))
+		if err != nil {
+			return fmt.Errorf("%w: %w", apperrors.ErrInvalidInput, err)
+		}
+		if err := checkWritable("gm config set", file.Path()); err != nil {
+			return err
+		}
+		if err := file.Set(key, value); err != nil {
+			return fmt.Errorf("%w: %w", apperrors.ErrInvalidInput, err)
+		}
+		if err := file.Save(); err != nil {
+			printFailure("❌ Failed to save %s: %v\n", file.Path(), err)
+			return err
+		}
+
+		if value == "" {
+			statusf("🗑️  Removed %s from %s\n", key, file.Path())
+		} else {
+			statusf("✅ Saved %s in %s\n", key, file.Path())
+		}
+		if currentSetting(setting).Source == sourceEnvironment {
+			statusf("⚠️  %s is set in the environment, which wins over the file\n", setting.Env)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/corpus"
+	"github.com/sazardev/go-money/internal/extractor"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/paths"
+	"github.com/sazardev/go-money/internal/sanitize"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+// errCorpusFailed makes gm corpus check exit with a failure when fixtures
+// no longer extract as expected
+var errCorpusFailed = errors.New("corpus fixtures failed")
+
+// unsafeNameChars are replaced in fixture names derived from message IDs
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+func init() {
+	rootCmd.AddCommand(corpusCmd)
+	corpusCmd.AddCommand(corpusAddCmd, corpusCheckCmd)
+
+	corpusCmd.PersistentFlags().String("dir", "", "Directory of the corpus (default "+corpus.DefaultDir+" in the data directory)")
+	corpusAddCmd.Flags().String("name", "", "Name of the fixture (default: the service and the message ID)")
+	corpusCheckCmd.Flags().Bool("update", false, "Rewrite the golden files of failing fixtures with what is extracted now")
+}
+
+var corpusCmd = &cobra.Command{
+	Use:   "corpus",
+	Short: "Build a regression suite of receipts from your own emails",
+	Long: `Corpus keeps receipts as fixtures: each is a sanitized .eml file next to a
+.golden.json file with the transaction expected from it (service,
+category, amount, currency, date and status), or null when none is.
+
+Add receipts that were read right, or fix the golden file of one that
+wasn't, and run gm corpus check after changing tracker-mails.json or a
+parser to catch regressions. The fixtures are plain files, so they can be
+shared in bug reports or copied into internal/extractor/testdata.`,
+}
+
+// corpusDir returns the directory given with --dir, or the default one
+func corpusDir(cmd *cobra.Command) string {
+	if dir, _ := cmd.Flags().GetString("dir"); dir != "" {
+		return dir
+	}
+	return paths.Data(corpus.DefaultDir)
+}
+
+// extractOne returns the transaction extracted from msg on its own, or nil
+func extractOne(te *extractor.TransactionExtractor, msg *models.Message) *models.Transaction {
+	if transactions := te.ExtractTransactions([]*models.Message{msg}); len(transactions) > 0 {
+		return transactions[0]
+	}
+	return nil
+}
+
+var corpusAddCmd = &cobra.Command{
+	Use:   "add <message-id>",
+	Short: "Save a sanitized copy of an email as a fixture with its expected transaction",
+	Long: `Add fetches an email from the mailbox, masks the addresses, card and
+account numbers in it, and saves it as a fixture with the transaction
+extracted from the sanitized copy as its expected output. Check the golden
+file: if the receipt was read wrong, correct it so gm corpus check fails
+until the extraction is fixed. Attachments aren't kept.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id := args[0]
+		msg, err := fetchMessage(context.Background(), id)
+		if err != nil {
+			return err
+		}
+
+		te, err := extractor.NewTransactionExtractor()
+		if err != nil {
+			printFailure("❌ Failed to initialize transaction extractor: %v\n", err)
+			return err
+		}
+		sanitized := sanitize.Message(msg)
+		want := corpus.ExpectedOf(extractOne(te, sanitized))
+
+		name, _ := cmd.Flags().GetString("name")
+		if name == "" {
+			name = strings.Trim(unsafeNameChars.ReplaceAllString(id, "-"), "-")
+			if want != nil {
+				name = want.ServiceID + "-" + name
+			}
+		}
+		files, err := corpus.Add(corpusDir(cmd), name, sanitized, want)
+		if err != nil {
+			return fmt.Errorf("%w: %w", apperrors.ErrInvalidInput, err)
+		}
+
+		for _, file := range files {
+			statusf("📝 Wrote %s\n", file)
+		}
+		if want == nil {
+			statusf("⚠️  No transaction was extracted; the fixture expects none\n")
+		} else {
+			statusf("✅ Expects %s %.2f %s on %s (%s)\n", want.ServiceID, want.Amount, want.Currency, want.Date, want.Category)
+		}
+		if diffs := corpus.Diff(want, corpus.ExpectedOf(extractOne(te, msg))); len(diffs) > 0 {
+			statusf("⚠️  Sanitizing changed what is extracted (%s); check the fixture\n", strings.Join(diffs, "; "))
+		}
+		return nil
+	},
+}
+
+var corpusCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Extract every fixture and compare it with its golden file",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		update, _ := cmd.Flags().GetBool("update")
+		te, err := extractor.NewTransactionExtractor()
+		if err != nil {
+			printFailure("❌ Failed to initialize transaction extractor: %v\n", err)
+			return err
+		}
+
+		dir := corpusDir(cmd)
+		results, err := corpus.Check(dir, func(msg *models.Message) *models.Transaction {
+			return extractOne(te, msg)
+		}, update)
+		if err != nil {
+			return fmt.Errorf("%w: %w", apperrors.ErrInvalidInput, err)
+		}
+
+		failed := 0
+		for _, result := range results {
+			if !result.Passed() && !result.Updated {
+				failed++
+			}
+		}
+
+		switch outputFormat {
+		case outputJSON:
+			if err := summary.WriteJSON(os.Stdout, results); err != nil {
+				return err
+			}
+		case outputYAML:
+			if err := summary.WriteYAML(os.Stdout, results); err != nil {
+				return err
+			}
+		default:
+			statusf("\n🧪 Checked %d fixtures in %s\n", len(results), dir)
+			for _, result := range results {
+				switch {
+				case result.Passed():
+					fmt.Printf("✅ %s\n", result.Name)
+				case result.Updated:
+					fmt.Printf("📝 %s: golden file updated\n", result.Name)
+				default:
+					fmt.Printf("❌ %s\n", result.Name)
+				}
+				for _, diff := range result.Diffs {
+					fmt.Printf("      %s\n", diff)
+				}
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%w: %d of %d", errCorpusFailed, failed, len(results))
+		}
+		return nil
+	},
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/export"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// passphraseEnv supplies the --encrypt passphrase without prompting
+const passphraseEnv = "GM_EXPORT_PASSPHRASE"
+
+// encryptExports writes exported files into passphrase-protected archives
+var encryptExports bool
+
+// addEncryptFlag adds --encrypt to a command that writes files
+func addEncryptFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&encryptExports, "encrypt", false, "Write the file into an AES-256 encrypted .zip protected by a passphrase ($"+passphraseEnv+" or prompt)")
+}
+
+// writeExportFile creates path and writes an export into it. With
+// --encrypt, the export is written into path.zip, encrypted with a
+// passphrase, instead. It returns the path of the file written.
+func writeExportFile(path string, write func(w io.Writer) error) (string, error) {
+	if !encryptExports {
+		file, err := os.Create(path)
+		if err != nil {
+			printFailure("❌ Failed to create %s: %v\n", path, err)
+			return "", err
+		}
+		defer file.Close()
+
+		if err := write(file); err != nil {
+			printFailure("❌ Failed to write %s: %v\n", path, err)
+			return "", err
+		}
+		return path, file.Close()
+	}
+
+	passphrase, err := exportPassphrase()
+	if err != nil {
+		return "", err
+	}
+
+	var content bytes.Buffer
+	if err := write(&content); err != nil {
+		printFailure("❌ Failed to write %s: %v\n", path, err)
+		return "", err
+	}
+
+	archive := path + ".zip"
+	file, err := os.OpenFile(archive, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		printFailure("❌ Failed to create %s: %v\n", archive, err)
+		return "", err
+	}
+	defer file.Close()
+
+	if err := export.WriteEncryptedZip(file, filepath.Base(path), passphrase, content.Bytes(), time.Now()); err != nil {
+		printFailure("❌ Failed to encrypt %s: %v\n", archive, err)
+		return "", err
+	}
+	return archive, file.Close()
+}
+
+// exportPassphrase reads the --encrypt passphrase from the environment, or
+// asks for it twice on the terminal
+func exportPassphrase() (string, error) {
+	if passphrase := os.Getenv(passphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("%w: --encrypt needs a passphrase; set %s when not running in a terminal", apperrors.ErrInvalidInput, passphraseEnv)
+	}
+
+	read := func(prompt string) (string, error) {
+		fmt.Fprint(os.Stderr, prompt)
+		passphrase, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return string(passphrase), err
+	}
+	passphrase, err := read("🔑 Passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("%w: the passphrase must not be empty", apperrors.ErrInvalidInput)
+	}
+	confirm, err := read("🔑 Repeat passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if confirm != passphrase {
+		return "", fmt.Errorf("%w: the passphrases don't match", apperrors.ErrInvalidInput)
+	}
+	return passphrase, nil
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/extractor"
+	"github.com/sazardev/go-money/internal/mail"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+}
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <file.eml>",
+	Short: "Show how the amount of a saved receipt email was chosen",
+	Long: `Explain runs the transaction extraction over one saved email and lists
+every amount found in it with its score, best first. The best-scored amount
+becomes the transaction; use the list to see why a receipt was read wrong
+before fixing its tracker-mails.json entry or merchant parser.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		msg, err := loadEML(args[0])
+		if err != nil {
+			return err
+		}
+
+		txExtractor, err := extractor.NewTransactionExtractor()
+		if err != nil {
+			printFailure("❌ Failed to initialize transaction extractor: %v\n", err)
+			return err
+		}
+
+		explanation := txExtractor.Explain(msg)
+		switch outputFormat {
+		case outputJSON:
+			return summary.WriteJSON(os.Stdout, explanation)
+		case outputYAML:
+			return summary.WriteYAML(os.Stdout, explanation)
+		}
+
+		fmt.Printf("\n📧 %s\n", msg.Subject)
+		if explanation == nil {
+			fmt.Println("❌ No service matched this email, or no amount was found in it")
+			return nil
+		}
+
+		tx := explanation.Transaction
+		matched := "keyword"
+		if explanation.ByDomain {
+			matched = "sender domain"
+		}
+		fmt.Printf("🏪 %s (%s), matched by %s by the %s parser\n", tx.ServiceName, tx.Category, matched, explanation.Parser)
+		fmt.Printf("💰 %s%.2f %s%s%s\n", tx.CurrencySymbol, tx.Amount, tx.Currency, statusMarker(tx), confidenceMarker(tx))
+
+		fmt.Println("\n🔢 Amounts found, best first:")
+		fmt.Println("─────────────────────────────────────────────────")
+		for i, amount := range explanation.Amounts {
+			fmt.Printf("%d. %-16s %5.2f  %q\n", i+1, fmt.Sprintf("%.2f %s", amount.Amount, amount.Currency), amount.Score, amount.Raw)
+			for _, reason := range amount.Reasons {
+				fmt.Printf("      %s\n", reason)
+			}
+		}
+
+		if len(explanation.Warnings) > 0 {
+			fmt.Println("\n⚠️  Warnings:")
+			fmt.Println("─────────────────────────────────────────────────")
+			for _, warning := range explanation.Warnings {
+				fmt.Printf("%s %s\n", severityMarker(warning.Severity), warning.Message)
+			}
+		}
+		return nil
+	},
+}
+
+// loadEML reads a saved email, identified by its path
+func loadEML(path string) (*models.Message, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", apperrors.ErrInvalidInput, err)
+	}
+	msg, err := mail.ParseMessage(raw, !noAttachments)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s isn't an email: %w", apperrors.ErrInvalidInput, path, err)
+	}
+	msg.ID = path
+	return msg, nil
+}
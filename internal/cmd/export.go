@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/export"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/subscriptions"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+// transactionExport describes a format transactions can be exported to
+type transactionExport struct {
+	file  string // default output path
+	short string
+	write func(w io.Writer, txs []*models.Transaction, now time.Time) error
+}
+
+// transactionExports are the formats accepted by gm export --format and as
+// gm export subcommands
+var transactionExports = map[string]transactionExport{
+	"csv": {
+		file:  "transactions.csv",
+		short: "Export transactions as a CSV file for spreadsheets and accounting software",
+		write: func(w io.Writer, txs []*models.Transaction, _ time.Time) error { return export.WriteCSV(w, txs) },
+	},
+	"ofx": {
+		file:  "transactions.ofx",
+		short: "Export transactions as an OFX statement for GnuCash, Quicken and banking tools",
+		write: export.WriteOFX,
+	},
+	"qif": {
+		file:  "transactions.qif",
+		short: "Export transactions as a QIF file for GnuCash and Quicken",
+		write: func(w io.Writer, txs []*models.Transaction, _ time.Time) error { return export.WriteQIF(w, txs) },
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportICalCmd)
+
+	exportCmd.Flags().String("format", "", "Transaction export format (csv, ofx, qif)")
+	exportCmd.Flags().String("out", "", "Path of the file to write (default transactions.<format>)")
+	addEncryptFlag(exportCmd)
+	exportFilters = addFilterFlags(exportCmd)
+	exportICalCmd.Flags().String("out", "renewals.ics", "Path of the .ics file to write")
+	addEncryptFlag(exportICalCmd)
+	exportICalFilters = addFilterFlags(exportICalCmd)
+
+	for _, name := range []string{"csv", "ofx", "qif"} {
+		exportCmd.AddCommand(newTransactionExportCmd(name))
+	}
+}
+
+var (
+	// exportFilters and exportICalFilters hold the filter flags of gm export and gm export ical
+	exportFilters     *filterFlags
+	exportICalFilters *filterFlags
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export transactions and predictions to other tools",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		out, _ := cmd.Flags().GetString("out")
+		if format == "" {
+			return cmd.Help()
+		}
+
+		return runTransactionExport(strings.ToLower(format), out, exportFilters)
+	},
+}
+
+// newTransactionExportCmd creates the gm export subcommand for a format
+func newTransactionExportCmd(format string) *cobra.Command {
+	var filters *filterFlags
+	cmd := &cobra.Command{
+		Use:   format,
+		Short: transactionExports[format].short,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out, _ := cmd.Flags().GetString("out")
+			return runTransactionExport(format, out, filters)
+		},
+	}
+	cmd.Flags().String("out", transactionExports[format].file, "Path of the file to write")
+	addEncryptFlag(cmd)
+	filters = addFilterFlags(cmd)
+	return cmd
+}
+
+// runTransactionExport syncs transactions and writes those accepted by
+// filters to out in format
+func runTransactionExport(format, out string, filters *filterFlags) error {
+	exporter, ok := transactionExports[format]
+	if !ok {
+		return fmt.Errorf("%w: unsupported export format %q (use csv, ofx or qif)", apperrors.ErrInvalidInput, format)
+	}
+	if out == "" {
+		out = exporter.file
+	}
+
+	selected, err := filters.filters()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	result, err := syncTransactions(ctx)
+	if err != nil {
+		return err
+	}
+	transactions := summary.Apply(result.Transactions, selected...)
+
+	written, err := writeExportFile(out, func(w io.Writer) error { return exporter.write(w, transactions, time.Now()) })
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n📄 %d transactions written to %s\n", len(transactions), written)
+	return nil
+}
+
+var exportICalCmd = &cobra.Command{
+	Use:   "ical",
+	Short: "Export upcoming subscription renewals as an iCalendar (.ics) file",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		out, _ := cmd.Flags().GetString("out")
+
+		selected, err := exportICalFilters.filters()
+		if err != nil {
+			return err
+		}
+
+		result, err := syncTransactions(ctx)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		subs := subscriptions.Detect(summary.Apply(result.Transactions, selected...), now)
+
+		written, err := writeExportFile(out, func(w io.Writer) error { return export.WriteICal(w, subs, now) })
+		if err != nil {
+			return err
+		}
+
+		active := 0
+		for _, sub := range subs {
+			if sub.Active {
+				active++
+			}
+		}
+		fmt.Printf("\n📅 Calendar with %d upcoming renewals written to %s\n", active, written)
+		fmt.Println("💡 Tip: Import or subscribe to this file from Google Calendar or Apple Calendar")
+
+		return nil
+	},
+}
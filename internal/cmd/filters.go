@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+// filterFlags is the transaction filter flag set shared by every command
+// that lists, summarizes or exports transactions
+type filterFlags struct {
+	from      string
+	to        string
+	month     string
+	currency  string
+	category  string
+	service   string
+	tags      []string
+	member    string
+	minAmount float64
+	maxAmount float64
+	minConf   float64
+	statuses  []string
+}
+
+// statusAll selects transactions in every status with --status
+const statusAll = "all"
+
+// addFilterFlags registers the shared filter flags on cmd
+func addFilterFlags(cmd *cobra.Command) *filterFlags {
+	f := &filterFlags{}
+	flags := cmd.Flags()
+	flags.StringVarP(&f.from, "from", "f", "", "Start date (YYYY-MM-DD format)")
+	flags.StringVarP(&f.to, "to", "t", "", "End date (YYYY-MM-DD format)")
+	flags.StringVarP(&f.month, "month", "m", "", "Specific month (YYYY-MM format)")
+	flags.StringVarP(&f.currency, "currency", "c", "", "Filter by currency (USD, MXN, EUR, GBP, JPY, CAD)")
+	flags.StringVar(&f.category, "category", "", "Filter by category")
+	flags.StringVar(&f.service, "service", "", "Filter by service ID or name")
+	flags.StringSliceVar(&f.tags, "tag", nil, "Filter by tag (repeatable; all tags must match)")
+	flags.StringVar(&f.member, "member", "", "Filter by household member")
+	flags.Float64Var(&f.minAmount, "min-amount", 0, "Only transactions of at least this amount")
+	flags.Float64Var(&f.maxAmount, "max-amount", 0, "Only transactions of at most this amount")
+	flags.Float64Var(&f.minConf, "min-confidence", 0, "Only transactions extracted with at least this confidence (0.4-1)")
+	flags.StringSliceVar(&f.statuses, "status", nil, "Only transactions in these statuses: pending, settled, refunded, cancelled or all (default: settled and refunded)")
+	return f
+}
+
+// filters parses the flags into summary filters
+func (f *filterFlags) filters() ([]summary.Filter, error) {
+	var filters []summary.Filter
+
+	var fromDate, toDate time.Time
+	var err error
+	if f.from != "" {
+		if fromDate, err = parseDate(f.from); err != nil {
+			printFailure("❌ Invalid --from date: %v (use YYYY-MM-DD)\n", err)
+			return nil, fmt.Errorf("%w: --from %q", apperrors.ErrInvalidInput, f.from)
+		}
+	}
+	if f.to != "" {
+		if toDate, err = parseDate(f.to); err != nil {
+			printFailure("❌ Invalid --to date: %v (use YYYY-MM-DD)\n", err)
+			return nil, fmt.Errorf("%w: --to %q", apperrors.ErrInvalidInput, f.to)
+		}
+		// Include the whole end day
+		toDate = toDate.Add(24*time.Hour - time.Nanosecond)
+	}
+	if f.month != "" {
+		monthDate, err := time.Parse("2006-01", f.month)
+		if err != nil {
+			printFailure("❌ Invalid --month: %v (use YYYY-MM)\n", err)
+			return nil, fmt.Errorf("%w: --month %q", apperrors.ErrInvalidInput, f.month)
+		}
+		fromDate = monthDate
+		toDate = monthDate.AddDate(0, 1, 0).Add(-time.Nanosecond)
+	}
+	if !fromDate.IsZero() || !toDate.IsZero() {
+		filters = append(filters, summary.Between(fromDate, toDate))
+	}
+
+	if f.currency != "" {
+		filters = append(filters, summary.InCurrency(f.currency))
+	}
+	if f.category != "" {
+		filters = append(filters, summary.InCategory(f.category))
+	}
+	if f.service != "" {
+		filters = append(filters, summary.FromService(f.service))
+	}
+	for _, tag := range f.tags {
+		filters = append(filters, summary.WithTag(strings.TrimSpace(tag)))
+	}
+	if f.member != "" {
+		filters = append(filters, summary.ForMember(f.member))
+	}
+
+	if f.minAmount < 0 || f.maxAmount < 0 || (f.maxAmount != 0 && f.minAmount > f.maxAmount) {
+		return nil, fmt.Errorf("%w: invalid amount range %v-%v", apperrors.ErrInvalidInput, f.minAmount, f.maxAmount)
+	}
+	if f.minAmount != 0 || f.maxAmount != 0 {
+		filters = append(filters, summary.AmountBetween(f.minAmount, f.maxAmount))
+	}
+
+	if f.minConf < 0 || f.minConf > 1 {
+		return nil, fmt.Errorf("%w: --min-confidence must be between 0 and 1, got %v", apperrors.ErrInvalidInput, f.minConf)
+	}
+	if f.minConf > 0 {
+		filters = append(filters, summary.MinConfidence(f.minConf))
+	}
+
+	status, err := f.statusFilter()
+	if err != nil {
+		return nil, err
+	}
+	if status != nil {
+		filters = append(filters, status)
+	}
+
+	return filters, nil
+}
+
+// statusFilter parses --status. Without it only the transactions that count
+// toward spending are kept; "all" keeps every status.
+func (f *filterFlags) statusFilter() (summary.Filter, error) {
+	if len(f.statuses) == 0 {
+		return summary.Counted(), nil
+	}
+	var statuses []string
+	for _, status := range f.statuses {
+		status = strings.ToLower(strings.TrimSpace(status))
+		switch status {
+		case statusAll:
+			return nil, nil
+		case models.StatusPending, models.StatusSettled, models.StatusRefunded, models.StatusCancelled:
+			statuses = append(statuses, status)
+		default:
+			return nil, fmt.Errorf("%w: --status %q (use pending, settled, refunded, cancelled or all)", apperrors.ErrInvalidInput, status)
+		}
+	}
+	return summary.WithStatus(statuses...), nil
+}
+
+// pending returns the filters of f for the pending transactions it leaves
+// out, or nil when --status was given
+func (f *filterFlags) pending() ([]summary.Filter, error) {
+	if len(f.statuses) != 0 {
+		return nil, nil
+	}
+	pending := *f
+	pending.statuses = []string{models.StatusPending}
+	return pending.filters()
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/cache"
+	"github.com/sazardev/go-money/internal/fx"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/paths"
+	"github.com/sazardev/go-money/internal/summary"
+)
+
+// newFXProvider returns the exchange rate provider, cached on disk
+func newFXProvider() fx.Provider {
+	return fx.NewCache(fx.NewECB(), cache.New(paths.Cache()), "ecb", fx.DefaultCacheTTL)
+}
+
+// buildConvertedSummary summarizes transactions with every amount converted
+// to base. The per-currency subtotals keep the original amounts.
+func buildConvertedSummary(ctx context.Context, transactions []*models.Transaction, base string) (*models.ExpenseSummary, error) {
+	base = strings.ToUpper(base)
+
+	converter, err := fx.NewConverter(ctx, newFXProvider())
+	if err != nil {
+		printFailure("❌ %v\n", err)
+		return nil, err
+	}
+	if !converter.Supports(base) {
+		return nil, fmt.Errorf("%w: no exchange rate for --base-currency %s", apperrors.ErrInvalidInput, base)
+	}
+
+	converted, skipped := converter.ConvertTransactions(transactions, base)
+	for _, tx := range skipped {
+		statusf("⚠️  No exchange rate for %s; leaving out %s %.2f (%s)\n", tx.Currency, tx.ServiceName, tx.Amount, tx.ID)
+	}
+
+	s := summary.Build(converted)
+	s.Currencies = summary.GroupBy(transactions, summary.ByCurrency)
+	rates := converter.Rates()
+	s.FXSource, s.FXDate = rates.Source, rates.Date
+
+	return s, nil
+}
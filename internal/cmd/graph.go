@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/categories"
+	"github.com/sazardev/go-money/internal/charts"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+// Charts gm graph can draw
+const (
+	chartCategories = "categories"
+	chartTimeline   = "timeline"
+	chartTrend      = "trend"
+	chartAll        = "all"
+)
+
+// trendDays is how many recent days the terminal trend chart shows
+const trendDays = 14
+
+// terminalBarWidth is the width of the longest bar in terminal charts
+const terminalBarWidth = 40
+
+func init() {
+	rootCmd.AddCommand(graphCmd)
+
+	graphCmd.Flags().String("out", "", "Write the chart to a .png or .svg file instead of the terminal")
+	graphCmd.Flags().String("chart", chartAll, "Chart to draw (categories, timeline, trend, all)")
+	graphFilters = addFilterFlags(graphCmd)
+}
+
+// graphFilters holds the filter flags of gm graph
+var graphFilters *filterFlags
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Chart spending by category, month and day",
+	Long: `Graph draws spending by category, a monthly spending timeline and a daily
+trend with 7-day and 30-day rolling averages in the terminal. With --out, the
+charts are rendered to a PNG or SVG file instead; with --chart all, the chart
+name ("-categories", "-timeline", "-trend") is added to the file name.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out, _ := cmd.Flags().GetString("out")
+		which, _ := cmd.Flags().GetString("chart")
+
+		switch which {
+		case chartCategories, chartTimeline, chartTrend, chartAll:
+		default:
+			return fmt.Errorf("%w: unsupported --chart %q (use categories, timeline, trend or all)", apperrors.ErrInvalidInput, which)
+		}
+
+		var format charts.Format
+		if out != "" {
+			var err error
+			if format, err = charts.FormatForPath(out); err != nil {
+				return fmt.Errorf("%w: %w", apperrors.ErrInvalidInput, err)
+			}
+		}
+
+		filters, err := graphFilters.filters()
+		if err != nil {
+			return err
+		}
+
+		result, err := syncTransactions(context.Background())
+		if err != nil {
+			return err
+		}
+
+		transactions := summary.Apply(result.Transactions, filters...)
+		if len(transactions) == 0 {
+			statusf("\n⚠️  No transactions to chart\n")
+			return nil
+		}
+		if len(summary.GroupBy(transactions, summary.ByCurrency)) > 1 {
+			statusf("💡 Tip: Amounts in different currencies are added together; use --currency to chart one\n")
+		}
+
+		styles := loadCategoryStyles()
+		if out == "" {
+			if draws(which, chartCategories) {
+				printCategoryChart(transactions, styles)
+			}
+			if draws(which, chartTimeline) {
+				printTimelineChart(transactions)
+			}
+			if draws(which, chartTrend) {
+				printTrendChart(transactions)
+			}
+			return nil
+		}
+
+		if draws(which, chartCategories) {
+			path := chartPath(out, chartCategories, which == chartAll)
+			err := writeChart(path, func(f *os.File) error {
+				return charts.WriteCategoryPie(f, format, transactions, styles)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if draws(which, chartTimeline) {
+			path := chartPath(out, chartTimeline, which == chartAll)
+			err := writeChart(path, func(f *os.File) error {
+				return charts.WriteMonthlyTimeline(f, format, transactions)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if draws(which, chartTrend) {
+			if days, _ := summary.Daily(transactions); len(days) < 2 {
+				statusf("⚠️  Skipping the trend chart: transactions span a single day\n")
+				return nil
+			}
+			path := chartPath(out, chartTrend, which == chartAll)
+			err := writeChart(path, func(f *os.File) error {
+				return charts.WriteDailyTrend(f, format, transactions)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+}
+
+// draws reports whether the --chart selection includes chart
+func draws(which, chart string) bool {
+	return which == chart || which == chartAll
+}
+
+// chartPath returns the file a chart is written to; when several charts
+// are drawn, the chart name is added before the extension
+func chartPath(out, name string, several bool) string {
+	if !several {
+		return out
+	}
+	ext := filepath.Ext(out)
+	return strings.TrimSuffix(out, ext) + "-" + name + ext
+}
+
+// writeChart creates path and renders a chart into it
+func writeChart(path string, render func(f *os.File) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		printFailure("❌ Failed to create %s: %v\n", path, err)
+		return err
+	}
+	defer file.Close()
+
+	if err := render(file); err != nil {
+		printFailure("❌ Failed to render %s: %v\n", path, err)
+		return err
+	}
+
+	fmt.Printf("📊 Chart written to %s\n", path)
+	return nil
+}
+
+// printCategoryChart draws spending per category as horizontal bars
+func printCategoryChart(transactions []*models.Transaction, styles *categories.Overrides) {
+	groups := summary.GroupBy(transactions, summary.ByCategory)
+
+	fmt.Println("\n📊 Spending by Category:")
+	fmt.Println("─────────────────────────────────────────────────")
+	for _, group := range groups {
+		bar := strings.Repeat("█", barLength(group.Total, groups[0].Total))
+		if colorEnabled() {
+			bar = styles.Style(group.Key).Paint(bar)
+		}
+		fmt.Printf("%s %s %.2f (%.1f%%)\n", categoryLabel(styles, group.Key, 20), bar, group.Total, group.Percent)
+	}
+}
+
+// printTimelineChart draws spending per month as horizontal bars, oldest first
+func printTimelineChart(transactions []*models.Transaction) {
+	groups := summary.GroupBy(transactions, summary.ByMonth)
+	max := groups[0].Total
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+
+	fmt.Println("\n📅 Monthly Spending:")
+	fmt.Println("─────────────────────────────────────────────────")
+	for _, group := range groups {
+		fmt.Printf("%s %s %.2f\n", group.Key, strings.Repeat("█", barLength(group.Total, max)), group.Total)
+	}
+}
+
+// printTrendChart draws daily spending over the last trendDays days, next to
+// the 7-day and 30-day rolling averages up to each day
+func printTrendChart(transactions []*models.Transaction) {
+	days, totals := summary.Daily(transactions)
+	week := summary.RollingAverage(totals, summary.WeekWindow)
+	month := summary.RollingAverage(totals, summary.MonthWindow)
+	start := max(len(days)-trendDays, 0)
+
+	peak := 0.0
+	for _, total := range totals[start:] {
+		peak = max(peak, total)
+	}
+
+	fmt.Println("\n📈 Daily Trend:")
+	fmt.Println("─────────────────────────────────────────────────")
+	fmt.Printf("%-10s %-*s %10s %10s %10s\n", "Day", terminalBarWidth, "", "Spent", "7d avg", "30d avg")
+	for i := start; i < len(days); i++ {
+		bar := strings.Repeat("█", barLength(totals[i], peak))
+		fmt.Printf("%s %-*s %10.2f %10.2f %10.2f\n", days[i].Format("2006-01-02"), terminalBarWidth, bar, totals[i], week[i], month[i])
+	}
+}
+
+// barLength scales value to a bar of at most terminalBarWidth cells
+func barLength(value, max float64) int {
+	if max <= 0 {
+		return 0
+	}
+	n := int(value / max * terminalBarWidth)
+	if n == 0 && value > 0 {
+		n = 1
+	}
+	return n
+}
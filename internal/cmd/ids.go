@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/paths"
+	"github.com/sazardev/go-money/internal/store"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+// findTransaction returns the stored transaction with the full or short ID
+// ref, as printed in the transaction list
+func findTransaction(ctx context.Context, st store.Store, ref string) (*models.Transaction, error) {
+	transactions, err := st.Transactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := summary.ResolveID(transactions, ref)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", apperrors.ErrInvalidInput, err)
+	}
+	return tx, nil
+}
+
+// completeTransactionIDs completes the first argument of a command with the
+// short IDs of the stored transactions, described by their date, merchant
+// and amount
+func completeTransactionIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 || noStore {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	// The hooks that pick the store ran before the flags of the command
+	// being completed were parsed
+	if err := setupDirs(cmd); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	if err := applySettingsFile(); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	if !cmd.Flags().Changed("store") {
+		storePath = paths.Data(store.DefaultPath)
+		if location := os.Getenv("GM_STORE"); location != "" {
+			storePath = location
+		}
+	}
+	st, err := store.OpenReadOnly(storePath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	defer st.Close()
+	transactions, err := st.Transactions(context.Background())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	short := summary.ShortIDs(transactions)
+	var completions []string
+	for i := len(transactions) - 1; i >= 0; i-- { // Newest first
+		tx := transactions[i]
+		if id := short[tx.ID]; strings.HasPrefix(id, strings.ToLower(toComplete)) {
+			completions = append(completions, fmt.Sprintf("%s\t%s %s %.2f %s", id, tx.Date.Format("2006-01-02"), tx.ServiceName, tx.Amount, tx.Currency))
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
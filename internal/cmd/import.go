@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/mail"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	importCmd.Flags().String("mbox", "", "Import an mbox file, such as a Google Takeout export")
+	importCmd.Flags().String("eml", "", "Import every .eml file in a directory")
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import receipts from exported emails, without mailbox access",
+	Long: `Import runs the usual transaction extraction over exported emails instead
+of a mailbox, so no login or API access is needed. Use it to import a
+Google Takeout export, or to try tracker-mails.json changes against saved
+receipts with --read-only and a --store that doesn't exist yet, so no email
+is skipped as already imported.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mboxPath, _ := cmd.Flags().GetString("mbox")
+		emlDir, _ := cmd.Flags().GetString("eml")
+
+		var archive *mail.Archive
+		var err error
+		switch {
+		case mboxPath != "" && emlDir != "":
+			return fmt.Errorf("%w: pass either --mbox or --eml, not both", apperrors.ErrInvalidInput)
+		case mboxPath != "":
+			archive, err = mail.OpenMbox(mboxPath)
+		case emlDir != "":
+			archive, err = mail.OpenEML(emlDir)
+		default:
+			return fmt.Errorf("%w: pass --mbox or --eml", apperrors.ErrInvalidInput)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %w", apperrors.ErrInvalidInput, err)
+		}
+		archive.SetAttachments(!noAttachments)
+		archive.SetProgress(printProgress)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		st, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		statusf("\n🔍 Searching %s for transaction emails...\n", archive.Name())
+		ids, err := archive.Search(ctx, mail.Query{Terms: transactionQueries, All: true})
+		if err != nil {
+			printFailure("❌ Failed to read %s: %v\n", archive.Name(), err)
+			return err
+		}
+		statusf("✅ Found %d transaction emails!\n", len(ids))
+
+		messages, transactions, err := syncMessages(ctx, st, archive, ids, syncOutput{})
+		if err != nil {
+			return err
+		}
+		statusf("✅ Imported %d new emails: %d transactions\n", len(messages), len(transactions))
+		for _, tx := range transactions {
+			statusf("   %s  %s %s%.2f %s%s%s\n", tx.Date.Format("2006-01-02"), tx.ServiceName, tx.CurrencySymbol, tx.Amount, tx.Currency, statusMarker(tx), confidenceMarker(tx))
+		}
+		if len(messages) < len(ids) {
+			statusf("💡 %d emails were imported before and were skipped\n", len(ids)-len(messages))
+		}
+		return nil
+	},
+}
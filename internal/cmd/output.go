@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/categories"
+	"github.com/sazardev/go-money/internal/export"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/paths"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/sazardev/go-money/pkg/redact"
+	"golang.org/x/term"
+)
+
+// Supported values for the --output flag
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+	outputCSV  = "csv"
+)
+
+var outputFormat string
+
+// jsonOutput reports whether machine-readable output was requested
+func jsonOutput() bool {
+	return outputFormat == outputJSON
+}
+
+// structuredOutput reports whether stdout is reserved for JSON/YAML/CSV results
+func structuredOutput() bool {
+	return outputFormat != outputText
+}
+
+// validateOutputFormat rejects unknown --output values
+func validateOutputFormat() error {
+	switch outputFormat {
+	case outputText, outputJSON, outputYAML, outputCSV:
+		return nil
+	default:
+		return fmt.Errorf("%w: unsupported --output %q (use text, json, yaml or csv)", apperrors.ErrInvalidInput, outputFormat)
+	}
+}
+
+// statusf prints progress messages. They go to stderr when stdout carries
+// structured output so results can be piped into other tools.
+func statusf(format string, a ...interface{}) {
+	if structuredOutput() {
+		fmt.Fprintf(os.Stderr, format, a...)
+		return
+	}
+	fmt.Printf(format, a...)
+}
+
+// printFailure prints a human-readable failure line. In JSON mode it is a
+// no-op because the error is reported once, as JSON, by Execute.
+func printFailure(format string, a ...interface{}) {
+	if jsonOutput() {
+		return
+	}
+	fmt.Printf(format, a...)
+}
+
+// errorPayload is the JSON shape of an error written to stderr
+type errorPayload struct {
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	Hint     string `json:"hint,omitempty"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// writeError reports a command error on w in the selected output format
+func writeError(w io.Writer, err error) {
+	message := redact.String(err.Error())
+	if !jsonOutput() {
+		fmt.Fprintf(w, "Error: %s\n", message)
+		if hint := textHint(err); hint != "" {
+			fmt.Fprintf(w, "💡 %s\n", hint)
+		}
+		return
+	}
+
+	payload := errorPayload{
+		Code:     apperrors.Code(err),
+		Message:  message,
+		Hint:     apperrors.Hint(err),
+		ExitCode: apperrors.ExitCode(err),
+	}
+	if encErr := json.NewEncoder(w).Encode(payload); encErr != nil {
+		fmt.Fprintf(w, "Error: %s\n", message)
+	}
+}
+
+// textHint returns the fix to print under a text error. Missing logins and
+// bad flags are explained where they happen, so only failures reported by
+// the mail APIs get one here.
+func textHint(err error) string {
+	for _, target := range []error{
+		apperrors.ErrTokenRevoked,
+		apperrors.ErrInsufficientScope,
+		apperrors.ErrAPIDisabled,
+		apperrors.ErrQuotaExceeded,
+	} {
+		if errors.Is(err, target) {
+			return apperrors.Hint(err)
+		}
+	}
+	return ""
+}
+
+// writeResults writes calculate results in the requested structured format:
+// the transactions and summary as JSON/YAML, or the transactions as CSV
+func writeResults(w io.Writer, transactions []*models.Transaction, s *models.ExpenseSummary) error {
+	if transactions == nil {
+		transactions = []*models.Transaction{}
+	}
+	report := &models.Report{Transactions: transactions, Summary: s}
+
+	switch outputFormat {
+	case outputCSV:
+		return export.WriteCSV(w, transactions)
+	case outputYAML:
+		return summary.WriteYAML(w, report)
+	default:
+		return summary.WriteJSON(w, report)
+	}
+}
+
+// colorEnabled reports whether terminal output may use ANSI colors
+func colorEnabled() bool {
+	return os.Getenv("NO_COLOR") == "" && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// categoryLabel renders a category with its emoji, padded to width and
+// colored when the terminal supports it
+func categoryLabel(styles *categories.Overrides, category string, width int) string {
+	style := styles.Style(category)
+	label := fmt.Sprintf("%s %-*s", style.Emoji, width, category)
+	if colorEnabled() {
+		return style.Paint(label)
+	}
+	return label
+}
+
+// loadCategoryStyles returns the user's category display settings, falling
+// back to the defaults if category-rules.json can't be read
+func loadCategoryStyles() *categories.Overrides {
+	styles, err := categories.Load(paths.Config(categories.DefaultFile))
+	if err != nil {
+		return &categories.Overrides{}
+	}
+	return styles
+}
@@ -0,0 +1,677 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/auth"
+	"github.com/sazardev/go-money/internal/categories"
+	"github.com/sazardev/go-money/internal/config"
+	"github.com/sazardev/go-money/internal/extractor"
+	"github.com/sazardev/go-money/internal/gmail"
+	"github.com/sazardev/go-money/internal/mail"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/outlook"
+	"github.com/sazardev/go-money/internal/paths"
+	"github.com/sazardev/go-money/internal/store"
+	"golang.org/x/oauth2"
+	"golang.org/x/term"
+)
+
+var (
+	// noPrefilter disables skipping messages that don't look like receipts
+	noPrefilter bool
+	// noAttachments disables downloading PDF attachments
+	noAttachments bool
+	// storePath is the SQLite database, or PostgreSQL URL, holding synced
+	// transactions
+	storePath string
+	// concurrency is how many emails (or batches) are downloaded in parallel
+	concurrency int
+	// batchSize is how many emails are requested per batch HTTP request
+	batchSize int
+	// maxMessages limits how many emails are listed per sync; 0 is unlimited
+	maxMessages int
+	// readOnly keeps the store and configuration files untouched
+	readOnly bool
+	// noStore keeps synced transactions in memory only, without opening a
+	// store at all
+	noStore bool
+	// providerName selects the mailbox transaction emails are read from
+	providerName string
+	// polite syncs gently, for mailboxes whose API quota is shared with
+	// the rest of a Workspace domain
+	polite bool
+)
+
+// politeDelay is how long --polite waits before each Gmail request
+const politeDelay = 250 * time.Millisecond
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&noPrefilter, "no-prefilter", false, "Download every matching email instead of skipping obvious non-receipts")
+	rootCmd.PersistentFlags().BoolVar(&noAttachments, "no-attachments", false, "Don't download PDF attachments to look for amounts in them")
+	rootCmd.PersistentFlags().StringVar(&providerName, "provider", "", "Mailbox to read emails from: gmail, outlook or imap (default GM_PROVIDER, or gmail)")
+	rootCmd.PersistentFlags().StringVar(&storePath, "store", "", "Path of the local transaction database, or a postgres:// URL (default GM_STORE, or "+store.DefaultPath+" in the data directory)")
+	rootCmd.PersistentFlags().BoolVar(&readOnly, "read-only", false, "Don't modify the local store or category-rules.json; changes last for this run only")
+	rootCmd.PersistentFlags().BoolVar(&noStore, "no-store", false, "Keep synced transactions in memory for this run only, without reading or creating a store")
+	rootCmd.PersistentFlags().IntVar(&concurrency, "concurrency", gmail.DefaultConcurrency, "Number of email requests to run in parallel")
+	rootCmd.PersistentFlags().IntVar(&maxMessages, "max-messages", 0, "Maximum number of emails to search per sync, newest first (0 = unlimited)")
+	rootCmd.PersistentFlags().BoolVar(&polite, "polite", false, "Sync gently for shared API quotas: one request at a time with a pause before each, no batches, and screening by headers before downloading (default GM_POLITE)")
+	rootCmd.PersistentFlags().IntVar(&batchSize, "batch-size", gmail.DefaultBatchSize, fmt.Sprintf("Emails fetched per batch request (up to %d; 0 disables batching)", gmail.MaxBatchSize))
+}
+
+// historySearchMargin widens the search window of incremental syncs
+const historySearchMargin = 48 * time.Hour
+
+// transactionQueries are the searches for common transaction keywords
+var transactionQueries = []string{
+	"receipt",
+	"payment",
+	"transaction",
+	"order confirmation",
+	"booking confirmation",
+}
+
+// syncResult is the outcome of an incremental sync
+type syncResult struct {
+	Messages     []*models.Message            // Messages fetched during this sync
+	Transactions []*models.Transaction        // Every stored transaction, with category overrides applied, and those of closed months as they were closed
+	Budgets      map[string]categories.Budget // Monthly budgets by category, see loadBudgets
+}
+
+// syncTransactions fetches the emails that haven't been processed before,
+// stores the transactions extracted from them and returns every stored
+// transaction, printing progress along the way
+func syncTransactions(ctx context.Context) (*syncResult, error) {
+	st, err := openStore()
+	if err != nil {
+		return nil, err
+	}
+	defer st.Close()
+
+	accounts, err := selectedAccounts()
+	if err != nil {
+		return nil, err
+	}
+	var messages []*models.Message
+	if len(accounts) > 1 {
+		messages, err = syncAccounts(ctx, st, accounts)
+	} else {
+		var account config.Account
+		if len(accounts) == 1 {
+			account = accounts[0]
+		}
+		messages, err = syncMailbox(ctx, st, account, syncOutput{})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := st.SetSyncState(ctx, store.LastSyncKey, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return nil, err
+	}
+
+	transactions, err := st.Transactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyCategoryOverrides(transactions); err != nil {
+		return nil, err
+	}
+	closed, err := st.ClosedMonths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	transactions = applyClosedMonths(transactions, closed)
+
+	budgets, err := loadBudgets(ctx, st)
+	if err != nil {
+		return nil, err
+	}
+
+	return &syncResult{Messages: messages, Transactions: transactions, Budgets: budgets}, nil
+}
+
+// syncMailbox fetches the new emails of one mailbox, stores their
+// transactions and returns the fetched messages. account is the zero value
+// for the single mailbox chosen with --provider.
+func syncMailbox(ctx context.Context, st store.Store, account config.Account, out syncOutput) (_ []*models.Message, err error) {
+	provider, err := connectProvider(ctx, account, out)
+	if err != nil {
+		return nil, err
+	}
+	defer provider.Close()
+	defer func() { forgetRevokedToken(err, account, out) }()
+
+	// Step 3: Find transaction emails. The change cursor is read first so
+	// emails arriving during the sync are picked up by the next one.
+	tracker, tracksChanges := provider.(mail.ChangeTracker)
+	var cursor string
+	if tracksChanges {
+		if cursor, err = tracker.Cursor(ctx); err != nil {
+			out.failuref("❌ %s request failed: %v\n", provider.Name(), err)
+			return nil, err
+		}
+	}
+	startedAt := time.Now().UTC()
+
+	ids, incremental, err := addedTransactionIDs(ctx, st, provider, account, out)
+	if err == nil && !incremental {
+		out.statusf("\n🔍 Searching for transaction emails...\n")
+		ids, err = provider.Search(ctx, mail.Query{Terms: transactionQueries})
+	}
+	if err != nil {
+		out.failuref("❌ %s request failed: %v\n", provider.Name(), err)
+		return nil, err
+	}
+	out.statusf("✅ Found %d transaction emails!\n", len(ids))
+
+	// Step 4: Fetch the new messages and store their transactions
+	messages, _, err := syncMessages(ctx, st, provider, ids, out)
+	if err != nil {
+		return nil, err
+	}
+	out.statusf("✅ Processed %d new emails\n", len(messages))
+
+	if tracksChanges {
+		if err := st.SetSyncState(ctx, store.AccountKey(store.HistoryIDKey, account.Name), cursor); err != nil {
+			return nil, err
+		}
+		if err := st.SetSyncState(ctx, store.AccountKey(store.HistorySinceKey, account.Name), startedAt.Format(time.RFC3339)); err != nil {
+			return nil, err
+		}
+	}
+	return messages, nil
+}
+
+// addedTransactionIDs lists the transaction emails added since the change
+// cursor stored by the previous sync, searching only the recent emails
+// instead of the whole mailbox. It reports false when a full search is
+// needed: on the first sync, with providers that don't track changes, or
+// when the provider no longer knows the changes since the cursor.
+func addedTransactionIDs(ctx context.Context, st store.Store, provider mail.Provider, account config.Account, out syncOutput) ([]string, bool, error) {
+	tracker, ok := provider.(mail.ChangeTracker)
+	if !ok {
+		return nil, false, nil
+	}
+	cursor, err := st.SyncState(ctx, store.AccountKey(store.HistoryIDKey, account.Name))
+	if err != nil {
+		return nil, false, err
+	}
+	savedSince, err := st.SyncState(ctx, store.AccountKey(store.HistorySinceKey, account.Name))
+	if err != nil {
+		return nil, false, err
+	}
+	since, err := time.Parse(time.RFC3339, savedSince)
+	if cursor == "" || err != nil {
+		return nil, false, nil
+	}
+
+	out.statusf("\n🔍 Checking for new emails since the last sync...\n")
+	added, err := tracker.AddedSince(ctx, cursor)
+	if errors.Is(err, mail.ErrChangesExpired) {
+		out.statusf("⚠️  %s no longer has the changes since the last sync, searching every email\n", provider.Name())
+		return nil, false, nil
+	}
+	if err != nil || len(added) == 0 {
+		return nil, true, err
+	}
+
+	// Run the usual searches over recent emails only and keep the added
+	// ones; the margin covers emails delivered with an older date
+	matching, err := provider.Search(ctx, mail.Query{Terms: transactionQueries, After: since.Add(-historySearchMargin)})
+	if err != nil {
+		return nil, true, err
+	}
+
+	isAdded := make(map[string]bool, len(added))
+	for _, id := range added {
+		isAdded[id] = true
+	}
+	var ids []string
+	for _, id := range matching {
+		if isAdded[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids, true, nil
+}
+
+// storeMu serializes saving transactions, so accounts syncing at once
+// don't merge threads against stale copies of each other's transactions
+var storeMu sync.Mutex
+
+// syncMessages fetches the messages in ids that haven't been processed
+// before, saves the transactions extracted from them and marks them
+// processed. It returns the fetched messages and their transactions.
+func syncMessages(ctx context.Context, st store.Store, provider mail.Provider, ids []string, out syncOutput) ([]*models.Message, []*models.Transaction, error) {
+	processed, err := st.ProcessedIDs(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Overlapping searches may list an email more than once
+	var newIDs []string
+	for _, id := range ids {
+		if !processed[id] {
+			processed[id] = true
+			newIDs = append(newIDs, id)
+		}
+	}
+
+	messages, err := provider.Fetch(ctx, newIDs)
+	if err != nil {
+		out.failuref("❌ %s request failed: %v\n", provider.Name(), err)
+		return nil, nil, err
+	}
+
+	newTransactions, err := extractTransactions(messages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	stored, err := st.Transactions(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	closed, err := st.ClosedMonths(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	toSave, locked := withoutClosedMonths(mergeIntoThreads(stored, newTransactions), closed)
+	if locked > 0 {
+		out.statusf("🔒 %d transaction(s) dated in closed months weren't changed; reopen them with gm close --reopen\n", locked)
+	}
+	if err := st.SaveTransactions(ctx, toSave); err != nil {
+		out.failuref("❌ Failed to save transactions: %v\n", err)
+		return nil, nil, err
+	}
+
+	processedIDs := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		processedIDs = append(processedIDs, msg.ID)
+	}
+	if err := st.MarkProcessed(ctx, processedIDs); err != nil {
+		return nil, nil, err
+	}
+
+	return messages, newTransactions, nil
+}
+
+// openStore opens the local store, a read-only view of it with
+// --read-only, or an empty in-memory one with --no-store
+func openStore() (store.Store, error) {
+	if noStore {
+		return store.OpenMemory(), nil
+	}
+	var st store.Store
+	var err error
+	if readOnly {
+		st, err = store.OpenReadOnly(storePath)
+	} else {
+		st, err = store.Open(storePath)
+	}
+	if err != nil {
+		printFailure("❌ Failed to open local store: %v\n", err)
+		return nil, err
+	}
+	return st, nil
+}
+
+// checkWritable rejects commands that only save changes to file when
+// --read-only is set
+func checkWritable(command, file string) error {
+	if readOnly {
+		return fmt.Errorf("%w: %s saves changes to %s, which --read-only forbids", apperrors.ErrInvalidInput, command, file)
+	}
+	return nil
+}
+
+// checkStored rejects commands that only change or check the store when
+// --no-store is set, since there is none
+func checkStored(command string) error {
+	if noStore {
+		return fmt.Errorf("%w: %s works on the store, which --no-store skips", apperrors.ErrInvalidInput, command)
+	}
+	return nil
+}
+
+// connectProvider connects to an account's mailbox, or to the one selected
+// with --provider when account is the zero value
+func connectProvider(ctx context.Context, account config.Account, out syncOutput) (mail.Provider, error) {
+	if maxMessages < 0 {
+		return nil, fmt.Errorf("%w: --max-messages must not be negative", apperrors.ErrInvalidInput)
+	}
+
+	var provider mail.Provider
+	var err error
+	switch name := accountProvider(account); name {
+	case "gmail":
+		provider, err = connectGmail(ctx, account, out)
+	case "outlook":
+		provider, err = connectOutlook(ctx, account, out)
+	case "imap":
+		provider, err = connectIMAP(ctx, account, out)
+	default:
+		return nil, fmt.Errorf("%w: --provider must be gmail, outlook or imap, got %q", apperrors.ErrInvalidInput, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// accountProvider returns the provider of an account, or the one selected
+// with --provider when account is the zero value
+func accountProvider(account config.Account) string {
+	if account.Name != "" {
+		return strings.ToLower(account.Provider)
+	}
+	return selectedProvider()
+}
+
+// accountAuthenticator returns the authenticator keeping the token of an
+// account, or of the --provider mailbox when account is the zero value
+func accountAuthenticator(account config.Account) *auth.Authenticator {
+	return providerAuthenticator(accountProvider(account)).ForAccount(account.Name)
+}
+
+// forgetRevokedToken deletes the saved token of an account once its
+// provider refuses it as revoked, so the next command starts a new login
+// instead of failing the same way. Under --read-only the token is kept and
+// only the way to sign in again is shown.
+func forgetRevokedToken(err error, account config.Account, out syncOutput) {
+	if !errors.Is(err, apperrors.ErrTokenRevoked) {
+		return
+	}
+	if readOnly {
+		out.statusf("🔑 The saved token was revoked; sign in again with %s\n", loginCommand(account))
+		return
+	}
+	if accountAuthenticator(account).DeleteToken() == nil {
+		out.statusf("🔑 Removed the revoked token; the next command will ask you to sign in again\n")
+	}
+}
+
+// selectedProvider returns the mail provider set with --provider, falling
+// back to GM_PROVIDER and then Gmail
+func selectedProvider() string {
+	name := providerName
+	if name == "" {
+		name = config.LoadConfig().Provider
+	}
+	if name == "" {
+		name = "gmail"
+	}
+	return strings.ToLower(name)
+}
+
+// loadToken loads the OAuth token of an account. Concurrent syncs only use
+// saved tokens, since they can't share the login page.
+func loadToken(ctx context.Context, authenticator *auth.Authenticator, account config.Account, out syncOutput) (*oauth2.Token, error) {
+	out.statusf("📊 Loading your authentication token...\n")
+	var token *oauth2.Token
+	var err error
+	if out.concurrent() {
+		token, err = authenticator.CachedToken()
+	} else {
+		token, err = authenticator.GetToken(ctx)
+	}
+	if err != nil {
+		login := "gm auth login"
+		switch {
+		case account.Name != "":
+			login += " --account " + account.Name
+		case selectedProvider() != "gmail":
+			login += " --provider " + selectedProvider()
+		}
+		out.failuref("❌ Failed to load authentication: %v\n", err)
+		out.failuref("💡 Tip: Run '%s' first to authenticate\n", login)
+		return nil, err
+	}
+	out.statusf("✅ Token loaded successfully!\n")
+	return token, nil
+}
+
+// connectGmail loads the OAuth token and connects to Gmail
+func connectGmail(ctx context.Context, account config.Account, out syncOutput) (*gmail.GmailService, error) {
+	if concurrency < 1 {
+		return nil, fmt.Errorf("%w: --concurrency must be at least 1", apperrors.ErrInvalidInput)
+	}
+	if batchSize < 0 || batchSize > gmail.MaxBatchSize {
+		return nil, fmt.Errorf("%w: --batch-size must be between 0 and %d", apperrors.ErrInvalidInput, gmail.MaxBatchSize)
+	}
+
+	// Step 1: Load existing token
+	token, err := loadToken(ctx, auth.NewAuthenticator().ForAccount(account.Name), account, out)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 2: Connect to Gmail
+	out.statusf("\n📧 Connecting to Gmail...\n")
+	gmailService, err := gmail.NewGmailService(ctx, token)
+	if err != nil {
+		out.failuref("❌ Failed to connect to Gmail: %v\n", err)
+		return nil, err
+	}
+	out.statusf("✅ Connected to Gmail!\n")
+	gmailService.SetPrefilter(!noPrefilter)
+	gmailService.SetAttachments(!noAttachments)
+	gmailService.SetConcurrency(concurrency)
+	gmailService.SetBatchSize(batchSize)
+	gmailService.SetMaxMessages(maxMessages)
+	gmailService.SetProgress(out.progress())
+	if polite {
+		gmailService.SetDelay(politeDelay)
+		out.statusf("🐢 Polite mode: one request at a time, %v apart\n", politeDelay)
+	}
+
+	return gmailService, nil
+}
+
+// connectOutlook loads the Microsoft OAuth token and connects to Outlook
+func connectOutlook(ctx context.Context, account config.Account, out syncOutput) (*outlook.OutlookService, error) {
+	if concurrency < 1 {
+		return nil, fmt.Errorf("%w: --concurrency must be at least 1", apperrors.ErrInvalidInput)
+	}
+
+	token, err := loadToken(ctx, auth.NewMicrosoftAuthenticator().ForAccount(account.Name), account, out)
+	if err != nil {
+		return nil, err
+	}
+
+	out.statusf("\n📧 Connecting to Outlook...\n")
+	outlookService := outlook.NewOutlookService(ctx, token)
+	outlookService.SetAttachments(!noAttachments)
+	outlookService.SetConcurrency(concurrency)
+	outlookService.SetMaxMessages(maxMessages)
+	outlookService.SetProgress(out.progress())
+	out.statusf("✅ Connected to Outlook!\n")
+
+	return outlookService, nil
+}
+
+// connectIMAP logs in to an IMAP mailbox configured in the account, falling
+// back to the environment for settings it leaves out
+func connectIMAP(ctx context.Context, account config.Account, out syncOutput) (*mail.IMAP, error) {
+	cfg := config.LoadConfig()
+	port := account.Port
+	if port == 0 && cfg.IMAPPort != "" {
+		var err error
+		if port, err = strconv.Atoi(cfg.IMAPPort); err != nil || port < 1 || port > 65535 {
+			return nil, fmt.Errorf("%w: GM_IMAP_PORT %q is not a port number", apperrors.ErrInvalidInput, cfg.IMAPPort)
+		}
+	}
+	password := cfg.IMAPPassword
+	if account.PasswordEnv != "" {
+		password = os.Getenv(account.PasswordEnv)
+	}
+	imapConfig := mail.IMAPConfig{
+		Host:     cmp.Or(account.Host, cfg.IMAPHost),
+		Port:     port,
+		Username: cmp.Or(account.Username, cfg.IMAPUsername),
+		Password: password,
+		Mailbox:  cmp.Or(account.Mailbox, cfg.IMAPMailbox),
+		Security: cmp.Or(account.Security, cfg.IMAPSecurity),
+	}
+
+	out.statusf("\n📧 Connecting to %s...\n", imapConfig.Host)
+	imapService, err := mail.DialIMAP(ctx, imapConfig)
+	if err != nil {
+		out.failuref("❌ Failed to connect to IMAP: %v\n", err)
+		if account.Name != "" {
+			out.failuref("💡 Tip: Check host, username and password_env of %s in %s\n", account.Name, config.DefaultAccountsFile)
+		} else {
+			out.failuref("💡 Tip: Set GM_IMAP_HOST, GM_IMAP_USERNAME and GM_IMAP_PASSWORD\n")
+		}
+		return nil, err
+	}
+	out.statusf("✅ Connected to %s!\n", imapConfig.Host)
+	imapService.SetAttachments(!noAttachments)
+	imapService.SetMaxMessages(maxMessages)
+	imapService.SetProgress(out.progress())
+
+	return imapService, nil
+}
+
+// printProgress shows mailbox progress on a single updating line, only when
+// status output goes to a terminal
+func printProgress(p mail.Progress) {
+	out := os.Stdout
+	if structuredOutput() {
+		out = os.Stderr
+	}
+	if !term.IsTerminal(int(out.Fd())) {
+		return
+	}
+
+	// Return to the start of the line and clear it before each update
+	switch p.Stage {
+	case mail.StageListing:
+		fmt.Fprintf(out, "\r\033[K   🔎 %q: %d emails", p.Query, p.Done)
+	case mail.StageScreening:
+		fmt.Fprintf(out, "\r\033[K   🧹 Screened %d/%d emails", p.Done, p.Total)
+	case mail.StageDownloading:
+		fmt.Fprintf(out, "\r\033[K   📥 Downloaded %d/%d emails", p.Done, p.Total)
+	case mail.StageAttachments:
+		fmt.Fprintf(out, "\r\033[K   📎 Downloaded %d/%d attachments", p.Done, p.Total)
+	}
+	if p.Finished {
+		fmt.Fprintln(out)
+	}
+}
+
+// extractTransactions runs the transaction extractor over messages
+func extractTransactions(messages []*models.Message) ([]*models.Transaction, error) {
+	if len(messages) == 0 {
+		return nil, nil
+	}
+
+	txExtractor, err := extractor.NewTransactionExtractor()
+	if err != nil {
+		printFailure("❌ Failed to initialize transaction extractor: %v\n", err)
+		return nil, err
+	}
+
+	return txExtractor.ExtractTransactions(messages), nil
+}
+
+// extractionWarnings returns the doubts about the transactions extracted
+// from messages, most severe first
+func extractionWarnings(messages []*models.Message) []models.ExtractionWarning {
+	if len(messages) == 0 {
+		return nil
+	}
+	txExtractor, err := extractor.NewTransactionExtractor()
+	if err != nil {
+		return nil
+	}
+	return txExtractor.Warnings(messages)
+}
+
+// applyCategoryOverrides applies the user's categorization choices (gm categorize)
+func applyCategoryOverrides(transactions []*models.Transaction) error {
+	overrides, err := categories.Load(paths.Config(categories.DefaultFile))
+	if err != nil {
+		printFailure("❌ Failed to load category rules: %v\n", err)
+		return err
+	}
+	overrides.Apply(transactions)
+	return nil
+}
+
+// mergeIntoThreads links new transactions whose order, thread (within a
+// few days) or charge already has a stored transaction to it instead of
+// storing them separately, so follow-up emails arriving in later syncs don't double
+// count and move the stored transaction to their status. A new refund marks
+// the stored charge it refunds in full, and a tip or updated total amends
+// the stored charge it adjusts. It returns the transactions that need
+// saving.
+func mergeIntoThreads(stored, fresh []*models.Transaction) []*models.Transaction {
+	byOrder := make(map[string]*models.Transaction)
+	for _, tx := range stored {
+		if key := extractor.OrderKey(tx); key != "" {
+			byOrder[key] = tx
+		}
+	}
+
+	var toSave []*models.Transaction
+	for _, tx := range fresh {
+		if charge := extractor.RefundedBy(stored, tx); charge != nil && charge.EffectiveStatus() != models.StatusRefunded {
+			extractor.MergeStatus(charge, &models.Transaction{Status: models.StatusRefunded})
+			toSave = append(toSave, charge)
+		}
+
+		if charge := extractor.AdjustedBy(stored, tx); charge != nil {
+			extractor.MergeAdjustment(charge, tx)
+			toSave = append(toSave, charge)
+			continue
+		}
+
+		if existing, ok := byOrder[extractor.OrderKey(tx)]; ok && existing.ID != tx.ID {
+			extractor.MergeOrder(existing, tx)
+			toSave = append(toSave, existing)
+			continue
+		}
+
+		existing, ok := storedPurchase(stored, tx, extractor.SameThread)
+		if !ok {
+			existing, ok = storedPurchase(stored, tx, extractor.SameCharge)
+		}
+		if !ok || existing.ID == tx.ID {
+			toSave = append(toSave, tx)
+			continue
+		}
+		existing.RelatedIDs = append(existing.RelatedIDs, tx.ID)
+		existing.RelatedIDs = append(existing.RelatedIDs, tx.RelatedIDs...)
+		extractor.MergeStatus(existing, tx)
+		extractor.MergeInvoice(existing, tx)
+		toSave = append(toSave, existing)
+	}
+
+	return toSave
+}
+
+// storedPurchase finds a stored transaction that same reports is about the
+// same purchase as tx, such as extractor.SameThread
+func storedPurchase(stored []*models.Transaction, tx *models.Transaction, same func(a, b *models.Transaction) bool) (*models.Transaction, bool) {
+	for _, existing := range stored {
+		if same(existing, tx) {
+			return existing, true
+		}
+	}
+	return nil, false
+}
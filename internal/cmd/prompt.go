@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// stdinReader is shared so buffered input isn't lost between prompts
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// readKey prints prompt and returns a single key press. On a terminal the key
+// is read without waiting for Enter; otherwise the first character of the
+// next input line is used. Ctrl-C and end of input are reported as 'q'.
+func readKey(prompt string) byte {
+	fmt.Print(prompt)
+
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		if state, err := term.MakeRaw(fd); err == nil {
+			b, err := stdinReader.ReadByte()
+			term.Restore(fd, state)
+			if err != nil || b == 3 {
+				fmt.Println()
+				return 'q'
+			}
+			fmt.Printf("%c\n", b)
+			return b
+		}
+	}
+
+	line, err := stdinReader.ReadString('\n')
+	line = strings.TrimSpace(line)
+	if line == "" {
+		if err != nil {
+			return 'q'
+		}
+		return '\n'
+	}
+	return line[0]
+}
+
+// errInputEnded is returned by prompts when input ends before an answer
+var errInputEnded = errors.New("input ended before the answer")
+
+// readLine prints prompt with def as the suggested answer and returns the
+// next input line, trimmed, or def when the line is empty
+func readLine(prompt, def string) (string, error) {
+	if def != "" {
+		fmt.Printf("%s [%s]: ", prompt, def)
+	} else {
+		fmt.Printf("%s: ", prompt)
+	}
+	line, err := stdinReader.ReadString('\n')
+	line = strings.TrimSpace(line)
+	if err != nil && line == "" {
+		fmt.Println()
+		return "", errInputEnded
+	}
+	if line == "" {
+		return def, nil
+	}
+	return line, nil
+}
+
+// readList reads a comma-separated list like readLine, suggesting def. A
+// single "-" clears the list.
+func readList(prompt string, def []string) ([]string, error) {
+	line, err := readLine(prompt, strings.Join(def, ", "))
+	if err != nil || line == "-" {
+		return nil, err
+	}
+	var values []string
+	for _, value := range strings.Split(line, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/config"
+	"github.com/sazardev/go-money/internal/export"
+	"github.com/sazardev/go-money/internal/extractor"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+// reimburseSummary is the CSV file of a reimbursement packet
+const reimburseSummary = "summary.csv"
+
+func init() {
+	rootCmd.AddCommand(reimburseCmd)
+
+	reimburseCmd.Flags().String("out", "", "Folder to write the packet to; it must be new or empty")
+	reimburseFilters = addFilterFlags(reimburseCmd)
+}
+
+// reimburseFilters holds the filter flags of gm reimburse
+var reimburseFilters *filterFlags
+
+var reimburseCmd = &cobra.Command{
+	Use:   "reimburse",
+	Short: "Bundle the receipts of the selected transactions into a reimbursement packet",
+	Long: `Reimburse writes a folder ready to submit for the transactions the filters
+select, such as --tag work --month 2025-03:
+
+  summary.csv   one row per transaction, with its receipt files, and a
+                total per currency
+  receipts/     the PDF attachments of each transaction's emails, or a
+                printable HTML page of the email when it has none
+
+The emails are downloaded again from the mailbox, so receipts imported with
+gm import can't be included; transactions missing one are listed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out, _ := cmd.Flags().GetString("out")
+		if out == "" {
+			return fmt.Errorf("%w: --out is required, for example --out packet/", apperrors.ErrInvalidInput)
+		}
+		if entries, err := os.ReadDir(out); err == nil && len(entries) > 0 {
+			return fmt.Errorf("%w: %s isn't empty; choose a new folder", apperrors.ErrInvalidInput, out)
+		} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		filters, err := reimburseFilters.filters()
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		result, err := syncTransactions(ctx)
+		if err != nil {
+			return err
+		}
+		claimed := summary.Apply(result.Transactions, filters...)
+		if len(claimed) == 0 {
+			statusf("\n⚠️  No transactions match the filters; nothing to reimburse\n")
+			return nil
+		}
+
+		var ids []string
+		for _, tx := range claimed {
+			ids = append(ids, tx.ID)
+			ids = append(ids, tx.RelatedIDs...)
+		}
+		statusf("\n📥 Downloading the receipts of %d transactions...\n", len(claimed))
+		messages, err := fetchReceipts(ctx, ids)
+		if err != nil {
+			return err
+		}
+
+		receipts := filepath.Join(out, "receipts")
+		if err := os.MkdirAll(receipts, 0700); err != nil {
+			printFailure("❌ Failed to create %s: %v\n", receipts, err)
+			return err
+		}
+
+		claims := make([]export.Claim, 0, len(claimed))
+		var missing []*models.Transaction
+		for i, tx := range claimed {
+			prefix := fmt.Sprintf("%02d-%s-%s", i+1, tx.Date.Format("2006-01-02"), fileSlug(tx.ServiceName))
+			files, err := writeReceipts(receipts, prefix, tx, messages)
+			if err != nil {
+				printFailure("❌ Failed to write the receipts of %s: %v\n", tx.ServiceName, err)
+				return err
+			}
+			if len(files) == 0 {
+				missing = append(missing, tx)
+			}
+			claims = append(claims, export.Claim{Transaction: tx, Receipts: files})
+		}
+
+		if err := writeFile(filepath.Join(out, reimburseSummary), func(w io.Writer) error {
+			return export.WriteReimbursementCSV(w, claims)
+		}); err != nil {
+			return err
+		}
+
+		fmt.Printf("✅ Wrote a reimbursement packet of %d transactions to %s\n", len(claims), out)
+		for _, group := range summary.GroupBy(summary.Apply(claimed, summary.Counted()), summary.ByCurrency) {
+			fmt.Printf("💰 To claim: %.2f %s\n", group.Total, group.Key)
+		}
+		for _, tx := range missing {
+			fmt.Printf("⚠️  No receipt found for %s %s %s%.2f\n", tx.Date.Format("2006-01-02"), tx.ServiceName, tx.CurrencySymbol, tx.Amount)
+		}
+		return nil
+	},
+}
+
+// fetchReceipts downloads the emails with the given IDs from every selected
+// mailbox, trying each one for the emails the others don't have
+func fetchReceipts(ctx context.Context, ids []string) (map[string]*models.Message, error) {
+	accounts, err := selectedAccounts()
+	if err != nil {
+		return nil, err
+	}
+	if len(accounts) == 0 {
+		accounts = []config.Account{{}}
+	}
+
+	messages := make(map[string]*models.Message, len(ids))
+	for _, account := range accounts {
+		var remaining []string
+		for _, id := range ids {
+			if messages[id] == nil {
+				remaining = append(remaining, id)
+			}
+		}
+		if len(remaining) == 0 {
+			break
+		}
+
+		provider, err := connectProvider(ctx, account, syncOutput{})
+		if err != nil {
+			return nil, err
+		}
+		fetched, err := provider.Fetch(ctx, remaining)
+		provider.Close()
+		if err != nil {
+			printFailure("❌ %s request failed: %v\n", provider.Name(), err)
+			return nil, err
+		}
+		for _, msg := range fetched {
+			messages[msg.ID] = msg
+		}
+	}
+	return messages, nil
+}
+
+// writeReceipts saves the receipts of tx to dir, naming them after prefix,
+// and returns their file names: the PDF attachments of its emails, or a
+// printout of its first email when they have none
+func writeReceipts(dir, prefix string, tx *models.Transaction, messages map[string]*models.Message) ([]string, error) {
+	var emails []*models.Message
+	for _, id := range append([]string{tx.ID}, tx.RelatedIDs...) {
+		if msg := messages[id]; msg != nil {
+			emails = append(emails, msg)
+		}
+	}
+	if len(emails) == 0 {
+		return nil, nil
+	}
+
+	// Receipts and payment confirmations often attach the same invoice
+	seen := make(map[[sha256.Size]byte]bool)
+	var files []string
+	for _, msg := range emails {
+		for _, att := range msg.Attachments {
+			sum := sha256.Sum256(att.Data)
+			if seen[sum] {
+				continue
+			}
+			seen[sum] = true
+			name := fmt.Sprintf("%s-%d-%s.pdf", prefix, len(files)+1, fileSlug(strings.TrimSuffix(att.Filename, filepath.Ext(att.Filename))))
+			if err := os.WriteFile(filepath.Join(dir, name), att.Data, 0600); err != nil {
+				return nil, err
+			}
+			files = append(files, filepath.Join("receipts", name))
+		}
+	}
+	if len(files) > 0 {
+		return files, nil
+	}
+
+	msg := emails[0]
+	name := prefix + ".html"
+	err := writeFile(filepath.Join(dir, name), func(w io.Writer) error {
+		return export.WriteReceiptPrintout(w, tx, msg, extractor.PlainText(msg.Body))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []string{filepath.Join("receipts", name)}, nil
+}
+
+// writeFile creates path, readable only by the user, and writes it
+func writeFile(path string, write func(w io.Writer) error) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		printFailure("❌ Failed to create %s: %v\n", path, err)
+		return err
+	}
+	defer file.Close()
+
+	if err := write(file); err != nil {
+		printFailure("❌ Failed to write %s: %v\n", path, err)
+		return err
+	}
+	return file.Close()
+}
+
+// fileSlug turns text from an email into a safe file name part, such as
+// "uber-eats" for "Uber Eats"
+func fileSlug(s string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			sb.WriteRune(r)
+			dash = false
+		} else if !dash && sb.Len() > 0 {
+			sb.WriteByte('-')
+			dash = true
+		}
+	}
+	slug := strings.TrimSuffix(sb.String(), "-")
+	if len(slug) > 40 {
+		slug = strings.TrimSuffix(slug[:40], "-")
+	}
+	if slug == "" {
+		return "receipt"
+	}
+	return slug
+}
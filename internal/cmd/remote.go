@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/remote"
+	"github.com/sazardev/go-money/internal/store"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(remoteCmd)
+	remoteCmd.AddCommand(remotePullCmd, remotePushCmd, remoteSyncCmd)
+
+	remoteCmd.PersistentFlags().String("url", "", "URL of the gm serve instance (default: $GM_REMOTE_URL)")
+	remoteCmd.PersistentFlags().String("token", "", "Access token of the gm serve instance (default: $GM_REMOTE_TOKEN)")
+}
+
+var remoteCmd = &cobra.Command{
+	Use:   "remote",
+	Short: "Keep the local store in step with a gm serve instance",
+	Long: `Remote exchanges the local store with gm serve running elsewhere, such as
+on a home server, so every install has the same transactions. Pull merges
+the remote store into the local one, push the local store into the remote
+one, and sync does both.
+
+Transactions are matched by ID and compared by a hash of their content;
+when both stores changed the same transaction, the copy extracted last
+wins on both sides, so the stores end up identical. Transactions dated in
+months closed on the receiving side are left alone. Emails processed on
+one side aren't downloaded again on the other.
+
+Give the instance with --url and --token, or GM_REMOTE_URL and
+GM_REMOTE_TOKEN.`,
+}
+
+var remotePullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Merge the remote store into the local one",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := remoteClient(cmd)
+		if err != nil {
+			return err
+		}
+		return pullRemote(context.Background(), client)
+	},
+}
+
+var remotePushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Merge the local store into the remote one",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := remoteClient(cmd)
+		if err != nil {
+			return err
+		}
+		return pushRemote(context.Background(), client)
+	},
+}
+
+var remoteSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Pull the remote store, then push the local one",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := remoteClient(cmd)
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+		if err := pullRemote(ctx, client); err != nil {
+			return err
+		}
+		return pushRemote(ctx, client)
+	},
+}
+
+// remoteClient returns the client for the instance given with the flags
+// or environment
+func remoteClient(cmd *cobra.Command) (*remote.Client, error) {
+	url, _ := cmd.Flags().GetString("url")
+	token, _ := cmd.Flags().GetString("token")
+	if url == "" {
+		url = os.Getenv("GM_REMOTE_URL")
+	}
+	if token == "" {
+		token = os.Getenv("GM_REMOTE_TOKEN")
+	}
+	if url == "" || token == "" {
+		return nil, fmt.Errorf("%w: give the gm serve instance with --url and --token (or GM_REMOTE_URL and GM_REMOTE_TOKEN)", apperrors.ErrInvalidInput)
+	}
+	return remote.NewClient(url, token), nil
+}
+
+// pullRemote merges the remote store into the local one
+func pullRemote(ctx context.Context, client *remote.Client) error {
+	if err := checkWritable("gm remote pull", store.Redacted(storePath)); err != nil {
+		return err
+	}
+	if err := checkStored("gm remote pull"); err != nil {
+		return err
+	}
+	statusf("⬇️  Pulling from %s...\n", client.URL)
+	bundle, err := client.Pull(ctx)
+	if err != nil {
+		printFailure("❌ Failed to pull from %s: %v\n", client.URL, err)
+		return err
+	}
+	result, err := storeReplica{}.Merge(ctx, bundle)
+	if err != nil {
+		printFailure("❌ Failed to merge the remote store: %v\n", err)
+		return err
+	}
+	printMergeResult("Pulled", result)
+	return nil
+}
+
+// pushRemote merges the local store into the remote one
+func pushRemote(ctx context.Context, client *remote.Client) error {
+	if err := checkWritable("gm remote push", client.URL); err != nil {
+		return err
+	}
+	bundle, err := storeReplica{}.Bundle(ctx)
+	if err != nil {
+		return err
+	}
+	statusf("⬆️  Pushing %d transactions to %s...\n", len(bundle.Transactions), client.URL)
+	result, err := client.Push(ctx, bundle)
+	if err != nil {
+		printFailure("❌ Failed to push to %s: %v\n", client.URL, err)
+		return err
+	}
+	printMergeResult("Pushed", result)
+	return nil
+}
+
+// printMergeResult prints what merging a bundle changed
+func printMergeResult(verb string, result remote.Result) {
+	fmt.Printf("✅ %s: %d added, %d updated, %d already up to date\n", verb, result.Added, result.Updated, result.Unchanged)
+	if result.Skipped > 0 {
+		fmt.Printf("🔒 %d transaction(s) dated in closed months weren't changed\n", result.Skipped)
+	}
+}
+
+// storeReplica exchanges the local store with remote instances
+type storeReplica struct{}
+
+var _ remote.Replica = storeReplica{}
+
+// Bundle returns the stored transactions, as extracted, and the processed
+// email IDs
+func (storeReplica) Bundle(ctx context.Context) (*remote.Bundle, error) {
+	st, err := openStore()
+	if err != nil {
+		return nil, err
+	}
+	defer st.Close()
+
+	transactions, err := st.Transactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	processed, err := st.ProcessedIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &remote.Bundle{Transactions: transactions, Processed: make([]string, 0, len(processed))}
+	if bundle.Transactions == nil {
+		bundle.Transactions = []*models.Transaction{}
+	}
+	for id := range processed {
+		bundle.Processed = append(bundle.Processed, id)
+	}
+	sort.Strings(bundle.Processed)
+	return bundle, nil
+}
+
+// Merge saves the transactions of bundle that win over the stored ones,
+// leaving closed months alone, and marks its emails processed
+func (storeReplica) Merge(ctx context.Context, bundle *remote.Bundle) (remote.Result, error) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	st, err := openStore()
+	if err != nil {
+		return remote.Result{}, err
+	}
+	defer st.Close()
+
+	stored, err := st.Transactions(ctx)
+	if err != nil {
+		return remote.Result{}, err
+	}
+	closed, err := st.ClosedMonths(ctx)
+	if err != nil {
+		return remote.Result{}, err
+	}
+	months := closedMonthSet(closed)
+	toSave, result := remote.Merge(stored, bundle.Transactions, func(tx *models.Transaction) bool {
+		return months[summary.ByMonth(tx)]
+	})
+
+	if err := st.SaveTransactions(ctx, toSave); err != nil {
+		return result, err
+	}
+	return result, st.MarkProcessed(ctx, bundle.Processed)
+}
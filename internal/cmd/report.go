@@ -0,0 +1,455 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/categories"
+	"github.com/sazardev/go-money/internal/export"
+	"github.com/sazardev/go-money/internal/extractor"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/paths"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// ruleFiles are the files that decide how transactions are extracted and
+// categorized, whose digests are saved with reports
+var ruleFiles = []string{extractor.TrackerFile, categories.DefaultFile}
+
+// Kinds of report
+const (
+	reportMonth = "month"
+	reportYear  = "year"
+	reportPivot = "pivot"
+)
+
+// Columns of the pivot table
+const (
+	pivotByMonth  = "month"
+	pivotByMember = "member"
+)
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+
+	reportCmd.Flags().Bool("pivot", false, "Show a table of spending per category and month or member")
+	reportCmd.Flags().Int("year", 0, "Summarize one year (YYYY) and compare it with the year before")
+	reportCmd.Flags().Int("months", 6, "Number of months in the pivot table, ending with the current month")
+	reportCmd.Flags().String("by", pivotByMonth, "Pivot table columns (month, member)")
+	reportCmd.Flags().String("reproduce", "", "Regenerate a saved report from the inputs it was made with")
+	reportCmd.Flags().String("out", "", "Write the report to a .csv or .html file instead of the terminal")
+	addEncryptFlag(reportCmd)
+	reportFilters = addFilterFlags(reportCmd)
+}
+
+// reportFilters holds the filter flags of gm report
+var reportFilters *filterFlags
+
+// reportFilterList returns the filters of gm report. --month is the shared
+// filter flag, but there it picks the month report, which needs the month
+// before too, so it doesn't filter.
+func reportFilterList() ([]summary.Filter, error) {
+	flags := *reportFilters
+	flags.month = ""
+	return flags.filters()
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Summarize a month or year, or compare spending across months or members",
+	Long: `Report --month 2024-06 or --year 2024 summarizes one period: its total,
+categories, top merchants and largest transactions, with the change from the
+period before, in total and per category. Use --output json or yaml for the
+structured report.
+
+Report --pivot shows spending per category for each of the last --months
+months, with totals per category and per month. With --by member, the columns
+are household members instead, over the transactions selected by the filters.
+Use --out to write the table to a CSV or HTML file, or --output csv, json or
+yaml to print it.
+
+Every report is saved with its inputs: the transactions the filters
+selected, with their categories then, the budgets, the flags given and a
+digest of the rule files. Report --reproduce <id> regenerates exactly the
+same report from them later, for audits and reimbursements, however the
+store and rules have changed since; it takes --out and --output like the
+original.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pivotFlag, _ := cmd.Flags().GetBool("pivot")
+		monthFlag := reportFilters.month
+		year, _ := cmd.Flags().GetInt("year")
+		months, _ := cmd.Flags().GetInt("months")
+		out, _ := cmd.Flags().GetString("out")
+		by, _ := cmd.Flags().GetString("by")
+		reproduce, _ := cmd.Flags().GetString("reproduce")
+
+		chosen := 0
+		for _, set := range []bool{pivotFlag, monthFlag != "", year != 0} {
+			if set {
+				chosen++
+			}
+		}
+		if reproduce != "" {
+			if chosen != 0 {
+				return fmt.Errorf("%w: --reproduce uses the report's own --month, --year or --pivot", apperrors.ErrInvalidInput)
+			}
+			return reproduceReport(reproduce, out)
+		}
+		if chosen != 1 {
+			return fmt.Errorf("%w: choose one report (--month, --year or --pivot)", apperrors.ErrInvalidInput)
+		}
+
+		report := &models.SavedReport{Kind: reportPivot, Months: months, By: by}
+		switch {
+		case monthFlag != "":
+			start, err := time.ParseInLocation("2006-01", monthFlag, time.Local)
+			if err != nil {
+				return fmt.Errorf("%w: invalid --month %q (use YYYY-MM)", apperrors.ErrInvalidInput, monthFlag)
+			}
+			report = &models.SavedReport{Kind: reportMonth, Month: start.Format("2006-01")}
+		case year != 0:
+			if year < 1 || year > 9999 {
+				return fmt.Errorf("%w: invalid --year %d", apperrors.ErrInvalidInput, year)
+			}
+			report = &models.SavedReport{Kind: reportYear, Year: year}
+		case by != pivotByMonth && by != pivotByMember:
+			return fmt.Errorf("%w: unsupported --by %q (use month or member)", apperrors.ErrInvalidInput, by)
+		case months < 1:
+			return fmt.Errorf("%w: --months must be at least 1", apperrors.ErrInvalidInput)
+		}
+		if err := checkReportOut(report, out); err != nil {
+			return err
+		}
+
+		filters, err := reportFilterList()
+		if err != nil {
+			return err
+		}
+
+		result, err := syncTransactions(context.Background())
+		if err != nil {
+			return err
+		}
+
+		report.CreatedAt = time.Now()
+		report.Rules = ruleDigests()
+		report.Transactions = summary.Apply(result.Transactions, filters...)
+		cmd.Flags().Visit(func(f *pflag.Flag) {
+			report.Flags = append(report.Flags, "--"+f.Name+"="+f.Value.String())
+		})
+		if report.Kind == reportPivot && by == pivotByMonth {
+			// Like gm calculate, budgets count every transaction, whatever the filters select
+			for category, b := range result.Budgets {
+				report.Budgets = append(report.Budgets, models.Budget{Category: category, Amount: b.Amount, Currency: b.Currency})
+				report.BudgetTransactions = append(report.BudgetTransactions, summary.Apply(result.Transactions, summary.InCategory(category))...)
+			}
+			sort.Slice(report.Budgets, func(i, j int) bool { return report.Budgets[i].Category < report.Budgets[j].Category })
+		}
+
+		if err := renderReport(report, out); err != nil {
+			return err
+		}
+		return saveReport(report)
+	},
+}
+
+// checkReportOut rejects --out and --output values a report can't be
+// written as
+func checkReportOut(report *models.SavedReport, out string) error {
+	if report.Kind != reportPivot {
+		if out != "" || outputFormat == outputCSV {
+			return fmt.Errorf("%w: --out and --output csv only apply to --pivot", apperrors.ErrInvalidInput)
+		}
+		return nil
+	}
+	ext := strings.ToLower(filepath.Ext(out))
+	if out != "" && ext != ".csv" && ext != ".html" {
+		return fmt.Errorf("%w: unsupported report file %q (use .csv or .html)", apperrors.ErrInvalidInput, out)
+	}
+	return nil
+}
+
+// renderReport builds a report from its inputs alone and prints or writes
+// it, so a saved report comes out the same every time
+func renderReport(report *models.SavedReport, out string) error {
+	if report.Kind != reportPivot {
+		return runRollup(report)
+	}
+
+	transactions := report.Transactions
+	var pivot *summary.Pivot
+	if report.By == pivotByMember {
+		pivot = summary.BuildPivot(transactions, summary.Members(transactions), summary.ByMember)
+	} else {
+		pivot = summary.BuildPivot(transactions, summary.LastMonths(report.CreatedAt, report.Months), summary.ByMonth)
+		budgets := make(map[string]categories.Budget, len(report.Budgets))
+		for _, b := range report.Budgets {
+			budgets[b.Category] = categories.Budget{Amount: b.Amount, Currency: b.Currency}
+		}
+		pivot.Budgets = summary.BudgetByMonth(report.BudgetTransactions, budgets, pivot.Columns, report.CreatedAt)
+	}
+	title := "Spending by Category and " + strings.ToUpper(report.By[:1]) + report.By[1:]
+	if len(summary.GroupBy(transactions, summary.ByCurrency)) > 1 {
+		statusf("💡 Tip: Amounts in different currencies are added together; use --currency to compare one\n")
+	}
+
+	styles := loadCategoryStyles()
+	switch ext := strings.ToLower(filepath.Ext(out)); {
+	case ext == ".csv":
+		return writeReportFile(out, func(w io.Writer) error { return export.WritePivotCSV(w, pivot) })
+	case ext == ".html":
+		return writeReportFile(out, func(w io.Writer) error { return export.WritePivotHTML(w, title, pivot, styles) })
+	case outputFormat == outputJSON:
+		return summary.WriteJSON(os.Stdout, pivot)
+	case outputFormat == outputYAML:
+		return summary.WriteYAML(os.Stdout, pivot)
+	case outputFormat == outputCSV:
+		return export.WritePivotCSV(os.Stdout, pivot)
+	}
+
+	if len(pivot.Rows) == 0 {
+		statusf("\n⚠️  No transactions to report\n")
+		return nil
+	}
+
+	fmt.Printf("\n📆 %s:\n", title)
+	fmt.Println(strings.Repeat("─", 23+11*(len(pivot.Columns)+1)))
+	fmt.Printf("%-23s", "")
+	for _, column := range pivot.Columns {
+		fmt.Printf(" %10.10s", column)
+	}
+	fmt.Printf(" %10s\n", "Total")
+	for _, row := range pivot.Rows {
+		fmt.Print(categoryLabel(styles, row.Category, 20) + " ")
+		printPivotValues(row.Values, row.Total)
+	}
+	fmt.Printf("%-23s", "Total")
+	printPivotValues(pivot.Totals, pivot.Total)
+
+	if len(pivot.Budgets) > 0 {
+		printBudgetHistory(pivot, styles)
+	}
+
+	return nil
+}
+
+// printBudgetHistory prints the percentage of each budget used in every
+// month of a pivot table, marking exceeded budgets with "!", followed by a
+// line for each of them
+func printBudgetHistory(pivot *summary.Pivot, styles *categories.Overrides) {
+	used := make(map[string][]string)
+	var budgeted []string
+	for _, b := range pivot.Budgets {
+		if _, ok := used[b.Category]; !ok {
+			budgeted = append(budgeted, b.Category)
+			used[b.Category] = make([]string, len(pivot.Columns))
+		}
+		for i, month := range pivot.Columns {
+			if month == b.Month {
+				used[b.Category][i] = fmt.Sprintf("%.0f%%", b.Used)
+				if b.Over() {
+					used[b.Category][i] += "!"
+				}
+			}
+		}
+	}
+	sort.Strings(budgeted)
+
+	fmt.Println("\n🎯 Budget used per month:")
+	fmt.Println(strings.Repeat("─", 23+11*len(pivot.Columns)))
+	for _, category := range budgeted {
+		fmt.Print(categoryLabel(styles, category, 20) + " ")
+		for _, cell := range used[category] {
+			fmt.Printf(" %10s", cell)
+		}
+		fmt.Println()
+	}
+	for _, b := range pivot.Budgets {
+		if b.Over() {
+			fmt.Printf("❗ %s\n", b.Message())
+		}
+	}
+}
+
+// runRollup prints the report of one month or year
+func runRollup(report *models.SavedReport) error {
+	var rollup *summary.Rollup
+	if report.Kind == reportMonth {
+		start, err := time.ParseInLocation("2006-01", report.Month, time.Local)
+		if err != nil {
+			return fmt.Errorf("%w: invalid month %q in report %s", apperrors.ErrInvalidInput, report.Month, report.ID)
+		}
+		rollup = summary.MonthRollup(report.Transactions, start)
+	} else {
+		rollup = summary.YearRollup(report.Transactions, report.Year, time.Local)
+	}
+
+	switch outputFormat {
+	case outputJSON:
+		return summary.WriteJSON(os.Stdout, rollup)
+	case outputYAML:
+		return summary.WriteYAML(os.Stdout, rollup)
+	}
+
+	if len(rollup.Summary.Currencies) > 1 {
+		statusf("💡 Tip: Amounts in different currencies are added together; use --currency to report one\n")
+	}
+	printRollup(rollup, loadCategoryStyles())
+	return nil
+}
+
+// printRollup prints the report of one month or year
+func printRollup(r *summary.Rollup, styles *categories.Overrides) {
+	s := r.Summary
+	fmt.Println("\n═══════════════════════════════════════════════════")
+	fmt.Printf("           📆 REPORT FOR %s\n", r.Period)
+	fmt.Println("═══════════════════════════════════════════════════")
+	if s.TotalCount == 0 {
+		fmt.Printf("No transactions in %s; %s%.2f in %s\n", r.Period, s.CurrencySymbol, r.Change.Previous, r.Previous)
+		return
+	}
+
+	displayRefunds(s)
+	fmt.Printf("💰 Total: %s%.2f (%s vs %s)\n", s.CurrencySymbol, s.TotalAmount, formatChange(r.Change, s.CurrencySymbol), r.Previous)
+	fmt.Printf("📈 Number of Transactions: %d\n", s.TotalCount)
+
+	fmt.Println("\n📊 By Category:")
+	fmt.Println("─────────────────────────────────────────────────")
+	for _, d := range r.Categories {
+		fmt.Printf("%s: %s%8.2f  %s\n", categoryLabel(styles, d.Key, 20), s.CurrencySymbol, d.Total, formatChange(d, s.CurrencySymbol))
+	}
+
+	fmt.Println("\n🏪 Top Merchants:")
+	fmt.Println("─────────────────────────────────────────────────")
+	for _, group := range r.TopMerchants {
+		fmt.Printf("%-20s: %s%8.2f (%d transactions)\n", truncateString(group.Key, 17), s.CurrencySymbol, group.Total, group.Count)
+	}
+
+	fmt.Println("\n🔝 Largest Transactions:")
+	fmt.Println("─────────────────────────────────────────────────")
+	for _, tx := range r.Largest {
+		fmt.Printf("%s  %-20s %s%.2f %s\n", tx.Date.Format("2006-01-02"), truncateString(tx.ServiceName, 17), tx.CurrencySymbol, tx.Amount, tx.Currency)
+	}
+}
+
+// formatChange describes a change from the previous period, such as
+// "▲ $12.00 (+8.5%)"
+func formatChange(d summary.Delta, symbol string) string {
+	switch {
+	case d.Change == 0:
+		return "no change"
+	case d.Previous == 0:
+		return fmt.Sprintf("▲ %s%.2f (new)", symbol, d.Change)
+	case d.Change > 0:
+		return fmt.Sprintf("▲ %s%.2f (+%.1f%%)", symbol, d.Change, d.Percent)
+	default:
+		return fmt.Sprintf("▼ %s%.2f (%.1f%%)", symbol, -d.Change, d.Percent)
+	}
+}
+
+// printPivotValues prints one line of pivot amounts followed by its total
+func printPivotValues(values []float64, total float64) {
+	for _, v := range values {
+		fmt.Printf(" %10.2f", v)
+	}
+	fmt.Printf(" %10.2f\n", total)
+}
+
+// writeReportFile writes a report file (encrypted with --encrypt)
+func writeReportFile(path string, write func(w io.Writer) error) error {
+	written, err := writeExportFile(path, write)
+	if err != nil {
+		return err
+	}
+
+	statusf("📄 Report written to %s\n", written)
+	return nil
+}
+
+// reproduceReport regenerates a saved report, noting the rule files that
+// changed since it was made
+func reproduceReport(id, out string) error {
+	ctx := context.Background()
+	st, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	report, err := st.Report(ctx, id)
+	if err != nil {
+		printFailure("❌ Failed to read report %s: %v\n", id, err)
+		return err
+	}
+	if report == nil {
+		return fmt.Errorf("%w: no saved report %q", apperrors.ErrInvalidInput, id)
+	}
+	if err := checkReportOut(report, out); err != nil {
+		return err
+	}
+
+	statusf("🧾 Report %s, made %s with %s\n", report.ID, report.CreatedAt.Format("2006-01-02 15:04"), strings.Join(report.Flags, " "))
+	current := ruleDigests()
+	for _, file := range ruleFiles {
+		if saved, ok := report.Rules[file]; ok && current[file] != saved {
+			statusf("💡 %s changed since; the report keeps the categories transactions had then\n", file)
+		}
+	}
+	return renderReport(report, out)
+}
+
+// saveReport saves the inputs of a report made just now and prints the ID
+// to reproduce it with. Nothing is saved with --read-only or --no-store.
+func saveReport(report *models.SavedReport) error {
+	if readOnly || noStore {
+		return nil
+	}
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	report.ID = report.CreatedAt.Format("20060102") + "-" + token[:6]
+
+	st, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	if err := st.SaveReport(context.Background(), *report); err != nil {
+		printFailure("❌ Failed to save report: %v\n", err)
+		return err
+	}
+	statusf("🧾 Saved as report %s; regenerate it with gm report --reproduce %s\n", report.ID, report.ID)
+	return nil
+}
+
+// ruleDigests returns the SHA-256 of each rule file, by file name; "" for
+// a missing file
+func ruleDigests() map[string]string {
+	digests := make(map[string]string)
+	for _, file := range ruleFiles {
+		path := paths.Config(file)
+		if file == extractor.TrackerFile {
+			path = extractor.TrackerPath()
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			digests[file] = ""
+			continue
+		}
+		sum := sha256.Sum256(data)
+		digests[file] = hex.EncodeToString(sum[:])
+	}
+	return digests
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/categories"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/remote"
+	"github.com/sazardev/go-money/internal/server"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().String("addr", "127.0.0.1:8090", "Address to listen on (use :8090 to serve other devices on the network)")
+	serveCmd.Flags().String("token", "", "Access token required by clients (default: $GM_SERVE_TOKEN or a random token)")
+	serveCmd.Flags().Duration("refresh", 6*time.Hour, "How often to sync new emails")
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve your transactions over HTTP (web dashboard and Atom feed)",
+	Long: `Serve keeps transactions in sync and serves a web dashboard, with spending
+per month and category, budgets and a transaction table, for household
+members who don't use the command line. The dashboard and the Atom feed of
+new transactions and budget alerts both need the access token, sent as an
+"Authorization: Bearer <token>" header; the dashboard asks for it.
+
+It listens on this computer only unless --addr says otherwise. Transactions
+are served over plain HTTP, so only listen on other addresses in a network
+you trust.
+
+Other installs can keep their store in step with this one with gm remote,
+using the same token.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString("addr")
+		token, _ := cmd.Flags().GetString("token")
+		refresh, _ := cmd.Flags().GetDuration("refresh")
+
+		if refresh <= 0 {
+			return fmt.Errorf("%w: --refresh must be positive", apperrors.ErrInvalidInput)
+		}
+
+		if token == "" {
+			token = os.Getenv("GM_SERVE_TOKEN")
+		}
+		if token == "" {
+			var err error
+			token, err = randomToken()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("🔑 Generated access token: %s\n", token)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		// Budgets are re-read on every sync so edits apply without a restart
+		var mu sync.Mutex
+		var latestBudgets map[string]categories.Budget
+		load := func(ctx context.Context) ([]*models.Transaction, error) {
+			result, err := syncTransactions(ctx)
+			if err != nil {
+				return nil, err
+			}
+			mu.Lock()
+			latestBudgets = result.Budgets
+			mu.Unlock()
+			return result.Transactions, nil
+		}
+		budgets := func(transactions []*models.Transaction, now time.Time) []models.BudgetStatus {
+			mu.Lock()
+			defer mu.Unlock()
+			return summary.BurnDown(transactions, latestBudgets, now)
+		}
+
+		host := addr
+		if strings.HasPrefix(host, ":") {
+			host = "localhost" + host
+		}
+		if !isLoopback(addr) {
+			statusf("⚠️  Serving your transactions over plain HTTP to other devices on %s; anyone on the network can see the traffic\n", addr)
+		}
+		fmt.Printf("📊 Dashboard: http://%s/\n", host)
+		fmt.Printf("📡 Atom feed: http://%s/feed.atom (with an Authorization: Bearer <token> header)\n", host)
+		// Remote instances may pull and push the store, unless changes
+		// wouldn't be saved or there is none
+		var replica remote.Replica
+		if !readOnly && !noStore {
+			replica = storeReplica{}
+		}
+		return server.NewServer(addr, token, refresh, load, budgets, replica).Run(ctx)
+	},
+}
+
+// isLoopback reports whether addr only listens on this computer
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// randomToken returns a random hex token suitable for authenticating clients
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,520 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/extractor"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/scaffold"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+// nonIDChars are dropped from merchant names to make service IDs
+var nonIDChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+func init() {
+	rootCmd.AddCommand(servicesCmd)
+	servicesCmd.AddCommand(servicesListCmd, servicesShowCmd, servicesTestCmd, servicesAddCmd, servicesEditCmd, servicesScaffoldCmd)
+
+	servicesTestCmd.Flags().String("eml", "", "Saved email (.eml) to test")
+	servicesTestCmd.Flags().String("message-id", "", "ID of an email in the mailbox to test")
+	servicesTestCmd.MarkFlagsOneRequired("eml", "message-id")
+	servicesTestCmd.MarkFlagsMutuallyExclusive("eml", "message-id")
+
+	servicesScaffoldCmd.Flags().StringSlice("domain", nil, "Sender domain of the merchant's receipts (repeatable, required)")
+	servicesScaffoldCmd.Flags().String("category", "Other", "Category of the merchant's transactions")
+	servicesScaffoldCmd.Flags().String("currency", "USD", "Currency of amounts written without one")
+	servicesScaffoldCmd.Flags().String("dir", ".", "Root of the go-money source checkout")
+}
+
+var servicesCmd = &cobra.Command{
+	Use:   "services",
+	Short: "Work on the merchants transactions are extracted for",
+}
+
+// serviceInfo is a service as gm services list and show print it
+type serviceInfo struct {
+	ID               string                     `json:"id" yaml:"id"`
+	Name             string                     `json:"name" yaml:"name"`
+	Category         string                     `json:"category" yaml:"category"`
+	Source           string                     `json:"source" yaml:"source"`
+	EmailDomains     []string                   `json:"email_domains" yaml:"email_domains"`
+	Keywords         []string                   `json:"keywords" yaml:"keywords"`
+	TransactionTypes []string                   `json:"transaction_types,omitempty" yaml:"transaction_types,omitempty"`
+	Currency         string                     `json:"currency" yaml:"currency"`
+	Locale           string                     `json:"locale,omitempty" yaml:"locale,omitempty"`
+	Fields           []string                   `json:"fields,omitempty" yaml:"fields,omitempty"`
+	AmountPattern    string                     `json:"amount_pattern,omitempty" yaml:"amount_pattern,omitempty"`
+	DatePattern      string                     `json:"date_pattern,omitempty" yaml:"date_pattern,omitempty"`
+	DateLayout       string                     `json:"date_layout,omitempty" yaml:"date_layout,omitempty"`
+	OrderPattern     string                     `json:"order_pattern,omitempty" yaml:"order_pattern,omitempty"`
+	Extraction       []extractor.StrategyConfig `json:"extraction,omitempty" yaml:"extraction,omitempty"`
+	RefundKeywords   []string                   `json:"refund_keywords,omitempty" yaml:"refund_keywords,omitempty"`
+}
+
+func newServiceInfo(service extractor.Service) serviceInfo {
+	return serviceInfo{
+		ID:               service.ID,
+		Name:             service.Name,
+		Category:         service.Category,
+		Source:           service.Source(),
+		EmailDomains:     service.EmailDomains,
+		Keywords:         service.Keywords,
+		TransactionTypes: service.TransactionTypes,
+		Currency:         service.PricePattern.Currency,
+		Fields:           service.PricePattern.Fields,
+		Locale:           service.PricePattern.Locale,
+		AmountPattern:    service.PricePattern.Amount,
+		DatePattern:      service.PricePattern.Date,
+		DateLayout:       service.PricePattern.DateLayout,
+		OrderPattern:     service.PricePattern.Order,
+		Extraction:       service.Extraction,
+		RefundKeywords:   service.RefundKeywords,
+	}
+}
+
+var servicesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the services transactions are extracted for, with their domains and categories",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		te, err := extractor.NewTransactionExtractor()
+		if err != nil {
+			printFailure("❌ Failed to initialize transaction extractor: %v\n", err)
+			return err
+		}
+
+		var infos []serviceInfo
+		for _, service := range te.GetAllServices() {
+			infos = append(infos, newServiceInfo(service))
+		}
+		switch outputFormat {
+		case outputJSON:
+			return summary.WriteJSON(os.Stdout, infos)
+		case outputYAML:
+			return summary.WriteYAML(os.Stdout, infos)
+		}
+
+		fmt.Printf("\n🏪 %d services:\n", len(infos))
+		fmt.Println("─────────────────────────────────────────────────")
+		for _, info := range infos {
+			marker := ""
+			switch info.Source {
+			case extractor.SourceBuiltIn:
+			case extractor.SourceCatalog:
+				marker = " +"
+			default:
+				marker = " *"
+			}
+			fmt.Printf("%-22s %-20s %s%s\n", info.ID, info.Category, strings.Join(info.EmailDomains, ", "), marker)
+		}
+		statusf("\n💡 * defined in %s, + from the community catalog (gm services update). Inspect one with gm services show <id>\n", extractor.TrackerPath())
+		return nil
+	},
+}
+
+var servicesShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show how a service's emails are recognized and read",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		te, err := extractor.NewTransactionExtractor()
+		if err != nil {
+			printFailure("❌ Failed to initialize transaction extractor: %v\n", err)
+			return err
+		}
+		id := strings.ToLower(strings.TrimSpace(args[0]))
+		service := te.GetServiceByID(id)
+		if service == nil {
+			return fmt.Errorf("%w: unknown service %q; see gm services list", apperrors.ErrInvalidInput, args[0])
+		}
+
+		info := newServiceInfo(*service)
+		switch outputFormat {
+		case outputJSON:
+			return summary.WriteJSON(os.Stdout, info)
+		case outputYAML:
+			return summary.WriteYAML(os.Stdout, info)
+		}
+
+		printService(info)
+		return nil
+	},
+}
+
+// printService prints the details of a service
+func printService(info serviceInfo) {
+	list := func(values []string, none string) string {
+		if len(values) == 0 {
+			return none
+		}
+		return strings.Join(values, ", ")
+	}
+	fmt.Printf("\n🏪 %s (%s)\n", info.Name, info.ID)
+	fmt.Println("─────────────────────────────────────────────────")
+	fmt.Printf("Category:          %s\n", info.Category)
+	if info.Source != "" {
+		fmt.Printf("Defined in:        %s\n", info.Source)
+	}
+	fmt.Printf("Email domains:     %s\n", list(info.EmailDomains, "none"))
+	fmt.Printf("Keywords:          %s\n", list(info.Keywords, "none"))
+	fmt.Printf("Transaction types: %s\n", list(info.TransactionTypes, "none"))
+	fmt.Printf("Currency:          %s\n", info.Currency)
+	if info.Locale != "" {
+		fmt.Printf("Number locale:     %s\n", info.Locale)
+	}
+	fmt.Printf("Refund keywords:   %s\n", list(info.RefundKeywords, "the defaults"))
+	if info.AmountPattern != "" {
+		fmt.Printf("Amount pattern:    %s\n", info.AmountPattern)
+	}
+	if info.DatePattern != "" {
+		layout := "common layouts"
+		if info.DateLayout != "" {
+			layout = info.DateLayout
+		}
+		fmt.Printf("Date pattern:      %s (%s)\n", info.DatePattern, layout)
+	}
+	if info.OrderPattern != "" {
+		fmt.Printf("Order pattern:     %s\n", info.OrderPattern)
+	}
+	if len(info.Extraction) == 0 {
+		fmt.Printf("Extraction:        %s\n", extractor.StrategyScan)
+		return
+	}
+	fmt.Println("Extraction:")
+	for i, strategy := range info.Extraction {
+		detail := strategy.Pattern + strategy.Selector + strings.Join(strategy.Labels, ", ")
+		fmt.Printf("  %d. %-14s %s\n", i+1, strategy.Type, detail)
+	}
+}
+
+var servicesTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Show which service matches an email and what is extracted from it",
+	Long: `Test runs the service matching and extraction over one email, saved with
+--eml or fetched from the mailbox with --message-id, and shows which service
+matched and why, the extraction stage or regular expression that found the
+amount, and the amount, currency and date read. Use it while writing a
+tracker-mails.json entry; gm explain lists every amount that was considered.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		emlPath, _ := cmd.Flags().GetString("eml")
+		messageID, _ := cmd.Flags().GetString("message-id")
+
+		var msg *models.Message
+		var err error
+		if emlPath != "" {
+			msg, err = loadEML(emlPath)
+		} else {
+			msg, err = fetchMessage(context.Background(), messageID)
+		}
+		if err != nil {
+			return err
+		}
+
+		te, err := extractor.NewTransactionExtractor()
+		if err != nil {
+			printFailure("❌ Failed to initialize transaction extractor: %v\n", err)
+			return err
+		}
+		explanation := te.Explain(msg)
+		switch outputFormat {
+		case outputJSON:
+			return summary.WriteJSON(os.Stdout, explanation)
+		case outputYAML:
+			return summary.WriteYAML(os.Stdout, explanation)
+		}
+
+		fmt.Printf("\n📧 %s\n", msg.Subject)
+		fmt.Printf("   From %s\n", msg.From)
+		if explanation == nil {
+			fmt.Println("❌ No service matched this email, or no amount was found in it")
+			statusf("💡 Compare the sender and text with a service's domains and keywords: gm services show <id>\n")
+			return nil
+		}
+
+		tx := explanation.Transaction
+		date := "when the email was sent"
+		if explanation.Dated {
+			date = "found in the email"
+		}
+		fmt.Println("─────────────────────────────────────────────────")
+		fmt.Printf("Service:   %s (%s), %s\n", tx.ServiceName, tx.ServiceID, tx.Category)
+		fmt.Printf("Matched:   by %s, with the %s parser\n", explanation.MatchedBy, explanation.Parser)
+		fmt.Printf("Found by:  %s\n", explanation.Stage)
+		fmt.Printf("Amount:    %s%.2f %s%s%s\n", tx.CurrencySymbol, tx.Amount, tx.Currency, statusMarker(tx), confidenceMarker(tx))
+		fmt.Printf("Date:      %s (%s)\n", tx.Date.Format("2006-01-02"), date)
+		if len(explanation.Warnings) > 0 {
+			fmt.Println()
+		}
+		for _, warning := range explanation.Warnings {
+			fmt.Printf("%s %s\n", severityMarker(warning.Severity), warning.Message)
+		}
+		if len(explanation.Amounts) > 1 {
+			statusf("\n💡 %d other amounts were considered; see them with gm explain <file.eml>\n", len(explanation.Amounts)-1)
+		}
+		return nil
+	},
+}
+
+var servicesAddCmd = &cobra.Command{
+	Use:   "add [name]",
+	Short: "Add a merchant to your tracker file by answering a few questions",
+	Long: `Add asks for a merchant's name, ID, category, sender domains, keywords,
+currency and, optionally, a regular expression for the amount of its
+receipts, checks the answers and adds the merchant to your
+tracker-mails.json. Press Enter to keep a suggested answer; for lists,
+separate values with commas.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		service := extractor.Service{Category: "Other", PricePattern: extractor.PricePatternConfig{Currency: "USD"}}
+		if len(args) > 0 {
+			service.Name = strings.TrimSpace(args[0])
+		}
+		return runServiceWizard("gm services add", service, true)
+	},
+}
+
+var servicesEditCmd = &cobra.Command{
+	Use:   "edit <id>",
+	Short: "Change a service by answering a few questions",
+	Long: `Edit asks again for each setting of a service, suggesting its current
+value: press Enter to keep it, or - to clear a list or a pattern.
+The service is saved to your tracker-mails.json; editing a built-in service
+saves your version there, which replaces the built-in one.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		te, err := extractor.NewTransactionExtractor()
+		if err != nil {
+			printFailure("❌ Failed to initialize transaction extractor: %v\n", err)
+			return err
+		}
+		service := te.GetServiceByID(strings.ToLower(strings.TrimSpace(args[0])))
+		if service == nil {
+			return fmt.Errorf("%w: unknown service %q; see gm services list", apperrors.ErrInvalidInput, args[0])
+		}
+		return runServiceWizard("gm services edit", *service, false)
+	},
+}
+
+// runServiceWizard prompts for the settings of service, suggesting its
+// current ones, and saves it to the user's tracker file once they are valid
+// and confirmed. New services get an ID that no service has yet.
+func runServiceWizard(command string, service extractor.Service, isNew bool) error {
+	path := extractor.TrackerPath()
+	if err := checkWritable(command, path); err != nil {
+		return err
+	}
+	te, err := extractor.NewTransactionExtractor()
+	if err != nil {
+		printFailure("❌ Failed to initialize transaction extractor: %v\n", err)
+		return err
+	}
+
+	service, err = promptService(te, service, isNew)
+	if errors.Is(err, errInputEnded) {
+		return fmt.Errorf("%w: %s was cancelled: %w", apperrors.ErrInvalidInput, command, err)
+	}
+	if err != nil {
+		return err
+	}
+	if err := extractor.ValidateService(service); err != nil {
+		printFailure("❌ %v\n", err)
+		return fmt.Errorf("%w: %w", apperrors.ErrInvalidInput, err)
+	}
+
+	info := newServiceInfo(service)
+	info.Source = path
+	printService(info)
+	switch {
+	case isNew:
+	case service.Source() == extractor.SourceBuiltIn:
+		statusf("\n💡 This is a built-in service; your version will replace it\n")
+	case service.Source() == extractor.SourceCatalog:
+		statusf("\n💡 This service comes from the community catalog; your version will replace it\n")
+	}
+	if key := readKey(fmt.Sprintf("\nSave to %s? [Y/n] ", path)); key != 'y' && key != 'Y' && key != '\n' && key != '\r' {
+		statusf("Nothing saved\n")
+		return nil
+	}
+
+	if err := extractor.SaveService(path, service); err != nil {
+		printFailure("❌ Failed to save %s: %v\n", service.ID, err)
+		return err
+	}
+	// Load the file as gm will, so a mistake shows now rather than on the next sync
+	if _, err := extractor.NewTransactionExtractor(); err != nil {
+		printFailure("❌ %s was saved but doesn't load: %v\n", path, err)
+		return err
+	}
+	statusf("✅ Saved %s to %s\n", service.ID, path)
+	statusf("💡 Try it on a receipt with gm services test --eml <file>\n")
+	return nil
+}
+
+// promptService asks for each setting of service, suggesting its current
+// value, and re-asks for the answers that can be checked on their own
+func promptService(te *extractor.TransactionExtractor, service extractor.Service, isNew bool) (extractor.Service, error) {
+	var err error
+	if service.Name, err = readLine("Name", service.Name); err != nil {
+		return service, err
+	}
+
+	if isNew {
+		suggested := nonIDChars.ReplaceAllString(strings.ToLower(service.Name), "")
+		for {
+			id, err := readLine("ID", suggested)
+			if err != nil {
+				return service, err
+			}
+			id = strings.ToLower(id)
+			switch {
+			case !extractor.ValidServiceID(id):
+				printFailure("❌ Use lowercase letters, digits, - and _\n")
+			case te.GetServiceByID(id) != nil:
+				printFailure("❌ %q already exists; change it with gm services edit %s\n", id, id)
+			default:
+				service.ID = id
+			}
+			if service.ID != "" {
+				break
+			}
+		}
+	}
+
+	statusf("   Known categories: %s\n", strings.Join(te.GetCategories(), ", "))
+	if service.Category, err = readLine("Category", service.Category); err != nil {
+		return service, err
+	}
+
+	if service.EmailDomains, err = readList("Sender domains or addresses", service.EmailDomains); err != nil {
+		return service, err
+	}
+	var domains []string
+	for _, domain := range service.EmailDomains {
+		if domain = strings.ToLower(strings.TrimPrefix(domain, "@")); !slices.Contains(domains, domain) {
+			domains = append(domains, domain)
+		}
+	}
+	service.EmailDomains = domains
+	if service.Keywords, err = readList("Keywords", service.Keywords); err != nil {
+		return service, err
+	}
+
+	for {
+		currency, err := readLine("Currency of amounts written without one", service.PricePattern.Currency)
+		if err != nil {
+			return service, err
+		}
+		service.PricePattern.Currency = strings.ToUpper(currency)
+		if _, ok := extractor.CurrencySymbol(service.PricePattern.Currency); ok {
+			break
+		}
+		printFailure("❌ Use an ISO 4217 code such as USD, MXN or INR\n")
+	}
+
+	pattern := &service.PricePattern
+	for {
+		if pattern.Locale, err = readLine("Number locale, such as de-DE for 1.234,56 (optional)", pattern.Locale); err != nil {
+			return service, err
+		}
+		if pattern.Locale == "-" {
+			pattern.Locale = ""
+		}
+		if extractor.ValidLocale(pattern.Locale) {
+			break
+		}
+		printFailure("❌ Unknown locale %q: use a language and region such as de-DE or en-US\n", pattern.Locale)
+	}
+	if pattern.Amount, err = readPattern("Amount regex, capture group 1 (optional)", pattern.Amount); err != nil {
+		return service, err
+	}
+	if pattern.Date, err = readPattern("Date regex, capture group 1 (optional)", pattern.Date); err != nil {
+		return service, err
+	}
+	if pattern.Date == "" {
+		pattern.DateLayout = ""
+	} else if pattern.DateLayout, err = readLine("Date layout, such as 02/01/2006 (optional)", pattern.DateLayout); err != nil {
+		return service, err
+	}
+	if pattern.DateLayout == "-" {
+		pattern.DateLayout = ""
+	}
+	if pattern.Order, err = readPattern("Order number regex, capture group 1 (optional)", pattern.Order); err != nil {
+		return service, err
+	}
+	return service, nil
+}
+
+// readPattern asks for a regular expression until a valid one is given,
+// returning def for an empty answer and nothing for "-"
+func readPattern(prompt, def string) (string, error) {
+	for {
+		pattern, err := readLine(prompt, def)
+		if err != nil {
+			return "", err
+		}
+		if pattern == "-" {
+			return "", nil
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			printFailure("❌ %v\n", err)
+			continue
+		}
+		return pattern, nil
+	}
+}
+
+var servicesScaffoldCmd = &cobra.Command{
+	Use:   "scaffold <name>",
+	Short: "Generate a merchant parser, a fixture email and a tracker entry",
+	Long: `Scaffold starts a new merchant parser in a go-money source checkout: a
+parser registered with the extractor, an example receipt to replace with a
+real one, and the merchant's entry in tracker-mails.json.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domains, _ := cmd.Flags().GetStringSlice("domain")
+		category, _ := cmd.Flags().GetString("category")
+		currency, _ := cmd.Flags().GetString("currency")
+		dir, _ := cmd.Flags().GetString("dir")
+
+		name := strings.TrimSpace(args[0])
+		id := nonIDChars.ReplaceAllString(strings.ToLower(name), "")
+		if id == "" {
+			return fmt.Errorf("%w: merchant name %q has no letters or digits", apperrors.ErrInvalidInput, name)
+		}
+		if len(domains) == 0 {
+			return fmt.Errorf("%w: pass the sender domain of %s receipts with --domain", apperrors.ErrInvalidInput, name)
+		}
+		for i, domain := range domains {
+			domains[i] = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@"))
+		}
+
+		files, err := scaffold.Generate(dir, scaffold.Merchant{
+			ID:       id,
+			Name:     name,
+			Category: category,
+			Currency: strings.ToUpper(currency),
+			Domains:  domains,
+		})
+		if err != nil {
+			printFailure("❌ Failed to scaffold %s: %v\n", name, err)
+			return err
+		}
+
+		fmt.Printf("✅ Scaffolded the %s parser:\n", name)
+		for _, file := range files {
+			fmt.Printf("   %s\n", file)
+		}
+		fmt.Println("💡 Next: replace the fixture with a real receipt, adjust the parser's labels and check the result with 'gm import --eml'")
+		return nil
+	},
+}
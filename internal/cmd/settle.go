@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/categories"
+	"github.com/sazardev/go-money/internal/export"
+	"github.com/sazardev/go-money/internal/paths"
+	"github.com/sazardev/go-money/internal/settle"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(settleCmd)
+
+	settleCmd.Flags().String("out", "", "Also write the transfers to a .csv file")
+	addEncryptFlag(settleCmd)
+	settleFilters = addFilterFlags(settleCmd)
+}
+
+// settleFilters holds the filter flags of gm settle
+var settleFilters *filterFlags
+
+var settleCmd = &cobra.Command{
+	Use:   "settle",
+	Short: "Work out who owes whom for shared expenses",
+	Long: `Settle splits transactions tagged "` + categories.SharedTag + `" between the household
+members configured in ` + categories.DefaultFile + `, using each member's share
+(equal shares when none is set), and lists the payments that even out what
+everyone paid. Use --month to settle one month.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out, _ := cmd.Flags().GetString("out")
+		if out != "" && strings.ToLower(filepath.Ext(out)) != ".csv" {
+			return fmt.Errorf("%w: unsupported settlement file %q (use .csv)", apperrors.ErrInvalidInput, out)
+		}
+
+		filters, err := settleFilters.filters()
+		if err != nil {
+			return err
+		}
+
+		overrides, err := categories.Load(paths.Config(categories.DefaultFile))
+		if err != nil {
+			return err
+		}
+		if len(overrides.Members) < 2 {
+			return fmt.Errorf("%w: configure at least two household members in %s", apperrors.ErrInvalidInput, categories.DefaultFile)
+		}
+
+		result, err := syncTransactions(context.Background())
+		if err != nil {
+			return err
+		}
+
+		settlements, unassigned := settle.Compute(summary.Apply(result.Transactions, filters...), overrides.Members)
+		if unassigned > 0 {
+			statusf("⚠️  %d shared transactions aren't attributed to a member and were left out\n", unassigned)
+		}
+		if settlements == nil {
+			settlements = []settle.Settlement{}
+		}
+
+		if out != "" {
+			err := writeReportFile(out, func(w io.Writer) error { return export.WriteSettlementCSV(w, settlements) })
+			if err != nil {
+				return err
+			}
+		}
+
+		switch outputFormat {
+		case outputJSON:
+			return summary.WriteJSON(os.Stdout, settlements)
+		case outputYAML:
+			return summary.WriteYAML(os.Stdout, settlements)
+		case outputCSV:
+			return export.WriteSettlementCSV(os.Stdout, settlements)
+		}
+
+		if len(settlements) == 0 {
+			statusf("\n✅ No shared expenses to settle (tag them with: gm tag <id> %s)\n", categories.SharedTag)
+			return nil
+		}
+
+		for _, s := range settlements {
+			fmt.Printf("\n🤝 Shared expenses in %s: %s%.2f (%d transactions)\n", s.Currency, s.CurrencySymbol, s.Total, s.Count)
+			fmt.Println("─────────────────────────────────────────────────")
+			fmt.Printf("%-20s %10s %10s %10s\n", "", "Paid", "Share", "Balance")
+			for _, b := range s.Balances {
+				fmt.Printf("%-20s %10.2f %10.2f %+10.2f\n", truncateString(b.Member, 20), b.Paid, b.Share, b.Net)
+			}
+
+			if len(s.Transfers) == 0 {
+				fmt.Println("✅ Already even")
+				continue
+			}
+			fmt.Println()
+			for _, t := range s.Transfers {
+				fmt.Printf("💸 %s pays %s %s%.2f\n", t.From, t.To, s.CurrencySymbol, t.Amount)
+			}
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+
+	statsCmd.Flags().Bool("distribution", false, "Also show a histogram of transaction sizes")
+	statsFilters = addFilterFlags(statsCmd)
+}
+
+// statsFilters holds the filter flags of gm stats
+var statsFilters *filterFlags
+
+// statsReport is the machine-readable output of gm stats
+type statsReport struct {
+	Overall      summary.Stats    `json:"overall" yaml:"overall"`
+	Categories   []summary.Stats  `json:"categories" yaml:"categories"`
+	Distribution []summary.Bucket `json:"distribution,omitempty" yaml:"distribution,omitempty"`
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show transaction size statistics per category",
+	Long: `Stats shows the median (p50), 90th percentile (p90) and largest
+transaction per category, to tell many small purchases from a few big ones.
+--distribution adds a histogram of transaction sizes.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		distribution, _ := cmd.Flags().GetBool("distribution")
+
+		filters, err := statsFilters.filters()
+		if err != nil {
+			return err
+		}
+
+		result, err := syncTransactions(context.Background())
+		if err != nil {
+			return err
+		}
+		transactions := summary.Apply(result.Transactions, filters...)
+
+		report := statsReport{
+			Overall:    summary.Overall(transactions),
+			Categories: summary.StatsBy(transactions, summary.ByCategory),
+		}
+		if report.Categories == nil {
+			report.Categories = []summary.Stats{}
+		}
+		if distribution {
+			report.Distribution = summary.Histogram(transactions)
+		}
+
+		switch outputFormat {
+		case outputJSON:
+			return summary.WriteJSON(os.Stdout, report)
+		case outputYAML:
+			return summary.WriteYAML(os.Stdout, report)
+		}
+
+		if len(transactions) == 0 {
+			statusf("\n⚠️  No transactions found\n")
+			return nil
+		}
+
+		styles := loadCategoryStyles()
+		fmt.Println("\n📐 Transaction Sizes:")
+		fmt.Println("─────────────────────────────────────────────────────────────")
+		fmt.Printf("%-23s %6s %10s %10s %10s\n", "", "Count", "p50", "p90", "Max")
+		for _, s := range report.Categories {
+			fmt.Printf("%s %6d %10.2f %10.2f %10.2f\n", categoryLabel(styles, s.Key, 20), s.Count, s.P50, s.P90, s.Max)
+		}
+		all := report.Overall
+		fmt.Printf("%-23s %6d %10.2f %10.2f %10.2f\n", "All", all.Count, all.P50, all.P90, all.Max)
+
+		if distribution {
+			printHistogram(report.Distribution)
+		}
+
+		return nil
+	},
+}
+
+// printHistogram draws the transaction size buckets as horizontal bars
+func printHistogram(buckets []summary.Bucket) {
+	most := 0
+	for _, b := range buckets {
+		if b.Count > most {
+			most = b.Count
+		}
+	}
+
+	fmt.Println("\n📊 Distribution:")
+	fmt.Println("─────────────────────────────────────────────────────────────")
+	for _, b := range buckets {
+		label := fmt.Sprintf("%g–%g", b.Min, b.Max)
+		if b.Max == 0 {
+			label = fmt.Sprintf("%g+", b.Min)
+		}
+		bar := strings.Repeat("█", barLength(float64(b.Count), float64(most)))
+		fmt.Printf("%10s %s %d (%.2f)\n", label, bar, b.Count, b.Total)
+	}
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sazardev/go-money/internal/subscriptions"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(subscriptionsCmd)
+
+	subscriptionsCmd.Flags().Bool("all", false, "Also list subscriptions whose last renewal was missed")
+	subscriptionsFilters = addFilterFlags(subscriptionsCmd)
+}
+
+// subscriptionsFilters holds the filter flags of gm subscriptions
+var subscriptionsFilters *filterFlags
+
+// subscriptionsReport is the machine-readable output of gm subscriptions
+type subscriptionsReport struct {
+	Subscriptions []subscriptions.Subscription `json:"subscriptions" yaml:"subscriptions"`
+	Totals        []subscriptions.Cost         `json:"totals" yaml:"totals"` // Active subscriptions only
+}
+
+var subscriptionsCmd = &cobra.Command{
+	Use:   "subscriptions",
+	Short: "List recurring charges with their monthly and annual cost",
+	Long: `Subscriptions groups transactions by service and lists those charged a
+similar amount on a regular cadence (weekly, monthly, quarterly or yearly),
+with the day of the month they bill on, what they cost per month and their
+annualized total. Subscriptions whose last renewal was missed are left out
+unless --all is given.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+
+		filters, err := subscriptionsFilters.filters()
+		if err != nil {
+			return err
+		}
+
+		result, err := syncTransactions(context.Background())
+		if err != nil {
+			return err
+		}
+
+		report := subscriptionsReport{Subscriptions: []subscriptions.Subscription{}}
+		for _, sub := range subscriptions.Detect(summary.Apply(result.Transactions, filters...), time.Now()) {
+			if sub.Active || all {
+				report.Subscriptions = append(report.Subscriptions, sub)
+			}
+		}
+		report.Totals = subscriptions.Costs(report.Subscriptions)
+		if report.Totals == nil {
+			report.Totals = []subscriptions.Cost{}
+		}
+
+		switch outputFormat {
+		case outputJSON:
+			return summary.WriteJSON(os.Stdout, report)
+		case outputYAML:
+			return summary.WriteYAML(os.Stdout, report)
+		}
+
+		if len(report.Subscriptions) == 0 {
+			statusf("\n✅ No subscriptions found\n")
+			return nil
+		}
+
+		fmt.Println("\n🔁 Subscriptions:")
+		fmt.Println("─────────────────────────────────────────────────────────────────────────")
+		fmt.Printf("%-20s %-10s %4s %14s %12s %12s\n", "Service", "Cadence", "Day", "Amount", "Per month", "Per year")
+		for _, sub := range report.Subscriptions {
+			lapsed := ""
+			if !sub.Active {
+				lapsed = "  (lapsed)"
+			}
+			fmt.Printf("%-20s %-10s %4d %14s %12.2f %12.2f%s\n", truncateString(sub.ServiceName, 17), sub.Cadence, sub.BillingDay,
+				fmt.Sprintf("%s%.2f %s", sub.CurrencySymbol, sub.Amount, sub.Currency), sub.MonthlyCost, sub.AnnualCost, lapsed)
+		}
+
+		fmt.Println()
+		for _, cost := range report.Totals {
+			fmt.Printf("💰 %d active in %s: %s%.2f per month, %s%.2f per year\n", cost.Subscriptions, cost.Currency,
+				cost.CurrencySymbol, cost.Monthly, cost.CurrencySymbol, cost.Annual)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/categories"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/paths"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(tagCmd)
+	tagCmd.Flags().Bool("remove", false, "Remove the tags instead of adding them")
+}
+
+var tagCmd = &cobra.Command{
+	Use:   "tag <transaction-id> <tag>...",
+	Short: "Add or remove tags on a stored transaction",
+	Long: `Tag labels a transaction (for example "work" or "shared") so it can be
+selected with --tag. Tags are saved to ` + categories.DefaultFile + `.
+
+The transaction is given by its ID or by its short ID, shown in the
+transaction list: a few characters from its start are enough, as with git
+hashes, as long as no other transaction starts with them.`,
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeTransactionIDs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		remove, _ := cmd.Flags().GetBool("remove")
+		ref, tags := args[0], args[1:]
+		if err := checkWritable("gm tag", categories.DefaultFile); err != nil {
+			return err
+		}
+
+		st, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		tx, err := findTransaction(context.Background(), st, ref)
+		if err != nil {
+			return err
+		}
+		id := tx.ID
+
+		overrides, err := categories.Load(paths.Config(categories.DefaultFile))
+		if err != nil {
+			return err
+		}
+
+		if remove {
+			overrides.RemoveTags(id, tags...)
+		} else {
+			overrides.AddTags(id, tags...)
+		}
+
+		if err := overrides.Save(); err != nil {
+			printFailure("❌ Failed to save %s: %v\n", categories.DefaultFile, err)
+			return err
+		}
+
+		current := strings.Join(overrides.Tags[id], ", ")
+		if current == "" {
+			current = "(no tags)"
+		}
+		fmt.Printf("🏷️  %s %s %s: %s\n", summary.ShortIDs([]*models.Transaction{tx})[id], tx.Date.Format("2006-01-02"), tx.ServiceName, current)
+		return nil
+	},
+}
@@ -0,0 +1,397 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/categories"
+	"github.com/sazardev/go-money/internal/extractor"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/paths"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// uiSorts are the orders gm ui cycles through with s
+var uiSorts = []string{"date", "amount", "service", "category"}
+
+// Keys gm ui reads besides printable ones
+const (
+	keyUp = iota + 256
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyEnter
+	keyBackspace
+	keyQuit
+)
+
+// uiChrome is the number of screen lines that aren't transaction rows
+const uiChrome = 8
+
+func init() {
+	rootCmd.AddCommand(uiCmd)
+}
+
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Browse, filter, recategorize and exclude transactions interactively",
+	Long: `UI opens a full-screen browser over every stored transaction, with a
+summary of the ones shown that updates as you go:
+
+  ↑ ↓ / j k    move            PgUp PgDn   move a page
+  /            filter by text  s / o       change the sort / its order
+  c            recategorize    x           exclude or count again
+  q            save and quit
+
+Category changes and exclusions are saved to ` + categories.DefaultFile + `
+on quit. Excluded transactions are left out of every total, like cancelled
+ones; list them with --status all.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fd := int(os.Stdin.Fd())
+		if !term.IsTerminal(fd) || !term.IsTerminal(int(os.Stdout.Fd())) {
+			return fmt.Errorf("%w: gm ui needs a terminal; use gm calculate or gm categorize in scripts", apperrors.ErrInvalidInput)
+		}
+		if err := checkWritable("gm ui", categories.DefaultFile); err != nil {
+			return err
+		}
+
+		txExtractor, err := extractor.NewTransactionExtractor()
+		if err != nil {
+			printFailure("❌ Failed to initialize transaction extractor: %v\n", err)
+			return err
+		}
+
+		result, err := syncTransactions(context.Background())
+		if err != nil {
+			return err
+		}
+		if len(result.Transactions) == 0 {
+			statusf("\n⚠️  No transactions to browse yet; run gm calculate first\n")
+			return nil
+		}
+
+		overrides, err := categories.Load(paths.Config(categories.DefaultFile))
+		if err != nil {
+			return err
+		}
+		choices := categories.List(overrides.Known(txExtractor.GetCategories()), result.Transactions)
+		if len(choices) > len(categoryKeys) {
+			choices = append(choices[:len(categoryKeys)-1], categories.Other)
+		}
+
+		b := &browser{all: result.Transactions, overrides: overrides, choices: choices, reverse: true}
+		b.refresh()
+
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return err
+		}
+		fmt.Print("\x1b[?1049h\x1b[?25l")
+		b.run(fd)
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		term.Restore(fd, state)
+
+		if b.changed == 0 {
+			fmt.Println("No changes made.")
+			return nil
+		}
+		if err := overrides.Save(); err != nil {
+			printFailure("❌ Failed to save %s: %v\n", categories.DefaultFile, err)
+			return err
+		}
+		fmt.Printf("💾 Saved %d change(s) to %s\n", b.changed, categories.DefaultFile)
+		return nil
+	},
+}
+
+// browser is the state of gm ui
+type browser struct {
+	all       []*models.Transaction
+	visible   []*models.Transaction // all, filtered and sorted
+	overrides *categories.Overrides
+	choices   []string
+	filter    string
+	sortBy    int // Index in uiSorts
+	reverse   bool
+	cursor    int
+	top       int // First visible row
+	changed   int
+	message   string
+}
+
+// run redraws the screen and handles keys until the user quits
+func (b *browser) run(fd int) {
+	for {
+		width, height, err := term.GetSize(fd)
+		if err != nil {
+			width, height = 80, 24
+		}
+		b.render(os.Stdout, width, height)
+
+		key := readUIKey()
+		b.message = ""
+		page := max(height-uiChrome, 1)
+		switch key {
+		case keyQuit, 'q':
+			return
+		case keyUp, 'k':
+			b.move(-1, page)
+		case keyDown, 'j':
+			b.move(1, page)
+		case keyPageUp:
+			b.move(-page, page)
+		case keyPageDown:
+			b.move(page, page)
+		case '/':
+			b.filter = b.prompt("Filter: ", b.filter, width, height)
+			b.refresh()
+		case 's':
+			b.sortBy = (b.sortBy + 1) % len(uiSorts)
+			b.refresh()
+		case 'o':
+			b.reverse = !b.reverse
+			b.refresh()
+		case 'c':
+			b.recategorize(width, height)
+		case 'x':
+			b.toggleExcluded()
+		}
+	}
+}
+
+// refresh filters and sorts the transactions after a change
+func (b *browser) refresh() {
+	b.visible = b.visible[:0]
+	filter := strings.ToLower(b.filter)
+	for _, tx := range b.all {
+		text := strings.ToLower(strings.Join(append([]string{tx.ServiceName, tx.Category, tx.Subject, tx.Currency}, tx.Tags...), " "))
+		if strings.Contains(text, filter) {
+			b.visible = append(b.visible, tx)
+		}
+	}
+
+	less := uiLess(uiSorts[b.sortBy])
+	sort.SliceStable(b.visible, func(i, j int) bool {
+		if b.reverse {
+			return less(b.visible[j], b.visible[i])
+		}
+		return less(b.visible[i], b.visible[j])
+	})
+
+	b.cursor = min(b.cursor, max(len(b.visible)-1, 0))
+}
+
+// uiLess orders transactions by one of uiSorts
+func uiLess(by string) func(a, c *models.Transaction) bool {
+	switch by {
+	case "amount":
+		return func(a, c *models.Transaction) bool { return a.Amount < c.Amount }
+	case "service":
+		return func(a, c *models.Transaction) bool {
+			return strings.ToLower(a.ServiceName) < strings.ToLower(c.ServiceName)
+		}
+	case "category":
+		return func(a, c *models.Transaction) bool { return strings.ToLower(a.Category) < strings.ToLower(c.Category) }
+	default:
+		return func(a, c *models.Transaction) bool { return a.Date.Before(c.Date) }
+	}
+}
+
+// move moves the cursor by delta rows, scrolling to keep it on a page of
+// the given height
+func (b *browser) move(delta, page int) {
+	b.cursor = min(max(b.cursor+delta, 0), max(len(b.visible)-1, 0))
+	if b.cursor < b.top {
+		b.top = b.cursor
+	}
+	if b.cursor >= b.top+page {
+		b.top = b.cursor - page + 1
+	}
+}
+
+// selected returns the transaction under the cursor, or nil
+func (b *browser) selected() *models.Transaction {
+	if b.cursor >= len(b.visible) {
+		return nil
+	}
+	return b.visible[b.cursor]
+}
+
+// recategorize asks for a category key and assigns it to the selected
+// transaction
+func (b *browser) recategorize(width, height int) {
+	tx := b.selected()
+	if tx == nil {
+		return
+	}
+	var menu []string
+	for i, category := range b.choices {
+		menu = append(menu, fmt.Sprintf("%c) %s", categoryKeys[i], category))
+	}
+	b.message = strings.Join(menu, "  ")
+	b.render(os.Stdout, width, height)
+
+	key := readUIKey()
+	category, ok := "", false
+	if key < 256 {
+		category, ok = pickCategory(b.choices, byte(key))
+	}
+	if !ok {
+		b.message = "Category unchanged"
+		return
+	}
+	b.overrides.SetCategory(tx.ID, category)
+	tx.Category = category
+	b.changed++
+	b.message = fmt.Sprintf("✅ %s → %s", tx.ServiceName, category)
+	b.refresh()
+}
+
+// toggleExcluded excludes the selected transaction, or counts it again
+func (b *browser) toggleExcluded() {
+	tx := b.selected()
+	if tx == nil {
+		return
+	}
+	tx.Excluded = !tx.Excluded
+	b.overrides.SetExcluded(tx.ID, tx.Excluded)
+	b.changed++
+	if tx.Excluded {
+		b.message = fmt.Sprintf("⛔ %s is left out of the totals", tx.ServiceName)
+	} else {
+		b.message = fmt.Sprintf("✅ %s counts again", tx.ServiceName)
+	}
+}
+
+// prompt reads a line of text on the message line, starting from value.
+// Enter accepts it.
+func (b *browser) prompt(label, value string, width, height int) string {
+	for {
+		b.message = label + value + "█"
+		b.render(os.Stdout, width, height)
+		switch key := readUIKey(); {
+		case key == keyEnter || key == keyQuit:
+			return value
+		case key == keyBackspace:
+			if runes := []rune(value); len(runes) > 0 {
+				value = string(runes[:len(runes)-1])
+			}
+		case key >= ' ' && key < 256:
+			value += string(rune(key))
+		}
+	}
+}
+
+// render draws the screen: a header, a page of transactions and the summary
+// of the transactions shown
+func (b *browser) render(w io.Writer, width, height int) {
+	var lines []string
+	order := "↑"
+	if b.reverse {
+		order = "↓"
+	}
+	header := fmt.Sprintf("💸 %d of %d transactions | sort: %s %s", len(b.visible), len(b.all), uiSorts[b.sortBy], order)
+	if b.filter != "" {
+		header += " | filter: " + b.filter
+	}
+	lines = append(lines, header, strings.Repeat("─", width))
+
+	page := max(height-uiChrome, 1)
+	b.move(0, page)
+	for i := b.top; i < len(b.visible) && i < b.top+page; i++ {
+		tx := b.visible[i]
+		row := fmt.Sprintf("%s  %-20s %s %s%10.2f %s%s", tx.Date.Format("2006-01-02"), fit(tx.ServiceName, 20),
+			categoryLabel(b.overrides, fit(tx.Category, 16), 16), tx.CurrencySymbol, tx.Amount, tx.Currency, statusMarker(tx))
+		if i == b.cursor {
+			row = "\x1b[7m" + row + "\x1b[0m"
+		}
+		lines = append(lines, row)
+	}
+	for len(lines) < page+2 {
+		lines = append(lines, "")
+	}
+
+	counted := summary.Apply(b.visible, summary.Counted())
+	s := summary.Build(counted)
+	total := fmt.Sprintf("💰 %s%.2f in %d counted", s.CurrencySymbol, s.TotalAmount, s.TotalCount)
+	if len(s.Currencies) > 1 {
+		total += " (mixed currencies; filter by one to total it)"
+	}
+	if left := len(b.visible) - len(counted); left > 0 {
+		total += fmt.Sprintf(", %d pending, cancelled or excluded", left)
+	}
+	var top []string
+	for i, group := range s.Categories {
+		if i == 3 {
+			break
+		}
+		top = append(top, fmt.Sprintf("%s %s%.2f (%.0f%%)", group.Key, s.CurrencySymbol, group.Total, group.Percent))
+	}
+	lines = append(lines, strings.Repeat("─", width), total, "📊 "+strings.Join(top, " · "))
+	if tx := b.selected(); tx != nil {
+		lines = append(lines, "📧 "+fit(tx.Subject, width-3))
+	} else {
+		lines = append(lines, "")
+	}
+	lines = append(lines, b.message, "↑↓ move  / filter  s sort  o order  c category  x exclude  q quit")
+
+	fmt.Fprint(w, "\x1b[H\x1b[2J"+strings.Join(lines, "\x1b[K\r\n"))
+}
+
+// readUIKey reads one key press in raw mode, decoding arrow and page keys.
+// Ctrl-C and end of input quit.
+func readUIKey() int {
+	b, err := stdinReader.ReadByte()
+	switch {
+	case err != nil || b == 3:
+		return keyQuit
+	case b == '\r' || b == '\n':
+		return keyEnter
+	case b == 127 || b == 8:
+		return keyBackspace
+	case b != 0x1b:
+		return int(b)
+	}
+
+	// Escape sequences: ESC [ A, ESC [ B, ESC [ 5 ~, ESC [ 6 ~
+	if next, err := stdinReader.ReadByte(); err != nil || next != '[' {
+		return keyQuit
+	}
+	code, err := stdinReader.ReadByte()
+	if err != nil {
+		return keyQuit
+	}
+	switch code {
+	case 'A':
+		return keyUp
+	case 'B':
+		return keyDown
+	case '5', '6':
+		stdinReader.ReadByte() // The closing ~
+		if code == '5' {
+			return keyPageUp
+		}
+		return keyPageDown
+	}
+	return 0
+}
+
+// fit cuts s to at most n runes
+func fit(s string, n int) string {
+	runes := []rune(s)
+	if n < 1 {
+		return ""
+	}
+	if len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	return s
+}
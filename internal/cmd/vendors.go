@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/export"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+// businessTag marks the transactions gm vendors reports by default
+const businessTag = "business"
+
+func init() {
+	rootCmd.AddCommand(vendorsCmd)
+
+	vendorsCmd.Flags().String("out", "", "Also write the report to a .csv file")
+	addEncryptFlag(vendorsCmd)
+	vendorsFilters = addFilterFlags(vendorsCmd)
+}
+
+// vendorsFilters holds the filter flags of gm vendors
+var vendorsFilters *filterFlags
+
+var vendorsCmd = &cobra.Command{
+	Use:   "vendors",
+	Short: "Total business spending per vendor, with invoice numbers and taxes",
+	Long: `Vendors lists the spending with each vendor for bookkeeping: the number of
+transactions, their total and the tax shown on their receipts, and the
+invoice or receipt numbers found in their emails. Only transactions tagged
+"` + businessTag + `" are included unless other tags are given with --tag; use
+--month or --from and --to for a period.
+
+Use --out vendors.csv or --output csv to hand the report to an accountant.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out, _ := cmd.Flags().GetString("out")
+		if out != "" && strings.ToLower(filepath.Ext(out)) != ".csv" {
+			return fmt.Errorf("%w: unsupported vendors file %q (use .csv)", apperrors.ErrInvalidInput, out)
+		}
+		if !cmd.Flags().Changed("tag") {
+			vendorsFilters.tags = []string{businessTag}
+		}
+
+		filters, err := vendorsFilters.filters()
+		if err != nil {
+			return err
+		}
+
+		result, err := syncTransactions(context.Background())
+		if err != nil {
+			return err
+		}
+
+		vendors := summary.Vendors(summary.Apply(result.Transactions, filters...))
+		if vendors == nil {
+			vendors = []summary.Vendor{}
+		}
+
+		if out != "" {
+			err := writeReportFile(out, func(w io.Writer) error { return export.WriteVendorsCSV(w, vendors) })
+			if err != nil {
+				return err
+			}
+		}
+
+		switch outputFormat {
+		case outputJSON:
+			return summary.WriteJSON(os.Stdout, vendors)
+		case outputYAML:
+			return summary.WriteYAML(os.Stdout, vendors)
+		case outputCSV:
+			return export.WriteVendorsCSV(os.Stdout, vendors)
+		}
+
+		if len(vendors) == 0 {
+			statusf("\n⚠️  No transactions tagged %s (tag them with: gm tag <id> %s)\n",
+				strings.Join(vendorsFilters.tags, ", "), businessTag)
+			return nil
+		}
+
+		fmt.Println("\n🏢 Spending per Vendor:")
+		fmt.Println("─────────────────────────────────────────────────────────────────────────")
+		fmt.Printf("%-20s %5s %16s %12s  %s\n", "Vendor", "Count", "Total", "Tax", "Invoices")
+		uninvoiced := 0
+		for i, v := range vendors {
+			if i > 0 && v.Currency != vendors[i-1].Currency {
+				fmt.Println()
+			}
+			fmt.Printf("%-20s %5d %16s %12.2f  %s\n", truncateString(v.Vendor, 17), v.Count,
+				fmt.Sprintf("%s%.2f %s", v.CurrencySymbol, v.Total, v.Currency), v.Tax, strings.Join(v.Invoices, ", "))
+			uninvoiced += v.Uninvoiced
+		}
+
+		fmt.Println()
+		for _, total := range vendorTotals(vendors) {
+			fmt.Printf("💰 Total in %s: %s%.2f, including %s%.2f tax\n", total.Currency, total.CurrencySymbol, total.Total, total.CurrencySymbol, total.Tax)
+		}
+		if uninvoiced > 0 {
+			fmt.Printf("⚠️  %d transaction(s) have no invoice number in their emails; check their receipts\n", uninvoiced)
+		}
+		return nil
+	},
+}
+
+// vendorTotals adds up the vendors of each currency
+func vendorTotals(vendors []summary.Vendor) []summary.Vendor {
+	var totals []summary.Vendor
+	for _, v := range vendors {
+		if len(totals) == 0 || totals[len(totals)-1].Currency != v.Currency {
+			totals = append(totals, summary.Vendor{Currency: v.Currency, CurrencySymbol: v.CurrencySymbol})
+		}
+		t := &totals[len(totals)-1]
+		t.Total += v.Total
+		t.Tax += v.Tax
+		t.Count += v.Count
+	}
+	return totals
+}
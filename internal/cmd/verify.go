@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/sazardev/go-money/internal/categories"
+	"github.com/sazardev/go-money/internal/paths"
+	"github.com/sazardev/go-money/internal/store"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/sazardev/go-money/internal/verify"
+	"github.com/spf13/cobra"
+)
+
+// errIssuesFound makes gm verify exit with a failure when problems remain
+var errIssuesFound = errors.New("the store has issues")
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+	verifyCmd.Flags().Bool("repair", false, "Fix what can be fixed: add missing store columns, drop orphan corrections and correct currency symbols")
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check the local store and " + categories.DefaultFile + " for inconsistencies",
+	Long: `Verify checks the local store without contacting Gmail: categories or tags
+in ` + categories.DefaultFile + ` for transactions that aren't stored, the same charge
+stored twice, unknown currencies or mismatched symbols, amounts that can't be
+charges, and store columns that differ from what this version expects.
+--repair fixes what it can; the rest is left for you to review.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		repair, _ := cmd.Flags().GetBool("repair")
+		if err := checkStored("gm verify"); err != nil {
+			return err
+		}
+		if repair {
+			if err := checkWritable("gm verify --repair", store.Redacted(storePath)+" and "+categories.DefaultFile); err != nil {
+				return err
+			}
+		}
+
+		schema, err := store.CheckSchema(ctx, storePath)
+		if err != nil {
+			printFailure("❌ Failed to check the store schema: %v\n", err)
+			return err
+		}
+		missing := false
+		for _, issue := range schema {
+			missing = missing || issue.Missing
+		}
+
+		// Only --repair may change the store, which opening it for writing does
+		var st store.Store
+		if repair {
+			st, err = store.Open(storePath)
+		} else {
+			st, err = store.OpenReadOnly(storePath)
+		}
+		if err != nil {
+			printFailure("❌ Failed to open local store: %v\n", err)
+			return err
+		}
+		defer st.Close()
+
+		overrides, err := categories.Load(paths.Config(categories.DefaultFile))
+		if err != nil {
+			printFailure("❌ Failed to load category rules: %v\n", err)
+			return err
+		}
+
+		var report *verify.Report
+		if missing && !repair {
+			// Reading transactions fails on an outdated schema
+			report = verify.Check(nil, overrides, schema)
+		} else {
+			transactions, err := st.Transactions(ctx)
+			if err != nil {
+				return err
+			}
+			report = verify.Check(transactions, overrides, schema)
+
+			if repair {
+				changed, overridesChanged := verify.Repair(transactions, overrides)
+				if err := st.SaveTransactions(ctx, changed); err != nil {
+					printFailure("❌ Failed to save transactions: %v\n", err)
+					return err
+				}
+				if overridesChanged {
+					if err := overrides.Save(); err != nil {
+						printFailure("❌ Failed to save %s: %v\n", categories.DefaultFile, err)
+						return err
+					}
+				}
+			}
+		}
+
+		remaining := report.Issues
+		if repair {
+			remaining = nil
+			for _, issue := range report.Issues {
+				if !issue.Repairable {
+					remaining = append(remaining, issue)
+				}
+			}
+		}
+
+		switch outputFormat {
+		case outputJSON:
+			if err := summary.WriteJSON(os.Stdout, report); err != nil {
+				return err
+			}
+		case outputYAML:
+			if err := summary.WriteYAML(os.Stdout, report); err != nil {
+				return err
+			}
+		default:
+			printVerifyReport(report, repair)
+		}
+
+		if len(remaining) > 0 {
+			return fmt.Errorf("%w: %d issues", errIssuesFound, len(remaining))
+		}
+		return nil
+	},
+}
+
+// printVerifyReport lists the issues found by gm verify
+func printVerifyReport(report *verify.Report, repaired bool) {
+	statusf("\n🔍 Checked %d stored transactions\n", report.Transactions)
+	if len(report.Issues) == 0 {
+		fmt.Println("✅ No issues found")
+		return
+	}
+
+	fmt.Printf("⚠️  %d issues found:\n", len(report.Issues))
+	for _, issue := range report.Issues {
+		line := "   [" + issue.Kind + "] "
+		if issue.TransactionID != "" {
+			line += issue.TransactionID + ": "
+		}
+		line += issue.Message
+		if issue.Repairable {
+			if repaired {
+				line += " 🔧 repaired"
+			} else {
+				line += " (repairable)"
+			}
+		}
+		fmt.Println(line)
+	}
+
+	if n := report.Repairable(); n > 0 && !repaired {
+		fmt.Printf("💡 Tip: Run 'gm verify --repair' to fix %d of them\n", n)
+	}
+}
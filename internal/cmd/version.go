@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/sazardev/go-money/internal/config"
+	"github.com/sazardev/go-money/internal/credentials"
+	"github.com/sazardev/go-money/internal/extractor"
+	"github.com/sazardev/go-money/internal/paths"
+	"github.com/sazardev/go-money/internal/store"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	versionCmd.Flags().Bool("build-info", false, "Also show the build, directories, store and integrations in use, for support requests")
+}
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show version",
+	Long: `Version shows the version of gm. With --build-info it also shows the Go
+version, commit and build date, the directories and files in use, the store
+backend and which integrations are configured, without any secret, ready to
+paste into a bug report.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		full, _ := cmd.Flags().GetBool("build-info")
+		var info any = map[string]string{"version": Version}
+		if full {
+			info = collectBuildInfo()
+		}
+		switch outputFormat {
+		case outputJSON:
+			return summary.WriteJSON(os.Stdout, info)
+		case outputYAML:
+			return summary.WriteYAML(os.Stdout, info)
+		}
+
+		if full {
+			printBuildInfo(info.(buildInfo))
+		} else {
+			fmt.Printf("GO Money v%s\n", Version)
+		}
+		return nil
+	},
+}
+
+// buildInfo is what gm version --build-info reports
+type buildInfo struct {
+	Version      string        `json:"version" yaml:"version"`
+	Commit       string        `json:"commit" yaml:"commit"`
+	BuildDate    string        `json:"build_date" yaml:"build_date"`
+	GoVersion    string        `json:"go_version" yaml:"go_version"`
+	Platform     string        `json:"platform" yaml:"platform"`
+	Profile      string        `json:"profile,omitempty" yaml:"profile,omitempty"`
+	ConfigDir    string        `json:"config_dir" yaml:"config_dir"`
+	DataDir      string        `json:"data_dir" yaml:"data_dir"`
+	CacheDir     string        `json:"cache_dir" yaml:"cache_dir"`
+	SettingsFile string        `json:"settings_file" yaml:"settings_file"`
+	TrackerFile  string        `json:"tracker_file" yaml:"tracker_file"`
+	Store        string        `json:"store" yaml:"store"`
+	StoreBackend string        `json:"store_backend" yaml:"store_backend"`
+	Provider     string        `json:"provider" yaml:"provider"`
+	Integrations []integration `json:"integrations" yaml:"integrations"`
+}
+
+// integration is an optional part of gm and whether it is set up
+type integration struct {
+	Name    string `json:"name" yaml:"name"`
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	Detail  string `json:"detail,omitempty" yaml:"detail,omitempty"`
+}
+
+// collectBuildInfo gathers the build and the environment gm runs in,
+// leaving out secrets and the credentials in store URLs
+func collectBuildInfo() buildInfo {
+	cfg := config.LoadConfig()
+	dirs := paths.Current()
+	info := buildInfo{
+		Version:      Version,
+		Commit:       Commit,
+		BuildDate:    BuildDate,
+		GoVersion:    runtime.Version(),
+		Platform:     runtime.GOOS + "/" + runtime.GOARCH,
+		Profile:      dirs.Profile,
+		ConfigDir:    dirs.Config,
+		DataDir:      dirs.Data,
+		CacheDir:     dirs.Cache,
+		SettingsFile: withPresence(paths.Config(config.FileName)),
+		TrackerFile:  withPresence(extractor.TrackerPath()),
+		Store:        store.Redacted(storePath),
+		StoreBackend: store.Backend(storePath),
+		Provider:     selectedProvider(),
+	}
+	if noStore {
+		info.Store, info.StoreBackend = "none (--no-store)", "memory"
+	}
+
+	// Builds from a git checkout carry their commit even without -ldflags
+	if build, ok := debug.ReadBuildInfo(); ok && Commit == "" {
+		modified := false
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Commit = setting.Value
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = setting.Value
+				}
+			case "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+		if modified && info.Commit != "" {
+			info.Commit += " (modified)"
+		}
+	}
+
+	credentialStore := cfg.Credentials
+	if credentialStore == "" {
+		credentialStore = credentials.KindFile
+	}
+	info.Integrations = []integration{
+		{Name: "Google sign-in", Enabled: cfg.GoogleClientID != "" && cfg.GoogleClientSecret != ""},
+		{Name: "Microsoft sign-in", Enabled: cfg.MicrosoftClientID != "", Detail: "tenant " + cfg.MicrosoftTenant},
+		{Name: "IMAP", Enabled: cfg.IMAPHost != "", Detail: cfg.IMAPHost},
+		{Name: "LLM extraction", Enabled: cfg.LLMURL != "", Detail: hostOf(cfg.LLMURL)},
+		{Name: "Remote sync", Enabled: os.Getenv("GM_REMOTE_URL") != "", Detail: hostOf(os.Getenv("GM_REMOTE_URL"))},
+		{Name: "Credential store", Enabled: true, Detail: credentialStore},
+		{Name: "SQLite", Enabled: store.SQLiteAvailable()},
+		{Name: "Polite mode", Enabled: polite},
+	}
+	catalog := integration{Name: "Service catalog"}
+	if installed, err := extractor.LoadCatalog(); err == nil && installed != nil {
+		catalog.Enabled, catalog.Detail = true, fmt.Sprintf("v%d", installed.Version)
+		if installed.Updated != "" {
+			catalog.Detail += ", " + installed.Updated
+		}
+	}
+	info.Integrations = append(info.Integrations, catalog)
+	return info
+}
+
+// withPresence returns path, noting when there is no file there
+func withPresence(path string) string {
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return path + " (none)"
+	}
+	return path
+}
+
+// hostOf returns the host of a URL, leaving out any credentials and path
+func hostOf(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return u.Host
+}
+
+func printBuildInfo(info buildInfo) {
+	unknown := func(s string) string {
+		if s == "" {
+			return "unknown"
+		}
+		return s
+	}
+	profile := info.Profile
+	if profile == "" {
+		profile = "default"
+	}
+
+	fmt.Printf("GO Money v%s\n", info.Version)
+	fmt.Println("─────────────────────────────────────────────────")
+	fmt.Printf("Commit:        %s\n", unknown(info.Commit))
+	fmt.Printf("Built:         %s\n", unknown(info.BuildDate))
+	fmt.Printf("Go:            %s %s\n", info.GoVersion, info.Platform)
+	fmt.Printf("Profile:       %s\n", profile)
+	fmt.Printf("Config dir:    %s\n", info.ConfigDir)
+	fmt.Printf("Data dir:      %s\n", info.DataDir)
+	fmt.Printf("Cache dir:     %s\n", info.CacheDir)
+	fmt.Printf("Settings file: %s\n", info.SettingsFile)
+	fmt.Printf("Tracker file:  %s\n", info.TrackerFile)
+	fmt.Printf("Store:         %s (%s)\n", info.Store, info.StoreBackend)
+	fmt.Printf("Provider:      %s\n", info.Provider)
+	fmt.Println("\nIntegrations:")
+	for _, in := range info.Integrations {
+		marker := "❌"
+		if in.Enabled {
+			marker = "✅"
+		}
+		if in.Enabled && in.Detail != "" {
+			fmt.Printf("  %s %s (%s)\n", marker, in.Name, in.Detail)
+		} else {
+			fmt.Printf("  %s %s\n", marker, in.Name)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/summary"
+	"github.com/sazardev/go-money/pkg/logger"
+	"github.com/spf13/cobra"
+)
+
+// minWatchInterval keeps gm watch from hammering the mail provider
+const minWatchInterval = time.Minute
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().Duration("interval", 6*time.Hour, "How often to sync new emails")
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Keep syncing new emails into the store and announce new spending",
+	Long: `Watch syncs new emails right away and then every --interval, saving the
+transactions found to the local store and printing one line per new
+transaction. It runs until interrupted (Ctrl-C or SIGTERM), so it can run
+as a systemd service; sign in with gm auth login first, as there is nobody
+to answer a sign-in prompt. A failed sync is logged and retried at the next
+interval.
+
+With --output json, every new transaction is printed as one JSON object
+per line.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		interval, _ := cmd.Flags().GetDuration("interval")
+		if interval < minWatchInterval {
+			return fmt.Errorf("%w: --interval must be at least %s", apperrors.ErrInvalidInput, minWatchInterval)
+		}
+		if outputFormat == outputYAML || outputFormat == outputCSV {
+			return fmt.Errorf("%w: gm watch prints text or json", apperrors.ErrInvalidInput)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		statusf("👀 Watching for new transactions every %s\n", interval)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := watchOnce(ctx); err != nil {
+				logger.GetLogger().Error(fmt.Sprintf("Sync failed: %v", err))
+			}
+			select {
+			case <-ctx.Done():
+				statusf("👋 Stopped watching\n")
+				return nil
+			case <-ticker.C:
+			}
+		}
+	},
+}
+
+// watchOnce syncs new emails and announces the transactions they added.
+// Emails merged into a transaction already stored, such as shipment or
+// tip emails, amend it instead and aren't announced.
+func watchOnce(ctx context.Context) error {
+	result, err := syncTransactions(ctx)
+	if err != nil {
+		return err
+	}
+
+	fetched := make(map[string]bool, len(result.Messages))
+	for _, msg := range result.Messages {
+		fetched[msg.ID] = true
+	}
+	var added []*models.Transaction
+	for _, tx := range result.Transactions {
+		if fetched[tx.ID] {
+			added = append(added, tx)
+		}
+	}
+
+	if outputFormat == outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, tx := range added {
+			if err := enc.Encode(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	now := time.Now().Format("2006-01-02 15:04")
+	if len(added) == 0 {
+		statusf("%s  No new transactions\n", now)
+		return nil
+	}
+	for _, tx := range added {
+		fmt.Printf("%s  🆕 %s %s - %s%.2f %s (%s)%s\n", now, tx.Date.Format("2006-01-02"), tx.ServiceName,
+			tx.CurrencySymbol, tx.Amount, tx.Currency, tx.Category, statusMarker(tx))
+	}
+	for _, group := range summary.GroupBy(summary.Apply(added, summary.Counted()), summary.ByCurrency) {
+		fmt.Printf("%s  💰 New spending: %.2f %s in %d transactions\n", now, group.Total, group.Key, group.Count)
+	}
+	return nil
+}
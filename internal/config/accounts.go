@@ -0,0 +1,63 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+)
+
+// DefaultAccountsFile lists the mailboxes to sync when there are several
+const DefaultAccountsFile = "accounts.json"
+
+// accountName keeps account names usable in token file names
+var accountName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Account is one mailbox to sync transactions from
+type Account struct {
+	Name     string `json:"name"`
+	Provider string `json:"provider,omitempty"` // gmail (default), outlook or imap
+
+	// IMAP settings; empty ones fall back to the GM_IMAP_* variables
+	Host        string `json:"host,omitempty"`
+	Port        int    `json:"port,omitempty"`
+	Username    string `json:"username,omitempty"`
+	PasswordEnv string `json:"password_env,omitempty"` // Variable holding the password, which never goes in the file
+	Mailbox     string `json:"mailbox,omitempty"`
+	Security    string `json:"security,omitempty"`
+}
+
+// LoadAccounts reads the accounts file at path. A missing file means a
+// single mailbox, chosen with --provider, and returns no accounts.
+func LoadAccounts(path string) ([]Account, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var accounts []Account
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	seen := make(map[string]bool, len(accounts))
+	for i := range accounts {
+		account := &accounts[i]
+		if !accountName.MatchString(account.Name) {
+			return nil, fmt.Errorf("failed to parse %s: account %d needs a name made of letters, digits, - and _", path, i+1)
+		}
+		if seen[account.Name] {
+			return nil, fmt.Errorf("failed to parse %s: account %q is listed twice", path, account.Name)
+		}
+		seen[account.Name] = true
+		if account.Provider == "" {
+			account.Provider = "gmail"
+		}
+	}
+	return accounts, nil
+}
@@ -13,21 +13,91 @@ type Config struct {
 	GoogleAuthURI      string
 	GoogleTokenURI     string
 	GoogleRedirectURI  string
-	TokenFile          string
+
+	// Microsoft app registration for the "outlook" mail provider
+	MicrosoftClientID     string
+	MicrosoftClientSecret string
+	MicrosoftTenant       string
+	MicrosoftRedirectURI  string
+
+	// Provider is the default mail provider when --provider isn't given
+	Provider string
+
+	// Store is the default store when --store isn't given: the path of a
+	// SQLite database or a postgres:// URL
+	Store string
+
+	// Optional OpenAI-compatible chat completions endpoint for the "llm"
+	// extraction strategy
+	LLMURL    string
+	LLMModel  string
+	LLMAPIKey string
+
+	// IMAP mailbox for the "imap" mail provider
+	IMAPHost     string
+	IMAPPort     string
+	IMAPUsername string
+	IMAPPassword string
+	IMAPMailbox  string
+	IMAPSecurity string
+
+	// Currency is the default of --base-currency
+	Currency string
+	// Queries replace the mailbox search terms for transaction emails
+	Queries []string
+	// Output is the default of --output
+	Output string
+	// Polite is the default of --polite
+	Polite bool
+
+	// Community service catalog gm services update downloads, and the
+	// base64 Ed25519 public key it must be signed with
+	CatalogURL string
+	CatalogKey string
+
+	// Credentials is where sign-in tokens are kept: "file" (the default),
+	// "keychain" or "encrypted"
+	Credentials string
+	// CredentialsPassphrase encrypts tokens when Credentials is "encrypted";
+	// without one a key in the user's configuration directory is used
+	CredentialsPassphrase string
 }
 
-// LoadConfig loads configuration from environment variables
+// LoadConfig loads configuration from environment variables, which the
+// settings file gives defaults for once applied, see File.Apply
 func LoadConfig() *Config {
 	log := logger.GetLogger()
 
 	config := &Config{
-		GoogleClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
-		GoogleClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
-		GoogleProjectID:    os.Getenv("GOOGLE_PROJECT_ID"),
-		GoogleAuthURI:      os.Getenv("GOOGLE_AUTH_URI"),
-		GoogleTokenURI:     os.Getenv("GOOGLE_TOKEN_URI"),
-		GoogleRedirectURI:  os.Getenv("GOOGLE_REDIRECT_URI"),
-		TokenFile:          ".credentials/token.json",
+		GoogleClientID:        os.Getenv("GOOGLE_CLIENT_ID"),
+		GoogleClientSecret:    os.Getenv("GOOGLE_CLIENT_SECRET"),
+		GoogleProjectID:       os.Getenv("GOOGLE_PROJECT_ID"),
+		GoogleAuthURI:         os.Getenv("GOOGLE_AUTH_URI"),
+		GoogleTokenURI:        os.Getenv("GOOGLE_TOKEN_URI"),
+		GoogleRedirectURI:     os.Getenv("GOOGLE_REDIRECT_URI"),
+		MicrosoftClientID:     os.Getenv("MICROSOFT_CLIENT_ID"),
+		MicrosoftClientSecret: os.Getenv("MICROSOFT_CLIENT_SECRET"),
+		MicrosoftTenant:       envOr("MICROSOFT_TENANT_ID", "common"),
+		MicrosoftRedirectURI:  envOr("MICROSOFT_REDIRECT_URI", "http://localhost:8080"),
+		Provider:              os.Getenv("GM_PROVIDER"),
+		Store:                 os.Getenv("GM_STORE"),
+		LLMURL:                os.Getenv("GM_LLM_URL"),
+		LLMModel:              os.Getenv("GM_LLM_MODEL"),
+		LLMAPIKey:             os.Getenv("GM_LLM_API_KEY"),
+		IMAPHost:              os.Getenv("GM_IMAP_HOST"),
+		IMAPPort:              os.Getenv("GM_IMAP_PORT"),
+		IMAPUsername:          os.Getenv("GM_IMAP_USERNAME"),
+		IMAPPassword:          os.Getenv("GM_IMAP_PASSWORD"),
+		IMAPMailbox:           os.Getenv("GM_IMAP_MAILBOX"),
+		IMAPSecurity:          os.Getenv("GM_IMAP_SECURITY"),
+		Currency:              os.Getenv("GM_CURRENCY"),
+		Queries:               List(os.Getenv("GM_QUERIES")),
+		Output:                os.Getenv("GM_OUTPUT"),
+		Polite:                os.Getenv("GM_POLITE") == "true",
+		CatalogURL:            os.Getenv("GM_CATALOG_URL"),
+		CatalogKey:            os.Getenv("GM_CATALOG_KEY"),
+		Credentials:           os.Getenv("GM_CREDENTIALS"),
+		CredentialsPassphrase: os.Getenv("GM_CREDENTIALS_PASSPHRASE"),
 	}
 
 	// Validate required fields
@@ -38,6 +108,14 @@ func LoadConfig() *Config {
 	return config
 }
 
+// envOr returns the environment variable key, or fallback when it is unset
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
 // IsValid checks if the configuration is valid
 func (c *Config) IsValid() bool {
 	return c.GoogleClientID != "" && c.GoogleClientSecret != ""
@@ -0,0 +1,218 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the settings file in the configuration directory
+const FileName = "config.yaml"
+
+// Setting is a key of the settings file and the environment variable it
+// gives a default for. Flags win over variables, which win over the file.
+type Setting struct {
+	Key         string // Dotted path in the file, such as "google.client_id"
+	Env         string
+	List        bool // A list in the file, comma-separated in the variable
+	Secret      bool // Masked when listing every setting
+	Description string
+}
+
+// Settings are the keys the settings file accepts
+var Settings = []Setting{
+	{Key: "google.client_id", Env: "GOOGLE_CLIENT_ID", Description: "Google OAuth client ID"},
+	{Key: "google.client_secret", Env: "GOOGLE_CLIENT_SECRET", Secret: true, Description: "Google OAuth client secret"},
+	{Key: "google.redirect_uri", Env: "GOOGLE_REDIRECT_URI", Description: "Google OAuth redirect URI"},
+	{Key: "microsoft.client_id", Env: "MICROSOFT_CLIENT_ID", Description: "Microsoft app registration client ID"},
+	{Key: "microsoft.client_secret", Env: "MICROSOFT_CLIENT_SECRET", Secret: true, Description: "Microsoft client secret, for apps registered as web apps"},
+	{Key: "microsoft.tenant_id", Env: "MICROSOFT_TENANT_ID", Description: "Microsoft tenant allowed to sign in (default common)"},
+	{Key: "microsoft.redirect_uri", Env: "MICROSOFT_REDIRECT_URI", Description: "Microsoft redirect URI"},
+	{Key: "provider", Env: "GM_PROVIDER", Description: "Mailbox to read emails from: gmail, outlook or imap (--provider)"},
+	{Key: "store", Env: "GM_STORE", Description: "Store path or postgres:// URL (--store)"},
+	{Key: "tracker", Env: "GM_TRACKER", Description: "Path of tracker-mails.json"},
+	{Key: "currency", Env: "GM_CURRENCY", Description: "Currency totals are converted to (--base-currency)"},
+	{Key: "queries", Env: "GM_QUERIES", List: true, Description: "Mailbox search terms for transaction emails"},
+	{Key: "output", Env: "GM_OUTPUT", Description: "Output format: text, json, yaml or csv (--output)"},
+	{Key: "polite", Env: "GM_POLITE", Description: "Set to true to sync gently for shared API quotas (--polite)"},
+	{Key: "catalog.url", Env: "GM_CATALOG_URL", Description: "Community service catalog gm services update downloads"},
+	{Key: "catalog.key", Env: "GM_CATALOG_KEY", Description: "Base64 Ed25519 public key the catalog must be signed with"},
+	{Key: "credentials.store", Env: "GM_CREDENTIALS", Description: "Where sign-ins are kept: file, keychain or encrypted"},
+	{Key: "credentials.passphrase", Env: "GM_CREDENTIALS_PASSPHRASE", Secret: true, Description: "Passphrase of encrypted sign-ins"},
+	{Key: "llm.url", Env: "GM_LLM_URL", Description: "OpenAI-compatible chat completions endpoint"},
+	{Key: "llm.model", Env: "GM_LLM_MODEL", Description: "LLM model name"},
+	{Key: "llm.api_key", Env: "GM_LLM_API_KEY", Secret: true, Description: "LLM API key"},
+	{Key: "imap.host", Env: "GM_IMAP_HOST", Description: "IMAP server"},
+	{Key: "imap.port", Env: "GM_IMAP_PORT", Description: "IMAP port (default 993)"},
+	{Key: "imap.username", Env: "GM_IMAP_USERNAME", Description: "IMAP username"},
+	{Key: "imap.password", Env: "GM_IMAP_PASSWORD", Secret: true, Description: "IMAP password"},
+	{Key: "imap.mailbox", Env: "GM_IMAP_MAILBOX", Description: "IMAP mailbox (default INBOX)"},
+	{Key: "imap.security", Env: "GM_IMAP_SECURITY", Description: "IMAP security: tls, starttls or none"},
+	{Key: "remote.url", Env: "GM_REMOTE_URL", Description: "gm serve instance gm remote syncs with"},
+	{Key: "remote.token", Env: "GM_REMOTE_TOKEN", Secret: true, Description: "Token of the gm serve instance"},
+	{Key: "serve.token", Env: "GM_SERVE_TOKEN", Secret: true, Description: "Token gm serve requires"},
+}
+
+// FindSetting returns the setting with the given key
+func FindSetting(key string) (Setting, bool) {
+	for _, setting := range Settings {
+		if setting.Key == key {
+			return setting, true
+		}
+	}
+	return Setting{}, false
+}
+
+// File is the settings file, nested by the dots of setting keys
+type File struct {
+	path   string
+	values map[string]any
+}
+
+// LoadFile reads the settings file at path. A missing file has no settings.
+func LoadFile(path string) (*File, error) {
+	file := &File{path: path, values: make(map[string]any)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, &file.values); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if file.values == nil {
+		file.values = make(map[string]any)
+	}
+
+	for _, key := range leafKeys("", file.values) {
+		if _, ok := FindSetting(key); !ok {
+			return nil, fmt.Errorf("failed to parse %s: unknown setting %q", path, key)
+		}
+	}
+	return file, nil
+}
+
+// leafKeys returns the dotted keys of the values in values, sorted
+func leafKeys(prefix string, values map[string]any) []string {
+	var keys []string
+	for key, value := range values {
+		if nested, ok := value.(map[string]any); ok {
+			keys = append(keys, leafKeys(prefix+key+".", nested)...)
+			continue
+		}
+		keys = append(keys, prefix+key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Path returns where the file is kept
+func (f *File) Path() string {
+	return f.path
+}
+
+// Get returns the value of a setting in the file, with lists joined by
+// commas, and whether it is set
+func (f *File) Get(key string) (string, bool) {
+	parts := strings.Split(key, ".")
+	values := f.values
+	for _, part := range parts[:len(parts)-1] {
+		nested, ok := values[part].(map[string]any)
+		if !ok {
+			return "", false
+		}
+		values = nested
+	}
+
+	switch value := values[parts[len(parts)-1]].(type) {
+	case nil:
+		return "", false
+	case []any:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ","), true
+	default:
+		return fmt.Sprint(value), true
+	}
+}
+
+// Set changes the value of a setting in the file; an empty value removes
+// it. Values of lists are split at commas.
+func (f *File) Set(key, value string) error {
+	setting, ok := FindSetting(key)
+	if !ok {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+
+	parts := strings.Split(key, ".")
+	values := f.values
+	for _, part := range parts[:len(parts)-1] {
+		nested, ok := values[part].(map[string]any)
+		if !ok {
+			nested = make(map[string]any)
+			values[part] = nested
+		}
+		values = nested
+	}
+
+	last := parts[len(parts)-1]
+	switch {
+	case value == "":
+		delete(values, last)
+	case setting.List:
+		values[last] = List(value)
+	default:
+		values[last] = value
+	}
+	return nil
+}
+
+// Save writes the file, readable only by the user since it may hold secrets
+func (f *File) Save() error {
+	data, err := yaml.Marshal(f.values)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(f.path, data, 0600)
+}
+
+// Apply sets the variable of every setting in the file that isn't set in
+// the environment, so the file gives defaults the environment overrides.
+// It returns the keys of the settings it applied.
+func (f *File) Apply() []string {
+	var applied []string
+	for _, setting := range Settings {
+		if _, set := os.LookupEnv(setting.Env); set {
+			continue
+		}
+		if value, ok := f.Get(setting.Key); ok {
+			os.Setenv(setting.Env, value)
+			applied = append(applied, setting.Key)
+		}
+	}
+	return applied
+}
+
+// List splits a comma-separated value, dropping empty items
+func List(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
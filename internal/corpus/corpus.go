@@ -0,0 +1,185 @@
+// Package corpus keeps a regression suite of receipts: sanitized emails
+// saved as .eml fixtures, each next to a golden file with the transaction
+// expected from it
+package corpus
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/mail"
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// DefaultDir holds the corpus in the data directory
+const DefaultDir = "corpus"
+
+// Extensions of a fixture and its golden file, which share a name
+const (
+	fixtureExt = ".eml"
+	goldenExt  = ".golden.json"
+)
+
+// namePattern is the form of a fixture name
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Expected is the golden output of a fixture: the fields of the transaction
+// extracted from it that a regression would change. A golden file holding
+// null expects no transaction.
+type Expected struct {
+	ServiceID string  `json:"service_id" yaml:"service_id"`
+	Category  string  `json:"category" yaml:"category"`
+	Amount    float64 `json:"amount" yaml:"amount"`
+	Currency  string  `json:"currency" yaml:"currency"`
+	Date      string  `json:"date" yaml:"date"` // YYYY-MM-DD
+	Status    string  `json:"status" yaml:"status"`
+}
+
+// ExpectedOf returns the golden output of tx, nil when there is none
+func ExpectedOf(tx *models.Transaction) *Expected {
+	if tx == nil {
+		return nil
+	}
+	return &Expected{
+		ServiceID: tx.ServiceID,
+		Category:  tx.Category,
+		Amount:    tx.Amount,
+		Currency:  tx.Currency,
+		Date:      tx.Date.Format("2006-01-02"),
+		Status:    tx.EffectiveStatus(),
+	}
+}
+
+// Add saves msg as the fixture name in dir with want as its golden output,
+// refusing to overwrite an existing fixture. It returns the files written.
+func Add(dir, name string, msg *models.Message, want *Expected) ([]string, error) {
+	if !namePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid fixture name %q: use letters, digits, - and _", name)
+	}
+	fixture, golden := filepath.Join(dir, name+fixtureExt), filepath.Join(dir, name+goldenExt)
+	for _, path := range []string{fixture, golden} {
+		if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%s already exists", path)
+		}
+	}
+
+	var eml bytes.Buffer
+	if err := mail.WriteEML(&eml, msg); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(fixture, eml.Bytes(), 0600); err != nil {
+		return nil, err
+	}
+	if err := writeGolden(golden, want); err != nil {
+		return nil, err
+	}
+	return []string{fixture, golden}, nil
+}
+
+func writeGolden(path string, want *Expected) error {
+	data, err := json.MarshalIndent(want, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// Result is how a fixture fared against its golden output
+type Result struct {
+	Name     string    `json:"name" yaml:"name"`
+	Expected *Expected `json:"expected" yaml:"expected"`
+	Got      *Expected `json:"got" yaml:"got"`
+	Diffs    []string  `json:"diffs,omitempty" yaml:"diffs,omitempty"` // The fields that changed
+	Updated  bool      `json:"updated,omitempty" yaml:"updated,omitempty"`
+}
+
+// Passed reports whether the fixture still extracts as expected
+func (r Result) Passed() bool {
+	return len(r.Diffs) == 0
+}
+
+// Check extracts the transaction of every fixture in dir with extract and
+// compares it with the fixture's golden output, by fixture name. With
+// update, golden files that differ or are missing are written with what
+// was extracted.
+func Check(dir string, extract func(*models.Message) *models.Transaction, update bool) ([]Result, error) {
+	fixtures, err := filepath.Glob(filepath.Join(dir, "*"+fixtureExt))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(fixtures)
+
+	var results []Result
+	for _, fixture := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(fixture), fixtureExt)
+		raw, err := os.ReadFile(fixture)
+		if err != nil {
+			return nil, err
+		}
+		msg, err := mail.ParseMessage(raw, false)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fixture, err)
+		}
+		msg.ID = name
+
+		golden := filepath.Join(dir, name+goldenExt)
+		result := Result{Name: name, Got: ExpectedOf(extract(msg))}
+		data, err := os.ReadFile(golden)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			result.Diffs = []string{"no golden file; --update creates it"}
+		case err != nil:
+			return nil, err
+		default:
+			if err := json.Unmarshal(data, &result.Expected); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", golden, err)
+			}
+			result.Diffs = Diff(result.Expected, result.Got)
+		}
+		if update && !result.Passed() {
+			if err := writeGolden(golden, result.Got); err != nil {
+				return nil, err
+			}
+			result.Updated = true
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Diff describes the fields of got that differ from want
+func Diff(want, got *Expected) []string {
+	switch {
+	case want == nil && got == nil:
+		return nil
+	case want == nil:
+		return []string{"no transaction expected, one was extracted"}
+	case got == nil:
+		return []string{"a transaction was expected, none was extracted"}
+	}
+
+	var diffs []string
+	field := func(name string, want, got any) {
+		if want != got {
+			diffs = append(diffs, fmt.Sprintf("%s: want %v, got %v", name, want, got))
+		}
+	}
+	field("service_id", want.ServiceID, got.ServiceID)
+	field("category", want.Category, got.Category)
+	field("amount", want.Amount, got.Amount)
+	field("currency", want.Currency, got.Currency)
+	field("date", want.Date, got.Date)
+	field("status", want.Status, got.Status)
+	return diffs
+}
@@ -0,0 +1,25 @@
+package corpus
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	want := &Expected{ServiceID: "uber", Category: "Transportation", Amount: 22.41, Currency: "USD", Date: "2025-03-13", Status: "completed"}
+	got := *want
+	got.Amount, got.Currency = 22.4, "EUR"
+
+	if diffs := Diff(want, want); diffs != nil {
+		t.Errorf("Diff of equal outputs = %v", diffs)
+	}
+	if diffs := Diff(want, &got); len(diffs) != 2 || !strings.HasPrefix(diffs[0], "amount:") || !strings.HasPrefix(diffs[1], "currency:") {
+		t.Errorf("Diff = %v; want the amount and currency", diffs)
+	}
+	if diffs := Diff(nil, want); len(diffs) != 1 {
+		t.Errorf("Diff(nil, got) = %v; want one difference", diffs)
+	}
+	if diffs := Diff(want, nil); len(diffs) != 1 {
+		t.Errorf("Diff(want, nil) = %v; want one difference", diffs)
+	}
+}
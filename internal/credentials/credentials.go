@@ -0,0 +1,148 @@
+// Package credentials keeps the saved sign-in tokens: in plain files, in the
+// operating system's keychain, or in files encrypted with a passphrase or a
+// key kept outside the working directory
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Backend names, as set with GM_CREDENTIALS
+const (
+	KindFile      = "file"
+	KindKeychain  = "keychain"
+	KindEncrypted = "encrypted"
+)
+
+// Backend keeps secrets by name, such as "token.json"
+type Backend interface {
+	// Name describes where secrets are kept, such as "the macOS Keychain"
+	Name() string
+	// Load returns the secret saved under name, or an error wrapping
+	// fs.ErrNotExist when there is none
+	Load(name string) ([]byte, error)
+	// Save stores data under name, replacing any earlier secret
+	Save(name string, data []byte) error
+	// Delete removes the secret saved under name; a missing one isn't an
+	// error
+	Delete(name string) error
+}
+
+// Open returns the backend of a kind, keeping its secrets for the files in
+// dir. An empty kind keeps plain files. Tokens saved as plain files by
+// earlier versions are moved into other backends as they are read. An
+// unknown kind returns a backend failing with the reason, so the error
+// shows up where a token is needed.
+func Open(kind, dir, passphrase string) Backend {
+	files := &Files{Dir: dir}
+	var backend Backend
+	switch strings.ToLower(kind) {
+	case "", KindFile:
+		return files
+	case KindKeychain:
+		backend = &Keychain{Dir: dir}
+	case KindEncrypted:
+		backend = &Encrypted{Dir: dir, Passphrase: passphrase}
+	default:
+		return failing{fmt.Errorf("unknown credential store %q (use %s, %s or %s)", kind, KindFile, KindKeychain, KindEncrypted)}
+	}
+	return &upgrading{Backend: backend, legacy: files}
+}
+
+// Files keeps each secret in a plain file readable only by the user
+type Files struct {
+	Dir string
+}
+
+// Name describes where secrets are kept
+func (f *Files) Name() string {
+	return "plain files in " + f.Dir
+}
+
+// Load reads the file of name
+func (f *Files) Load(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(f.Dir, name))
+}
+
+// Save writes the file of name. It is written to a temporary file first,
+// so a command reading it meanwhile never sees half of it.
+func (f *Files) Save(name string, data []byte) error {
+	return writeFileAtomic(f.Dir, name, data)
+}
+
+// Delete removes the file of name
+func (f *Files) Delete(name string) error {
+	err := os.Remove(filepath.Join(f.Dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// writeFileAtomic replaces dir/name with data, creating dir if needed
+func writeFileAtomic(dir, name string, data []byte) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(dir, name))
+}
+
+// upgrading moves plain token files into a safer backend the first time
+// they are read, so switching backends doesn't require signing in again
+type upgrading struct {
+	Backend
+	legacy *Files
+}
+
+// Load returns the secret from the backend, or moves the plain file of name
+// into it
+func (u *upgrading) Load(name string) ([]byte, error) {
+	data, err := u.Backend.Load(name)
+	if !errors.Is(err, fs.ErrNotExist) {
+		return data, err
+	}
+	data, legacyErr := u.legacy.Load(name)
+	if legacyErr != nil {
+		return nil, err
+	}
+	if err := u.Backend.Save(name, data); err != nil {
+		return nil, fmt.Errorf("moving %s into %s: %w", name, u.Backend.Name(), err)
+	}
+	return data, u.legacy.Delete(name)
+}
+
+// Delete removes the secret from the backend and any plain file left over
+func (u *upgrading) Delete(name string) error {
+	if err := u.Backend.Delete(name); err != nil {
+		return err
+	}
+	return u.legacy.Delete(name)
+}
+
+// failing is the backend of an invalid configuration
+type failing struct {
+	err error
+}
+
+func (f failing) Name() string                { return "an invalid credential store" }
+func (f failing) Load(string) ([]byte, error) { return nil, f.err }
+func (f failing) Save(string, []byte) error   { return f.err }
+func (f failing) Delete(string) error         { return f.err }
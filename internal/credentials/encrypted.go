@@ -0,0 +1,156 @@
+package credentials
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/sazardev/go-money/internal/paths"
+)
+
+const (
+	// encryptedExt is added to the names of encrypted secret files
+	encryptedExt = ".enc"
+	// encryptedMagic starts every encrypted secret file
+	encryptedMagic = "GMC1"
+	// saltSize is the size of the salt the passphrase key is derived with
+	saltSize = 16
+	// kdfIterations of PBKDF2-SHA256 turning a passphrase into a key
+	kdfIterations = 600000
+	// keySize is the size of AES-256 keys
+	keySize = 32
+)
+
+// machineKeyFile holds the key used without a passphrase, in gm's
+// configuration directory
+const machineKeyFile = "credentials.key"
+
+// Encrypted keeps each secret in a file encrypted with AES-256-GCM. The key
+// is derived from Passphrase, or without one is a random key saved in gm's
+// configuration directory (paths.Current().Config). That key only protects
+// against someone copying the credentials folder on its own: on macOS and
+// Windows it sits next to it, and anyone who can read the user's files can
+// read both, so use a passphrase or the keychain for more.
+type Encrypted struct {
+	Dir        string
+	Passphrase string
+}
+
+// Name describes where secrets are kept
+func (e *Encrypted) Name() string {
+	if e.Passphrase != "" {
+		return "passphrase-encrypted files in " + e.Dir
+	}
+	return "encrypted files in " + e.Dir
+}
+
+// Load decrypts the file of name
+func (e *Encrypted) Load(name string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(e.Dir, name+encryptedExt))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < len(encryptedMagic)+saltSize || string(data[:len(encryptedMagic)]) != encryptedMagic {
+		return nil, fmt.Errorf("%s isn't an encrypted credential file", name+encryptedExt)
+	}
+	salt := data[len(encryptedMagic) : len(encryptedMagic)+saltSize]
+	sealed := data[len(encryptedMagic)+saltSize:]
+
+	aead, err := e.cipher(salt, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("%s is truncated", name+encryptedExt)
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt %s; check GM_CREDENTIALS_PASSPHRASE, or sign in again", name+encryptedExt)
+	}
+	return plain, nil
+}
+
+// Save encrypts data into the file of name
+func (e *Encrypted) Save(name string, data []byte) error {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	aead, err := e.cipher(salt, true)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	out.WriteString(encryptedMagic)
+	out.Write(salt)
+	out.Write(nonce)
+	out.Write(aead.Seal(nil, nonce, data, []byte(name)))
+	return writeFileAtomic(e.Dir, name+encryptedExt, out.Bytes())
+}
+
+// Delete removes the file of name
+func (e *Encrypted) Delete(name string) error {
+	err := os.Remove(filepath.Join(e.Dir, name+encryptedExt))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// cipher returns the AES-GCM cipher of a file with salt. The machine key is
+// only created when saving.
+func (e *Encrypted) cipher(salt []byte, create bool) (cipher.AEAD, error) {
+	var key []byte
+	var err error
+	if e.Passphrase != "" {
+		key, err = pbkdf2.Key(sha256.New, e.Passphrase, salt, kdfIterations, keySize)
+	} else {
+		key, err = machineKey(create)
+	}
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// machineKey returns the key saved in gm's configuration directory,
+// creating it if asked to
+func machineKey(create bool) ([]byte, error) {
+	path := filepath.Join(paths.Current().Config, machineKeyFile)
+
+	key, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && create {
+		key = make([]byte, keySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		if err := writeFileAtomic(filepath.Dir(path), filepath.Base(path), key); err != nil {
+			return nil, fmt.Errorf("unable to save the key of encrypted credentials: %w", err)
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the key of encrypted credentials %s: %w", path, err)
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("the key of encrypted credentials %s is invalid", path)
+	}
+	return key, nil
+}
@@ -0,0 +1,47 @@
+package credentials
+
+import (
+	"path/filepath"
+)
+
+// keychainService names the entries gm adds to the keychain
+const keychainService = "go-money"
+
+// Keychain keeps secrets in the operating system's credential store: the
+// macOS Keychain, the Windows Credential Manager, or the Secret Service
+// (GNOME Keyring, KWallet) on Linux through secret-tool. Entries are named
+// after the file they replace, including its absolute path, so installs in
+// different directories keep their own sign-ins.
+type Keychain struct {
+	Dir string
+}
+
+// Name describes where secrets are kept
+func (k *Keychain) Name() string {
+	return keychainName
+}
+
+// Load returns the keychain entry of name
+func (k *Keychain) Load(name string) ([]byte, error) {
+	return keychainGet(k.account(name))
+}
+
+// Save adds or replaces the keychain entry of name
+func (k *Keychain) Save(name string, data []byte) error {
+	return keychainSet(k.account(name), data)
+}
+
+// Delete removes the keychain entry of name
+func (k *Keychain) Delete(name string) error {
+	return keychainDelete(k.account(name))
+}
+
+// account returns the entry name of a secret: the path of the file it
+// would otherwise be kept in
+func (k *Keychain) account(name string) string {
+	path := filepath.Join(k.Dir, name)
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
@@ -0,0 +1,67 @@
+//go:build darwin
+
+package credentials
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"strings"
+)
+
+// keychainName describes the keychain of this platform
+const keychainName = "the macOS Keychain"
+
+// errItemNotFound is the exit status of security for a missing item
+const errItemNotFound = 44
+
+// keychainGet reads a generic password item with the security tool.
+// Secrets are stored base64-encoded, since it prints binary ones as hex.
+func keychainGet(account string) ([]byte, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", keychainService, "-a", account, "-w").Output()
+	if exitStatus(err) == errItemNotFound {
+		return nil, fmt.Errorf("no keychain item for %s: %w", account, fs.ErrNotExist)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading the keychain: %w", err)
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(string(out)))
+}
+
+// keychainSet adds or updates a generic password item. The secret is
+// passed on standard input rather than the command line, where other
+// processes could read it.
+func keychainSet(account string, data []byte) error {
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+		quote(keychainService), quote(account), base64.StdEncoding.EncodeToString(data)))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("writing to the keychain: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// keychainDelete removes a generic password item, if there is one
+func keychainDelete(account string) error {
+	err := exec.Command("security", "delete-generic-password", "-s", keychainService, "-a", account).Run()
+	if err != nil && exitStatus(err) != errItemNotFound {
+		return fmt.Errorf("deleting from the keychain: %w", err)
+	}
+	return nil
+}
+
+// quote single-quotes s for the command parser of security -i
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+// exitStatus returns the exit status of a command that failed, or -1
+func exitStatus(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
@@ -0,0 +1,68 @@
+//go:build linux
+
+package credentials
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"strings"
+)
+
+// keychainName describes the keychain of this platform
+const keychainName = "the Secret Service keyring"
+
+// keychainGet looks up a secret with secret-tool, which exits with status
+// 1 and prints nothing when there is none. Secrets are stored
+// base64-encoded, so no newline is added or lost on the way.
+func keychainGet(account string) ([]byte, error) {
+	out, err := exec.Command("secret-tool", "lookup", "service", keychainService, "account", account).Output()
+	if (err == nil || exitStatus(err) == 1) && len(out) == 0 {
+		return nil, fmt.Errorf("no keyring secret for %s: %w", account, fs.ErrNotExist)
+	}
+	if err != nil {
+		return nil, secretToolError("reading the keyring", err)
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(string(out)))
+}
+
+// keychainSet stores a secret with secret-tool, which reads it from
+// standard input
+func keychainSet(account string, data []byte) error {
+	cmd := exec.Command("secret-tool", "store", "--label=GO Money sign-in ("+account+")",
+		"service", keychainService, "account", account)
+	cmd.Stdin = strings.NewReader(base64.StdEncoding.EncodeToString(data))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return secretToolError("writing to the keyring", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out))))
+	}
+	return nil
+}
+
+// keychainDelete removes a secret, if there is one
+func keychainDelete(account string) error {
+	err := exec.Command("secret-tool", "clear", "service", keychainService, "account", account).Run()
+	if err != nil && exitStatus(err) != 1 {
+		return secretToolError("deleting from the keyring", err)
+	}
+	return nil
+}
+
+// secretToolError explains a failed secret-tool command, such as when it
+// isn't installed
+func secretToolError(action string, err error) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("%s: secret-tool isn't installed (package libsecret-tools); install it or set GM_CREDENTIALS=%s", action, KindEncrypted)
+	}
+	return fmt.Errorf("%s: %w", action, err)
+}
+
+// exitStatus returns the exit status of a command that failed, or -1
+func exitStatus(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
@@ -0,0 +1,18 @@
+//go:build !darwin && !linux && !windows
+
+package credentials
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// keychainName describes the keychain of this platform
+const keychainName = "the system keychain"
+
+// errNoKeychain fails every keychain operation where none is supported
+var errNoKeychain = fmt.Errorf("no keychain is supported on %s; set GM_CREDENTIALS=%s instead", runtime.GOOS, KindEncrypted)
+
+func keychainGet(account string) ([]byte, error)    { return nil, errNoKeychain }
+func keychainSet(account string, data []byte) error { return errNoKeychain }
+func keychainDelete(account string) error           { return errNoKeychain }
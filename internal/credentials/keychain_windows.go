@@ -0,0 +1,163 @@
+//go:build windows
+
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+// keychainName describes the keychain of this platform
+const keychainName = "the Windows Credential Manager"
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	errorNotFound           = syscall.Errno(1168)
+	// maxCredentialBlob is the largest secret one credential holds; larger
+	// ones, such as Microsoft tokens, are split over several
+	maxCredentialBlob = 5 * 512
+)
+
+var (
+	advapi32        = syscall.NewLazyDLL("advapi32.dll")
+	procCredReadW   = advapi32.NewProc("CredReadW")
+	procCredWriteW  = advapi32.NewProc("CredWriteW")
+	procCredDeleteW = advapi32.NewProc("CredDeleteW")
+	procCredFree    = advapi32.NewProc("CredFree")
+)
+
+// credential is the CREDENTIALW structure
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// credentialTarget names the part of a secret kept in one credential
+func credentialTarget(account string, part int) string {
+	target := keychainService + ":" + account
+	if part > 0 {
+		target += "#" + strconv.Itoa(part)
+	}
+	return target
+}
+
+// keychainGet reads the credentials of account and joins their parts
+func keychainGet(account string) ([]byte, error) {
+	var data []byte
+	for part := 0; ; part++ {
+		blob, err := credRead(credentialTarget(account, part))
+		if errors.Is(err, errorNotFound) {
+			if part == 0 {
+				return nil, fmt.Errorf("no credential for %s: %w", account, fs.ErrNotExist)
+			}
+			return data, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading the Credential Manager: %w", err)
+		}
+		data = append(data, blob...)
+	}
+}
+
+// keychainSet writes data over as many credentials as it needs, and
+// removes the parts of a longer earlier secret
+func keychainSet(account string, data []byte) error {
+	part := 0
+	for start := 0; start < len(data) || part == 0; start += maxCredentialBlob {
+		end := min(start+maxCredentialBlob, len(data))
+		if err := credWrite(credentialTarget(account, part), account, data[start:end]); err != nil {
+			return fmt.Errorf("writing to the Credential Manager: %w", err)
+		}
+		part++
+	}
+	return deleteParts(account, part)
+}
+
+// keychainDelete removes every credential of account
+func keychainDelete(account string) error {
+	return deleteParts(account, 0)
+}
+
+// deleteParts removes the credentials of account from part on
+func deleteParts(account string, part int) error {
+	for ; ; part++ {
+		err := credDelete(credentialTarget(account, part))
+		if errors.Is(err, errorNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("deleting from the Credential Manager: %w", err)
+		}
+	}
+}
+
+func credRead(target string) ([]byte, error) {
+	name, err := syscall.UTF16PtrFromString(target)
+	if err != nil {
+		return nil, err
+	}
+	var cred *credential
+	ok, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(name)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if ok == 0 {
+		return nil, err
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	if cred.CredentialBlobSize == 0 {
+		return nil, nil
+	}
+	return append([]byte(nil), unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)...), nil
+}
+
+func credWrite(target, account string, blob []byte) error {
+	name, err := syscall.UTF16PtrFromString(target)
+	if err != nil {
+		return err
+	}
+	user, err := syscall.UTF16PtrFromString(account)
+	if err != nil {
+		return err
+	}
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         name,
+		CredentialBlobSize: uint32(len(blob)),
+		Persist:            credPersistLocalMachine,
+		UserName:           user,
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+	ok, _, err := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0)
+	if ok == 0 {
+		return err
+	}
+	return nil
+}
+
+func credDelete(target string) error {
+	name, err := syscall.UTF16PtrFromString(target)
+	if err != nil {
+		return err
+	}
+	ok, _, err := procCredDeleteW.Call(uintptr(unsafe.Pointer(name)), credTypeGeneric, 0)
+	if ok == 0 {
+		return err
+	}
+	return nil
+}
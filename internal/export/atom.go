@@ -0,0 +1,110 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// atomFeedLimit caps the number of entries in a feed; readers only need recent items
+const atomFeedLimit = 100
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  atomPerson  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title     string       `xml:"title"`
+	ID        string       `xml:"id"`
+	Published string       `xml:"published"`
+	Updated   string       `xml:"updated"`
+	Category  atomCategory `xml:"category"`
+	Content   atomContent  `xml:"content"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// WriteAtom writes the most recent transactions as an Atom 1.0 feed,
+// preceded by an alert for each budget projected to be exceeded.
+// selfURL is the public URL of the feed and updated the time of the last sync.
+func WriteAtom(w io.Writer, transactions []*models.Transaction, budgets []models.BudgetStatus, selfURL string, updated time.Time) error {
+	sorted := make([]*models.Transaction, len(transactions))
+	copy(sorted, transactions)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Date.After(sorted[j].Date)
+	})
+	if len(sorted) > atomFeedLimit {
+		sorted = sorted[:atomFeedLimit]
+	}
+
+	feed := atomFeed{
+		Title:   "GO Money transactions",
+		ID:      "urn:go-money:transactions",
+		Updated: updated.UTC().Format(time.RFC3339),
+		Links:   []atomLink{{Href: selfURL, Rel: "self"}},
+		Author:  atomPerson{Name: "GO Money"},
+	}
+
+	for _, b := range budgets {
+		if !b.AtRisk() {
+			continue
+		}
+		feed.Entries = append(feed.Entries, atomEntry{
+			Title:     fmt.Sprintf("Budget alert: %s", b.Category),
+			ID:        fmt.Sprintf("urn:go-money:budget:%s:%s:%s", url.PathEscape(b.Category), b.Currency, b.Month),
+			Published: updated.UTC().Format(time.RFC3339),
+			Updated:   updated.UTC().Format(time.RFC3339),
+			Category:  atomCategory{Term: "budget"},
+			Content:   atomContent{Type: "text", Body: b.Message()},
+		})
+	}
+
+	for _, tx := range sorted {
+		feed.Entries = append(feed.Entries, atomEntry{
+			Title:     fmt.Sprintf("%s - %s%.2f %s", tx.ServiceName, tx.CurrencySymbol, tx.Amount, tx.Currency),
+			ID:        "urn:go-money:transaction:" + tx.ID,
+			Published: tx.Date.UTC().Format(time.RFC3339),
+			Updated:   tx.Timestamp.UTC().Format(time.RFC3339),
+			Category:  atomCategory{Term: tx.Category},
+			Content: atomContent{
+				Type: "text",
+				Body: fmt.Sprintf("%s\nCategory: %s\nDate: %s\nFrom: %s",
+					tx.Subject, tx.Category, tx.Date.Format("2006-01-02"), tx.Email),
+			},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(feed)
+}
@@ -0,0 +1,55 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// csvHeader lists the columns written by WriteCSV
+var csvHeader = []string{"date", "service", "category", "amount", "currency", "subject", "email"}
+
+// WriteCSV writes transactions as CSV, oldest first, for spreadsheets and
+// accounting software
+func WriteCSV(w io.Writer, txs []*models.Transaction) error {
+	sorted := make([]*models.Transaction, len(txs))
+	copy(sorted, txs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, tx := range sorted {
+		row := []string{
+			tx.Date.Format("2006-01-02"),
+			csvText(tx.ServiceName),
+			csvText(tx.Category),
+			strconv.FormatFloat(tx.Amount, 'f', 2, 64),
+			tx.Currency,
+			csvText(tx.Subject),
+			csvText(tx.Email),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvText keeps spreadsheets from evaluating email-controlled text as a formula
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,126 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// WinZip AES (AE-2) parameters, readable by 7-Zip, WinZip and libarchive
+const (
+	aesMethod     = 99     // Compression method marking AES-encrypted entries
+	aesExtraID    = 0x9901 // Extra field describing the encryption
+	aesVersion    = 2      // AE-2: no CRC, the authentication code covers the data
+	aesStrength   = 3      // AES-256
+	aesSaltSize   = 16
+	aesKeySize    = 32
+	aesIterations = 1000
+	aesMACSize    = 10
+)
+
+// WriteEncryptedZip writes a zip archive holding content as a single file,
+// name, compressed and encrypted with AES-256 under passphrase
+func WriteEncryptedZip(w io.Writer, name, passphrase string, content []byte, modified time.Time) error {
+	if passphrase == "" {
+		return fmt.Errorf("an empty passphrase can't protect %s", name)
+	}
+
+	var compressed bytes.Buffer
+	fw, err := flate.NewWriter(&compressed, flate.DefaultCompression)
+	if err != nil {
+		return err
+	}
+	if _, err := fw.Write(content); err != nil {
+		return err
+	}
+	if err := fw.Close(); err != nil {
+		return err
+	}
+
+	salt := make([]byte, aesSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	keys, err := pbkdf2.Key(sha1.New, passphrase, salt, aesIterations, 2*aesKeySize+2)
+	if err != nil {
+		return err
+	}
+	encKey, macKey, verifier := keys[:aesKeySize], keys[aesKeySize:2*aesKeySize], keys[2*aesKeySize:]
+
+	data := compressed.Bytes()
+	if err := aesCTR(encKey, data); err != nil {
+		return err
+	}
+	mac := hmac.New(sha1.New, macKey)
+	mac.Write(data)
+
+	// Entry data: salt, password verifier, ciphertext, authentication code
+	raw := make([]byte, 0, len(salt)+len(verifier)+len(data)+aesMACSize)
+	raw = append(raw, salt...)
+	raw = append(raw, verifier...)
+	raw = append(raw, data...)
+	raw = append(raw, mac.Sum(nil)[:aesMACSize]...)
+
+	extra := make([]byte, 11)
+	binary.LittleEndian.PutUint16(extra[0:], aesExtraID)
+	binary.LittleEndian.PutUint16(extra[2:], 7)
+	binary.LittleEndian.PutUint16(extra[4:], aesVersion)
+	copy(extra[6:], "AE")
+	extra[8] = aesStrength
+	binary.LittleEndian.PutUint16(extra[9:], zip.Deflate)
+
+	header := &zip.FileHeader{
+		Name:               name,
+		Method:             aesMethod,
+		Flags:              0x1, // Encrypted
+		Extra:              extra,
+		CompressedSize64:   uint64(len(raw)),
+		UncompressedSize64: uint64(len(content)),
+	}
+	header.ModifiedDate, header.ModifiedTime = msDOSTime(modified)
+
+	zw := zip.NewWriter(w)
+	entry, err := zw.CreateRaw(header)
+	if err != nil {
+		return err
+	}
+	if _, err := entry.Write(raw); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// aesCTR encrypts data in place with AES in the counter mode WinZip uses: a
+// little-endian block counter starting at 1
+func aesCTR(key, data []byte) error {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+
+	var counter, stream [aes.BlockSize]byte
+	for i, n := 0, uint64(1); i < len(data); i, n = i+aes.BlockSize, n+1 {
+		binary.LittleEndian.PutUint64(counter[:], n)
+		block.Encrypt(stream[:], counter[:])
+		for j := 0; j < aes.BlockSize && i+j < len(data); j++ {
+			data[i+j] ^= stream[j]
+		}
+	}
+	return nil
+}
+
+// msDOSTime converts t to the date and time fields of a zip header
+func msDOSTime(t time.Time) (date, clock uint16) {
+	date = uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9)
+	clock = uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)
+	return date, clock
+}
@@ -0,0 +1,125 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// openEncryptedZip reads back the single entry of an archive written by
+// WriteEncryptedZip, checking it the way a WinZip AES reader does
+func openEncryptedZip(archive []byte, passphrase string) (*zip.File, []byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(zr.File) != 1 {
+		return nil, nil, errors.New("want a single entry")
+	}
+	f := zr.File[0]
+	extra := f.Extra
+	if f.Method != aesMethod || len(extra) != 11 || binary.LittleEndian.Uint16(extra) != aesExtraID ||
+		binary.LittleEndian.Uint16(extra[4:]) != aesVersion || string(extra[6:8]) != "AE" || extra[8] != aesStrength {
+		return nil, nil, errors.New("not a WinZip AES-256 entry")
+	}
+
+	r, err := f.OpenRaw()
+	if err != nil {
+		return nil, nil, err
+	}
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	salt, verifier := raw[:aesSaltSize], raw[aesSaltSize:aesSaltSize+2]
+	data, mac := raw[aesSaltSize+2:len(raw)-aesMACSize], raw[len(raw)-aesMACSize:]
+
+	keys, err := pbkdf2.Key(sha1.New, passphrase, salt, aesIterations, 2*aesKeySize+2)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !bytes.Equal(verifier, keys[2*aesKeySize:]) {
+		return nil, nil, errors.New("wrong passphrase")
+	}
+	h := hmac.New(sha1.New, keys[aesKeySize:2*aesKeySize])
+	h.Write(data)
+	if !hmac.Equal(mac, h.Sum(nil)[:aesMACSize]) {
+		return nil, nil, errors.New("authentication code mismatch")
+	}
+
+	if err := aesCTR(keys[:aesKeySize], data); err != nil {
+		return nil, nil, err
+	}
+	content, err := io.ReadAll(flate.NewReader(bytes.NewReader(data)))
+	return f, content, err
+}
+
+func TestWriteEncryptedZip(t *testing.T) {
+	content := bytes.Repeat([]byte("date,amount,currency\n2025-03-01,15.49,USD\n"), 50)
+	modified := time.Date(2025, 3, 14, 9, 26, 54, 0, time.UTC)
+
+	var archive bytes.Buffer
+	if err := WriteEncryptedZip(&archive, "transactions.csv", "correct horse", content, modified); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(archive.Bytes(), []byte("2025-03-01,15.49")) {
+		t.Fatal("the archive holds the content in the clear")
+	}
+
+	f, got, err := openEncryptedZip(archive.Bytes(), "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("decrypted %d bytes that differ from the %d written", len(got), len(content))
+	}
+	if f.Name != "transactions.csv" || f.UncompressedSize64 != uint64(len(content)) || f.Flags&0x1 == 0 {
+		t.Errorf("header = %s, %d bytes, flags %#x", f.Name, f.UncompressedSize64, f.Flags)
+	}
+	if !f.Modified.Equal(modified) {
+		t.Errorf("modified = %v; want %v", f.Modified, modified)
+	}
+
+	if _, _, err := openEncryptedZip(archive.Bytes(), "wrong horse"); err == nil {
+		t.Error("opened the archive with the wrong passphrase")
+	}
+
+	// Tampering with the ciphertext breaks the authentication code
+	tampered := bytes.Clone(archive.Bytes())
+	at := bytes.Index(tampered, []byte("transactions.csv")) + len("transactions.csv") + 11 + aesSaltSize + 2
+	tampered[at] ^= 0xff
+	if _, _, err := openEncryptedZip(tampered, "correct horse"); err == nil {
+		t.Error("opened a tampered archive")
+	}
+
+	if err := WriteEncryptedZip(io.Discard, "transactions.csv", "", content, modified); err == nil {
+		t.Error("wrote an archive with an empty passphrase")
+	}
+}
+
+func TestAESCTRCounter(t *testing.T) {
+	// Encrypting zeros yields the key stream: AES of the little-endian
+	// counters 1, 2, ... so blocks differ and the stream isn't reused
+	key := bytes.Repeat([]byte{0x42}, aesKeySize)
+	stream := make([]byte, 40)
+	if err := aesCTR(key, stream); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(stream[:16], stream[16:32]) {
+		t.Error("consecutive blocks share a key stream")
+	}
+	again := make([]byte, 40)
+	copy(again, stream)
+	aesCTR(key, again)
+	if !bytes.Equal(again, make([]byte, 40)) {
+		t.Error("applying the key stream twice doesn't restore the data")
+	}
+}
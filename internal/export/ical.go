@@ -0,0 +1,132 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sazardev/go-money/internal/bills"
+	"github.com/sazardev/go-money/internal/subscriptions"
+)
+
+// icalLineLimit is the maximum line length in octets allowed by RFC 5545
+const icalLineLimit = 75
+
+// icalRecurrence maps subscription cadences to RRULE values
+var icalRecurrence = map[string]string{
+	subscriptions.Weekly:    "FREQ=WEEKLY",
+	subscriptions.Monthly:   "FREQ=MONTHLY",
+	subscriptions.Quarterly: "FREQ=MONTHLY;INTERVAL=3",
+	subscriptions.Yearly:    "FREQ=YEARLY",
+}
+
+// WriteICal writes an iCalendar (.ics) feed with one recurring all-day event
+// per active subscription, starting at its next predicted renewal
+func WriteICal(w io.Writer, subs []subscriptions.Subscription, now time.Time) error {
+	bw := bufio.NewWriter(w)
+	line := func(format string, a ...interface{}) {
+		writeICalLine(bw, fmt.Sprintf(format, a...))
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//go-money//gm//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:GO Money renewals")
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	for _, sub := range subs {
+		if !sub.Active {
+			continue
+		}
+
+		start := sub.NextCharge
+		line("BEGIN:VEVENT")
+		line("UID:%s-%s@go-money", sub.ServiceID, strings.ToLower(sub.Currency))
+		line("DTSTAMP:%s", stamp)
+		line("DTSTART;VALUE=DATE:%s", start.Format("20060102"))
+		line("DTEND;VALUE=DATE:%s", start.AddDate(0, 0, 1).Format("20060102"))
+		if rule, ok := icalRecurrence[sub.Cadence]; ok {
+			line("RRULE:%s", rule)
+		}
+		line("SUMMARY:%s", escapeICalText(fmt.Sprintf("%s renewal (%s%.2f %s)",
+			sub.ServiceName, sub.CurrencySymbol, sub.Amount, sub.Currency)))
+		line("DESCRIPTION:%s", escapeICalText(fmt.Sprintf("%s %s charge, last billed %s (%d charges seen)",
+			sub.Category, sub.Cadence, sub.LastCharge.Format("2006-01-02"), sub.Charges)))
+		line("CATEGORIES:%s", escapeICalText(sub.Category))
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+// WriteBillsICal writes an iCalendar (.ics) file with an all-day event on
+// each bill's due date and an alarm remindDays days before it
+func WriteBillsICal(w io.Writer, due []bills.Bill, remindDays int, now time.Time) error {
+	bw := bufio.NewWriter(w)
+	line := func(format string, a ...interface{}) {
+		writeICalLine(bw, fmt.Sprintf(format, a...))
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//go-money//gm//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:GO Money bills")
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	for _, bill := range due {
+		summary := escapeICalText(fmt.Sprintf("%s bill due (%s%.2f %s)",
+			bill.ServiceName, bill.CurrencySymbol, bill.Amount, bill.Currency))
+
+		line("BEGIN:VEVENT")
+		line("UID:bill-%s@go-money", bill.ID)
+		line("DTSTAMP:%s", stamp)
+		line("DTSTART;VALUE=DATE:%s", bill.DueDate.Format("20060102"))
+		line("DTEND;VALUE=DATE:%s", bill.DueDate.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY:%s", summary)
+		line("DESCRIPTION:%s", escapeICalText(bill.Subject))
+		line("CATEGORIES:%s", escapeICalText(bill.Category))
+		line("BEGIN:VALARM")
+		line("ACTION:DISPLAY")
+		line("TRIGGER:-P%dD", remindDays)
+		line("DESCRIPTION:%s", summary)
+		line("END:VALARM")
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+// escapeICalText escapes a TEXT property value per RFC 5545
+func escapeICalText(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`, "\r", "")
+	return r.Replace(s)
+}
+
+// writeICalLine writes a content line terminated by CRLF, folding it into
+// continuation lines when it exceeds the octet limit
+func writeICalLine(w *bufio.Writer, s string) {
+	limit := icalLineLimit
+	for len(s) > limit {
+		// Don't split a multi-byte UTF-8 sequence
+		cut := limit
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		// Continuation lines start with a space, which counts towards the limit
+		limit = icalLineLimit - 1
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
@@ -0,0 +1,145 @@
+package export
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+const (
+	// ofxNameLimit and ofxMemoLimit are the OFX 1.0.2 field lengths
+	ofxNameLimit = 32
+	ofxMemoLimit = 255
+	// fitIDLimit keeps FITIDs short enough for Quicken
+	fitIDLimit = 32
+	// ofxAccountPrefix identifies the pseudo accounts transactions are filed under
+	ofxAccountPrefix = "GOMONEY-"
+)
+
+// WriteOFX writes transactions as an OFX 1.0.2 bank statement, one statement
+// per currency, for GnuCash, Quicken and other bank-statement-aware tools.
+// Expenses are written as debits.
+func WriteOFX(w io.Writer, txs []*models.Transaction, now time.Time) error {
+	bw := bufio.NewWriter(w)
+	line := func(format string, a ...interface{}) {
+		fmt.Fprintf(bw, format+"\r\n", a...)
+	}
+
+	for _, header := range []string{
+		"OFXHEADER:100", "DATA:OFXSGML", "VERSION:102", "SECURITY:NONE",
+		"ENCODING:UTF-8", "CHARSET:NONE", "COMPRESSION:NONE", "OLDFILEUID:NONE", "NEWFILEUID:NONE",
+	} {
+		line("%s", header)
+	}
+	line("")
+
+	stamp := ofxTime(now)
+	line("<OFX>")
+	line("<SIGNONMSGSRSV1><SONRS>")
+	line("<STATUS><CODE>0<SEVERITY>INFO</STATUS>")
+	line("<DTSERVER>%s", stamp)
+	line("<LANGUAGE>ENG")
+	line("</SONRS></SIGNONMSGSRSV1>")
+	line("<BANKMSGSRSV1>")
+
+	for i, group := range groupByCurrency(txs) {
+		line("<STMTTRNRS>")
+		line("<TRNUID>%d", i+1)
+		line("<STATUS><CODE>0<SEVERITY>INFO</STATUS>")
+		line("<STMTRS>")
+		line("<CURDEF>%s", group.currency)
+		line("<BANKACCTFROM><BANKID>GOMONEY<ACCTID>%s%s<ACCTTYPE>CHECKING</BANKACCTFROM>", ofxAccountPrefix, group.currency)
+		line("<BANKTRANLIST>")
+		line("<DTSTART>%s", ofxTime(group.txs[0].Date))
+		line("<DTEND>%s", ofxTime(group.txs[len(group.txs)-1].Date))
+
+		for _, tx := range group.txs {
+			line("<STMTTRN>")
+			line("<TRNTYPE>DEBIT")
+			line("<DTPOSTED>%s", ofxTime(tx.Date))
+			line("<TRNAMT>%.2f", -tx.Amount)
+			line("<FITID>%s", FITID(tx.ID))
+			line("<NAME>%s", ofxText(tx.ServiceName, ofxNameLimit))
+			line("<MEMO>%s", ofxText(tx.Category+": "+tx.Subject, ofxMemoLimit))
+			line("</STMTTRN>")
+		}
+
+		line("</BANKTRANLIST>")
+		line("<LEDGERBAL><BALAMT>0.00<DTASOF>%s</LEDGERBAL>", stamp)
+		line("</STMTRS>")
+		line("</STMTTRNRS>")
+	}
+
+	line("</BANKMSGSRSV1>")
+	line("</OFX>")
+	return bw.Flush()
+}
+
+// FITID derives a stable financial transaction ID from a Gmail message ID so
+// re-importing the same file doesn't create duplicates. Short alphanumeric
+// IDs are used as is; anything else is hashed.
+func FITID(messageID string) string {
+	if messageID != "" && len(messageID) <= fitIDLimit && isAlnum(messageID) {
+		return messageID
+	}
+	sum := sha256.Sum256([]byte(messageID))
+	return hex.EncodeToString(sum[:])[:fitIDLimit]
+}
+
+// currencyGroup is the transactions of one currency, oldest first
+type currencyGroup struct {
+	currency string
+	txs      []*models.Transaction
+}
+
+// groupByCurrency splits transactions per currency, sorted by currency code
+func groupByCurrency(txs []*models.Transaction) []currencyGroup {
+	byCurrency := make(map[string][]*models.Transaction)
+	for _, tx := range txs {
+		currency := tx.Currency
+		if currency == "" {
+			currency = "USD"
+		}
+		byCurrency[currency] = append(byCurrency[currency], tx)
+	}
+
+	groups := make([]currencyGroup, 0, len(byCurrency))
+	for currency, list := range byCurrency {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].Date.Before(list[j].Date) })
+		groups = append(groups, currencyGroup{currency: currency, txs: list})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].currency < groups[j].currency })
+
+	return groups
+}
+
+// ofxTime formats t as an OFX date-time in UTC
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405") + "[0:GMT]"
+}
+
+// ofxText makes s safe for an SGML element value: one line, escaped, and
+// at most limit characters
+func ofxText(s string, limit int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if runes := []rune(s); len(runes) > limit {
+		s = string(runes[:limit])
+	}
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+func isAlnum(s string) bool {
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package export
+
+import (
+	"encoding/csv"
+	"html/template"
+	"io"
+	"strconv"
+
+	"github.com/sazardev/go-money/internal/categories"
+	"github.com/sazardev/go-money/internal/summary"
+)
+
+// WritePivotCSV writes a pivot table as CSV, with a total column and a
+// total row
+func WritePivotCSV(w io.Writer, pivot *summary.Pivot) error {
+	cw := csv.NewWriter(w)
+
+	header := []string{"category"}
+	for _, c := range pivot.Columns {
+		header = append(header, csvText(c))
+	}
+	if err := cw.Write(append(header, "total")); err != nil {
+		return err
+	}
+
+	for _, row := range pivot.Rows {
+		if err := cw.Write(pivotCSVRow(csvText(row.Category), row.Values, row.Total)); err != nil {
+			return err
+		}
+	}
+	if err := cw.Write(pivotCSVRow("total", pivot.Totals, pivot.Total)); err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func pivotCSVRow(label string, values []float64, total float64) []string {
+	row := []string{label}
+	for _, v := range values {
+		row = append(row, strconv.FormatFloat(v, 'f', 2, 64))
+	}
+	return append(row, strconv.FormatFloat(total, 'f', 2, 64))
+}
+
+// pivotTemplate renders a standalone HTML page; html/template escapes
+// category names, which come from emails
+var pivotTemplate = template.Must(template.New("pivot").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { padding: 0.3em 0.8em; border-bottom: 1px solid #ddd; }
+td.amount, th.amount { text-align: right; font-variant-numeric: tabular-nums; }
+tr.total { font-weight: bold; border-top: 2px solid #333; }
+.swatch { display: inline-block; width: 0.8em; height: 0.8em; margin-right: 0.4em; border-radius: 2px; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<table>
+<thead>
+<tr><th>Category</th>{{range .Columns}}<th class="amount">{{.}}</th>{{end}}<th class="amount">Total</th></tr>
+</thead>
+<tbody>
+{{range .Rows}}<tr><td><span class="swatch" style="background: {{.Color}}"></span>{{.Emoji}} {{.Category}}</td>{{range .Values}}<td class="amount">{{printf "%.2f" .}}</td>{{end}}<td class="amount">{{printf "%.2f" .Total}}</td></tr>
+{{end}}<tr class="total"><td>Total</td>{{range .Totals}}<td class="amount">{{printf "%.2f" .}}</td>{{end}}<td class="amount">{{printf "%.2f" .Total}}</td></tr>
+</tbody>
+</table>
+</body>
+</html>
+`))
+
+// pivotHTMLRow is a pivot row with its category's display style
+type pivotHTMLRow struct {
+	summary.PivotRow
+	Emoji string
+	Color template.CSS
+}
+
+// WritePivotHTML writes a pivot table as an HTML page titled title,
+// showing each category with its emoji and color
+func WritePivotHTML(w io.Writer, title string, pivot *summary.Pivot, styles *categories.Overrides) error {
+	rows := make([]pivotHTMLRow, 0, len(pivot.Rows))
+	for _, row := range pivot.Rows {
+		style := styles.Style(row.Category)
+		rows = append(rows, pivotHTMLRow{
+			PivotRow: row,
+			Emoji:    style.Emoji,
+			// Colors are validated as #rrggbb when the config is loaded
+			Color: template.CSS(style.Color),
+		})
+	}
+
+	return pivotTemplate.Execute(w, struct {
+		Title   string
+		Columns []string
+		Rows    []pivotHTMLRow
+		Totals  []float64
+		Total   float64
+	}{title, pivot.Columns, rows, pivot.Totals, pivot.Total})
+}
@@ -0,0 +1,38 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// WriteQIF writes transactions as QIF bank registers, one account per
+// currency since QIF has no notion of currency. Expenses are negative.
+func WriteQIF(w io.Writer, txs []*models.Transaction) error {
+	bw := bufio.NewWriter(w)
+
+	for _, group := range groupByCurrency(txs) {
+		fmt.Fprintf(bw, "!Account\nNGO Money %s\nTBank\n^\n", group.currency)
+		fmt.Fprintf(bw, "!Type:Bank\n")
+
+		for _, tx := range group.txs {
+			fmt.Fprintf(bw, "D%s\n", tx.Date.Format("01/02/2006"))
+			fmt.Fprintf(bw, "T%.2f\n", -tx.Amount)
+			fmt.Fprintf(bw, "N%s\n", FITID(tx.ID))
+			fmt.Fprintf(bw, "P%s\n", qifText(tx.ServiceName))
+			fmt.Fprintf(bw, "M%s\n", qifText(tx.Subject))
+			fmt.Fprintf(bw, "L%s\n", qifText(tx.Category))
+			fmt.Fprintf(bw, "^\n")
+		}
+	}
+
+	return bw.Flush()
+}
+
+// qifText keeps a value on a single line, as QIF fields are line based
+func qifText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
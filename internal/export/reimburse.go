@@ -0,0 +1,109 @@
+package export
+
+import (
+	"encoding/csv"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/summary"
+)
+
+// reimburseHeader lists the columns written by WriteReimbursementCSV
+var reimburseHeader = []string{"date", "service", "category", "amount", "currency", "subject", "receipts"}
+
+// Claim is a transaction claimed for reimbursement with the receipt files
+// saved for it
+type Claim struct {
+	Transaction *models.Transaction
+	Receipts    []string // File names in the packet
+}
+
+// WriteReimbursementCSV writes the claims as CSV, oldest first, followed by
+// a total row per currency
+func WriteReimbursementCSV(w io.Writer, claims []Claim) error {
+	sorted := make([]Claim, len(claims))
+	copy(sorted, claims)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Transaction.Date.Before(sorted[j].Transaction.Date)
+	})
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(reimburseHeader); err != nil {
+		return err
+	}
+
+	transactions := make([]*models.Transaction, 0, len(sorted))
+	for _, c := range sorted {
+		tx := c.Transaction
+		transactions = append(transactions, tx)
+		row := []string{
+			tx.Date.Format("2006-01-02"),
+			csvText(tx.ServiceName),
+			csvText(tx.Category),
+			strconv.FormatFloat(tx.Amount, 'f', 2, 64),
+			tx.Currency,
+			csvText(tx.Subject),
+			csvText(strings.Join(c.Receipts, "; ")),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	for _, group := range summary.GroupBy(transactions, summary.ByCurrency) {
+		row := []string{"", "Total", "", strconv.FormatFloat(group.Total, 'f', 2, 64), group.Key, "", ""}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// printoutTemplate renders an email as a printable page. Only its text is
+// shown, escaped by html/template, so the page never runs the email's
+// scripts or loads its remote images.
+var printoutTemplate = template.Must(template.New("printout").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Subject}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; max-width: 50em; }
+dl { display: grid; grid-template-columns: max-content auto; gap: 0.2em 1em; }
+dt { font-weight: bold; }
+pre { white-space: pre-wrap; font-family: inherit; border-top: 1px solid #ccc; padding-top: 1em; }
+</style>
+</head>
+<body>
+<h1>{{.Subject}}</h1>
+<dl>
+<dt>From</dt><dd>{{.From}}</dd>
+<dt>To</dt><dd>{{.To}}</dd>
+<dt>Date</dt><dd>{{.Date}}</dd>
+<dt>Amount</dt><dd>{{.Amount}}</dd>
+</dl>
+<pre>{{.Text}}</pre>
+</body>
+</html>
+`))
+
+// WriteReceiptPrintout writes an email backing tx as a printable HTML page
+// of its headers and text, for receipts sent without a PDF
+func WriteReceiptPrintout(w io.Writer, tx *models.Transaction, msg *models.Message, text string) error {
+	return printoutTemplate.Execute(w, struct {
+		Subject, From, To, Date, Amount, Text string
+	}{
+		Subject: msg.Subject,
+		From:    msg.From,
+		To:      msg.To,
+		Date:    msg.Date.Format("2006-01-02 15:04 MST"),
+		Amount:  tx.CurrencySymbol + strconv.FormatFloat(tx.Amount, 'f', 2, 64) + " " + tx.Currency,
+		Text:    text,
+	})
+}
@@ -0,0 +1,33 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/sazardev/go-money/internal/settle"
+)
+
+// settleHeader lists the columns written by WriteSettlementCSV
+var settleHeader = []string{"currency", "from", "to", "amount"}
+
+// WriteSettlementCSV writes the transfers that settle shared expenses as
+// CSV, one row per payment
+func WriteSettlementCSV(w io.Writer, settlements []settle.Settlement) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(settleHeader); err != nil {
+		return err
+	}
+
+	for _, s := range settlements {
+		for _, t := range s.Transfers {
+			row := []string{s.Currency, csvText(t.From), csvText(t.To), strconv.FormatFloat(t.Amount, 'f', 2, 64)}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,40 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/summary"
+)
+
+// vendorsHeader lists the columns written by WriteVendorsCSV
+var vendorsHeader = []string{"vendor", "currency", "transactions", "total", "tax", "invoices", "without_invoice"}
+
+// WriteVendorsCSV writes the spending per vendor as CSV, one row per vendor
+// and currency, with its invoice numbers separated by semicolons
+func WriteVendorsCSV(w io.Writer, vendors []summary.Vendor) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(vendorsHeader); err != nil {
+		return err
+	}
+
+	for _, v := range vendors {
+		row := []string{
+			csvText(v.Vendor),
+			v.Currency,
+			strconv.Itoa(v.Count),
+			strconv.FormatFloat(v.Total, 'f', 2, 64),
+			strconv.FormatFloat(v.Tax, 'f', 2, 64),
+			csvText(strings.Join(v.Invoices, "; ")),
+			strconv.Itoa(v.Uninvoiced),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
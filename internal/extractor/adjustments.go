@@ -0,0 +1,96 @@
+package extractor
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// Kinds of adjustment emails, which change the amount of an earlier charge
+const (
+	AdjustmentTip   = "tip"   // A tip added after the ride or delivery
+	AdjustmentTotal = "total" // The charge's new total
+)
+
+// adjustWindow is how long after a charge a tip or a new total can arrive
+// in another thread
+const adjustWindow = 3 * 24 * time.Hour
+
+// Subject keywords of adjustment emails
+var (
+	tipKeywords   = []string{"tip was added", "added a tip", "tip added", "your tip", "thanks for tipping", "propina"}
+	totalKeywords = []string{"updated total", "updated receipt", "adjusted total", "adjusted receipt", "final total", "revised receipt", "total actualizado", "recibo actualizado"}
+)
+
+// adjustment returns the kind of adjustment an email is, or ""
+func adjustment(subject string) string {
+	subject = strings.ToLower(subject)
+	for _, keyword := range tipKeywords {
+		if strings.Contains(subject, keyword) {
+			return AdjustmentTip
+		}
+	}
+	for _, keyword := range totalKeywords {
+		if strings.Contains(subject, keyword) {
+			return AdjustmentTotal
+		}
+	}
+	return ""
+}
+
+// AdjustedBy returns the charge among transactions that adj changes, or
+// nil: the charge in its thread or, failing that, the latest charge of the
+// same service and currency within adjustWindow before it
+func AdjustedBy(transactions []*models.Transaction, adj *models.Transaction) *models.Transaction {
+	if adj.Adjustment == "" {
+		return nil
+	}
+	var match *models.Transaction
+	for _, tx := range transactions {
+		if tx == adj || tx.ID == adj.ID || tx.Adjustment != "" || IsRefund(tx) || tx.ServiceID != adj.ServiceID || tx.Currency != adj.Currency {
+			continue
+		}
+		if tx.ThreadID != "" && tx.ThreadID == adj.ThreadID {
+			return tx
+		}
+		gap := adj.Date.Sub(tx.Date)
+		if gap >= 0 && gap <= adjustWindow && (match == nil || tx.Date.After(match.Date)) {
+			match = tx
+		}
+	}
+	return match
+}
+
+// MergeAdjustment amends kept, the charge adj adjusts. A tip email smaller
+// than the charge holds the tip, which is added; any other adjustment holds
+// the new total. An adjustment already merged is ignored.
+func MergeAdjustment(kept, adj *models.Transaction) {
+	if slices.Contains(kept.RelatedIDs, adj.ID) {
+		return
+	}
+	if adj.Adjustment == AdjustmentTip && adj.Amount < kept.Amount {
+		kept.Amount += adj.Amount
+	} else {
+		kept.Amount, kept.RawAmount = adj.Amount, adj.RawAmount
+	}
+	kept.RelatedIDs = append(kept.RelatedIDs, adj.ID)
+	kept.RelatedIDs = append(kept.RelatedIDs, adj.RelatedIDs...)
+	MergeStatus(kept, adj)
+}
+
+// applyAdjustments folds tip and updated-total emails into the charges
+// they adjust. Adjustments without their charge are kept as charges of
+// their own, which a later sync can still amend with them.
+func applyAdjustments(transactions []*models.Transaction) []*models.Transaction {
+	var result []*models.Transaction
+	for _, tx := range transactions {
+		if charge := AdjustedBy(transactions, tx); charge != nil {
+			MergeAdjustment(charge, tx)
+			continue
+		}
+		result = append(result, tx)
+	}
+	return result
+}
@@ -0,0 +1,71 @@
+package extractor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/sazardev/go-money/internal/paths"
+)
+
+// CatalogFile holds the community catalog installed by gm services update,
+// in the data directory
+const CatalogFile = "catalog.json"
+
+// CatalogSchema is the catalog layout this version reads; catalogs with a
+// later one need a newer gm
+const CatalogSchema = 1
+
+// SourceCatalog is the Source of services from the community catalog
+const SourceCatalog = "community catalog"
+
+// Catalog is a versioned list of services published upstream, merged over
+// the built-in ones and under the user's TrackerFile
+type Catalog struct {
+	Schema   int       `json:"schema"`
+	Version  int       `json:"version"`           // Raised with every release of the catalog
+	Updated  string    `json:"updated,omitempty"` // YYYY-MM-DD
+	Services []Service `json:"services"`
+}
+
+// CatalogPath returns where the community catalog is installed. Unlike the
+// files of earlier versions, it is never read from the working directory.
+func CatalogPath() string {
+	return filepath.Join(paths.Current().Data, CatalogFile)
+}
+
+// ParseCatalog parses a catalog and checks its schema and services
+func ParseCatalog(data []byte) (*Catalog, error) {
+	var catalog Catalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, err
+	}
+	switch {
+	case catalog.Schema > CatalogSchema:
+		return nil, fmt.Errorf("catalog schema %d is newer than this gm reads (%d); upgrade gm", catalog.Schema, CatalogSchema)
+	case catalog.Schema < 1 || catalog.Version < 1:
+		return nil, fmt.Errorf("not a service catalog: schema and version must be set")
+	}
+	for _, service := range catalog.Services {
+		if err := ValidateService(service); err != nil {
+			return nil, err
+		}
+	}
+	return &catalog, nil
+}
+
+// LoadCatalog returns the installed community catalog, or nil when there is
+// none
+func LoadCatalog() (*Catalog, error) {
+	data, err := os.ReadFile(CatalogPath())
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	return ParseCatalog(data)
+}
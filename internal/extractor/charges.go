@@ -0,0 +1,53 @@
+package extractor
+
+import (
+	"time"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// chargeWindow is how far apart the emails about a single charge can be
+const chargeWindow = 24 * time.Hour
+
+// SameCharge reports whether a and b look like separate emails about one
+// charge, such as a receipt and a payment confirmation: the same service,
+// amount and currency within chargeWindow of each other
+func SameCharge(a, b *models.Transaction) bool {
+	if a.ServiceID != b.ServiceID || a.Amount != b.Amount || a.Currency != b.Currency {
+		return false
+	}
+	gap := a.Date.Sub(b.Date)
+	return gap < chargeWindow && gap > -chargeWindow
+}
+
+// suppressChargeDuplicates keeps one transaction per charge when it arrives
+// in several threads. Like thread duplicates, the kept transaction is the
+// one with an explicit total (earliest first) and the others are linked to
+// it through RelatedIDs.
+func suppressChargeDuplicates(transactions []*models.Transaction, explicitTotals map[*models.Transaction]bool) []*models.Transaction {
+	var result []*models.Transaction
+	for _, tx := range transactions {
+		i := -1
+		for j, kept := range result {
+			if SameCharge(tx, kept) {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			result = append(result, tx)
+			continue
+		}
+
+		kept := result[i]
+		if preferInThread(tx, kept, explicitTotals) {
+			kept, tx = tx, kept
+			result[i] = kept
+		}
+		kept.RelatedIDs = append(kept.RelatedIDs, tx.ID)
+		kept.RelatedIDs = append(kept.RelatedIDs, tx.RelatedIDs...)
+		MergeStatus(kept, tx)
+		MergeInvoice(kept, tx)
+	}
+	return result
+}
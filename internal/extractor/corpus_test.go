@@ -0,0 +1,47 @@
+package extractor_test
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/sazardev/go-money/internal/corpus"
+	"github.com/sazardev/go-money/internal/extractor"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/paths"
+)
+
+var update = flag.Bool("update", false, "write the golden files of testdata with what is extracted")
+
+// TestCorpus extracts every fixture in testdata with the built-in services
+// and compares it with its golden file, as gm corpus check does
+func TestCorpus(t *testing.T) {
+	// Only the built-in services: no installed catalog or user tracker file
+	dir := t.TempDir()
+	saved := paths.Current()
+	paths.Set(paths.Dirs{Config: dir, Data: dir, Cache: dir})
+	t.Cleanup(func() { paths.Set(saved) })
+	t.Setenv("GM_TRACKER", "")
+
+	te, err := extractor.NewTransactionExtractor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := corpus.Check("testdata", func(msg *models.Message) *models.Transaction {
+		if transactions := te.ExtractTransactions([]*models.Message{msg}); len(transactions) > 0 {
+			return transactions[0]
+		}
+		return nil
+	}, *update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) == 0 {
+		t.Fatal("no fixtures in testdata")
+	}
+	for _, result := range results {
+		if !result.Passed() && !result.Updated {
+			t.Errorf("%s: %s", result.Name, strings.Join(result.Diffs, "; "))
+		}
+	}
+}
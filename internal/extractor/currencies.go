@@ -0,0 +1,277 @@
+package extractor
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// currency describes an ISO 4217 currency for reading and printing amounts
+type currency struct {
+	code     string
+	symbol   string       // Printed before amounts; shared symbols such as "$" are fine here
+	markers  []string     // Symbols and abbreviations that name only this currency next to an amount
+	decimals int          // Minor units, such as 0 for JPY and 3 for KWD
+	format   numberFormat // How receipts in it usually write decimals; formatAuto when both ways are common
+}
+
+// currencies are the ISO 4217 currencies, leaving out funds, metals and
+// test codes. "$", "¥" and "kr" name several, so only the historical USD
+// and JPY read them, and the others need a code or a marker such as "R$".
+var currencies = []currency{
+	{"AED", "د.إ", []string{"د.إ"}, 2, formatDecimalPoint},
+	{"AFN", "؋", []string{"؋"}, 2, formatAuto},
+	{"ALL", "L", nil, 2, formatDecimalComma},
+	{"AMD", "֏", []string{"֏"}, 2, formatAuto},
+	{"ANG", "ƒ", nil, 2, formatAuto},
+	{"AOA", "Kz", nil, 2, formatDecimalComma},
+	{"ARS", "$", []string{"AR$"}, 2, formatDecimalComma},
+	{"AUD", "$", []string{"A$", "AU$"}, 2, formatDecimalPoint},
+	{"AWG", "ƒ", nil, 2, formatAuto},
+	{"AZN", "₼", []string{"₼"}, 2, formatDecimalComma},
+	{"BAM", "KM", nil, 2, formatDecimalComma},
+	{"BBD", "$", []string{"Bds$"}, 2, formatDecimalPoint},
+	{"BDT", "৳", []string{"৳"}, 2, formatDecimalPoint},
+	{"BGN", "лв.", []string{"лв"}, 2, formatDecimalComma},
+	{"BHD", ".د.ب", nil, 3, formatDecimalPoint},
+	{"BIF", "FBu", nil, 0, formatAuto},
+	{"BMD", "$", nil, 2, formatDecimalPoint},
+	{"BND", "$", []string{"B$"}, 2, formatDecimalPoint},
+	{"BOB", "Bs.", nil, 2, formatDecimalComma},
+	{"BRL", "R$", []string{"R$"}, 2, formatDecimalComma},
+	{"BSD", "$", nil, 2, formatDecimalPoint},
+	{"BTN", "Nu.", nil, 2, formatDecimalPoint},
+	{"BWP", "P", nil, 2, formatDecimalPoint},
+	{"BYN", "Br", nil, 2, formatDecimalComma},
+	{"BZD", "$", []string{"BZ$"}, 2, formatDecimalPoint},
+	{"CAD", "$", []string{"C$", "CA$"}, 2, formatDecimalPoint},
+	{"CDF", "FC", nil, 2, formatAuto},
+	{"CHF", "Fr.", []string{"SFr."}, 2, formatDecimalPoint},
+	{"CLP", "$", []string{"CLP$"}, 0, formatDecimalComma},
+	{"CNY", "¥", []string{"CN¥", "元"}, 2, formatDecimalPoint},
+	{"COP", "$", []string{"COL$"}, 2, formatDecimalComma},
+	{"CRC", "₡", []string{"₡"}, 2, formatDecimalComma},
+	{"CUP", "$", nil, 2, formatDecimalPoint},
+	{"CVE", "$", nil, 2, formatDecimalComma},
+	{"CZK", "Kč", []string{"Kč"}, 2, formatDecimalComma},
+	{"DJF", "Fdj", nil, 0, formatAuto},
+	{"DKK", "kr.", nil, 2, formatDecimalComma},
+	{"DOP", "$", []string{"RD$"}, 2, formatDecimalPoint},
+	{"DZD", "دج", nil, 2, formatDecimalComma},
+	{"EGP", "E£", []string{"E£"}, 2, formatDecimalPoint},
+	{"ERN", "Nfk", nil, 2, formatDecimalPoint},
+	{"ETB", "Br", nil, 2, formatDecimalPoint},
+	{"EUR", "€", []string{"€"}, 2, formatAuto},
+	{"FJD", "$", []string{"FJ$"}, 2, formatDecimalPoint},
+	{"FKP", "£", nil, 2, formatDecimalPoint},
+	{"GBP", "£", []string{"£"}, 2, formatDecimalPoint},
+	{"GEL", "₾", []string{"₾"}, 2, formatDecimalComma},
+	{"GHS", "₵", []string{"₵", "GH₵"}, 2, formatDecimalPoint},
+	{"GIP", "£", nil, 2, formatDecimalPoint},
+	{"GMD", "D", nil, 2, formatDecimalPoint},
+	{"GNF", "FG", nil, 0, formatAuto},
+	{"GTQ", "Q", nil, 2, formatDecimalPoint},
+	{"GYD", "$", []string{"G$"}, 2, formatDecimalPoint},
+	{"HKD", "$", []string{"HK$"}, 2, formatDecimalPoint},
+	{"HNL", "L", nil, 2, formatDecimalPoint},
+	{"HTG", "G", nil, 2, formatAuto},
+	{"HUF", "Ft", nil, 2, formatDecimalComma},
+	{"IDR", "Rp", []string{"Rp"}, 2, formatDecimalComma},
+	{"ILS", "₪", []string{"₪"}, 2, formatDecimalPoint},
+	{"INR", "₹", []string{"₹", "Rs.", "Rs"}, 2, formatDecimalPoint},
+	{"IQD", "ع.د", nil, 3, formatAuto},
+	{"IRR", "﷼", []string{"﷼"}, 2, formatAuto},
+	{"ISK", "kr", nil, 0, formatDecimalComma},
+	{"JMD", "$", []string{"J$"}, 2, formatDecimalPoint},
+	{"JOD", "د.ا", nil, 3, formatDecimalPoint},
+	{"JPY", "¥", []string{"¥", "円"}, 0, formatDecimalPoint},
+	{"KES", "KSh", []string{"KSh"}, 2, formatDecimalPoint},
+	{"KGS", "сом", nil, 2, formatDecimalComma},
+	{"KHR", "៛", []string{"៛"}, 2, formatAuto},
+	{"KMF", "CF", nil, 0, formatAuto},
+	{"KPW", "₩", nil, 2, formatAuto},
+	{"KRW", "₩", []string{"₩", "원"}, 0, formatDecimalPoint},
+	{"KWD", "د.ك", nil, 3, formatDecimalPoint},
+	{"KYD", "$", []string{"CI$"}, 2, formatDecimalPoint},
+	{"KZT", "₸", []string{"₸"}, 2, formatDecimalComma},
+	{"LAK", "₭", []string{"₭"}, 2, formatAuto},
+	{"LBP", "ل.ل", nil, 2, formatAuto},
+	{"LKR", "Rs", nil, 2, formatDecimalPoint},
+	{"LRD", "$", []string{"L$"}, 2, formatDecimalPoint},
+	{"LSL", "L", nil, 2, formatAuto},
+	{"LYD", "ل.د", nil, 3, formatAuto},
+	{"MAD", "DH", nil, 2, formatDecimalComma},
+	{"MDL", "L", nil, 2, formatDecimalComma},
+	{"MGA", "Ar", nil, 2, formatAuto},
+	{"MKD", "ден", nil, 2, formatDecimalComma},
+	{"MMK", "K", nil, 2, formatDecimalPoint},
+	{"MNT", "₮", []string{"₮"}, 2, formatDecimalPoint},
+	{"MOP", "MOP$", []string{"MOP$"}, 2, formatDecimalPoint},
+	{"MRU", "UM", nil, 2, formatAuto},
+	{"MUR", "Rs", nil, 2, formatDecimalPoint},
+	{"MVR", "Rf", nil, 2, formatDecimalPoint},
+	{"MWK", "MK", nil, 2, formatDecimalPoint},
+	{"MXN", "$", []string{"M$", "MX$", "Mex$"}, 2, formatDecimalPoint},
+	{"MYR", "RM", []string{"RM"}, 2, formatDecimalPoint},
+	{"MZN", "MT", nil, 2, formatDecimalComma},
+	{"NAD", "$", []string{"N$"}, 2, formatDecimalPoint},
+	{"NGN", "₦", []string{"₦"}, 2, formatDecimalPoint},
+	{"NIO", "C$", nil, 2, formatDecimalPoint},
+	{"NOK", "kr", nil, 2, formatDecimalComma},
+	{"NPR", "Rs", nil, 2, formatDecimalPoint},
+	{"NZD", "$", []string{"NZ$"}, 2, formatDecimalPoint},
+	{"OMR", "ر.ع.", nil, 3, formatDecimalPoint},
+	{"PAB", "B/.", []string{"B/."}, 2, formatDecimalPoint},
+	{"PEN", "S/", []string{"S/"}, 2, formatDecimalPoint},
+	{"PGK", "K", nil, 2, formatDecimalPoint},
+	{"PHP", "₱", []string{"₱"}, 2, formatDecimalPoint},
+	{"PKR", "Rs", []string{"₨"}, 2, formatDecimalPoint},
+	{"PLN", "zł", []string{"zł"}, 2, formatDecimalComma},
+	{"PYG", "₲", []string{"₲"}, 0, formatDecimalComma},
+	{"QAR", "ر.ق", nil, 2, formatDecimalPoint},
+	{"RON", "lei", []string{"lei"}, 2, formatDecimalComma},
+	{"RSD", "дин.", []string{"дин"}, 2, formatDecimalComma},
+	{"RUB", "₽", []string{"₽", "руб"}, 2, formatDecimalComma},
+	{"RWF", "FRw", nil, 0, formatAuto},
+	{"SAR", "﷼", nil, 2, formatDecimalPoint},
+	{"SBD", "$", []string{"SI$"}, 2, formatDecimalPoint},
+	{"SCR", "Rs", nil, 2, formatDecimalPoint},
+	{"SDG", "ج.س.", nil, 2, formatAuto},
+	{"SEK", "kr", nil, 2, formatDecimalComma},
+	{"SGD", "$", []string{"S$"}, 2, formatDecimalPoint},
+	{"SHP", "£", nil, 2, formatDecimalPoint},
+	{"SLE", "Le", nil, 2, formatDecimalPoint},
+	{"SOS", "Sh", nil, 2, formatAuto},
+	{"SRD", "$", nil, 2, formatDecimalComma},
+	{"SSP", "£", nil, 2, formatAuto},
+	{"STN", "Db", nil, 2, formatDecimalComma},
+	{"SVC", "₡", nil, 2, formatDecimalPoint},
+	{"SYP", "£", nil, 2, formatAuto},
+	{"SZL", "E", nil, 2, formatDecimalPoint},
+	{"THB", "฿", []string{"฿"}, 2, formatDecimalPoint},
+	{"TJS", "SM", nil, 2, formatDecimalComma},
+	{"TMT", "m", nil, 2, formatDecimalComma},
+	{"TND", "د.ت", nil, 3, formatDecimalComma},
+	{"TOP", "T$", []string{"T$"}, 2, formatDecimalPoint},
+	{"TRY", "₺", []string{"₺"}, 2, formatDecimalComma},
+	{"TTD", "$", []string{"TT$"}, 2, formatDecimalPoint},
+	{"TWD", "$", []string{"NT$"}, 2, formatDecimalPoint},
+	{"TZS", "TSh", []string{"TSh"}, 2, formatDecimalPoint},
+	{"UAH", "₴", []string{"₴", "грн"}, 2, formatDecimalComma},
+	{"UGX", "USh", []string{"USh"}, 0, formatDecimalPoint},
+	{"USD", "$", []string{"$", "US$"}, 2, formatDecimalPoint},
+	{"UYU", "$", []string{"$U"}, 2, formatDecimalComma},
+	{"UZS", "soʻm", nil, 2, formatDecimalComma},
+	{"VES", "Bs.", []string{"Bs.S"}, 2, formatDecimalComma},
+	{"VND", "₫", []string{"₫"}, 0, formatDecimalComma},
+	{"VUV", "VT", nil, 0, formatAuto},
+	{"WST", "T", nil, 2, formatDecimalPoint},
+	{"XAF", "FCFA", []string{"FCFA"}, 0, formatDecimalComma},
+	{"XCD", "$", []string{"EC$"}, 2, formatDecimalPoint},
+	{"XCG", "Cg", nil, 2, formatAuto},
+	{"XOF", "CFA", []string{"CFA"}, 0, formatDecimalComma},
+	{"XPF", "₣", []string{"CFP"}, 0, formatDecimalComma},
+	{"YER", "﷼", nil, 2, formatAuto},
+	{"ZAR", "R", nil, 2, formatAuto},
+	{"ZMW", "K", nil, 2, formatDecimalPoint},
+	{"ZWG", "ZiG", []string{"ZiG"}, 2, formatDecimalPoint},
+}
+
+// currencyAbbreviations are three-letter markers that aren't ISO codes but
+// name a currency next to an amount
+var currencyAbbreviations = map[string]string{
+	"MEX": "MXN",
+	"RMB": "CNY",
+	"NIS": "ILS",
+	"YEN": "JPY",
+	"SFR": "CHF",
+}
+
+// currencyPriority keeps the historical preference when a message mentions
+// several currencies: the first currency with a candidate wins
+var currencyPriority = []string{"USD", "MXN", "EUR", "GBP", "JPY", "CAD"}
+
+var (
+	currencyByCode = make(map[string]currency, len(currencies))
+
+	// currencyCodes maps the three-letter markers found next to amounts to ISO codes
+	currencyCodes = make(map[string]string, len(currencies)+len(currencyAbbreviations))
+
+	// symbolMarkers maps the markers of currencies to their ISO codes;
+	// longer markers come first so "R$" is preferred over "$"
+	symbolMarkers []symbolMarker
+)
+
+type symbolMarker struct {
+	symbol   string
+	currency string
+}
+
+func init() {
+	for _, c := range currencies {
+		currencyByCode[c.code] = c
+		currencyCodes[c.code] = c.code
+		for _, marker := range c.markers {
+			symbolMarkers = append(symbolMarkers, symbolMarker{marker, c.code})
+		}
+	}
+	for abbreviation, code := range currencyAbbreviations {
+		currencyCodes[abbreviation] = code
+	}
+	sort.SliceStable(symbolMarkers, func(i, j int) bool {
+		return len(symbolMarkers[i].symbol) > len(symbolMarkers[j].symbol)
+	})
+}
+
+// CurrencySymbol returns the symbol extracted transactions in currency
+// carry, and whether the currency is one the extractor recognizes
+func CurrencySymbol(code string) (string, bool) {
+	c, ok := currencyByCode[code]
+	return c.symbol, ok
+}
+
+// currencyDecimals returns the minor units amounts in code are written with,
+// 2 for unknown currencies
+func currencyDecimals(code string) int {
+	if c, ok := currencyByCode[code]; ok {
+		return c.decimals
+	}
+	return 2
+}
+
+// currencyFormat returns the number format receipts in code almost always
+// use, formatAuto when they write it both ways or the currency is unknown
+func currencyFormat(code string) numberFormat {
+	return currencyByCode[code].format
+}
+
+// currencyCode returns the ISO code of a three-letter marker. Lowercase
+// markers only count for the historical currencies, so words such as "all"
+// or "top" next to a number aren't read as ALL or TOP.
+func currencyCode(marker string) (string, bool) {
+	code, ok := currencyCodes[strings.ToUpper(marker)]
+	if !ok || marker == strings.ToUpper(marker) {
+		return code, ok
+	}
+	for _, preferred := range currencyPriority {
+		if preferred == code {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+// wordMarker reports whether a marker is spelled with letters, such as "Rs"
+// or "zł", so it must not run into the words around it
+func wordMarker(marker string) bool {
+	first, _ := utf8.DecodeRuneInString(marker)
+	last, _ := utf8.DecodeLastRuneInString(marker)
+	return unicode.IsLetter(first) || unicode.IsLetter(last)
+}
+
+// afterAmountMarker reports whether a marker may follow an amount, as in
+// "12,50 €"; dollar-like markers such as "R$" only precede them
+func afterAmountMarker(marker string) bool {
+	return !strings.ContainsAny(marker, "$/")
+}
@@ -0,0 +1,47 @@
+package extractor
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// dueDatePattern finds a date introduced by "due", "due date", "due by",
+// "payment due on", "pay by" and similar phrases
+var dueDatePattern = regexp.MustCompile(`(?i)\b(?:due(?:\s+date)?|pay(?:ment)?\s+by)(?:\s+(?:on|by))?\s*[:|]?\s*` +
+	`(\d{4}-\d{2}-\d{2}|\d{1,2}/\d{1,2}/\d{4}|` +
+	`(?:jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?\s+\d{1,2}(?:st|nd|rd|th)?,?\s+\d{4}|` +
+	`\d{1,2}(?:st|nd|rd|th)?\s+(?:jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?,?\s+\d{4})`)
+
+var ordinalSuffix = regexp.MustCompile(`(?i)(\d)(st|nd|rd|th)`)
+
+// dueDateLayouts are tried in order against the normalized date text
+var dueDateLayouts = []string{
+	"2006-01-02",
+	"01/02/2006",
+	"1/2/2006",
+	"Jan 2 2006",
+	"January 2 2006",
+	"2 Jan 2006",
+	"2 January 2006",
+}
+
+// extractDueDate returns the payment due date of a bill, or the zero time
+// when the text doesn't mention one
+func extractDueDate(text string) time.Time {
+	match := dueDatePattern.FindStringSubmatch(text)
+	if match == nil {
+		return time.Time{}
+	}
+
+	date := ordinalSuffix.ReplaceAllString(match[1], "$1")
+	date = strings.NewReplacer(",", " ", ".", " ").Replace(date)
+	date = strings.Title(strings.ToLower(strings.Join(strings.Fields(date), " ")))
+
+	for _, layout := range dueDateLayouts {
+		if t, err := time.Parse(layout, date); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,76 @@
+package extractor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// Explanation describes how an email was read: which parser and service
+// matched it and how each amount in it scored
+type Explanation struct {
+	Parser      string                     `json:"parser" yaml:"parser"`
+	ByDomain    bool                       `json:"matched_by_domain" yaml:"matched_by_domain"`
+	MatchedBy   string                     `json:"matched_by" yaml:"matched_by"` // The domain or keyword that matched
+	Stage       string                     `json:"stage" yaml:"stage"`           // The extraction stage or merchant label that found the amount
+	Dated       bool                       `json:"dated" yaml:"dated"`           // The date was found in the email, rather than being when it was sent
+	Transaction *models.Transaction        `json:"transaction" yaml:"transaction"`
+	Amounts     []ScoredAmount             `json:"amounts" yaml:"amounts"` // Best first; the first is the transaction's
+	Warnings    []models.ExtractionWarning `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+}
+
+// ScoredAmount is one amount candidate and its score
+type ScoredAmount struct {
+	Amount   float64  `json:"amount" yaml:"amount"`
+	Currency string   `json:"currency" yaml:"currency"`
+	Raw      string   `json:"raw" yaml:"raw"`
+	Score    float64  `json:"score" yaml:"score"`
+	Reasons  []string `json:"reasons" yaml:"reasons"`
+}
+
+// Explain extracts the transaction of msg and keeps every amount candidate
+// that was considered. It returns nil when no parser recognizes msg.
+func (te *TransactionExtractor) Explain(msg *models.Message) *Explanation {
+	p := te.parse(msg)
+	if p == nil {
+		return nil
+	}
+
+	tx := te.transaction(msg, p)
+	e := &Explanation{
+		Parser:      p.parser,
+		ByDomain:    p.byDomain,
+		MatchedBy:   matchedBy(msg, p),
+		Stage:       p.amounts[0].stage,
+		Dated:       !tx.Date.Equal(msg.Date),
+		Transaction: tx,
+		Amounts:     make([]ScoredAmount, len(p.amounts)),
+		Warnings:    extractionWarnings(msg, p, tx),
+	}
+	for i, c := range p.amounts {
+		e.Amounts[i] = ScoredAmount{Amount: c.value, Currency: c.currency, Raw: c.raw, Score: c.score, Reasons: c.reasons}
+	}
+	return e
+}
+
+// matchedBy returns what matched msg to its service: the email domain found
+// in its sender or the keyword found in it
+func matchedBy(msg *models.Message, p *parsed) string {
+	if p.byDomain {
+		sender := strings.ToLower(msg.From)
+		for _, domain := range p.service.EmailDomains {
+			if strings.Contains(sender, strings.ToLower(domain)) {
+				return fmt.Sprintf("sender %s", domain)
+			}
+		}
+		return fmt.Sprintf("sender domain known to the %s parser", p.parser)
+	}
+	text := strings.ToLower(msg.Body + " " + msg.Subject)
+	for _, keyword := range p.service.Keywords {
+		if strings.Contains(text, strings.ToLower(keyword)) {
+			return fmt.Sprintf("keyword %q", keyword)
+		}
+	}
+	return ""
+}
@@ -2,15 +2,32 @@ package extractor
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"log"
+	"io/fs"
+	"math"
+	"os"
 	"regexp"
-	"strconv"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/sazardev/go-money/internal/apperrors"
 	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/pkg/utils"
+)
+
+// Confidence contributed by each part of an extraction. A transaction's
+// Confidence is the sum for its service match, amount and date, from 0.4
+// to 1.
+const (
+	confidenceDomain  = 0.4  // The sender is one of the service's email domains
+	confidenceKeyword = 0.2  // Only a keyword of the service appears in the email
+	confidenceLabeled = 0.4  // The amount is labeled as a total or found by a merchant-specific strategy
+	confidenceTagged  = 0.25 // The amount has a currency or two decimals
+	confidenceGuessed = 0.1  // The amount is just the largest number in the email
+	confidenceDated   = 0.2  // The date was found in the email
+	confidenceUndated = 0.1  // The date is when the email was sent
 )
 
 type ServiceTracker struct {
@@ -25,11 +42,35 @@ type Service struct {
 	TransactionTypes []string           `json:"transactionTypes"`
 	Keywords         []string           `json:"keywords"`
 	PricePattern     PricePatternConfig `json:"pricePattern"`
+	Extraction       []StrategyConfig   `json:"extraction,omitempty"`
+	RefundKeywords   []string           `json:"refundKeywords,omitempty"` // Subject words of refund emails; nil uses defaultRefundKeywords
+
+	strategies []strategy
+	patterns   servicePatterns
+	source     string
 }
 
+// SourceBuiltIn is the Source of services from the built-in catalog
+const SourceBuiltIn = "built-in"
+
+// Source returns where the service is defined: SourceBuiltIn,
+// SourceCatalog or the path of the user's tracker file
+func (s Service) Source() string {
+	return s.source
+}
+
+// PricePatternConfig holds what a service's receipts look like. The amount,
+// date and order regexes take precedence over the generic heuristics: each
+// reads capture group 1, or the whole match when there is no group, and the
+// heuristics are used when it doesn't match.
 type PricePatternConfig struct {
-	Currency string   `json:"currency"`
-	Fields   []string `json:"fields"`
+	Currency   string   `json:"currency"` // Currency of amounts without a symbol or code
+	Fields     []string `json:"fields"`   // Labels of the amount lines on the receipt, for reference
+	Amount     string   `json:"amount,omitempty"`
+	Date       string   `json:"date,omitempty"`
+	DateLayout string   `json:"dateLayout,omitempty"` // Go layout of what date matches, such as 02/01/2006; common ones are tried when empty
+	Order      string   `json:"order,omitempty"`
+	Locale     string   `json:"locale,omitempty"` // Locale amounts are written in, such as de-DE for 1.234,56; told apart by the separators when empty
 }
 
 // TransactionExtractor handles extraction of transactions from emails
@@ -49,88 +90,177 @@ func NewTransactionExtractor() (*TransactionExtractor, error) {
 	}, nil
 }
 
-// loadServiceTracker loads the service configuration from tracker-mails.json
+// loadServiceTracker loads the built-in service catalog and merges the
+// installed community catalog, then the user's tracker-mails.json, over it:
+// services with the ID of an earlier one replace it and the others are
+// added. The file only has to exist when its path was given with --tracker
+// or GM_TRACKER.
 func loadServiceTracker() (*ServiceTracker, error) {
-	data, err := ioutil.ReadFile("tracker-mails.json")
+	services, err := parseServices(defaultTracker)
 	if err != nil {
-		log.Fatalf("Failed to load tracker-mails.json: %v", err)
-		return nil, err
+		return nil, fmt.Errorf("%w: failed to parse the built-in tracker-mails.json: %w", apperrors.ErrTrackerConfig, err)
+	}
+	for i := range services {
+		services[i].source = SourceBuiltIn
 	}
 
-	var trackerData struct {
-		Services []Service `json:"services"`
+	catalog, err := LoadCatalog()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to load %s: %w; run gm services update again", apperrors.ErrTrackerConfig, CatalogPath(), err)
+	}
+	if catalog != nil {
+		for _, service := range catalog.Services {
+			service.source = SourceCatalog
+			services = append(services, service)
+		}
 	}
 
-	if err := json.Unmarshal(data, &trackerData); err != nil {
-		return nil, err
+	path := TrackerPath()
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist) && !trackerGiven():
+	case err != nil:
+		return nil, fmt.Errorf("%w: failed to load %s: %w", apperrors.ErrTrackerConfig, path, err)
+	default:
+		user, err := parseServices(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to parse %s: %w", apperrors.ErrTrackerConfig, path, err)
+		}
+		for i := range user {
+			user[i].source = path
+		}
+		services = append(services, user...)
 	}
 
-	// Convert to map
+	// Later services replace earlier ones with the same ID
 	tracker := &ServiceTracker{
 		Services: make(map[string]Service),
 	}
-	for _, service := range trackerData.Services {
+	for _, service := range services {
+		strategies, err := compileStrategies(service)
+		if err != nil {
+			return nil, err
+		}
+		patterns, err := compilePatterns(service)
+		if err != nil {
+			return nil, err
+		}
+		service.strategies, service.patterns = strategies, patterns
 		tracker.Services[service.ID] = service
 	}
 
 	return tracker, nil
 }
 
-// ExtractTransactions extracts transactions from messages
+// parseServices returns the services of a tracker file
+func parseServices(data []byte) ([]Service, error) {
+	var trackerData struct {
+		Services []Service `json:"services"`
+	}
+	if err := json.Unmarshal(data, &trackerData); err != nil {
+		return nil, err
+	}
+	return trackerData.Services, nil
+}
+
+// ExtractTransactions extracts transactions from messages, keeping a single
+// transaction per order, per email thread and per charge with the status of
+// its latest email. Tips and updated totals amend the charge they adjust.
 func (te *TransactionExtractor) ExtractTransactions(messages []*models.Message) []*models.Transaction {
 	var transactions []*models.Transaction
+	explicitTotals := make(map[*models.Transaction]bool)
 
 	for _, msg := range messages {
-		if txn := te.extractTransactionFromMessage(msg); txn != nil {
+		if txn, explicitTotal := te.extractTransactionFromMessage(msg); txn != nil {
 			transactions = append(transactions, txn)
+			explicitTotals[txn] = explicitTotal
 		}
 	}
 
+	transactions = applyAdjustments(transactions)
+	transactions = suppressOrderDuplicates(transactions)
+	transactions = suppressThreadDuplicates(transactions, explicitTotals)
+	transactions = suppressChargeDuplicates(transactions, explicitTotals)
+	markRefunded(transactions)
 	return transactions
 }
 
-// extractTransactionFromMessage extracts transaction from a single message
-func (te *TransactionExtractor) extractTransactionFromMessage(msg *models.Message) *models.Transaction {
-	// Check email domain
-	service := te.matchService(msg)
-	if service == nil {
-		return nil
+// extractTransactionFromMessage extracts transaction from a single message.
+// The boolean reports whether the amount came from an explicit total label.
+func (te *TransactionExtractor) extractTransactionFromMessage(msg *models.Message) (*models.Transaction, bool) {
+	p := te.parse(msg)
+	if p == nil {
+		return nil, false
 	}
+	return te.transaction(msg, p), p.amounts[0].labeled
+}
 
-	// Extract amount and currency
-	amount, currency, currencySymbol, rawAmount := te.extractAmountWithCurrency(msg.Body)
-	if amount <= 0 {
-		return nil
+// transaction builds the transaction of a parsed message from its
+// best-scored amount
+func (te *TransactionExtractor) transaction(msg *models.Message, p *parsed) *models.Transaction {
+	service, amount, doc := p.service, p.amounts[0], p.doc
+	confidence := confidenceKeyword
+	if p.byDomain {
+		confidence = confidenceDomain
 	}
 
-	// Try to extract transaction date from email body
-	txDate := te.extractTransactionDate(msg.Body, msg.Subject)
+	// Try the service's date pattern, then the dates in the email body
+	txDate := patternDate(service, doc.text, msg.Subject)
+	if txDate.IsZero() {
+		txDate = te.extractTransactionDate(doc.text, msg.Subject)
+	}
 	if txDate.IsZero() {
 		txDate = msg.Date
+		confidence += confidenceUndated
+	} else {
+		confidence += confidenceDated
+	}
+	confidence += amount.confidence()
+
+	// Refunds are negative so they reduce the net spend, and settle the
+	// money they give back whatever their subject says about the charge
+	value, status, adjusts := amount.value, emailStatus(msg.Subject), adjustment(msg.Subject)
+	tax := taxAmount(doc.text, value, numberFormatOf(service))
+	if isRefund(service, msg) {
+		value, status, adjusts, tax = -value, models.StatusSettled, "", -tax
+	}
+
+	order := patternOrder(service, msg.Subject, doc.text)
+	if order == "" {
+		order = orderID(msg.Subject, doc.text)
 	}
 
 	// Create transaction
-	txn := &models.Transaction{
+	return &models.Transaction{
 		ID:             msg.ID,
+		ThreadID:       msg.ThreadID,
 		ServiceID:      service.ID,
 		ServiceName:    service.Name,
 		Category:       service.Category,
-		Amount:         amount,
-		Currency:       currency,
-		CurrencySymbol: currencySymbol,
+		Amount:         value,
+		Currency:       amount.currency,
+		CurrencySymbol: currencyByCode[amount.currency].symbol,
 		Date:           txDate,
 		Description:    msg.Subject,
 		Email:          msg.From,
+		Account:        strings.ToLower(utils.ExtractEmail(msg.To)),
 		Subject:        msg.Subject,
 		Timestamp:      time.Now(),
-		RawAmount:      rawAmount,
+		RawAmount:      amount.raw,
+		DueDate:        extractDueDate(doc.text),
+		Confidence:     math.Round(confidence*100) / 100,
+		OrderID:        order,
+		Partial:        order != "" && isShipment(msg.Subject),
+		Status:         status,
+		Adjustment:     adjusts,
+		InvoiceNumber:  invoiceNumber(msg.Subject, doc.text),
+		Tax:            tax,
 	}
-
-	return txn
 }
 
-// matchService finds the matching service for a message
-func (te *TransactionExtractor) matchService(msg *models.Message) *Service {
+// matchService finds the matching service for a message and reports
+// whether it matched by email domain rather than by keyword
+func (te *TransactionExtractor) matchService(msg *models.Message) (*Service, bool) {
 	sender := strings.ToLower(msg.From)
 	body := strings.ToLower(msg.Body + " " + msg.Subject)
 
@@ -139,7 +269,7 @@ func (te *TransactionExtractor) matchService(msg *models.Message) *Service {
 		for _, domain := range service.EmailDomains {
 			if strings.Contains(sender, strings.ToLower(domain)) {
 				// Found match by email domain
-				return &service
+				return &service, true
 			}
 		}
 	}
@@ -149,217 +279,57 @@ func (te *TransactionExtractor) matchService(msg *models.Message) *Service {
 		for _, keyword := range service.Keywords {
 			if strings.Contains(body, strings.ToLower(keyword)) {
 				// Found match by keyword
-				return &service
+				return &service, false
 			}
 		}
 	}
 
-	return nil
+	return nil, false
 }
 
-// extractAmountWithCurrency extracts amount AND currency from text
-func (te *TransactionExtractor) extractAmountWithCurrency(text string) (float64, string, string, string) {
-	if text == "" {
-		return 0, "USD", "$", ""
-	}
-
-	// Pattern: match currency codes and symbols with amounts
-	currencyPatterns := []struct {
-		pattern  string // regex pattern
-		currency string // currency code
-		symbol   string // currency symbol
-	}{
-		{`(\$|\$\s*)[\s]*(\d[\d,]*\.?\d{0,2})\s*(USD)?`, "USD", "$"},
-		{`(\d[\d,]*\.?\d{0,2})\s*(USD)`, "USD", "$"},
-		{`(MXN|M\$|MEX|\$\s*M)\s*(\d[\d,]*\.?\d{0,2})`, "MXN", "$"},
-		{`(\d[\d,]*\.?\d{0,2})\s*(MXN|M\$|MEX)`, "MXN", "$"},
-		{`(€)\s*(\d[\d,]*\.?\d{0,2})`, "EUR", "€"},
-		{`(\d[\d,]*\.?\d{0,2})\s*(EUR|€)`, "EUR", "€"},
-		{`(£)\s*(\d[\d,]*\.?\d{0,2})`, "GBP", "£"},
-		{`(\d[\d,]*\.?\d{0,2})\s*(GBP|£)`, "GBP", "£"},
-		{`(¥|JPY)\s*(\d[\d,]*\.?\d{0,2})`, "JPY", "¥"},
-		{`(\d[\d,]*\.?\d{0,2})\s*(JPY|¥)`, "JPY", "¥"},
-		{`(CAD|\$\s*C)\s*(\d[\d,]*\.?\d{0,2})`, "CAD", "$"},
-		{`(\d[\d,]*\.?\d{0,2})\s*(CAD)`, "CAD", "$"},
-	}
-
-	// Try each currency pattern
-	for _, cp := range currencyPatterns {
-		re := regexp.MustCompile("(?i)" + cp.pattern)
-		matches := re.FindAllStringSubmatch(text, -1)
-
-		var maxAmount float64
-		var rawAmount string
-
-		for _, match := range matches {
-			var amountStr string
-
-			// Extract the numeric part (usually second or third group depending on pattern)
-			if len(match) >= 3 {
-				// Try the last group (usually the number)
-				for i := len(match) - 1; i >= 1; i-- {
-					if match[i] != "" && !strings.ContainsAny(match[i], "$€£¥") {
-						if num, err := strconv.Atoi(strings.ReplaceAll(match[i], ",", "")); err == nil && num > 0 {
-							amountStr = match[i]
-							break
-						}
-					}
-				}
-			}
-
-			// Remove commas and parse
-			amountStr = strings.ReplaceAll(amountStr, ",", "")
-			if amount, err := strconv.ParseFloat(amountStr, 64); err == nil {
-				if amount > maxAmount && amount < 1000000 { // Sanity check
-					maxAmount = amount
-					rawAmount = match[0]
-				}
-			}
-		}
-
-		if maxAmount > 0 {
-			return maxAmount, cp.currency, cp.symbol, rawAmount
-		}
-	}
-
-	// Fallback: use extractAmount without currency detection, default to USD
-	amount := te.extractAmount(text)
-	if amount > 0 {
-		return amount, "USD", "$", ""
-	}
-
-	return 0, "USD", "$", ""
+// parsed is how a message was read: the parser and service that matched it,
+// its amount candidates, best first, and the prepared document
+type parsed struct {
+	parser   string
+	service  *Service
+	byDomain bool
+	amounts  []amountCandidate
+	doc      *document
 }
 
-// extractAmount extracts the amount from text (fallback function)
-func (te *TransactionExtractor) extractAmount(text string) float64 {
-	if text == "" {
-		return 0
-	}
-
-	// Common patterns for monetary amounts
-	patterns := []string{
-		// Dollar amounts: $123.45
-		`\$\s*([\d,]+\.?\d{0,2})`,
-		// USD amounts: USD 123.45
-		`USD\s+([\d,]+\.?\d{0,2})`,
-		// Total field: Total: $123.45 or Total: 123.45
-		`(?i)total\s*:?\s*\$?\s*([\d,]+\.?\d{0,2})`,
-		// Amount field: Amount: $123.45
-		`(?i)amount\s*:?\s*\$?\s*([\d,]+\.?\d{0,2})`,
-		// Charge field: Charge: $123.45
-		`(?i)charge\s*:?\s*\$?\s*([\d,]+\.?\d{0,2})`,
-		// Price field: Price: $123.45
-		`(?i)price\s*:?\s*\$?\s*([\d,]+\.?\d{0,2})`,
-		// Generic number pattern with currency symbol
-		`[\$£€]\s*([\d,]+\.?\d{0,2})`,
-		// Generic number at end of likely currency string
-		`[\d,]+\.\d{2}\s*(USD|EUR|GBP)`,
-	}
-
-	// Try each pattern
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		matches := re.FindAllStringSubmatch(text, -1)
-
-		// Get the largest match (in case of multiple amounts, pick the biggest one)
-		var maxAmount float64
-		for _, match := range matches {
-			if len(match) >= 2 {
-				// Extract the number group
-				amountStr := match[1]
-				// Remove commas
-				amountStr = strings.ReplaceAll(amountStr, ",", "")
-
-				if amount, err := strconv.ParseFloat(amountStr, 64); err == nil {
-					if amount > maxAmount {
-						maxAmount = amount
-					}
-				}
-			} else if len(match) >= 1 {
-				// Try parsing the whole match
-				amountStr := match[0]
-				// Remove currency symbols
-				amountStr = strings.TrimPrefix(amountStr, "$")
-				amountStr = strings.TrimPrefix(amountStr, "£")
-				amountStr = strings.TrimPrefix(amountStr, "€")
-				amountStr = strings.TrimSpace(amountStr)
-				amountStr = strings.ReplaceAll(amountStr, ",", "")
-
-				if amount, err := strconv.ParseFloat(amountStr, 64); err == nil {
-					if amount > maxAmount {
-						maxAmount = amount
-					}
-				}
-			}
+// parse offers msg to the registered parsers, highest priority first, and
+// returns what the first that handles it found, or nil when none does
+func (te *TransactionExtractor) parse(msg *models.Message) *parsed {
+	var doc *document
+	for _, p := range parsers {
+		service, byDomain := p.parser.match(te, msg)
+		if service == nil {
+			continue
 		}
 
-		if maxAmount > 0 {
-			return maxAmount
+		if doc == nil {
+			// Convert HTML receipts to text once so tables keep label → value adjacency
+			doc = newDocument(msg.Body, msg.HTMLBody)
+			doc.addAttachments(msg.Attachments)
 		}
-	}
-
-	// Fallback: find any number that looks like a price
-	// Match any number with 2 decimal places
-	re := regexp.MustCompile(`\d+\.\d{2}`)
-	matches := re.FindAllString(text, -1)
-	if len(matches) > 0 {
-		// Get the last match (often amounts are listed last in receipts)
-		for i := len(matches) - 1; i >= 0; i-- {
-			if amount, err := strconv.ParseFloat(matches[i], 64); err == nil && amount > 0 {
-				return amount
-			}
+		if doc.text == "" {
+			break
 		}
-	}
 
-	// Last resort: find largest number
-	re = regexp.MustCompile(`[\d,]+\.?\d{0,2}`)
-	matches = re.FindAllString(text, -1)
-	if len(matches) > 0 {
-		var maxAmount float64
-		for _, match := range matches {
-			match = strings.ReplaceAll(match, ",", "")
-			if amount, err := strconv.ParseFloat(match, 64); err == nil {
-				if amount > maxAmount && amount < 1000000 { // Sanity check
-					maxAmount = amount
-				}
-			}
+		amounts := rankAmounts(localized(patternAmounts(doc, service), service))
+		if len(amounts) == 0 {
+			amounts = rankAmounts(localized(p.parser.extract(doc, service), service))
 		}
-		if maxAmount > 0 {
-			return maxAmount
+		if len(amounts) > 0 {
+			return &parsed{parser: p.name, service: service, byDomain: byDomain, amounts: amounts, doc: doc}
 		}
 	}
-
-	return 0
-}
-
-// cleanHTMLTags removes HTML tags and common HTML entities from text
-func (te *TransactionExtractor) cleanHTMLTags(text string) string {
-	// Remove HTML tags
-	reHtmlTag := regexp.MustCompile("<[^>]*>")
-	text = reHtmlTag.ReplaceAllString(text, " ")
-
-	// Remove common HTML entities
-	text = strings.ReplaceAll(text, "&nbsp;", " ")
-	text = strings.ReplaceAll(text, "&amp;", "&")
-	text = strings.ReplaceAll(text, "&lt;", "<")
-	text = strings.ReplaceAll(text, "&gt;", ">")
-	text = strings.ReplaceAll(text, "&quot;", "\"")
-	text = strings.ReplaceAll(text, "&#39;", "'")
-	text = strings.ReplaceAll(text, "&apos;", "'")
-
-	// Collapse multiple spaces
-	text = regexp.MustCompile(`\s+`).ReplaceAllString(text, " ")
-	text = strings.TrimSpace(text)
-
-	return text
+	return nil
 }
 
-// extractTransactionDate tries to extract the transaction date from email body and subject
-func (te *TransactionExtractor) extractTransactionDate(body, subject string) time.Time {
-	// Clean HTML from body
-	cleanBody := te.cleanHTMLTags(body)
-	fullText := cleanBody + " " + subject
+// extractTransactionDate tries to extract the transaction date from the email text and subject
+func (te *TransactionExtractor) extractTransactionDate(text, subject string) time.Time {
+	fullText := text + " " + subject
 	fullText = strings.ToLower(fullText)
 
 	// Try exact date patterns first (YYYY-MM-DD, MM/DD/YYYY, etc.)
@@ -430,11 +400,28 @@ func (te *TransactionExtractor) GetServiceByID(id string) *Service {
 	return nil
 }
 
-// GetAllServices returns all services
+// GetAllServices returns all services, sorted by ID
 func (te *TransactionExtractor) GetAllServices() []Service {
 	var services []Service
 	for _, service := range te.tracker.Services {
 		services = append(services, service)
 	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].ID < services[j].ID
+	})
 	return services
 }
+
+// GetCategories returns the distinct categories of all services, sorted
+func (te *TransactionExtractor) GetCategories() []string {
+	seen := make(map[string]bool)
+	var categories []string
+	for _, service := range te.tracker.Services {
+		if !seen[service.Category] {
+			seen[service.Category] = true
+			categories = append(categories, service.Category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
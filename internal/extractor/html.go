@@ -0,0 +1,136 @@
+package extractor
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// cellSeparator joins adjacent table cells so label → value pairs such as
+// "Total | $23.10" stay on one line
+const cellSeparator = " | "
+
+// blockElements start a new line in the text output
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"br": true, "caption": true, "center": true, "details": true, "div": true,
+	"dl": true, "dt": true, "dd": true, "fieldset": true, "figcaption": true,
+	"figure": true, "footer": true, "form": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "header": true, "hr": true,
+	"legend": true, "li": true, "main": true, "nav": true, "ol": true, "p": true,
+	"pre": true, "section": true, "summary": true, "table": true, "tbody": true,
+	"thead": true, "tfoot": true, "tr": true, "ul": true,
+}
+
+// skippedElements never contribute visible text
+var skippedElements = map[string]bool{
+	"head":     true,
+	"noscript": true,
+	"script":   true,
+	"style":    true,
+	"svg":      true,
+	"template": true,
+}
+
+var (
+	htmlWhitespace = regexp.MustCompile(`[\s\x{00a0}\x{2007}\x{202f}]+`)
+	repeatedCells  = regexp.MustCompile(`(\s*\|\s*)+`)
+	hiddenStyle    = regexp.MustCompile(`(?i)display\s*:\s*none|visibility\s*:\s*hidden`)
+)
+
+// invisibleChars are zero-width characters emails use to pad preview text
+// or break up numbers
+var invisibleChars = strings.NewReplacer("\u200b", "", "\u200c", "", "\u200d", "", "\u2060", "", "\ufeff", "", "\u00ad", "")
+
+// PlainText returns the text of an email body as extraction reads it, with
+// HTML converted to lines of text
+func PlainText(body string) string {
+	return htmlToText(body)
+}
+
+// htmlToText converts an HTML email body to plain text, one block per line,
+// with table cells of the same row joined by cellSeparator. Scripts, styles
+// and hidden elements are dropped and entities decoded. Plain-text bodies
+// only have their entities decoded.
+func htmlToText(body string) string {
+	if !strings.Contains(body, "<") {
+		return html.UnescapeString(body)
+	}
+
+	doc, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		return body
+	}
+
+	var sb strings.Builder
+	writeNodeText(&sb, doc)
+
+	// Tidy up lines: empty spacer cells leave runs of separators behind
+	var lines []string
+	for _, line := range strings.Split(sb.String(), "\n") {
+		line = repeatedCells.ReplaceAllString(line, cellSeparator)
+		line = strings.Trim(strings.TrimSpace(line), "|")
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// writeNodeText appends the visible text of n and its children to sb
+func writeNodeText(sb *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		sb.WriteString(htmlWhitespace.ReplaceAllString(invisibleChars.Replace(n.Data), " "))
+		return
+	case html.ElementNode:
+		if skippedElements[n.Data] || hidden(n) {
+			return
+		}
+		if (n.Data == "td" || n.Data == "th") && previousCell(n) != nil {
+			sb.WriteString(cellSeparator)
+		}
+		if blockElements[n.Data] {
+			sb.WriteString("\n")
+		}
+	case html.CommentNode, html.DoctypeNode:
+		return
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeNodeText(sb, c)
+	}
+
+	if n.Type == html.ElementNode && blockElements[n.Data] {
+		sb.WriteString("\n")
+	}
+}
+
+// previousCell returns the table cell before n in the same row, if any
+func previousCell(n *html.Node) *html.Node {
+	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+		if s.Type == html.ElementNode && (s.Data == "td" || s.Data == "th") {
+			return s
+		}
+	}
+	return nil
+}
+
+// hidden reports whether an element isn't displayed, like the preview
+// text many receipts hide at the top
+func hidden(n *html.Node) bool {
+	for _, attr := range n.Attr {
+		switch strings.ToLower(attr.Key) {
+		case "hidden":
+			return true
+		case "style":
+			if hiddenStyle.MatchString(attr.Val) {
+				return true
+			}
+		}
+	}
+	return false
+}
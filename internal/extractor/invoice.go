@@ -0,0 +1,56 @@
+package extractor
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// invoicePattern matches an invoice or receipt number; like order numbers
+// it must hold a digit, so "Receipt from" isn't read as receipt "from"
+var invoicePattern = regexp.MustCompile(`(?i)\b(?:invoice|factura|receipt|recibo|folio)\s*(?:number|n[uú]mero|no\.?|id)?\s*[:#]?\s*([A-Z0-9][A-Z0-9/-]{3,})`)
+
+// taxPattern matches a tax line, such as "Tax: $1.60", "VAT (20%) £2.00" or
+// an "IVA | $16.00" table row. The label must start the line or cell, so
+// "Total before tax: $20.00" isn't one.
+var taxPattern = regexp.MustCompile(`(?im)(?:^|\|)\s*(?:estimated\s+|sales\s+)?(?:tax|taxes|vat|iva|gst|hst|impuestos?)\b[^\n:|$€£¥\d]{0,12}(?:\(?\d+(?:\.\d+)?\s*%\)?)?\s*[:|]?\s*(?:[A-Z]{3}\s*)?(?:M\$|C\$|[$€£¥])?\s*(\d(?:[\d.,']*\d)?)`)
+
+// invoiceNumber returns the invoice or receipt number of an email,
+// searched in the subject first, or "" when it has none
+func invoiceNumber(subject, text string) string {
+	for _, s := range []string{subject, text} {
+		for _, match := range invoicePattern.FindAllStringSubmatch(s, -1) {
+			if strings.ContainsAny(match[1], "0123456789") {
+				return strings.ToUpper(strings.TrimRight(match[1], "-/"))
+			}
+		}
+	}
+	return ""
+}
+
+// taxAmount returns the tax on the first tax line of an email, or 0 when it
+// has none, read in the number format of the receipt. A tax that isn't less
+// than the total it is part of is a misread.
+func taxAmount(text string, total float64, format numberFormat) float64 {
+	match := taxPattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0
+	}
+	tax, _, ok := parseNumber(match[1], format)
+	if !ok || tax <= 0 || tax >= total {
+		return 0
+	}
+	return tax
+}
+
+// MergeInvoice fills in the invoice number and tax of kept from tx, another
+// email about its charge, when its own emails didn't show them
+func MergeInvoice(kept, tx *models.Transaction) {
+	if kept.InvoiceNumber == "" {
+		kept.InvoiceNumber = tx.InvoiceNumber
+	}
+	if kept.Tax == 0 && tx.Tax != 0 && (tx.Tax < 0) == (kept.Amount < 0) {
+		kept.Tax = tx.Tax
+	}
+}
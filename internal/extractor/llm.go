@@ -0,0 +1,160 @@
+package extractor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/sazardev/go-money/internal/cache"
+	"github.com/sazardev/go-money/internal/config"
+	"github.com/sazardev/go-money/internal/paths"
+)
+
+const (
+	// llmTimeout bounds a single completion request
+	llmTimeout = 30 * time.Second
+	// llmMaxInput caps how much of a message is sent to the model
+	llmMaxInput = 8000
+	// llmDefaultModel is used when GM_LLM_MODEL is not set
+	llmDefaultModel = "gpt-4o-mini"
+	// llmCacheTTL is how long replies are reused; receipts don't change
+	llmCacheTTL = 30 * 24 * time.Hour
+	// llmCacheNamespace groups replies in the shared lookup cache
+	llmCacheNamespace = "llm"
+)
+
+const llmPrompt = `You read purchase receipts. Reply with only the total amount charged ` +
+	`followed by its ISO 4217 currency code, for example "23.10 USD". ` +
+	`Reply NONE if the email is not a receipt.`
+
+// llmStrategy asks an OpenAI-compatible chat completions endpoint for the
+// total. It is skipped unless GM_LLM_URL is set, since it sends email text
+// to that service.
+type llmStrategy struct {
+	url    string
+	model  string
+	apiKey string
+	client *http.Client
+	cache  *cache.Cache
+}
+
+func newLLMStrategy() llmStrategy {
+	cfg := config.LoadConfig()
+	model := cfg.LLMModel
+	if model == "" {
+		model = llmDefaultModel
+	}
+
+	return llmStrategy{
+		url:    cfg.LLMURL,
+		model:  model,
+		apiKey: cfg.LLMAPIKey,
+		client: &http.Client{Timeout: llmTimeout},
+		cache:  cache.New(paths.Cache()),
+	}
+}
+
+type llmMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type llmRequest struct {
+	Model       string       `json:"model"`
+	Messages    []llmMessage `json:"messages"`
+	Temperature float64      `json:"temperature"`
+}
+
+type llmResponse struct {
+	Choices []struct {
+		Message llmMessage `json:"message"`
+	} `json:"choices"`
+}
+
+func (s llmStrategy) extract(doc *document, service *Service) []amountCandidate {
+	if s.url == "" {
+		return nil
+	}
+
+	reply, err := s.cachedComplete(truncateInput(doc.text))
+	if err != nil {
+		log.Printf("⚠️  Warning: llm extraction failed for %s: %v", service.Name, err)
+		return nil
+	}
+
+	return targeted(scanAmounts(reply), service)
+}
+
+// cachedComplete returns the model's earlier reply to the same text, if
+// any, so re-processing an email doesn't pay for another completion
+func (s llmStrategy) cachedComplete(text string) (string, error) {
+	key := s.url + "\n" + s.model + "\n" + text
+
+	var reply string
+	if found, fresh, err := s.cache.Get(llmCacheNamespace, key, &reply); err == nil && found && fresh {
+		return reply, nil
+	}
+
+	reply, err := s.complete(text)
+	if err != nil {
+		return "", err
+	}
+	if err := s.cache.Set(llmCacheNamespace, key, reply, llmCacheTTL); err != nil {
+		log.Printf("⚠️  Warning: could not cache llm reply: %v", err)
+	}
+	return reply, nil
+}
+
+// complete sends text to the model and returns its reply
+func (s llmStrategy) complete(text string) (string, error) {
+	payload, err := json.Marshal(llmRequest{
+		Model: s.model,
+		Messages: []llmMessage{
+			{Role: "system", Content: llmPrompt},
+			{Role: "user", Content: text},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var out llmResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", err
+	}
+	if len(out.Choices) == 0 {
+		return "", fmt.Errorf("empty response")
+	}
+
+	return out.Choices[0].Message.Content, nil
+}
+
+// truncateInput keeps requests small; totals are rarely past the first few KB
+func truncateInput(text string) string {
+	if len(text) <= llmMaxInput {
+		return text
+	}
+	return text[:llmMaxInput]
+}
@@ -0,0 +1,27 @@
+package extractor
+
+import (
+	"cmp"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+func init() {
+	registerParser("airlines", priorityIndustry, merchantParser{
+		domains: []string{
+			"aa.com", "united.com", "delta.com", "southwest.com", "jetblue.com", "alaskaair.com",
+			"aircanada.com", "westjet.com", "aeromexico.com", "volaris.com", "vivaaerobus.com",
+			"latam.com", "avianca.com", "copaair.com", "britishairways.com", "ba.com",
+			"lufthansa.com", "airfrance.fr", "klm.com", "iberia.com", "ryanair.com", "easyjet.com",
+			"emirates.com", "qatarairways.com", "turkishairlines.com", "qantas.com.au", "singaporeair.com",
+		},
+		// Booking confirmations list the fare, taxes and fees before the total
+		labels: totalLabels("total price", "total fare", "total charged", "total paid", "amount paid", "grand total", "total"),
+		service: func(te *TransactionExtractor, msg *models.Message) *Service {
+			service := trackedService(te, Service{ID: "airlines", Name: "Airline", Category: "Travel & Accommodation"})
+			// Name the transaction after the airline that sent it
+			service.Name = cmp.Or(senderName(msg), service.Name)
+			return service
+		},
+	})
+}
@@ -0,0 +1,25 @@
+package extractor
+
+import (
+	"strings"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+func init() {
+	registerParser("amazon", priorityMerchant, merchantParser{
+		domains: []string{
+			"amazon.com", "amazon.ca", "amazon.com.mx", "amazon.com.br", "amazon.co.uk",
+			"amazon.de", "amazon.es", "amazon.fr", "amazon.it", "amazon.in", "amazon.co.jp",
+		},
+		// Order confirmations end with "Order Total: $12.34"; item prices come
+		// before it. Shipment emails charge their "Shipment Total" only.
+		labels: totalLabels("shipment total", "order total", "grand total", "total for this order"),
+		service: func(te *TransactionExtractor, msg *models.Message) *Service {
+			if strings.Contains(strings.ToLower(msg.Subject), "prime") {
+				return trackedService(te, Service{ID: "amazonprime", Name: "Amazon Prime", Category: "Subscription"})
+			}
+			return trackedService(te, Service{ID: "amazon", Name: "Amazon", Category: "E-commerce"})
+		},
+	})
+}
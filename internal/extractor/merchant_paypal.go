@@ -0,0 +1,17 @@
+package extractor
+
+import (
+	"github.com/sazardev/go-money/internal/models"
+)
+
+func init() {
+	registerParser("paypal", priorityMerchant, merchantParser{
+		domains: []string{"paypal.com"},
+		// "You sent $25.00 USD to ..." and "You paid $25.00 USD" come before
+		// the summary table, whose "Total" is the fallback
+		labels: totalLabels("you sent", "you paid", "total"),
+		service: func(te *TransactionExtractor, msg *models.Message) *Service {
+			return trackedService(te, Service{ID: "paypal", Name: "PayPal", Category: "Financial Services"})
+		},
+	})
+}
@@ -0,0 +1,22 @@
+package extractor
+
+import (
+	"cmp"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+func init() {
+	registerParser("stripe", priorityMerchant, merchantParser{
+		domains: []string{"stripe.com"},
+		// Stripe sends receipts on behalf of many businesses, all with the
+		// same layout: "Amount paid $12.00" above the itemized summary
+		labels: totalLabels("amount paid", "amount charged", "total"),
+		service: func(te *TransactionExtractor, msg *models.Message) *Service {
+			service := trackedService(te, Service{ID: "stripe", Name: "Stripe", Category: "Financial Services"})
+			// The sender's name is the business that was paid
+			service.Name = cmp.Or(senderName(msg), service.Name)
+			return service
+		},
+	})
+}
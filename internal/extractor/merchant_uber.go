@@ -0,0 +1,22 @@
+package extractor
+
+import (
+	"strings"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+func init() {
+	registerParser("uber", priorityMerchant, merchantParser{
+		domains: []string{"uber.com"},
+		// Trip and order receipts show the fare breakdown, then "Total $23.45"
+		labels: totalLabels("total", "amount charged"),
+		service: func(te *TransactionExtractor, msg *models.Message) *Service {
+			// Rides and Uber Eats orders come from the same addresses
+			if strings.Contains(strings.ToLower(msg.Subject+" "+msg.Body), "uber eats") {
+				return trackedService(te, Service{ID: "ubereats", Name: "Uber Eats", Category: "Food Delivery"})
+			}
+			return trackedService(te, Service{ID: "uber", Name: "Uber", Category: "Transportation"})
+		},
+	})
+}
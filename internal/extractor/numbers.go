@@ -0,0 +1,181 @@
+package extractor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// numberFormat is which separator a receipt writes decimals after
+type numberFormat int
+
+const (
+	formatAuto         numberFormat = iota // Told apart by the separators, see parseNumber
+	formatDecimalPoint                     // 1,234.56
+	formatDecimalComma                     // 1.234,56
+)
+
+// Languages of locales by the separator their numbers use for decimals
+var (
+	decimalPointLanguages = []string{"en", "ja", "zh", "ko", "hi", "th", "he", "ms", "fil", "tl", "ga", "mt"}
+	decimalCommaLanguages = []string{
+		"de", "es", "fr", "it", "pt", "nl", "ru", "pl", "tr", "sv", "da", "nb", "nn", "no", "fi", "cs",
+		"sk", "hu", "ro", "id", "vi", "el", "uk", "ca", "hr", "sl", "bg", "lt", "lv", "et", "sr", "is",
+	}
+)
+
+// decimalPointRegions write a decimal point though their language usually
+// writes a comma, such as Mexico and Switzerland
+var decimalPointRegions = []string{"MX", "US", "PR", "GT", "HN", "NI", "SV", "PA", "DO", "PE", "CH", "LI"}
+
+// ValidLocale reports whether the number format of locale, such as "de-DE"
+// or "pt_BR", is known
+func ValidLocale(locale string) bool {
+	_, err := localeFormat(locale)
+	return err == nil
+}
+
+// localeFormat returns the number format of locale; an empty one is told
+// apart by the separators
+func localeFormat(locale string) (numberFormat, error) {
+	if locale == "" {
+		return formatAuto, nil
+	}
+	language, region, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	language, region = strings.ToLower(language), strings.ToUpper(region)
+	switch {
+	case containsString(decimalPointLanguages, language):
+		return formatDecimalPoint, nil
+	case containsString(decimalCommaLanguages, language) && containsString(decimalPointRegions, region):
+		return formatDecimalPoint, nil
+	case containsString(decimalCommaLanguages, language):
+		return formatDecimalComma, nil
+	}
+	return formatAuto, fmt.Errorf("unknown locale %q: use a language and region such as de-DE or en-US", locale)
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// isSeparator reports whether b can group or end the digits of a number
+func isSeparator(b byte) bool {
+	return b == '.' || b == ',' || b == '\''
+}
+
+// parseNumber reads a number written with thousands and decimal separators,
+// such as "1,234.56", "1.234,56" or "1'234.50", and returns it with its
+// number of decimals. Apostrophes only group thousands. With formatAuto
+// the last of a dot and a comma marks the decimals when both appear, a
+// separator used more than once groups thousands, a lone comma before
+// three digits groups thousands and any other lone separator marks the
+// decimals. Numbers that don't read in the given format, such as "45.90"
+// in formatDecimalComma, are read as formatAuto would.
+func parseNumber(token string, format numberFormat) (float64, int, bool) {
+	if format != formatAuto {
+		sep := byte('.')
+		if format == formatDecimalComma {
+			sep = ','
+		}
+		if value, decimals, ok := parseWithDecimal(token, sep); ok {
+			return value, decimals, true
+		}
+	}
+
+	dot, comma := strings.LastIndexByte(token, '.'), strings.LastIndexByte(token, ',')
+	var sep byte
+	switch {
+	case dot >= 0 && comma >= 0:
+		sep = token[max(dot, comma)]
+	case dot >= 0 && strings.Count(token, ".") == 1:
+		sep = '.'
+	case comma >= 0 && strings.Count(token, ",") == 1 && len(token)-comma-1 != 3:
+		sep = ','
+	}
+	return parseWithDecimal(token, sep)
+}
+
+// parseWithDecimal reads token with sep marking the decimals, if it
+// appears, and another separator grouping the digits before them in
+// threes, or in twos then three as in Indian lakhs ("1,23,456")
+func parseWithDecimal(token string, sep byte) (float64, int, bool) {
+	whole, fraction := token, ""
+	if i := strings.LastIndexByte(token, sep); sep != 0 && i >= 0 {
+		whole, fraction = token[:i], token[i+1:]
+	}
+	if strings.ContainsAny(fraction, ".,'") {
+		return 0, 0, false
+	}
+
+	var group byte
+	for i := 0; i < len(whole); i++ {
+		if !isSeparator(whole[i]) {
+			continue
+		}
+		if whole[i] == sep || (group != 0 && whole[i] != group) {
+			return 0, 0, false
+		}
+		group = whole[i]
+	}
+	groups := []string{whole}
+	if group != 0 {
+		groups = strings.Split(whole, string(group))
+		last := len(groups) - 1
+		if len(groups[0]) > 3 || len(groups[last]) != 3 {
+			return 0, 0, false
+		}
+		for _, g := range groups[1:last] {
+			if len(g) != 2 && len(g) != 3 {
+				return 0, 0, false
+			}
+		}
+	}
+
+	number := strings.Join(groups, "")
+	if fraction != "" {
+		number += "." + fraction
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return value, len(fraction), true
+}
+
+// numberFormatOf returns the number format amounts of service are read in:
+// its pricePattern.locale, or the format usual for its currency
+func numberFormatOf(service *Service) numberFormat {
+	if service.patterns.format != formatAuto {
+		return service.patterns.format
+	}
+	return currencyFormat(service.PricePattern.Currency)
+}
+
+// localized reads candidates again in the number format of their service,
+// for the amounts whose own currency doesn't settle it, dropping those
+// that don't read as amounts
+func localized(candidates []amountCandidate, service *Service) []amountCandidate {
+	format := numberFormatOf(service)
+	if format == formatAuto {
+		return candidates
+	}
+	kept := candidates[:0]
+	for _, c := range candidates {
+		if service.patterns.format == formatAuto && currencyFormat(c.currency) != formatAuto {
+			kept = append(kept, c)
+			continue
+		}
+		value, decimals, ok := parseNumber(c.number, format)
+		if !ok || value <= 0 || value >= maxSaneAmount {
+			continue
+		}
+		c.value, c.decimals = value, decimals == currencyDecimals(c.currency)
+		kept = append(kept, c)
+	}
+	return kept
+}
@@ -0,0 +1,101 @@
+package extractor
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// Order numbers, most specific first. A generic match must hold a digit so
+// "Order confirmation" isn't read as order "confirmation".
+var (
+	amazonOrderPattern  = regexp.MustCompile(`\b\d{3}-\d{7}-\d{7}\b`)
+	genericOrderPattern = regexp.MustCompile(`(?i)\b(?:order|pedido|orden)\s*(?:number|n[uú]mero|no\.?|id)?\s*[:#]?\s*([A-Z0-9][A-Z0-9-]{4,})`)
+)
+
+// shipmentKeywords mark emails about one shipment of an order, which charge
+// only for the items shipped
+var shipmentKeywords = []string{"shipped", "shipment", "dispatched", "enviado", "envío", "en camino"}
+
+// orderID returns the order number of an email, searched in the subject
+// first, or "" when it has none
+func orderID(subject, text string) string {
+	for _, s := range []string{subject, text} {
+		if id := amazonOrderPattern.FindString(s); id != "" {
+			return id
+		}
+		for _, match := range genericOrderPattern.FindAllStringSubmatch(s, -1) {
+			if strings.ContainsAny(match[1], "0123456789") {
+				return strings.ToUpper(match[1])
+			}
+		}
+	}
+	return ""
+}
+
+// isShipment reports whether an email is about a single shipment
+func isShipment(subject string) bool {
+	subject = strings.ToLower(subject)
+	for _, keyword := range shipmentKeywords {
+		if strings.Contains(subject, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderKey groups the emails of one order; it is "" for transactions that
+// aren't part of one. Refunds are kept apart from their order.
+func OrderKey(tx *models.Transaction) string {
+	if tx.OrderID == "" || IsRefund(tx) {
+		return ""
+	}
+	return tx.ServiceID + "|" + tx.OrderID
+}
+
+// MergeOrder folds tx, another email of the order of kept, into kept. The
+// order total, from a confirmation or any email that isn't about a single
+// shipment, wins over shipment charges; until it arrives, kept adds up the
+// charges of the shipments.
+func MergeOrder(kept, tx *models.Transaction) {
+	if tx.ID == kept.ID || slices.Contains(kept.RelatedIDs, tx.ID) {
+		return
+	}
+	switch {
+	case kept.Partial && !tx.Partial:
+		kept.Amount, kept.RawAmount, kept.Partial = tx.Amount, tx.RawAmount, false
+	case kept.Partial && tx.Partial:
+		kept.Amount += tx.Amount
+	}
+	if tx.Date.Before(kept.Date) {
+		kept.Date = tx.Date
+	}
+	kept.RelatedIDs = append(kept.RelatedIDs, tx.ID)
+	kept.RelatedIDs = append(kept.RelatedIDs, tx.RelatedIDs...)
+	MergeStatus(kept, tx)
+	MergeInvoice(kept, tx)
+}
+
+// suppressOrderDuplicates keeps one transaction per order, so the
+// confirmation and shipment emails of an order count once, for the order
+// total
+func suppressOrderDuplicates(transactions []*models.Transaction) []*models.Transaction {
+	orders := make(map[string]*models.Transaction)
+	var result []*models.Transaction
+	for _, tx := range transactions {
+		key := OrderKey(tx)
+		if key == "" {
+			result = append(result, tx)
+			continue
+		}
+		if kept, ok := orders[key]; ok {
+			MergeOrder(kept, tx)
+			continue
+		}
+		orders[key] = tx
+		result = append(result, tx)
+	}
+	return result
+}
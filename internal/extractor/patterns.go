@@ -0,0 +1,116 @@
+package extractor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+)
+
+// patternDateLayouts are tried, in order, on what a service's date pattern
+// matches when it has no dateLayout. Day-first numeric dates need one.
+var patternDateLayouts = []string{
+	"2006-01-02",
+	"01/02/2006",
+	"1/2/2006",
+	"January 2, 2006",
+	"January 2 2006",
+	"Jan 2, 2006",
+	"Jan 2 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+}
+
+// servicePatterns are the compiled regexes of a service's pricePattern,
+// nil for those it doesn't set, and the number format of its locale
+type servicePatterns struct {
+	amount *regexp.Regexp
+	date   *regexp.Regexp
+	order  *regexp.Regexp
+	format numberFormat
+}
+
+// compilePatterns compiles the amount, date and order regexes of service's
+// pricePattern and looks up the number format of its locale
+func compilePatterns(service Service) (servicePatterns, error) {
+	format, err := localeFormat(service.PricePattern.Locale)
+	if err != nil {
+		return servicePatterns{}, fmt.Errorf("%w: service %q pricePattern.locale: %v", apperrors.ErrTrackerConfig, service.ID, err)
+	}
+	patterns := servicePatterns{format: format}
+	for _, field := range []struct {
+		name    string
+		pattern string
+		re      **regexp.Regexp
+	}{
+		{"amount", service.PricePattern.Amount, &patterns.amount},
+		{"date", service.PricePattern.Date, &patterns.date},
+		{"order", service.PricePattern.Order, &patterns.order},
+	} {
+		if field.pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(field.pattern)
+		if err != nil {
+			return servicePatterns{}, fmt.Errorf("%w: service %q pricePattern.%s: invalid pattern %q: %v", apperrors.ErrTrackerConfig, service.ID, field.name, field.pattern, err)
+		}
+		*field.re = re
+	}
+	return patterns, nil
+}
+
+// patternAmounts returns the amounts matched by service's amount pattern,
+// which take precedence over those its parser finds
+func patternAmounts(doc *document, service *Service) []amountCandidate {
+	re := service.patterns.amount
+	if re == nil {
+		return nil
+	}
+	return withStage(regexStrategy{re: re}.extract(doc, service), "pricePattern.amount "+re.String())
+}
+
+// patternDate returns the date matched by service's date pattern in the
+// texts, the zero time when it has none or what it matches isn't a date
+func patternDate(service *Service, texts ...string) time.Time {
+	value := firstMatch(service.patterns.date, texts...)
+	if value == "" {
+		return time.Time{}
+	}
+	value = strings.Join(strings.Fields(value), " ")
+
+	layouts := patternDateLayouts
+	if service.PricePattern.DateLayout != "" {
+		layouts = []string{service.PricePattern.DateLayout}
+	}
+	for _, layout := range layouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date
+		}
+	}
+	return time.Time{}
+}
+
+// patternOrder returns the order number matched by service's order pattern
+// in the texts, empty when it has none
+func patternOrder(service *Service, texts ...string) string {
+	return strings.TrimSpace(firstMatch(service.patterns.order, texts...))
+}
+
+// firstMatch returns capture group 1 of the first match of re in the texts,
+// or the whole match when the pattern has no group
+func firstMatch(re *regexp.Regexp, texts ...string) string {
+	if re == nil {
+		return ""
+	}
+	for _, text := range texts {
+		if match := re.FindStringSubmatch(text); match != nil {
+			if len(match) > 1 {
+				return match[1]
+			}
+			return match[0]
+		}
+	}
+	return ""
+}
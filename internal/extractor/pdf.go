@@ -0,0 +1,690 @@
+package extractor
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// errNotPDF means an attachment isn't a PDF file
+var errNotPDF = errors.New("not a PDF file")
+
+var (
+	pdfObjectStart = regexp.MustCompile(`(\d+)\s+\d+\s+obj\b`)
+	pdfReference   = regexp.MustCompile(`(\d+)\s+\d+\s+R\b`)
+	pdfFontRefs    = regexp.MustCompile(`/([^\s/<>\[\]()]+)\s*(\d+)\s+\d+\s+R\b`)
+	pdfFontDict    = regexp.MustCompile(`(?s)/Font\s*<<(.*?)>>`)
+	pdfFontObject  = regexp.MustCompile(`/Font\s+(\d+)\s+\d+\s+R\b`)
+	pdfToUnicode   = regexp.MustCompile(`/ToUnicode\s+(\d+)\s+\d+\s+R\b`)
+	pdfContents    = regexp.MustCompile(`(?s)/Contents\s*(\[.*?\]|\d+\s+\d+\s+R)`)
+	pdfKids        = regexp.MustCompile(`(?s)/Kids\s*\[(.*?)\]`)
+	pdfPagesRef    = regexp.MustCompile(`/Pages\s+(\d+)\s+\d+\s+R\b`)
+	pdfLength      = regexp.MustCompile(`/Length\s+(\d+)(\s+\d+\s+R)?`)
+	pdfFilter      = regexp.MustCompile(`/(\w+Decode)\b`)
+	pdfCMapSection = regexp.MustCompile(`(?s)begin(bfchar|bfrange)(.*?)endbf(?:char|range)`)
+	pdfCMapToken   = regexp.MustCompile(`<[0-9A-Fa-f\s]*>|\[|\]`)
+	pdfSpaces      = regexp.MustCompile(`[ \t]+`)
+)
+
+// pdfObject is an indirect object: its dictionary (or other value) and
+// its decoded stream, if any
+type pdfObject struct {
+	dict   string
+	stream []byte
+}
+
+// pdfCMap maps character codes of a font to text (a ToUnicode CMap)
+type pdfCMap struct {
+	codeLen int
+	runes   map[int]string
+}
+
+// addAttachments appends the text of PDF attachments to the document, so
+// amounts and dates only found in an attached invoice are extracted too
+func (d *document) addAttachments(attachments []models.Attachment) {
+	for _, attachment := range attachments {
+		text, err := pdfToText(attachment.Data)
+		if err != nil {
+			log.Printf("⚠️  Warning: could not read attachment %s: %v", attachment.Filename, err)
+			continue
+		}
+		if text != "" {
+			d.text += "\n\n" + text
+		}
+	}
+}
+
+// pdfToText extracts the text of a PDF, such as an invoice attachment, one
+// line per line of the page where the layout allows. It handles the common
+// cases of generated invoices: Flate-compressed streams, object streams and
+// fonts with ToUnicode maps; text it can't decode is left out.
+func pdfToText(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF-")) {
+		return "", errNotPDF
+	}
+
+	objects := parsePDFObjects(data)
+	fonts := pdfFonts(objects)
+
+	var out strings.Builder
+	for _, num := range pdfContentStreams(objects) {
+		out.WriteString(pdfStreamText(objects[num].stream, fonts))
+		out.WriteString("\n")
+	}
+
+	var lines []string
+	for _, line := range strings.Split(out.String(), "\n") {
+		line = strings.TrimSpace(pdfSpaces.ReplaceAllString(line, " "))
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// parsePDFObjects reads every indirect object, including those packed in
+// object streams
+func parsePDFObjects(data []byte) map[int]*pdfObject {
+	objects := make(map[int]*pdfObject)
+
+	for pos := 0; pos < len(data); {
+		loc := pdfObjectStart.FindSubmatchIndex(data[pos:])
+		if loc == nil {
+			break
+		}
+		num, _ := strconv.Atoi(string(data[pos+loc[2] : pos+loc[3]]))
+		start := pos + loc[1]
+		obj, end := parsePDFObject(data, start)
+		objects[num] = obj
+		pos = end
+	}
+
+	// Object streams hold further objects, often the font dictionaries
+	var packed []map[int]*pdfObject
+	for _, obj := range objects {
+		if strings.Contains(obj.dict, "/ObjStm") && obj.stream != nil {
+			packed = append(packed, parseObjectStream(obj))
+		}
+	}
+	for _, stream := range packed {
+		for num, obj := range stream {
+			if _, ok := objects[num]; !ok {
+				objects[num] = obj
+			}
+		}
+	}
+	return objects
+}
+
+// parsePDFObject parses the object starting at start, after "obj", and
+// returns it with the position after its end
+func parsePDFObject(data []byte, start int) (*pdfObject, int) {
+	end := bytes.Index(data[start:], []byte("endobj"))
+	streamAt := bytes.Index(data[start:], []byte("stream"))
+	if end < 0 {
+		end = len(data) - start
+	}
+	if streamAt < 0 || streamAt > end {
+		return &pdfObject{dict: string(data[start : start+end])}, start + end + len("endobj")
+	}
+
+	obj := &pdfObject{dict: string(data[start : start+streamAt])}
+	dataStart := start + streamAt + len("stream")
+	if dataStart < len(data) && data[dataStart] == '\r' {
+		dataStart++
+	}
+	if dataStart < len(data) && data[dataStart] == '\n' {
+		dataStart++
+	}
+
+	// Trust a direct /Length; binary data may contain "endstream"
+	dataEnd := -1
+	if m := pdfLength.FindStringSubmatch(obj.dict); m != nil && m[2] == "" {
+		if n, err := strconv.Atoi(m[1]); err == nil && dataStart+n <= len(data) {
+			dataEnd = dataStart + n
+		}
+	}
+	if dataEnd < 0 {
+		i := bytes.Index(data[dataStart:], []byte("endstream"))
+		if i < 0 {
+			return obj, len(data)
+		}
+		dataEnd = dataStart + i
+	}
+
+	obj.stream = decodePDFStream(obj.dict, data[dataStart:dataEnd])
+	next := bytes.Index(data[dataEnd:], []byte("endobj"))
+	if next < 0 {
+		return obj, len(data)
+	}
+	return obj, dataEnd + next + len("endobj")
+}
+
+// maxPDFStream bounds what one stream may decompress to, so a small
+// attachment can't expand to gigabytes; the text of a receipt is far less
+const maxPDFStream = 8 << 20
+
+// decodePDFStream undoes Flate compression; streams with other filters,
+// such as images, and those decompressing to more than maxPDFStream are
+// returned as nil
+func decodePDFStream(dict string, raw []byte) []byte {
+	filters := pdfFilter.FindAllStringSubmatch(dict, -1)
+	switch {
+	case len(filters) == 0:
+		return raw
+	case len(filters) == 1 && filters[0][1] == "FlateDecode":
+		r, err := zlib.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil
+		}
+		// Keep what was decoded before a truncated or corrupt end
+		decoded, _ := io.ReadAll(io.LimitReader(r, maxPDFStream+1))
+		if len(decoded) > maxPDFStream {
+			return nil
+		}
+		return decoded
+	default:
+		return nil
+	}
+}
+
+// parseObjectStream splits an object stream into its objects
+func parseObjectStream(obj *pdfObject) map[int]*pdfObject {
+	n := pdfDictInt(obj.dict, "N")
+	first := pdfDictInt(obj.dict, "First")
+	if n <= 0 || first <= 0 || first > len(obj.stream) {
+		return nil
+	}
+
+	header := strings.Fields(string(obj.stream[:first]))
+	if len(header) < 2*n {
+		return nil
+	}
+	objects := make(map[int]*pdfObject, n)
+	for i := 0; i < n; i++ {
+		num, err1 := strconv.Atoi(header[2*i])
+		offset, err2 := strconv.Atoi(header[2*i+1])
+		end := len(obj.stream) - first
+		if i+1 < n {
+			next, err := strconv.Atoi(header[2*i+3])
+			if err == nil {
+				end = next
+			}
+		}
+		if err1 != nil || err2 != nil || offset > end || first+end > len(obj.stream) {
+			continue
+		}
+		objects[num] = &pdfObject{dict: string(obj.stream[first+offset : first+end])}
+	}
+	return objects
+}
+
+// pdfDictInt returns the integer value of /key in a dictionary, or 0
+func pdfDictInt(dict, key string) int {
+	m := regexp.MustCompile(`/` + key + `\s+(\d+)`).FindStringSubmatch(dict)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+// pdfFonts maps font resource names to the ToUnicode maps of their fonts.
+// Names are resolved across all pages, which is right for the usual
+// invoice with one set of fonts.
+func pdfFonts(objects map[int]*pdfObject) map[string]*pdfCMap {
+	nums := make([]int, 0, len(objects))
+	for num := range objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+
+	fonts := make(map[string]*pdfCMap)
+	addFonts := func(dict string) {
+		for _, m := range pdfFontRefs.FindAllStringSubmatch(dict, -1) {
+			fontNum, _ := strconv.Atoi(m[2])
+			font, ok := objects[fontNum]
+			if !ok {
+				continue
+			}
+			if _, seen := fonts[m[1]]; seen {
+				continue
+			}
+			cmap := &pdfCMap{}
+			if ref := pdfToUnicode.FindStringSubmatch(font.dict); ref != nil {
+				cmapNum, _ := strconv.Atoi(ref[1])
+				if obj, ok := objects[cmapNum]; ok && obj.stream != nil {
+					cmap = parseCMap(obj.stream)
+				}
+			}
+			fonts[m[1]] = cmap
+		}
+	}
+
+	for _, num := range nums {
+		dict := objects[num].dict
+		for _, m := range pdfFontDict.FindAllStringSubmatch(dict, -1) {
+			addFonts(m[1])
+		}
+		for _, m := range pdfFontObject.FindAllStringSubmatch(dict, -1) {
+			ref, _ := strconv.Atoi(m[1])
+			if obj, ok := objects[ref]; ok {
+				addFonts(obj.dict)
+			}
+		}
+	}
+	return fonts
+}
+
+// parseCMap reads the bfchar and bfrange mappings of a ToUnicode CMap
+func parseCMap(data []byte) *pdfCMap {
+	cmap := &pdfCMap{runes: make(map[int]string)}
+	for _, section := range pdfCMapSection.FindAllStringSubmatch(string(data), -1) {
+		tokens := pdfCMapToken.FindAllString(section[2], -1)
+		if section[1] == "bfchar" {
+			for i := 0; i+1 < len(tokens); i += 2 {
+				code, n := pdfHexCode(tokens[i])
+				cmap.add(code, n, pdfUTF16(tokens[i+1]))
+			}
+			continue
+		}
+
+		for i := 0; i+2 < len(tokens); {
+			lo, n := pdfHexCode(tokens[i])
+			hi, _ := pdfHexCode(tokens[i+1])
+			if tokens[i+2] == "[" {
+				i += 3
+				for code := lo; i < len(tokens) && tokens[i] != "]"; code, i = code+1, i+1 {
+					cmap.add(code, n, pdfUTF16(tokens[i]))
+				}
+				i++
+				continue
+			}
+
+			dst := []rune(pdfUTF16(tokens[i+2]))
+			for code := lo; code <= hi && len(dst) > 0 && code-lo < 0x10000; code++ {
+				shifted := append([]rune{}, dst...)
+				shifted[len(shifted)-1] += rune(code - lo)
+				cmap.add(code, n, string(shifted))
+			}
+			i += 3
+		}
+	}
+	return cmap
+}
+
+// add maps code, n bytes long, to text
+func (c *pdfCMap) add(code, n int, text string) {
+	if n > c.codeLen {
+		c.codeLen = n
+	}
+	c.runes[code] = text
+}
+
+// decode converts a string shown with the font to text. Fonts without a
+// ToUnicode map are assumed to use a Latin-1 based encoding.
+func (c *pdfCMap) decode(s []byte) string {
+	var b strings.Builder
+	if c == nil || len(c.runes) == 0 {
+		for _, ch := range s {
+			if r, ok := winAnsiExtras[ch]; ok {
+				b.WriteRune(r)
+			} else if ch >= 0x20 {
+				b.WriteRune(rune(ch))
+			}
+		}
+		return b.String()
+	}
+
+	n := max(c.codeLen, 1)
+	for i := 0; i+n <= len(s); i += n {
+		code := 0
+		for _, ch := range s[i : i+n] {
+			code = code<<8 | int(ch)
+		}
+		b.WriteString(c.runes[code])
+	}
+	return b.String()
+}
+
+// winAnsiExtras are the WinAnsiEncoding characters that differ from Latin-1
+var winAnsiExtras = map[byte]rune{
+	0x80: '€', 0x85: '…', 0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”',
+	0x95: '•', 0x96: '–', 0x97: '—', 0x99: '™',
+}
+
+// pdfHexCode parses a hex string token such as <0041> into its value and
+// length in bytes
+func pdfHexCode(token string) (int, int) {
+	hex := strings.Join(strings.Fields(strings.Trim(token, "<>")), "")
+	code, _ := strconv.ParseInt(hex, 16, 64)
+	return int(code), (len(hex) + 1) / 2
+}
+
+// pdfUTF16 decodes a hex string token holding UTF-16BE text
+func pdfUTF16(token string) string {
+	raw := pdfHexBytes(strings.Trim(token, "<>"))
+	units := make([]uint16, 0, len(raw)/2)
+	for i := 0; i+1 < len(raw); i += 2 {
+		units = append(units, uint16(raw[i])<<8|uint16(raw[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// pdfHexBytes decodes hex digits, ignoring whitespace; an odd last digit
+// is padded with 0 as the PDF spec says
+func pdfHexBytes(hex string) []byte {
+	hex = strings.Join(strings.Fields(hex), "")
+	if len(hex)%2 == 1 {
+		hex += "0"
+	}
+	out := make([]byte, 0, len(hex)/2)
+	for i := 0; i+1 < len(hex); i += 2 {
+		v, err := strconv.ParseUint(hex[i:i+2], 16, 8)
+		if err != nil {
+			break
+		}
+		out = append(out, byte(v))
+	}
+	return out
+}
+
+// pdfContentStreams returns the page content streams in page order,
+// falling back to object order when the page tree can't be followed
+func pdfContentStreams(objects map[int]*pdfObject) []int {
+	var pages []int
+	seen := make(map[int]bool)
+	var walk func(num int)
+	walk = func(num int) {
+		obj, ok := objects[num]
+		if !ok || seen[num] {
+			return
+		}
+		seen[num] = true
+		if kids := pdfKids.FindStringSubmatch(obj.dict); kids != nil {
+			for _, ref := range pdfReference.FindAllStringSubmatch(kids[1], -1) {
+				kid, _ := strconv.Atoi(ref[1])
+				walk(kid)
+			}
+			return
+		}
+		pages = append(pages, num)
+	}
+
+	nums := make([]int, 0, len(objects))
+	for num := range objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		if strings.Contains(objects[num].dict, "/Catalog") {
+			if m := pdfPagesRef.FindStringSubmatch(objects[num].dict); m != nil {
+				root, _ := strconv.Atoi(m[1])
+				walk(root)
+			}
+			break
+		}
+	}
+
+	var streams []int
+	for _, page := range pages {
+		m := pdfContents.FindStringSubmatch(objects[page].dict)
+		if m == nil {
+			continue
+		}
+		for _, ref := range pdfReference.FindAllStringSubmatch(m[1], -1) {
+			num, _ := strconv.Atoi(ref[1])
+			if obj, ok := objects[num]; ok && obj.stream != nil {
+				streams = append(streams, num)
+			}
+		}
+	}
+	if len(streams) > 0 {
+		return streams
+	}
+
+	for _, num := range nums {
+		obj := objects[num]
+		if obj.stream != nil && !strings.Contains(obj.dict, "/Subtype") && bytes.Contains(obj.stream, []byte("BT")) {
+			streams = append(streams, num)
+		}
+	}
+	return streams
+}
+
+// pdfToken is a content stream token: an operator, or an operand
+type pdfToken struct {
+	op    string     // Operator, empty for operands
+	name  string     // Name operand, without the slash
+	str   []byte     // String operand
+	isStr bool       // Whether str is set
+	num   float64    // Number operand
+	isNum bool       // Whether num is set
+	array []pdfToken // Array operand
+}
+
+// pdfStreamText runs the text operators of a content stream
+func pdfStreamText(stream []byte, fonts map[string]*pdfCMap) string {
+	var (
+		out      strings.Builder
+		operands []pdfToken
+		font     *pdfCMap
+		lastY    float64
+	)
+	show := func(s []byte) { out.WriteString(font.decode(s)) }
+	newline := func() { out.WriteString("\n") }
+
+	lex := &pdfLexer{data: stream}
+	for {
+		tok, ok := lex.next()
+		if !ok {
+			break
+		}
+		if tok.op == "" {
+			operands = append(operands, tok)
+			continue
+		}
+
+		arg := func(i int) pdfToken {
+			if i < len(operands) {
+				return operands[len(operands)-1-i]
+			}
+			return pdfToken{}
+		}
+		switch tok.op {
+		case "BT", "ET", "T*":
+			newline()
+		case "Tf":
+			font = fonts[arg(1).name]
+		case "Td", "TD":
+			if arg(0).num != 0 {
+				newline()
+			} else {
+				out.WriteString(" ")
+			}
+		case "Tm":
+			if y := arg(0).num; y != lastY {
+				lastY = y
+				newline()
+			} else {
+				out.WriteString(" ")
+			}
+		case "Tj":
+			show(arg(0).str)
+		case "'", "\"":
+			newline()
+			show(arg(0).str)
+		case "TJ":
+			for _, item := range arg(0).array {
+				switch {
+				case item.isStr:
+					show(item.str)
+				case item.isNum && item.num < -200:
+					// A wide negative kern is how many PDFs draw a space
+					out.WriteString(" ")
+				}
+			}
+		case "ID":
+			lex.skipInlineImage()
+		}
+		operands = operands[:0]
+	}
+	return out.String()
+}
+
+// pdfLexer splits a content stream into tokens
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+// isPDFDelimiter reports whether c ends a name, number or operator
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte(" \t\r\n\f\x00()<>[]{}/%", c) >= 0
+}
+
+// next returns the next token, or false at the end of the stream
+func (l *pdfLexer) next() (pdfToken, bool) {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		case strings.IndexByte(" \t\r\n\f\x00", c) >= 0:
+			l.pos++
+		case c == '(':
+			return pdfToken{str: l.literal(), isStr: true}, true
+		case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<',
+			c == '>' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '>':
+			// Dictionaries only appear in marked content; skip their brackets
+			l.pos += 2
+		case c == '<':
+			end := bytes.IndexByte(l.data[l.pos:], '>')
+			if end < 0 {
+				end = len(l.data) - l.pos
+			}
+			str := pdfHexBytes(string(l.data[l.pos+1 : l.pos+end]))
+			l.pos += end + 1
+			return pdfToken{str: str, isStr: true}, true
+		case c == '[':
+			l.pos++
+			var array []pdfToken
+			for {
+				tok, ok := l.next()
+				if !ok || tok.op == "]" {
+					break
+				}
+				array = append(array, tok)
+			}
+			return pdfToken{array: array}, true
+		case c == ']':
+			l.pos++
+			return pdfToken{op: "]"}, true
+		case c == '/':
+			l.pos++
+			return pdfToken{name: l.word()}, true
+		default:
+			word := l.word()
+			if word == "" {
+				// A stray delimiter such as '{'
+				l.pos++
+				continue
+			}
+			if n, err := strconv.ParseFloat(word, 64); err == nil {
+				return pdfToken{num: n, isNum: true}, true
+			}
+			return pdfToken{op: word}, true
+		}
+	}
+	return pdfToken{}, false
+}
+
+// word reads up to the next delimiter
+func (l *pdfLexer) word() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return string(l.data[start:l.pos])
+}
+
+// literal reads a (string) with nested parentheses and escapes
+func (l *pdfLexer) literal() []byte {
+	var out []byte
+	depth := 0
+	for l.pos++; l.pos < len(l.data); l.pos++ {
+		c := l.data[l.pos]
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				l.pos++
+				return out
+			}
+			depth--
+		case '\\':
+			l.pos++
+			if l.pos >= len(l.data) {
+				return out
+			}
+			e := l.data[l.pos]
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				// Line continuation
+				if e == '\r' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '\n' {
+					l.pos++
+				}
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := 0
+					for i := 0; i < 3 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					l.pos--
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// skipInlineImage skips the binary data of an inline image up to EI
+func (l *pdfLexer) skipInlineImage() {
+	for l.pos+2 < len(l.data) {
+		if l.data[l.pos] == 'E' && l.data[l.pos+1] == 'I' && isPDFDelimiter(l.data[l.pos+2]) &&
+			l.pos > 0 && isPDFDelimiter(l.data[l.pos-1]) {
+			l.pos += 2
+			return
+		}
+		l.pos++
+	}
+	l.pos = len(l.data)
+}
@@ -0,0 +1,111 @@
+package extractor
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// buildPDF writes a PDF holding objects, numbered from 1. Streams are
+// given as a dictionary and its data, joined by "\x00stream\x00".
+func buildPDF(objects ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	for i, obj := range objects {
+		fmt.Fprintf(&b, "%d 0 obj\n", i+1)
+		if dict, data, ok := strings.Cut(obj, "\x00stream\x00"); ok {
+			fmt.Fprintf(&b, "%s\nstream\n%s\nendstream\n", strings.Replace(dict, "LENGTH", fmt.Sprint(len(data)), 1), data)
+		} else {
+			b.WriteString(obj + "\n")
+		}
+		b.WriteString("endobj\n")
+	}
+	b.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+func deflate(data []byte) string {
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	w.Write(data)
+	w.Close()
+	return b.String()
+}
+
+func TestPDFToText(t *testing.T) {
+	content := "BT /F1 12 Tf 72 720 Td (Invoice INV-0042) Tj 0 -14 Td [(Total) -400 (due:)] TJ ( $48.20) Tj ET"
+	// F2 maps two-byte codes to text through a ToUnicode CMap
+	cmap := "begincmap\n1 begincodespacerange <0000> <FFFF> endcodespacerange\n" +
+		"2 beginbfchar <0001> <0050> <0002> <0061> endbfchar\n" +
+		"1 beginbfrange <0003> <0004> <0069> endbfrange\nendcmap"
+	second := "BT /F2 10 Tf 72 700 Td <0001000200030004> Tj ET"
+
+	tests := []struct {
+		name string
+		pdf  []byte
+		want string
+	}{
+		{
+			"plain stream",
+			buildPDF(
+				"<< /Type /Catalog /Pages 2 0 R >>",
+				"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+				"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 5 0 R >> >> /Contents 4 0 R >>",
+				"<< /Length LENGTH >>\x00stream\x00"+content,
+				"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+			),
+			"Invoice INV-0042\nTotal due: $48.20",
+		},
+		{
+			"flate stream and ToUnicode font",
+			buildPDF(
+				"<< /Type /Catalog /Pages 2 0 R >>",
+				"<< /Type /Pages /Kids [3 0 R 6 0 R] /Count 2 >>",
+				"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 5 0 R >> >> /Contents 4 0 R >>",
+				"<< /Length LENGTH /Filter /FlateDecode >>\x00stream\x00"+deflate([]byte(content)),
+				"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+				"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F2 8 0 R >> >> /Contents 7 0 R >>",
+				"<< /Length LENGTH >>\x00stream\x00"+second,
+				"<< /Type /Font /Subtype /Type0 /ToUnicode 9 0 R >>",
+				"<< /Length LENGTH >>\x00stream\x00"+cmap,
+			),
+			"Invoice INV-0042\nTotal due: $48.20\nPaij",
+		},
+	}
+	for _, tt := range tests {
+		got, err := pdfToText(tt.pdf)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: pdfToText = %q; want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := pdfToText([]byte("<html>not a pdf</html>")); !errors.Is(err, errNotPDF) {
+		t.Errorf("pdfToText of HTML = %v; want errNotPDF", err)
+	}
+}
+
+func TestDecodePDFStream(t *testing.T) {
+	text := []byte("BT (Total $12.00) Tj ET")
+	if got := decodePDFStream("<< /Length 10 >>", text); !bytes.Equal(got, text) {
+		t.Errorf("unfiltered stream = %q", got)
+	}
+	if got := decodePDFStream("<< /Filter /FlateDecode >>", []byte(deflate(text))); !bytes.Equal(got, text) {
+		t.Errorf("Flate stream = %q", got)
+	}
+	if got := decodePDFStream("<< /Filter /DCTDecode >>", text); got != nil {
+		t.Errorf("image stream = %q; want nil", got)
+	}
+
+	// A small stream that decompresses past the cap is dropped
+	bomb := deflate(make([]byte, maxPDFStream+1))
+	if got := decodePDFStream("<< /Filter /FlateDecode >>", []byte(bomb)); got != nil {
+		t.Errorf("stream of %d bytes decompressed to %d bytes; want nil", len(bomb), len(got))
+	}
+}
@@ -0,0 +1,44 @@
+package extractor
+
+import (
+	"strings"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// defaultRefundKeywords mark refund emails of services without
+// refundKeywords in tracker-mails.json
+var defaultRefundKeywords = []string{
+	"refund",
+	"reversal",
+	"reversed",
+	"credit issued",
+	"credited",
+	"chargeback",
+	"reembolso",
+	"devolución",
+	"reembolsado",
+}
+
+// isRefund reports whether msg gives money back rather than charging it.
+// Only the subject is searched: receipts often mention the refund policy
+// in their body.
+func isRefund(service *Service, msg *models.Message) bool {
+	keywords := service.RefundKeywords
+	if keywords == nil {
+		keywords = defaultRefundKeywords
+	}
+	subject := strings.ToLower(msg.Subject)
+	for _, keyword := range keywords {
+		if keyword != "" && strings.Contains(subject, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRefund reports whether tx is a refund, which is stored as a negative
+// amount
+func IsRefund(tx *models.Transaction) bool {
+	return tx.Amount < 0
+}
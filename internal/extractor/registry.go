@@ -0,0 +1,177 @@
+package extractor
+
+import (
+	"net/mail"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/pkg/utils"
+)
+
+// Priorities of the registered parsers. Merchant parsers outrank the
+// generic one, which handles every service in tracker-mails.json.
+const (
+	priorityGeneric  = 0   // tracker-mails.json services, the fallback
+	priorityIndustry = 50  // Parsers for a kind of merchant, such as airlines
+	priorityMerchant = 100 // Parsers for a single merchant
+)
+
+// parser finds the service and amount of the emails it recognizes
+type parser interface {
+	// match returns the service msg belongs to, and whether it matched by
+	// sender domain, or nil when the parser doesn't handle msg
+	match(te *TransactionExtractor, msg *models.Message) (*Service, bool)
+	// extract returns the amount candidates of a matched email
+	extract(doc *document, service *Service) []amountCandidate
+}
+
+// registeredParser is a parser and its priority
+type registeredParser struct {
+	name     string
+	priority int
+	parser   parser
+}
+
+// parsers holds the registered parsers, highest priority first
+var parsers []registeredParser
+
+// registerParser adds a parser to the registry, usually from an init
+// function. Emails are offered to parsers from the highest priority down;
+// the first one that matches an email and finds an amount in it wins, so a
+// parser that doesn't find an amount falls through to the next.
+func registerParser(name string, priority int, p parser) {
+	parsers = append(parsers, registeredParser{name: name, priority: priority, parser: p})
+	sort.SliceStable(parsers, func(i, j int) bool {
+		return parsers[i].priority > parsers[j].priority
+	})
+}
+
+func init() {
+	registerParser("generic", priorityGeneric, genericParser{})
+}
+
+// genericParser matches the services of tracker-mails.json by sender
+// domain, then keyword, and runs their extraction strategies
+type genericParser struct{}
+
+func (genericParser) match(te *TransactionExtractor, msg *models.Message) (*Service, bool) {
+	return te.matchService(msg)
+}
+
+func (genericParser) extract(doc *document, service *Service) []amountCandidate {
+	for i, s := range service.strategies {
+		if candidates := s.extract(doc, service); len(candidates) > 0 {
+			if _, ok := pickAmount(candidates); ok {
+				return withStage(candidates, stageName(service, i))
+			}
+		}
+	}
+	return nil
+}
+
+// stageName describes the i-th extraction stage of service, such as
+// "regex Total: (\S+)"
+func stageName(service *Service, i int) string {
+	if i >= len(service.Extraction) {
+		return StrategyScan
+	}
+	cfg := service.Extraction[i]
+	switch cfg.Type {
+	case StrategyRegex:
+		return cfg.Type + " " + cfg.Pattern
+	case StrategyCSS:
+		return cfg.Type + " " + cfg.Selector
+	case StrategyTable:
+		if len(cfg.Labels) > 0 {
+			return cfg.Type + " " + strings.Join(cfg.Labels, ", ")
+		}
+	}
+	return cfg.Type
+}
+
+// withStage records the stage that found candidates
+func withStage(candidates []amountCandidate, stage string) []amountCandidate {
+	for i := range candidates {
+		candidates[i].stage = stage
+	}
+	return candidates
+}
+
+// merchantParser recognizes a merchant by the domain of the sender and
+// reads the amount that follows the total labels of its receipts
+type merchantParser struct {
+	domains []string         // Sender domains, subdomains included
+	labels  []*regexp.Regexp // Text before the total, most reliable first
+	// service picks the service of a matched email; it may return nil to
+	// leave an email of the merchant to the other parsers
+	service func(te *TransactionExtractor, msg *models.Message) *Service
+}
+
+// totalLabels compiles the labels of a merchantParser, matched ignoring case
+// at the start of a word, so "total" doesn't match "Subtotal"
+func totalLabels(labels ...string) []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, len(labels))
+	for i, label := range labels {
+		patterns[i] = regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(label))
+	}
+	return patterns
+}
+
+func (p merchantParser) match(te *TransactionExtractor, msg *models.Message) (*Service, bool) {
+	if !fromDomain(msg, p.domains...) {
+		return nil, false
+	}
+	service := p.service(te, msg)
+	return service, service != nil
+}
+
+func (p merchantParser) extract(doc *document, service *Service) []amountCandidate {
+	lines := strings.Split(doc.text, "\n")
+	for _, label := range p.labels {
+		var candidates []amountCandidate
+		for _, line := range lines {
+			if loc := label.FindStringIndex(line); loc != nil {
+				candidates = append(candidates, scanAmounts(line[loc[1]:])...)
+			}
+		}
+		if len(candidates) > 0 {
+			return withStage(targeted(candidates, service), "label "+label.String())
+		}
+	}
+	return nil
+}
+
+// fromDomain reports whether msg was sent from one of the domains or their
+// subdomains
+func fromDomain(msg *models.Message, domains ...string) bool {
+	_, domain, ok := strings.Cut(strings.ToLower(utils.ExtractEmail(msg.From)), "@")
+	if !ok {
+		return false
+	}
+	for _, d := range domains {
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// trackedService returns the tracker-mails.json service with the given ID,
+// so its name and category can be customized, or fallback when it isn't
+// configured
+func trackedService(te *TransactionExtractor, fallback Service) *Service {
+	if service, ok := te.tracker.Services[fallback.ID]; ok {
+		return &service
+	}
+	return &fallback
+}
+
+// senderName returns the display name of the sender of msg, if it has one
+func senderName(msg *models.Message) string {
+	if addr, err := mail.ParseAddress(msg.From); err == nil {
+		return addr.Name
+	}
+	return ""
+}
@@ -0,0 +1,249 @@
+package extractor
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxSaneAmount discards numbers that are almost certainly not prices
+// (order numbers, phone numbers, tracking codes)
+const maxSaneAmount = 1000000
+
+// labelWindow is how many bytes before an amount are checked for a label
+const labelWindow = 24
+
+// amountLabelPattern matches a label like "Total:" or a "Total |" table cell
+// right before an amount; "Subtotal:" isn't one
+var amountLabelPattern = regexp.MustCompile(`(?i)\b(total|amount|charge|price|importe|monto|cargo)\s*[:|]?\s*$`)
+
+// amountCandidate is a money-like token found while scanning a message
+type amountCandidate struct {
+	value    float64
+	number   string // the digits and separators of the amount, as written
+	raw      string
+	currency string // empty when no currency code or symbol was attached
+	assumed  bool   // currency defaulted to USD by rankAmounts, with no marker attached
+	labeled  bool   // preceded by total/amount/charge/price
+	decimals bool   // written with the decimal places of its currency, two when it has none
+	guessed  bool   // picked with nothing but its size and position to go on
+
+	// Context scored by rankAmounts
+	lineLabel bool    // a total label appears earlier on the amount's line
+	partial   bool    // a subtotal, tax, shipping or discount label is closer than any total label
+	inTable   bool    // in the value cell of a table row
+	position  float64 // where the amount is in the scanned text, from 0 to 1
+
+	score   float64
+	reasons []string // What the score is made of, for gm explain
+	stage   string   // The extraction stage or merchant label that found the amount
+}
+
+// confidence scores how the amount was found
+func (c amountCandidate) confidence() float64 {
+	switch {
+	case c.labeled:
+		return confidenceLabeled
+	case c.guessed:
+		return confidenceGuessed
+	default:
+		return confidenceTagged
+	}
+}
+
+// scanAmounts walks the text once and returns every plausible amount together
+// with the currency markers found around it
+func scanAmounts(text string) []amountCandidate {
+	var candidates []amountCandidate
+	// Most lines hold no label at all, so each is searched for one once
+	labelLine, lineHasLabel := -1, false
+
+	for i := 0; i < len(text); {
+		if !isDigit(text[i]) {
+			i++
+			continue
+		}
+
+		// Consume digits, grouped or ended by separators: "1,234.56", "1.234,56", "1'234"
+		start := i
+		for i < len(text) && isDigit(text[i]) {
+			i++
+			if i+1 < len(text) && isSeparator(text[i]) && isDigit(text[i+1]) {
+				i++
+			}
+		}
+		number := text[start:i]
+		end := i
+
+		prefixStart, prefixCurrency := currencyBefore(text, start)
+		suffixEnd, suffixCurrency := currencyAfter(text, end)
+
+		// Explicit codes win over symbols because "$" is shared by several currencies
+		currency := prefixCurrency.code
+		if currency == "" {
+			currency = suffixCurrency.code
+		}
+		if currency == "" {
+			currency = prefixCurrency.symbol
+		}
+		if currency == "" {
+			currency = suffixCurrency.symbol
+		}
+
+		// Tokens that don't read as numbers, such as the date 14.03.2025, are skipped
+		value, decimals, ok := parseNumber(number, currencyFormat(currency))
+		if !ok || value <= 0 || value >= maxSaneAmount {
+			continue
+		}
+
+		lineStart := strings.LastIndexByte(text[:prefixStart], '\n') + 1
+		if lineStart != labelLine {
+			lineEnd := len(text)
+			if i := strings.IndexByte(text[lineStart:], '\n'); i >= 0 {
+				lineEnd = lineStart + i
+			}
+			labelLine, lineHasLabel = lineStart, mayHoldLabel(text[lineStart:lineEnd])
+		}
+		line := text[lineStart:prefixStart]
+		var lineLabel, partial bool
+		if lineHasLabel {
+			lineLabel, partial = lineLabels(line)
+		}
+		candidates = append(candidates, amountCandidate{
+			value:     value,
+			number:    number,
+			raw:       text[prefixStart:suffixEnd],
+			currency:  currency,
+			labeled:   labeledAt(text, prefixStart),
+			decimals:  decimals == currencyDecimals(currency),
+			lineLabel: lineLabel,
+			partial:   partial,
+			inTable:   strings.Contains(line, cellSeparator),
+			position:  float64(start) / float64(len(text)),
+		})
+	}
+
+	return candidates
+}
+
+// labeledAt reports whether a label like "Total:" ends at pos. Only a
+// letter before the spaces and separator can end one, which rules most
+// amounts out before the pattern is tried.
+func labeledAt(text string, pos int) bool {
+	j := pos
+	for j > 0 && isSpace(text[j-1]) {
+		j--
+	}
+	if j > 0 && (text[j-1] == ':' || text[j-1] == '|') {
+		j--
+	}
+	for j > 0 && isSpace(text[j-1]) {
+		j--
+	}
+	if j == 0 || !isLetter(text[j-1]) {
+		return false
+	}
+	return amountLabelPattern.MatchString(text[max(0, pos-labelWindow):pos])
+}
+
+// currencyMarkers holds the currency implied by a code and by a symbol
+type currencyMarkers struct {
+	code   string
+	symbol string
+}
+
+// currencyBefore looks for "CODE $" style markers ending at pos and returns
+// where they start
+func currencyBefore(text string, pos int) (int, currencyMarkers) {
+	var markers currencyMarkers
+	start := pos
+
+	j := skipSpacesBack(text, pos)
+	for _, m := range symbolMarkers {
+		if strings.HasSuffix(text[:j], m.symbol) && !(wordMarker(m.symbol) && letterBefore(text, j-len(m.symbol))) {
+			markers.symbol = m.currency
+			j -= len(m.symbol)
+			start = j
+			j = skipSpacesBack(text, j)
+			break
+		}
+	}
+
+	if j >= 3 && (j == 3 || !isLetter(text[j-4])) {
+		if code, ok := currencyCode(text[j-3 : j]); ok {
+			markers.code = code
+			start = j - 3
+		}
+	}
+
+	return start, markers
+}
+
+// currencyAfter looks for "€" or "USD" style markers starting at pos and
+// returns where they end
+func currencyAfter(text string, pos int) (int, currencyMarkers) {
+	var markers currencyMarkers
+
+	j := pos
+	for j < len(text) && text[j] == ' ' {
+		j++
+	}
+
+	if j+3 <= len(text) && (j+3 == len(text) || !isLetter(text[j+3])) {
+		if code, ok := currencyCode(text[j : j+3]); ok {
+			markers.code = code
+			return j + 3, markers
+		}
+	}
+
+	for _, m := range symbolMarkers {
+		if strings.HasPrefix(text[j:], m.symbol) && afterAmountMarker(m.symbol) && !(wordMarker(m.symbol) && letterAfter(text, j+len(m.symbol))) {
+			markers.symbol = m.currency
+			return j + len(m.symbol), markers
+		}
+	}
+
+	return pos, markers
+}
+
+// letterBefore reports whether a letter ends text[:pos]
+func letterBefore(text string, pos int) bool {
+	r, _ := utf8.DecodeLastRuneInString(text[:pos])
+	return unicode.IsLetter(r)
+}
+
+// letterAfter reports whether a letter starts text[pos:]
+func letterAfter(text string, pos int) bool {
+	r, _ := utf8.DecodeRuneInString(text[pos:])
+	return unicode.IsLetter(r)
+}
+
+func skipSpacesBack(text string, pos int) int {
+	for pos > 0 && text[pos-1] == ' ' {
+		pos--
+	}
+	return pos
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// isSpace reports whether b is one of the spaces \s matches in a pattern
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\f' || b == '\r'
+}
+
+func isLetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// pickAmount chooses the best-scored candidate, see rankAmounts
+func pickAmount(candidates []amountCandidate) (amountCandidate, bool) {
+	ranked := rankAmounts(candidates)
+	if len(ranked) == 0 {
+		return amountCandidate{}, false
+	}
+	return ranked[0], true
+}
@@ -0,0 +1,150 @@
+package extractor
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		token    string
+		format   numberFormat
+		value    float64
+		decimals int
+		ok       bool
+	}{
+		{"45", formatAuto, 45, 0, true},
+		{"45.90", formatAuto, 45.9, 2, true},
+		{"45,90", formatAuto, 45.9, 2, true},
+		{"1,234.56", formatAuto, 1234.56, 2, true},
+		{"1.234,56", formatAuto, 1234.56, 2, true},
+		{"1'234.50", formatAuto, 1234.5, 2, true},
+		{"1,234", formatAuto, 1234, 0, true},
+		{"1,234,567", formatAuto, 1234567, 0, true},
+		{"1.234.567", formatAuto, 1234567, 0, true},
+		{"1,23,456.00", formatAuto, 123456, 2, true},
+		{"1.234", formatAuto, 1.234, 3, true},
+		{"1.234", formatDecimalComma, 1234, 0, true},
+		{"1.234", formatDecimalPoint, 1.234, 3, true},
+		{"45.90", formatDecimalComma, 45.9, 2, true},
+		{"1.234,56", formatDecimalPoint, 1234.56, 2, true},
+		{"14.03.2025", formatAuto, 0, 0, false},
+		{"1.234,567,8", formatAuto, 0, 0, false},
+		{"12,34,5", formatAuto, 0, 0, false},
+		{"1234,567.00", formatAuto, 0, 0, false},
+	}
+	for _, tt := range tests {
+		value, decimals, ok := parseNumber(tt.token, tt.format)
+		if ok != tt.ok || (ok && (value != tt.value || decimals != tt.decimals)) {
+			t.Errorf("parseNumber(%q, %d) = %v, %d, %v; want %v, %d, %v",
+				tt.token, tt.format, value, decimals, ok, tt.value, tt.decimals, tt.ok)
+		}
+	}
+}
+
+func TestScanAmounts(t *testing.T) {
+	type found struct {
+		value    float64
+		currency string
+		labeled  bool
+	}
+	tests := []struct {
+		text string
+		want []found
+	}{
+		{"Total: $23.10", []found{{23.1, "USD", true}}},
+		{"Amount charged 12.99 USD", []found{{12.99, "USD", false}}},
+		{"usd 5.00", []found{{5, "USD", false}}},
+		{"Importe: 1.234,56 €", []found{{1234.56, "EUR", true}}},
+		{"R$ 1.500,90", []found{{1500.9, "BRL", false}}},
+		{"₹1,23,456.00", []found{{123456, "INR", false}}},
+		{"CAD 45", []found{{45, "CAD", false}}},
+		{"top 10 picks", []found{{10, "", false}}},
+		{"Subtotal £8.00\nTotal £9.60", []found{{8, "GBP", false}, {9.6, "GBP", true}}},
+		{"Ordered on 14.03.2025", nil},
+		{"Order 0", nil},
+		{"Tracking 12345678901", nil},
+	}
+	for _, tt := range tests {
+		var got []found
+		for _, c := range scanAmounts(tt.text) {
+			got = append(got, found{c.value, c.currency, c.labeled})
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("scanAmounts(%q) = %v; want %v", tt.text, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("scanAmounts(%q) = %v; want %v", tt.text, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+// receiptBody is a receipt of about 7KB, in the text gm extracts from an
+// HTML email
+var receiptBody = func() string {
+	var b strings.Builder
+	b.WriteString("Thanks for your order #112-4432190-5512\nOrdered on 14.03.2025\n")
+	for i := 1; b.Len() < 7000; i++ {
+		b.WriteString("Item " + strconv.Itoa(i) + " | Qty 1 | $" + strconv.Itoa(i) + ".99\n")
+		b.WriteString("Ships from and sold by Example Store, call 1-800-555-0100 for help\n")
+	}
+	b.WriteString("Subtotal $97.45\nShipping $4.99\nTax $7.80\nTotal: $110.24\n")
+	return b.String()
+}()
+
+// legacyPatterns are the currency patterns amounts were found with before
+// scanAmounts, tried one after the other
+var legacyPatterns = []string{
+	`(\$|\$\s*)[\s]*(\d[\d,]*\.?\d{0,2})\s*(USD)?`,
+	`(\d[\d,]*\.?\d{0,2})\s*(USD)`,
+	`(MXN|M\$|MEX|\$\s*M)\s*(\d[\d,]*\.?\d{0,2})`,
+	`(\d[\d,]*\.?\d{0,2})\s*(MXN|M\$|MEX)`,
+	`(€)\s*(\d[\d,]*\.?\d{0,2})`,
+	`(\d[\d,]*\.?\d{0,2})\s*(EUR|€)`,
+	`(£)\s*(\d[\d,]*\.?\d{0,2})`,
+	`(\d[\d,]*\.?\d{0,2})\s*(GBP|£)`,
+	`(¥|JPY)\s*(\d[\d,]*\.?\d{0,2})`,
+	`(\d[\d,]*\.?\d{0,2})\s*(JPY|¥)`,
+	`(CAD|\$\s*C)\s*(\d[\d,]*\.?\d{0,2})`,
+	`(\d[\d,]*\.?\d{0,2})\s*(CAD)`,
+}
+
+// legacyScan finds amounts the way extractAmountWithCurrency did before
+// scanAmounts: every currency pattern compiled and matched in turn
+func legacyScan(text string) []float64 {
+	var amounts []float64
+	for _, pattern := range legacyPatterns {
+		re := regexp.MustCompile("(?i)" + pattern)
+		for _, match := range re.FindAllStringSubmatch(text, -1) {
+			for i := len(match) - 1; i >= 1; i-- {
+				if amount, err := strconv.ParseFloat(strings.ReplaceAll(match[i], ",", ""), 64); err == nil && amount > 0 {
+					amounts = append(amounts, amount)
+					break
+				}
+			}
+		}
+	}
+	return amounts
+}
+
+func BenchmarkScanAmounts(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(receiptBody)))
+	for i := 0; i < b.N; i++ {
+		scanAmounts(receiptBody)
+	}
+}
+
+func BenchmarkLegacyScan(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(receiptBody)))
+	for i := 0; i < b.N; i++ {
+		legacyScan(receiptBody)
+	}
+}
@@ -0,0 +1,133 @@
+package extractor
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Score contributed by each clue that an amount is the total charged
+const (
+	scoreLabel     = 3    // A total label right before the amount
+	scoreLineLabel = 1.5  // A total label earlier on the amount's line
+	scorePartial   = -2   // A subtotal, tax, shipping or discount label is closer
+	scoreCurrency  = 2    // A currency code or symbol is attached
+	scoreDecimals  = 1    // Written with the decimal places of its currency
+	scoreTable     = 0.5  // The value cell of a table row
+	scorePosition  = 1    // Scaled by how far into the email the amount is; totals come last
+	scoreLargest   = 1    // The largest amount; a total adds up the others
+	scorePreferred = 0.01 // Per rank in currencyPriority, to break ties between currencies
+)
+
+// Labels that mark an amount as the total, or as a part of it
+const (
+	totalWords   = `grand total|total|importe|amount|monto|charged|cargo|paid|pagado|price|precio`
+	partialWords = `subtotal|sub-total|tax|taxes|iva|vat|shipping|env[ií]o|discount|descuento|tip|propina|savings|ahorro`
+)
+
+// totalWordPattern and partialWordPattern find the labels on an amount's
+// line. "Subtotal" is partial: word boundaries keep it from matching "total".
+var (
+	totalWordPattern   = regexp.MustCompile(`(?i)\b(` + totalWords + `)\b`)
+	partialWordPattern = regexp.MustCompile(`(?i)\b(` + partialWords + `)\b`)
+)
+
+// labelStems are the labels in lowercase, up to any character class, so
+// lines without one are told apart without running the patterns
+var labelStems = func() []string {
+	var stems []string
+	for _, word := range strings.Split(totalWords+"|"+partialWords, "|") {
+		if i := strings.IndexByte(word, '['); i >= 0 {
+			word = word[:i]
+		}
+		stems = append(stems, word)
+	}
+	return stems
+}()
+
+// mayHoldLabel reports whether a label could appear in line
+func mayHoldLabel(line string) bool {
+	line = strings.ToLower(line)
+	for _, stem := range labelStems {
+		if strings.Contains(line, stem) {
+			return true
+		}
+	}
+	return false
+}
+
+// lineLabels reports whether the text before an amount on its line holds a
+// total label, and whether a partial label comes after the last one
+func lineLabels(line string) (total, partial bool) {
+	totalAt, partialAt := -1, -1
+	if loc := totalWordPattern.FindAllStringIndex(line, -1); loc != nil {
+		totalAt = loc[len(loc)-1][0]
+	}
+	if loc := partialWordPattern.FindAllStringIndex(line, -1); loc != nil {
+		partialAt = loc[len(loc)-1][0]
+	}
+	return totalAt >= 0, partialAt > totalAt
+}
+
+// rankAmounts scores the candidates and returns them best first. The score
+// adds up the clues that an amount is the total: a total label before it,
+// an attached currency, two decimals, a table cell, a late position in the
+// email and being the largest amount. Untagged amounts default to USD.
+func rankAmounts(candidates []amountCandidate) []amountCandidate {
+	largest := 0.0
+	for _, c := range candidates {
+		largest = max(largest, c.value)
+	}
+
+	ranked := make([]amountCandidate, len(candidates))
+	for i, c := range candidates {
+		c.score, c.reasons = 0, nil
+		add := func(score float64, format string, a ...interface{}) {
+			c.score += score
+			c.reasons = append(c.reasons, fmt.Sprintf("%+.2f ", score)+fmt.Sprintf(format, a...))
+		}
+
+		switch {
+		case c.labeled && !c.partial:
+			add(scoreLabel, "total label")
+		case c.lineLabel && !c.partial:
+			add(scoreLineLabel, "total label on its line")
+		}
+		if c.partial {
+			add(scorePartial, "subtotal, tax, shipping or discount label")
+		}
+		if c.currency != "" {
+			add(scoreCurrency, "currency %s", c.currency)
+			for rank, currency := range currencyPriority {
+				if currency == c.currency {
+					c.score -= scorePreferred * float64(rank)
+				}
+			}
+		}
+		if c.decimals {
+			add(scoreDecimals, "its currency's decimals")
+		}
+		if c.inTable {
+			add(scoreTable, "table cell")
+		}
+		if c.position > 0 {
+			add(scorePosition*c.position, "%.0f%% into the email", c.position*100)
+		}
+		if c.value == largest {
+			add(scoreLargest, "largest amount")
+		}
+
+		c.guessed = !c.labeled && !c.lineLabel && c.currency == "" && !c.decimals
+		c.assumed = c.currency == ""
+		if c.assumed {
+			c.currency = "USD"
+		}
+		ranked[i] = c
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+	return ranked
+}
@@ -0,0 +1,222 @@
+package extractor
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// selector is a small CSS selector subset for picking amounts out of
+// receipts: type, #id, .class and [attr] / [attr=value] / [attr*=value]
+// simple selectors, descendant and child (>) combinators, and comma-separated
+// groups
+type selector [][]selectorStep
+
+// selectorStep is one compound selector and how it relates to the step before it
+type selectorStep struct {
+	child   bool // ">" combinator rather than descendant
+	tag     string
+	id      string
+	classes []string
+	attrs   []attrMatch
+}
+
+type attrMatch struct {
+	name  string
+	op    string // "", "=" or "*="
+	value string
+}
+
+// parseSelector parses a comma-separated list of complex selectors
+func parseSelector(s string) (selector, error) {
+	var sel selector
+	for _, group := range strings.Split(s, ",") {
+		steps, err := parseComplex(strings.TrimSpace(group))
+		if err != nil {
+			return nil, err
+		}
+		sel = append(sel, steps)
+	}
+	return sel, nil
+}
+
+func parseComplex(s string) ([]selectorStep, error) {
+	if s == "" {
+		return nil, errors.New("empty selector")
+	}
+
+	var steps []selectorStep
+	child := false
+	for _, field := range strings.Fields(strings.ReplaceAll(s, ">", " > ")) {
+		if field == ">" {
+			if len(steps) == 0 || child {
+				return nil, errors.New("misplaced '>'")
+			}
+			child = true
+			continue
+		}
+
+		step, err := parseCompound(field)
+		if err != nil {
+			return nil, err
+		}
+		step.child = child
+		child = false
+		steps = append(steps, step)
+	}
+
+	if child {
+		return nil, errors.New("selector ends with '>'")
+	}
+	return steps, nil
+}
+
+func parseCompound(s string) (selectorStep, error) {
+	var step selectorStep
+
+	name := func(i int) int {
+		for i < len(s) && strings.IndexByte(".#[", s[i]) < 0 {
+			i++
+		}
+		return i
+	}
+
+	i := name(0)
+	step.tag = strings.ToLower(s[:i])
+	if step.tag == "*" {
+		step.tag = ""
+	}
+
+	for i < len(s) {
+		switch s[i] {
+		case '#', '.':
+			end := name(i + 1)
+			if end == i+1 {
+				return step, errors.New("missing name after '" + string(s[i]) + "'")
+			}
+			if s[i] == '#' {
+				step.id = s[i+1 : end]
+			} else {
+				step.classes = append(step.classes, s[i+1:end])
+			}
+			i = end
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return step, errors.New("unterminated '['")
+			}
+			step.attrs = append(step.attrs, parseAttr(s[i+1:i+end]))
+			i += end + 1
+		default:
+			return step, errors.New("unexpected " + string(s[i]))
+		}
+	}
+
+	return step, nil
+}
+
+func parseAttr(s string) attrMatch {
+	for _, op := range []string{"*=", "="} {
+		if name, value, ok := strings.Cut(s, op); ok {
+			return attrMatch{
+				name:  strings.ToLower(strings.TrimSpace(name)),
+				op:    op,
+				value: strings.Trim(strings.TrimSpace(value), `"'`),
+			}
+		}
+	}
+	return attrMatch{name: strings.ToLower(strings.TrimSpace(s))}
+}
+
+// selectAll returns the elements under root matching any group, in document order
+func (sel selector) selectAll(root *html.Node) []*html.Node {
+	var matches []*html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			for _, steps := range sel {
+				if matchSteps(n, steps, len(steps)-1) {
+					matches = append(matches, n)
+					break
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+	return matches
+}
+
+// matchSteps reports whether n matches steps[i] with its ancestors matching the earlier steps
+func matchSteps(n *html.Node, steps []selectorStep, i int) bool {
+	if !steps[i].matches(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+
+	for p := n.Parent; p != nil && p.Type == html.ElementNode; p = p.Parent {
+		if matchSteps(p, steps, i-1) {
+			return true
+		}
+		if steps[i].child {
+			return false
+		}
+	}
+	return false
+}
+
+func (st selectorStep) matches(n *html.Node) bool {
+	if n.Type != html.ElementNode || (st.tag != "" && n.Data != st.tag) {
+		return false
+	}
+	if st.id != "" && attrValue(n, "id") != st.id {
+		return false
+	}
+
+	classes := strings.Fields(attrValue(n, "class"))
+	for _, want := range st.classes {
+		found := false
+		for _, c := range classes {
+			if c == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for _, am := range st.attrs {
+		value, ok := lookupAttr(n, am.name)
+		switch {
+		case !ok:
+			return false
+		case am.op == "=" && value != am.value:
+			return false
+		case am.op == "*=" && !strings.Contains(value, am.value):
+			return false
+		}
+	}
+
+	return true
+}
+
+func attrValue(n *html.Node, name string) string {
+	value, _ := lookupAttr(n, name)
+	return value
+}
+
+func lookupAttr(n *html.Node, name string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,91 @@
+package extractor
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// Subject keywords of emails about a charge that isn't final. Cancellation
+// is checked first so "pending order cancelled" reads as cancelled.
+var (
+	cancelledKeywords = []string{"cancelled", "canceled", "cancellation", "voided", "cancelado", "cancelada", "anulado"}
+	pendingKeywords   = []string{"authorization", "authorisation", "authorized", "pre-auth", "pending", "temporary charge", "autorización", "pendiente"}
+)
+
+// refundWindow is how long after a charge a refund of the same amount, in
+// another thread, is taken to refund it
+const refundWindow = 90 * 24 * time.Hour
+
+// statusRank orders the statuses along a charge's life, so the status of
+// the latest stage reached wins when its emails are merged
+var statusRank = map[string]int{
+	models.StatusPending:   0,
+	models.StatusSettled:   1,
+	models.StatusRefunded:  2,
+	models.StatusCancelled: 3,
+}
+
+// emailStatus reads the status of the charge an email is about from its
+// subject. Refund emails settle the money they give back.
+func emailStatus(subject string) string {
+	subject = strings.ToLower(subject)
+	for _, keyword := range cancelledKeywords {
+		if strings.Contains(subject, keyword) {
+			return models.StatusCancelled
+		}
+	}
+	for _, keyword := range pendingKeywords {
+		if strings.Contains(subject, keyword) {
+			return models.StatusPending
+		}
+	}
+	return models.StatusSettled
+}
+
+// MergeStatus moves kept to the status of tx, another email about its
+// charge, when tx is further along: an authorization settles once the
+// receipt arrives and a cancellation cancels the charge
+func MergeStatus(kept, tx *models.Transaction) {
+	if statusRank[tx.EffectiveStatus()] > statusRank[kept.EffectiveStatus()] {
+		kept.Status = tx.EffectiveStatus()
+	}
+}
+
+// RefundedBy returns the charge among transactions that refund gives back
+// in full, or nil. The charge is in the thread or order of the refund or,
+// failing that, is the latest charge of the same service and amount before
+// it.
+func RefundedBy(transactions []*models.Transaction, refund *models.Transaction) *models.Transaction {
+	if !IsRefund(refund) {
+		return nil
+	}
+	var match *models.Transaction
+	for _, tx := range transactions {
+		if IsRefund(tx) || tx.ServiceID != refund.ServiceID || tx.Currency != refund.Currency {
+			continue
+		}
+		if (tx.ThreadID != "" && tx.ThreadID == refund.ThreadID) || (tx.OrderID != "" && tx.OrderID == refund.OrderID) {
+			if -refund.Amount >= tx.Amount {
+				return tx
+			}
+			return nil
+		}
+		gap := refund.Date.Sub(tx.Date)
+		if tx.Amount == -refund.Amount && gap >= 0 && gap <= refundWindow && (match == nil || tx.Date.After(match.Date)) {
+			match = tx
+		}
+	}
+	return match
+}
+
+// markRefunded sets the status of the charges refunded in full by a refund
+// among transactions
+func markRefunded(transactions []*models.Transaction) {
+	for _, tx := range transactions {
+		if charge := RefundedBy(transactions, tx); charge != nil {
+			MergeStatus(charge, &models.Transaction{Status: models.StatusRefunded})
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package extractor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"golang.org/x/net/html"
+)
+
+// Extraction strategy types usable in a service's "extraction" list
+const (
+	StrategyScan  = "scan"
+	StrategyRegex = "regex"
+	StrategyCSS   = "css-selector"
+	StrategyTable = "table-scan"
+	StrategyLLM   = "llm"
+)
+
+// defaultTableLabels are the row labels table-scan looks for when none are configured
+var defaultTableLabels = []string{"total", "amount charged", "amount paid", "grand total"}
+
+// StrategyConfig configures one extraction stage of a service. Stages run in
+// order and the first one that finds an amount wins.
+type StrategyConfig struct {
+	Type     string   `json:"type"`
+	Pattern  string   `json:"pattern,omitempty"`  // regex: the amount is capture group 1, or the whole match
+	Selector string   `json:"selector,omitempty"` // css-selector: elements whose text holds the amount
+	Labels   []string `json:"labels,omitempty"`   // table-scan: row labels to look for
+}
+
+// document is a message body prepared for the strategies. Text strategies
+// read the plain-text body; the HTML tree, from the HTML alternative when
+// there is one, is only parsed when a strategy needs it.
+type document struct {
+	html   string
+	text   string
+	root   *html.Node
+	parsed bool
+}
+
+func newDocument(body, htmlBody string) *document {
+	if htmlBody == "" && strings.Contains(body, "<") {
+		htmlBody = body
+	}
+	return &document{html: htmlBody, text: htmlToText(body)}
+}
+
+// tree returns the parsed HTML body, or nil for plain-text emails
+func (d *document) tree() *html.Node {
+	if !d.parsed {
+		d.parsed = true
+		if d.html != "" {
+			d.root, _ = html.Parse(strings.NewReader(d.html))
+		}
+	}
+	return d.root
+}
+
+// strategy finds amount candidates in a document
+type strategy interface {
+	extract(doc *document, service *Service) []amountCandidate
+}
+
+// defaultStrategies is used by services without an "extraction" list
+var defaultStrategies = []strategy{scanStrategy{}}
+
+// compileStrategies validates a service's extraction list
+func compileStrategies(service Service) ([]strategy, error) {
+	if len(service.Extraction) == 0 {
+		return defaultStrategies, nil
+	}
+
+	strategies := make([]strategy, 0, len(service.Extraction))
+	for i, cfg := range service.Extraction {
+		invalid := func(format string, a ...interface{}) error {
+			return fmt.Errorf("%w: service %q extraction[%d]: %s", apperrors.ErrTrackerConfig, service.ID, i, fmt.Sprintf(format, a...))
+		}
+
+		switch cfg.Type {
+		case StrategyScan:
+			strategies = append(strategies, scanStrategy{})
+		case StrategyRegex:
+			re, err := regexp.Compile(cfg.Pattern)
+			if err != nil || cfg.Pattern == "" {
+				return nil, invalid("invalid pattern %q: %v", cfg.Pattern, err)
+			}
+			strategies = append(strategies, regexStrategy{re: re})
+		case StrategyCSS:
+			sel, err := parseSelector(cfg.Selector)
+			if err != nil {
+				return nil, invalid("invalid selector %q: %v", cfg.Selector, err)
+			}
+			strategies = append(strategies, cssStrategy{sel: sel})
+		case StrategyTable:
+			labels := cfg.Labels
+			if len(labels) == 0 {
+				labels = defaultTableLabels
+			}
+			strategies = append(strategies, tableStrategy{labels: lowerAll(labels)})
+		case StrategyLLM:
+			strategies = append(strategies, newLLMStrategy())
+		default:
+			return nil, invalid("unknown strategy type %q", cfg.Type)
+		}
+	}
+
+	return strategies, nil
+}
+
+// scanStrategy considers every amount in the message text
+type scanStrategy struct{}
+
+func (scanStrategy) extract(doc *document, _ *Service) []amountCandidate {
+	return scanAmounts(doc.text)
+}
+
+// regexStrategy takes amounts from the matches of a merchant-specific pattern
+type regexStrategy struct {
+	re *regexp.Regexp
+}
+
+func (s regexStrategy) extract(doc *document, service *Service) []amountCandidate {
+	var candidates []amountCandidate
+	for _, match := range s.re.FindAllStringSubmatch(doc.text, -1) {
+		text := match[0]
+		if len(match) > 1 && match[1] != "" {
+			text = match[1]
+		}
+		candidates = append(candidates, scanAmounts(text)...)
+	}
+	return targeted(candidates, service)
+}
+
+// cssStrategy takes amounts from the text of the elements matching a selector
+type cssStrategy struct {
+	sel selector
+}
+
+func (s cssStrategy) extract(doc *document, service *Service) []amountCandidate {
+	root := doc.tree()
+	if root == nil {
+		return nil
+	}
+
+	var candidates []amountCandidate
+	for _, n := range s.sel.selectAll(root) {
+		var sb strings.Builder
+		writeNodeText(&sb, n)
+		candidates = append(candidates, scanAmounts(sb.String())...)
+	}
+	return targeted(candidates, service)
+}
+
+// tableStrategy takes amounts from table rows whose first cell carries one of the labels
+type tableStrategy struct {
+	labels []string
+}
+
+func (s tableStrategy) extract(doc *document, service *Service) []amountCandidate {
+	var candidates []amountCandidate
+	for _, line := range strings.Split(doc.text, "\n") {
+		label, rest, ok := strings.Cut(line, cellSeparator)
+		if !ok {
+			continue
+		}
+		label = strings.ToLower(strings.TrimSpace(label))
+		for _, want := range s.labels {
+			if strings.Contains(label, want) {
+				candidates = append(candidates, scanAmounts(rest)...)
+				break
+			}
+		}
+	}
+	return targeted(candidates, service)
+}
+
+// targeted marks candidates found by a merchant-specific strategy as explicit
+// totals and tags untagged ones with the service's configured currency
+func targeted(candidates []amountCandidate, service *Service) []amountCandidate {
+	for i := range candidates {
+		candidates[i].labeled = true
+		if candidates[i].currency == "" {
+			candidates[i].currency = service.PricePattern.Currency
+		}
+	}
+	return candidates
+}
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, v := range values {
+		lowered[i] = strings.ToLower(v)
+	}
+	return lowered
+}
@@ -0,0 +1,85 @@
+package extractor
+
+import (
+	"time"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// threadWindow is how far apart the emails of a thread can be and still be
+// about one purchase. Gmail also threads receipts with the same subject,
+// such as a monthly subscription's, which are separate charges.
+const threadWindow = 4 * 24 * time.Hour
+
+// SameThread reports whether a and b look like emails about one purchase in
+// a thread: the same thread, both charges or both refunds, within
+// threadWindow of each other
+func SameThread(a, b *models.Transaction) bool {
+	if a.ThreadID == "" || ThreadKey(a) != ThreadKey(b) {
+		return false
+	}
+	gap := a.Date.Sub(b.Date)
+	return gap < threadWindow && gap > -threadWindow
+}
+
+// suppressThreadDuplicates keeps one transaction per purchase in an email
+// thread so a receipt and its "your order has shipped" follow-ups aren't
+// counted several times. The kept transaction is the one with an explicit
+// total (earliest first) and the other messages about the purchase are
+// linked to it through RelatedIDs. A refund in the thread of its purchase
+// is kept separately, and so are emails of the thread further apart than
+// threadWindow.
+func suppressThreadDuplicates(transactions []*models.Transaction, explicitTotals map[*models.Transaction]bool) []*models.Transaction {
+	var result []*models.Transaction
+	byThread := make(map[string][]int) // Indexes in result
+	for _, tx := range transactions {
+		i := -1
+		for _, j := range byThread[ThreadKey(tx)] {
+			if SameThread(tx, result[j]) {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			if tx.ThreadID != "" {
+				byThread[ThreadKey(tx)] = append(byThread[ThreadKey(tx)], len(result))
+			}
+			result = append(result, tx)
+			continue
+		}
+
+		kept := result[i]
+		if preferInThread(tx, kept, explicitTotals) {
+			kept, tx = tx, kept
+			result[i] = kept
+		}
+		kept.RelatedIDs = append(kept.RelatedIDs, tx.ID)
+		kept.RelatedIDs = append(kept.RelatedIDs, tx.RelatedIDs...)
+		MergeStatus(kept, tx)
+		MergeInvoice(kept, tx)
+	}
+
+	return result
+}
+
+// preferInThread reports whether candidate should replace current as the
+// transaction that represents their thread. An order, which may add up
+// several emails, represents the thread it is in.
+func preferInThread(candidate, current *models.Transaction, explicitTotals map[*models.Transaction]bool) bool {
+	if (candidate.OrderID != "") != (current.OrderID != "") {
+		return candidate.OrderID != ""
+	}
+	if explicitTotals[candidate] != explicitTotals[current] {
+		return explicitTotals[candidate]
+	}
+	return candidate.Date.Before(current.Date)
+}
+
+// ThreadKey groups the transactions of a thread: its charges, and
+// separately its refunds
+func ThreadKey(tx *models.Transaction) string {
+	if IsRefund(tx) {
+		return tx.ThreadID + "\x00refund"
+	}
+	return tx.ThreadID
+}
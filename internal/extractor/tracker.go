@@ -0,0 +1,163 @@
+package extractor
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/sazardev/go-money/internal/paths"
+)
+
+// TrackerFile holds the services transactions are extracted for
+const TrackerFile = "tracker-mails.json"
+
+// defaultTracker is the built-in service catalog, which the user's
+// TrackerFile adds to
+//
+//go:embed tracker-mails.json
+var defaultTracker []byte
+
+// trackerOverride is the tracker file given with --tracker
+var trackerOverride string
+
+// SetTrackerPath makes the extractor read the user's services from path,
+// over GM_TRACKER
+func SetTrackerPath(path string) {
+	trackerOverride = path
+}
+
+// TrackerPath returns where the user's TrackerFile is read from: the path
+// set with SetTrackerPath, GM_TRACKER, or the configuration directory
+func TrackerPath() string {
+	if trackerOverride != "" {
+		return trackerOverride
+	}
+	if path := os.Getenv("GM_TRACKER"); path != "" {
+		return path
+	}
+	return paths.Config(TrackerFile)
+}
+
+// trackerGiven reports whether the path of the user's TrackerFile was
+// given, so it must exist
+func trackerGiven() bool {
+	return trackerOverride != "" || os.Getenv("GM_TRACKER") != ""
+}
+
+// serviceID is the form of a service ID
+var serviceID = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ValidServiceID reports whether id can name a service
+func ValidServiceID(id string) bool {
+	return serviceID.MatchString(id)
+}
+
+// ValidateService checks that service can be written to a tracker file: it
+// has an ID, a name and a category, is recognized by a sender domain or a
+// keyword, has a currency code and valid extraction stages
+func ValidateService(service Service) error {
+	switch {
+	case !ValidServiceID(service.ID):
+		return fmt.Errorf("invalid service ID %q: use lowercase letters, digits, - and _", service.ID)
+	case service.Name == "":
+		return fmt.Errorf("service %q has no name", service.ID)
+	case service.Category == "":
+		return fmt.Errorf("service %q has no category", service.ID)
+	case len(service.EmailDomains) == 0 && len(service.Keywords) == 0:
+		return fmt.Errorf("service %q needs an email domain or a keyword to be recognized", service.ID)
+	case currencyByCode[service.PricePattern.Currency].code == "":
+		return fmt.Errorf("invalid currency %q for service %q: use an ISO 4217 code such as USD", service.PricePattern.Currency, service.ID)
+	}
+	if _, err := compileStrategies(service); err != nil {
+		return err
+	}
+	_, err := compilePatterns(service)
+	return err
+}
+
+// trackerFile is the layout of TrackerFile, kept in its key order so
+// rewriting the file only changes what was added
+type trackerFile struct {
+	Services []json.RawMessage `json:"services"`
+	Metadata struct {
+		LastUpdated   string   `json:"lastUpdated"`
+		TotalServices int      `json:"totalServices"`
+		Categories    []string `json:"categories"`
+	} `json:"metadata"`
+}
+
+// AddService appends a service to the tracker file at path, updating its
+// metadata. Services already listed under the same ID are refused.
+func AddService(path string, service Service) error {
+	return writeService(path, service, false)
+}
+
+// SaveService writes a service to the tracker file at path, replacing the
+// one listed under the same ID or appending it, and creates the file when
+// there is none
+func SaveService(path string, service Service) error {
+	return writeService(path, service, true)
+}
+
+func writeService(path string, service Service, replace bool) error {
+	var tracker trackerFile
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist) && replace:
+	case err != nil:
+		return err
+	default:
+		if err := json.Unmarshal(data, &tracker); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+
+	raw, err := json.Marshal(service)
+	if err != nil {
+		return err
+	}
+	index := -1
+	for i, existingRaw := range tracker.Services {
+		var existing struct {
+			ID string `json:"id"`
+		}
+		if json.Unmarshal(existingRaw, &existing) == nil && existing.ID == service.ID {
+			index = i
+			break
+		}
+	}
+	switch {
+	case index < 0:
+		tracker.Services = append(tracker.Services, raw)
+	case replace:
+		tracker.Services[index] = raw
+	default:
+		return fmt.Errorf("%s already has a service with ID %q", path, service.ID)
+	}
+
+	tracker.Metadata.LastUpdated = time.Now().Format("2006-01-02")
+	tracker.Metadata.TotalServices = len(tracker.Services)
+	if !slices.Contains(tracker.Metadata.Categories, service.Category) {
+		tracker.Metadata.Categories = append(tracker.Metadata.Categories, service.Category)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "    ")
+	if err := encoder.Encode(tracker); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, bytes.TrimSuffix(buf.Bytes(), []byte("\n")), 0644)
+}
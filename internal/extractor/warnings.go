@@ -0,0 +1,96 @@
+package extractor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// ambiguousMargin is the score within which another amount makes the pick
+// a coin toss
+const ambiguousMargin = 1
+
+// Dates further than these from when an email was sent are suspicious
+const (
+	maxDateBefore = 90 * 24 * time.Hour
+	maxDateAfter  = 24 * time.Hour
+)
+
+// severityRank orders severities, most severe first
+var severityRank = map[string]int{
+	models.SeverityHigh:   0,
+	models.SeverityMedium: 1,
+	models.SeverityLow:    2,
+}
+
+// Warnings returns the doubts about the values picked for the transactions
+// of messages, most severe first. Messages no parser recognizes have none.
+func (te *TransactionExtractor) Warnings(messages []*models.Message) []models.ExtractionWarning {
+	var warnings []models.ExtractionWarning
+	for _, msg := range messages {
+		if p := te.parse(msg); p != nil {
+			warnings = append(warnings, extractionWarnings(msg, p, te.transaction(msg, p))...)
+		}
+	}
+	slices.SortStableFunc(warnings, func(a, b models.ExtractionWarning) int {
+		return severityRank[a.Severity] - severityRank[b.Severity]
+	})
+	return warnings
+}
+
+// extractionWarnings lists what is doubtful about tx, read from msg: its
+// currency, its amount among the other candidates and its date
+func extractionWarnings(msg *models.Message, p *parsed, tx *models.Transaction) []models.ExtractionWarning {
+	var warnings []models.ExtractionWarning
+	add := func(severity, kind, format string, a ...interface{}) {
+		warnings = append(warnings, models.ExtractionWarning{
+			MessageID: msg.ID,
+			Subject:   msg.Subject,
+			Severity:  severity,
+			Kind:      kind,
+			Message:   fmt.Sprintf(format, a...),
+		})
+	}
+	best := p.amounts[0]
+
+	// Currency
+	var others []string
+	for _, c := range p.amounts[1:] {
+		if !c.assumed && c.currency != best.currency && !slices.Contains(others, c.currency) {
+			others = append(others, c.currency)
+		}
+	}
+	switch {
+	case best.assumed:
+		add(models.SeverityMedium, models.WarningCurrency, "no currency next to %q; assumed USD", best.raw)
+	case len(others) > 0:
+		add(models.SeverityLow, models.WarningCurrency, "amounts in %s too; picked %s", strings.Join(others, ", "), best.currency)
+	}
+
+	// Amount
+	if best.guessed {
+		add(models.SeverityHigh, models.WarningAmount, "picked %.2f by its size and position alone", best.value)
+	} else if len(p.amounts) > 1 {
+		runnerUp := p.amounts[1]
+		if runnerUp.value != best.value && best.score-runnerUp.score < ambiguousMargin {
+			add(models.SeverityMedium, models.WarningAmount, "%.2f scored about as well as the %.2f picked", runnerUp.value, best.value)
+		}
+	}
+
+	// Date
+	if msg.Date.IsZero() {
+		return warnings
+	}
+	switch {
+	case tx.Date.Equal(msg.Date):
+		add(models.SeverityLow, models.WarningDate, "no date in the email; used the date it was sent")
+	case tx.Date.After(msg.Date.Add(maxDateAfter)):
+		add(models.SeverityHigh, models.WarningDate, "dated %s, after the email was sent on %s", tx.Date.Format("2006-01-02"), msg.Date.Format("2006-01-02"))
+	case tx.Date.Before(msg.Date.Add(-maxDateBefore)):
+		add(models.SeverityMedium, models.WarningDate, "dated %s, %d days before the email was sent", tx.Date.Format("2006-01-02"), int(msg.Date.Sub(tx.Date).Hours()/24))
+	}
+	return warnings
+}
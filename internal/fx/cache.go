@@ -0,0 +1,57 @@
+package fx
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sazardev/go-money/internal/cache"
+)
+
+// cacheNamespace groups exchange rates in the shared lookup cache
+const cacheNamespace = "fx"
+
+// DefaultCacheTTL is how long cached rates are used before refreshing;
+// reference rates are published once per working day
+const DefaultCacheTTL = 12 * time.Hour
+
+// Cache wraps a provider and keeps its rates in the lookup cache, so rates
+// are fetched at most once per TTL and stale rates are used if a refresh fails
+type Cache struct {
+	Provider Provider
+	Store    *cache.Cache
+	Key      string // Identifies the provider's rates in the cache
+	TTL      time.Duration
+}
+
+// NewCache caches the rates of provider, identified by key, for ttl
+func NewCache(provider Provider, store *cache.Cache, key string, ttl time.Duration) *Cache {
+	return &Cache{Provider: provider, Store: store, Key: key, TTL: ttl}
+}
+
+// Latest returns cached rates while they are fresh, otherwise fetches new ones
+func (c *Cache) Latest(ctx context.Context) (*Rates, error) {
+	var cached Rates
+	found, fresh, cacheErr := c.Store.Get(cacheNamespace, c.Key, &cached)
+	if cacheErr != nil {
+		log.Printf("⚠️  Warning: ignoring cached exchange rates: %v", cacheErr)
+		found = false
+	}
+	if found && fresh {
+		return &cached, nil
+	}
+
+	rates, err := c.Provider.Latest(ctx)
+	if err != nil {
+		if found {
+			log.Printf("⚠️  Warning: using exchange rates from %s: %v", cached.Date, err)
+			return &cached, nil
+		}
+		return nil, err
+	}
+
+	if err := c.Store.Set(cacheNamespace, c.Key, rates, c.TTL); err != nil {
+		log.Printf("⚠️  Warning: could not cache exchange rates: %v", err)
+	}
+	return rates, nil
+}
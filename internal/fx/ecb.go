@@ -0,0 +1,69 @@
+package fx
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ecbDailyURL publishes the euro reference rates every working day
+const ecbDailyURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+// ECB fetches the European Central Bank euro reference rates. No API key is needed.
+type ECB struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewECB returns a provider for the ECB daily reference rates
+func NewECB() *ECB {
+	return &ECB{URL: ecbDailyURL, Client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+type ecbEnvelope struct {
+	Cube struct {
+		Days []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string  `xml:"currency,attr"`
+				Rate     float64 `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// Latest returns the most recent reference rates, based on EUR
+func (p *ECB) Latest(ctx context.Context) (*Rates, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ECB rates: unexpected status %s", resp.Status)
+	}
+
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("ECB rates: %w", err)
+	}
+	if len(envelope.Cube.Days) == 0 {
+		return nil, fmt.Errorf("ECB rates: no rates published")
+	}
+
+	day := envelope.Cube.Days[0]
+	rates := &Rates{Base: "EUR", Date: day.Time, Source: "ECB", Rates: make(map[string]float64)}
+	for _, r := range day.Rates {
+		rates.Rates[r.Currency] = r.Rate
+	}
+
+	return rates, nil
+}
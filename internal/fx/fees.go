@@ -0,0 +1,42 @@
+package fx
+
+import (
+	"math"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// FeeCategory is the category of estimated foreign transaction fees
+const FeeCategory = "Foreign Transaction Fees"
+
+// MaxFeePercent bounds the foreign transaction fee a card can charge
+const MaxFeePercent = 20
+
+// EstimateFees returns an estimated foreign transaction fee for every charge
+// in a currency other than primary, the currency of the card: percent of
+// the charge, in its currency, dated like it. Refunds get none, as card
+// issuers rarely give the fee back.
+func EstimateFees(transactions []*models.Transaction, primary string, percent float64) []*models.Transaction {
+	var fees []*models.Transaction
+	for _, tx := range transactions {
+		if tx.Amount <= 0 || strings.EqualFold(tx.Currency, primary) {
+			continue
+		}
+		fees = append(fees, &models.Transaction{
+			ID:             tx.ID + "#fx-fee",
+			ServiceID:      tx.ServiceID,
+			ServiceName:    tx.ServiceName + " (FX fee)",
+			Category:       FeeCategory,
+			Amount:         math.Round(tx.Amount*percent) / 100,
+			Currency:       tx.Currency,
+			CurrencySymbol: tx.CurrencySymbol,
+			Date:           tx.Date,
+			Description:    "Estimated foreign transaction fee",
+			Account:        tx.Account,
+			Member:         tx.Member,
+			Status:         tx.Status,
+		})
+	}
+	return fees
+}
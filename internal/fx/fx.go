@@ -0,0 +1,110 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// ErrUnknownCurrency is returned when no rate is known for a currency
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// Rates are exchange rates quoted as units of each currency per one unit of Base
+type Rates struct {
+	Base   string             `json:"base"`
+	Date   string             `json:"date"` // Day the rates were published (YYYY-MM-DD)
+	Source string             `json:"source"`
+	Rates  map[string]float64 `json:"rates"`
+}
+
+// Provider supplies exchange rates
+type Provider interface {
+	Latest(ctx context.Context) (*Rates, error)
+}
+
+// Converter converts amounts between currencies using one set of rates
+type Converter struct {
+	rates *Rates
+}
+
+// NewConverter loads the latest rates from provider
+func NewConverter(ctx context.Context, provider Provider) (*Converter, error) {
+	rates, err := provider.Latest(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load exchange rates: %w", err)
+	}
+	return &Converter{rates: rates}, nil
+}
+
+// Rates returns the rates used for conversions
+func (c *Converter) Rates() *Rates {
+	return c.rates
+}
+
+// Convert converts amount from one currency code to another
+func (c *Converter) Convert(amount float64, from, to string) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return amount, nil
+	}
+
+	fromRate, err := c.rate(from)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := c.rate(to)
+	if err != nil {
+		return 0, err
+	}
+
+	return amount / fromRate * toRate, nil
+}
+
+// Supports reports whether rates are known for currency
+func (c *Converter) Supports(currency string) bool {
+	_, err := c.rate(strings.ToUpper(currency))
+	return err == nil
+}
+
+func (c *Converter) rate(currency string) (float64, error) {
+	if currency == c.rates.Base {
+		return 1, nil
+	}
+	if rate, ok := c.rates.Rates[currency]; ok && rate > 0 {
+		return rate, nil
+	}
+	return 0, fmt.Errorf("%w: %s", ErrUnknownCurrency, currency)
+}
+
+// ConvertTransactions returns copies of transactions with amounts converted
+// to base. Transactions in currencies without a known rate are returned
+// separately, unconverted.
+func (c *Converter) ConvertTransactions(transactions []*models.Transaction, base string) (converted, skipped []*models.Transaction) {
+	base = strings.ToUpper(base)
+	symbol := base + " "
+	for _, tx := range transactions {
+		if strings.EqualFold(tx.Currency, base) && tx.CurrencySymbol != "" {
+			symbol = tx.CurrencySymbol
+			break
+		}
+	}
+
+	for _, tx := range transactions {
+		amount, err := c.Convert(tx.Amount, tx.Currency, base)
+		if err != nil {
+			skipped = append(skipped, tx)
+			continue
+		}
+
+		copied := *tx
+		copied.Amount = amount
+		copied.Currency = base
+		copied.CurrencySymbol = symbol
+		converted = append(converted, &copied)
+	}
+
+	return converted, skipped
+}
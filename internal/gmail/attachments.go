@@ -0,0 +1,105 @@
+package gmail
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/sazardev/go-money/internal/mail"
+	"github.com/sazardev/go-money/internal/models"
+	gmail "google.golang.org/api/gmail/v1"
+)
+
+// SetAttachments enables or disables downloading PDF attachments, which
+// some merchants use for the only copy of the amount
+func (gs *GmailService) SetAttachments(enabled bool) {
+	gs.attachments = enabled
+}
+
+// pdfParts returns the PDF attachments in a MIME tree
+func pdfParts(part *gmail.MessagePart) []*gmail.MessagePart {
+	if part == nil {
+		return nil
+	}
+	var parts []*gmail.MessagePart
+	isPDF := strings.EqualFold(part.MimeType, "application/pdf") ||
+		(part.Filename != "" && strings.HasSuffix(strings.ToLower(part.Filename), ".pdf"))
+	if isPDF && part.Body != nil && part.Body.Size <= mail.MaxAttachmentSize {
+		parts = append(parts, part)
+	}
+	for _, child := range part.Parts {
+		parts = append(parts, pdfParts(child)...)
+	}
+	return parts
+}
+
+// fetchAttachments downloads the PDF attachments of the fetched messages
+// into the matching messages, skipping attachments that fail on their own
+func (gs *GmailService) fetchAttachments(ctx context.Context, fetched []*gmail.Message, messages []*models.Message) error {
+	type job struct {
+		message *models.Message
+		index   int
+		id      string
+		part    *gmail.MessagePart
+	}
+	var jobs []job
+	for i, message := range fetched {
+		parts := pdfParts(message.Payload)
+		if len(parts) == 0 {
+			continue
+		}
+		messages[i].Attachments = make([]models.Attachment, len(parts))
+		for j, part := range parts {
+			jobs = append(jobs, job{message: messages[i], index: j, id: message.Id, part: part})
+		}
+	}
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	var (
+		mu   sync.Mutex
+		done int
+	)
+	skipped, err := gs.forEach(ctx, len(jobs), func(ctx context.Context, i int) error {
+		j := jobs[i]
+		data := j.part.Body.Data
+		if data == "" {
+			body, err := gs.service.Users.Messages.Attachments.Get("me", j.id, j.part.Body.AttachmentId).Context(ctx).Do()
+			if err != nil {
+				return wrapAPIError("unable to retrieve attachment", err)
+			}
+			data = body.Data
+		}
+		content, err := decodeBase64URL(data)
+
+		mu.Lock()
+		defer mu.Unlock()
+		done++
+		gs.report(Progress{Stage: StageAttachments, Done: done, Total: len(jobs), Finished: done == len(jobs)})
+		if err != nil {
+			return err
+		}
+		j.message.Attachments[j.index] = models.Attachment{Filename: j.part.Filename, MimeType: j.part.MimeType, Data: content}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(skipped) > 0 {
+		log.Printf("⚠️  Warning: Could not download %d of %d attachments: %v", len(skipped), len(jobs), skipped[0])
+	}
+
+	// Drop the attachments that failed
+	for _, message := range messages {
+		kept := message.Attachments[:0]
+		for _, attachment := range message.Attachments {
+			if attachment.Data != nil {
+				kept = append(kept, attachment)
+			}
+		}
+		message.Attachments = kept
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package gmail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	gmail "google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// Batch sizes: Gmail accepts up to 100 requests per batch but recommends
+// at most 50 to avoid rate limiting
+const (
+	DefaultBatchSize = 50
+	MaxBatchSize     = 100
+)
+
+// batchEndpoint is the Gmail API batch URL
+const batchEndpoint = "https://gmail.googleapis.com/batch/gmail/v1"
+
+// getAll fetches messages in the given format ("full" or "metadata"),
+// batching requests when enabled and running up to gs.concurrency requests
+// at a time. The result is indexed like ids; messages that failed on their
+// own are nil and their errors are returned as skipped.
+func (gs *GmailService) getAll(ctx context.Context, ids []string, format string) ([]*gmail.Message, []error, error) {
+	messages := make([]*gmail.Message, len(ids))
+
+	stage := StageDownloading
+	if format == "metadata" {
+		stage = StageScreening
+	}
+	var (
+		doneMu sync.Mutex
+		done   int
+	)
+	advance := func(n int) {
+		// Report under the lock so counts never go backwards
+		doneMu.Lock()
+		defer doneMu.Unlock()
+		done += n
+		gs.report(Progress{Stage: stage, Done: done, Total: len(ids), Finished: done == len(ids)})
+	}
+
+	if gs.batchSize <= 1 {
+		skipped, err := gs.forEach(ctx, len(ids), func(ctx context.Context, i int) error {
+			call := gs.service.Users.Messages.Get("me", ids[i]).Format(format).Context(ctx)
+			if format == "metadata" {
+				call = call.MetadataHeaders(metadataHeaders...)
+			}
+			message, err := call.Do()
+			advance(1)
+			if err != nil {
+				return wrapAPIError("unable to retrieve message", err)
+			}
+			messages[i] = message
+			return nil
+		})
+		return messages, skipped, err
+	}
+
+	query := url.Values{"format": {format}}
+	if format == "metadata" {
+		query["metadataHeaders"] = metadataHeaders
+	}
+
+	var (
+		mu      sync.Mutex
+		skipped []error
+	)
+	batches := (len(ids) + gs.batchSize - 1) / gs.batchSize
+	failed, err := gs.forEach(ctx, batches, func(ctx context.Context, b int) error {
+		start := b * gs.batchSize
+		end := min(start+gs.batchSize, len(ids))
+
+		batch, errs, err := gs.batchGet(ctx, ids[start:end], query)
+		advance(end - start)
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for i, err := range errs {
+			if err == nil {
+				messages[start+i] = batch[i]
+				continue
+			}
+			if fatal(err) {
+				return err
+			}
+			skipped = append(skipped, err)
+		}
+		return nil
+	})
+
+	return messages, append(skipped, failed...), err
+}
+
+// batchGet sends one users.messages.get request per ID, with the options
+// in query, as a single batch HTTP request. Messages and errors are
+// indexed like ids; the error is set when the whole batch failed.
+func (gs *GmailService) batchGet(ctx context.Context, ids []string, query url.Values) ([]*gmail.Message, []error, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for i, id := range ids {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", "application/http")
+		header.Set("Content-ID", fmt.Sprintf("<item%d>", i))
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			return nil, nil, err
+		}
+		fmt.Fprintf(part, "GET /gmail/v1/users/me/messages/%s?%s\r\n\r\n", url.PathEscape(id), query.Encode())
+	}
+	if err := mw.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, batchEndpoint, &body)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+
+	resp, err := gs.client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to send batch request: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := googleapi.CheckResponse(resp); err != nil {
+		return nil, nil, wrapAPIError("unable to send batch request", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		return nil, nil, fmt.Errorf("unexpected batch response type %q", resp.Header.Get("Content-Type"))
+	}
+
+	messages := make([]*gmail.Message, len(ids))
+	errs := make([]error, len(ids))
+	reader := multipart.NewReader(resp.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read batch response: %w", err)
+		}
+
+		i, ok := batchIndex(part.Header.Get("Content-ID"), len(ids))
+		if !ok {
+			continue
+		}
+		res, err := http.ReadResponse(bufio.NewReader(part), nil)
+		if err != nil {
+			errs[i] = fmt.Errorf("unable to read batch response for message %s: %w", ids[i], err)
+			continue
+		}
+		messages[i], errs[i] = decodeBatchPart(res)
+	}
+
+	for i := range ids {
+		if messages[i] == nil && errs[i] == nil {
+			errs[i] = fmt.Errorf("no batch response for message %s", ids[i])
+		}
+	}
+	return messages, errs, nil
+}
+
+// batchIndex parses the Content-ID of a batch response part, such as
+// "<response-item3>", into the index of its request
+func batchIndex(contentID string, n int) (int, bool) {
+	id := strings.Trim(contentID, "<>")
+	id = strings.TrimPrefix(id, "response-")
+	i, err := strconv.Atoi(strings.TrimPrefix(id, "item"))
+	if err != nil || i < 0 || i >= n {
+		return 0, false
+	}
+	return i, true
+}
+
+// decodeBatchPart decodes the response to one request of a batch
+func decodeBatchPart(res *http.Response) (*gmail.Message, error) {
+	defer res.Body.Close()
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, wrapAPIError("unable to retrieve message", err)
+	}
+
+	var message gmail.Message
+	if err := json.NewDecoder(res.Body).Decode(&message); err != nil {
+		return nil, fmt.Errorf("unable to decode message: %w", err)
+	}
+	return &message, nil
+}
@@ -2,22 +2,41 @@ package gmail
 
 import (
 	"context"
-	"encoding/base64"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/sazardev/go-money/internal/apperrors"
 	"github.com/sazardev/go-money/internal/auth"
 	"github.com/sazardev/go-money/internal/models"
 	"golang.org/x/oauth2"
 	gmail "google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
 )
 
 type GmailService struct {
-	service *gmail.Service
+	service     *gmail.Service
+	client      *http.Client
+	prefilter   bool
+	attachments bool
+	concurrency int
+	batchSize   int
+	delay       time.Duration
+	maxMessages int
+	progress    func(Progress)
+	progressMu  sync.Mutex
 }
 
+// listPageSize is the largest page of IDs users.messages.list returns
+const listPageSize = 500
+
+// errListLimit stops paging once the message limit is reached
+var errListLimit = errors.New("message limit reached")
+
 // NewGmailService creates a new Gmail service instance
 func NewGmailService(ctx context.Context, token *oauth2.Token) (*GmailService, error) {
 	authenticator := auth.NewAuthenticator()
@@ -25,53 +44,237 @@ func NewGmailService(ctx context.Context, token *oauth2.Token) (*GmailService, e
 
 	service, err := gmail.New(client)
 	if err != nil {
-		log.Fatalf("Unable to create Gmail service: %v", err)
-		return nil, err
+		return nil, fmt.Errorf("unable to create Gmail service: %w", err)
 	}
 
-	return &GmailService{service: service}, nil
+	return &GmailService{
+		service:     service,
+		client:      client,
+		prefilter:   true,
+		attachments: true,
+		concurrency: DefaultConcurrency,
+		batchSize:   DefaultBatchSize,
+	}, nil
+}
+
+// SetPrefilter enables or disables the metadata-based pre-filtering that
+// skips downloading bodies of messages that are unlikely to be receipts
+func (gs *GmailService) SetPrefilter(enabled bool) {
+	gs.prefilter = enabled
+}
+
+// SetConcurrency sets how many messages are fetched in parallel
+func (gs *GmailService) SetConcurrency(workers int) {
+	gs.concurrency = workers
+}
+
+// SetBatchSize sets how many messages are requested per batch HTTP
+// request; 0 or 1 sends one request per message
+func (gs *GmailService) SetBatchSize(size int) {
+	gs.batchSize = size
+}
+
+// SetDelay makes every request for a message, an attachment or a page of
+// results wait d first, to stay well under quotas shared with other users
+func (gs *GmailService) SetDelay(d time.Duration) {
+	gs.delay = d
+}
+
+// SetMaxMessages limits how many messages a search lists; 0 is unlimited
+func (gs *GmailService) SetMaxMessages(limit int) {
+	gs.maxMessages = limit
 }
 
 // GetMessages retrieves messages from Gmail with optional query
 func (gs *GmailService) GetMessages(ctx context.Context, query string) ([]*models.Message, error) {
-	var messages []*models.Message
+	ids, err := gs.ListMessageIDs(ctx, query)
+	if err != nil {
+		return nil, err
+	}
 
-	// List messages
-	call := gs.service.Users.Messages.List("me")
+	if len(ids) == 0 {
+		log.Println("No messages found.")
+		return nil, nil
+	}
+
+	return gs.fetchMessages(ctx, ids)
+}
+
+// GetMessagesForQueries runs several queries, de-duplicates the matching
+// message IDs and fetches each unique message only once
+func (gs *GmailService) GetMessagesForQueries(ctx context.Context, queries []string) ([]*models.Message, error) {
+	ids, err := gs.ListMessageIDsForQueries(ctx, queries)
+	if err != nil {
+		return nil, err
+	}
+
+	return gs.fetchMessages(ctx, ids)
+}
+
+// ListMessageIDsForQueries runs several queries and returns the unique IDs
+// of the matching messages, in the order they were first seen
+func (gs *GmailService) ListMessageIDsForQueries(ctx context.Context, queries []string) ([]string, error) {
+	var ids []string
+	seen := make(map[string]bool)
+
+	for _, query := range queries {
+		if gs.maxMessages > 0 && len(ids) >= gs.maxMessages {
+			break
+		}
+
+		queryIDs, err := gs.ListMessageIDs(ctx, query)
+		if err != nil {
+			if errors.Is(err, apperrors.ErrQuotaExceeded) || errors.Is(err, apperrors.ErrAuthRequired) {
+				return nil, err
+			}
+			log.Printf("⚠️  Warning: Could not search for '%s': %v\n", query, err)
+			continue
+		}
+
+		for _, id := range queryIDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	if gs.maxMessages > 0 && len(ids) > gs.maxMessages {
+		ids = ids[:gs.maxMessages]
+	}
+	return ids, nil
+}
+
+// GetMessagesByID fetches full details for the given message IDs
+func (gs *GmailService) GetMessagesByID(ctx context.Context, ids []string) ([]*models.Message, error) {
+	return gs.fetchMessages(ctx, ids)
+}
+
+// ListMessageIDs returns the IDs of messages matching query without
+// fetching them, newest first, following result pages up to the limit set
+// with SetMaxMessages
+func (gs *GmailService) ListMessageIDs(ctx context.Context, query string) ([]string, error) {
+	return gs.listMessageIDs(ctx, query, gs.maxMessages)
+}
+
+// ListAllMessageIDs returns the IDs of every message matching query,
+// following result pages regardless of the message limit
+func (gs *GmailService) ListAllMessageIDs(ctx context.Context, query string) ([]string, error) {
+	return gs.listMessageIDs(ctx, query, 0)
+}
+
+// listMessageIDs lists at most limit messages matching query; 0 is unlimited
+func (gs *GmailService) listMessageIDs(ctx context.Context, query string, limit int) ([]string, error) {
+	var ids []string
+
+	call := gs.service.Users.Messages.List("me").MaxResults(listPageSize)
 	if query != "" {
 		call = call.Q(query)
 	}
-	call = call.MaxResults(100)
+	err := call.Pages(ctx, func(page *gmail.ListMessagesResponse) error {
+		for _, message := range page.Messages {
+			if limit > 0 && len(ids) >= limit {
+				return errListLimit
+			}
+			ids = append(ids, message.Id)
+		}
+		gs.report(Progress{Stage: StageListing, Query: query, Done: len(ids)})
+		// Before the next page is requested
+		return gs.wait(ctx)
+	})
+	if errors.Is(err, errListLimit) {
+		log.Printf("Listed the newest %d messages matching '%s'; raise --max-messages to include older ones", limit, query)
+	} else if err != nil {
+		return nil, wrapAPIError("unable to retrieve messages", err)
+	}
 
-	results, err := call.Do()
+	gs.report(Progress{Stage: StageListing, Query: query, Done: len(ids), Total: len(ids), Finished: true})
+	return ids, nil
+}
+
+// fetchMessages retrieves full details for each message ID in parallel,
+// keeping their order and skipping messages that fail individually
+func (gs *GmailService) fetchMessages(ctx context.Context, ids []string) ([]*models.Message, error) {
+	if gs.prefilter {
+		var err error
+		ids, err = gs.prefilterMessages(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fetched, skipped, err := gs.getAll(ctx, ids, "full")
 	if err != nil {
-		return nil, fmt.Errorf("unable to retrieve messages: %v", err)
+		return nil, err
+	}
+	if len(skipped) > 0 {
+		log.Printf("⚠️  Warning: Could not download %d of %d messages: %v", len(ids)-countFetched(fetched), len(ids), skipped[0])
 	}
 
-	if len(results.Messages) == 0 {
-		log.Println("No messages found.")
-		return messages, nil
+	var found []*gmail.Message
+	messages := make([]*models.Message, 0, len(fetched))
+	for _, message := range fetched {
+		if message != nil {
+			found = append(found, message)
+			messages = append(messages, toMessage(message))
+		}
 	}
 
-	// Get full message details
-	for _, message := range results.Messages {
-		msg, err := gs.GetMessage(ctx, message.Id)
-		if err != nil {
-			continue
+	if gs.attachments {
+		if err := gs.fetchAttachments(ctx, found, messages); err != nil {
+			return nil, err
 		}
-		messages = append(messages, msg)
 	}
 
 	return messages, nil
 }
 
+// prefilterMessages fetches only headers and snippets and drops the
+// messages that are obviously not receipts
+func (gs *GmailService) prefilterMessages(ctx context.Context, ids []string) ([]string, error) {
+	metadata, _, err := gs.getAll(ctx, ids, "metadata")
+	if err != nil {
+		return nil, err
+	}
+
+	var kept []string
+	for i, id := range ids {
+		// Messages whose metadata failed to load are left to the full fetch
+		if metadata[i] == nil || likelyReceipt(metadata[i]) {
+			kept = append(kept, id)
+		}
+	}
+
+	if skipped := len(ids) - len(kept); skipped > 0 {
+		log.Printf("Skipped %d of %d messages that don't look like receipts", skipped, len(ids))
+	}
+
+	return kept, nil
+}
+
 // GetMessage retrieves a single message with full details
 func (gs *GmailService) GetMessage(ctx context.Context, msgID string) (*models.Message, error) {
-	message, err := gs.service.Users.Messages.Get("me", msgID).Do()
+	message, err := gs.service.Users.Messages.Get("me", msgID).Context(ctx).Do()
 	if err != nil {
-		return nil, fmt.Errorf("unable to retrieve message: %v", err)
+		return nil, wrapAPIError("unable to retrieve message", err)
 	}
 
+	return toMessage(message), nil
+}
+
+// countFetched counts the messages that were retrieved
+func countFetched(messages []*gmail.Message) int {
+	n := 0
+	for _, message := range messages {
+		if message != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// toMessage converts a Gmail API message with full details
+func toMessage(message *gmail.Message) *models.Message {
 	msg := &models.Message{
 		ID:       message.Id,
 		ThreadID: message.ThreadId,
@@ -79,7 +282,11 @@ func (gs *GmailService) GetMessage(ctx context.Context, msgID string) (*models.M
 	}
 
 	// Parse headers
-	for _, header := range message.Payload.Headers {
+	var headers []*gmail.MessagePartHeader
+	if message.Payload != nil {
+		headers = message.Payload.Headers
+	}
+	for _, header := range headers {
 		switch header.Name {
 		case "From":
 			msg.From = header.Value
@@ -92,24 +299,18 @@ func (gs *GmailService) GetMessage(ctx context.Context, msgID string) (*models.M
 		}
 	}
 
-	// Get body
-	if message.Payload.Parts != nil {
-		for _, part := range message.Payload.Parts {
-			if part.MimeType == "text/plain" || part.MimeType == "text/html" {
-				if part.Body != nil && part.Body.Data != "" {
-					msg.Body = decodeBase64(part.Body.Data)
-					break
-				}
-			}
-		}
-	} else if message.Payload.Body != nil && message.Payload.Body.Data != "" {
-		msg.Body = decodeBase64(message.Payload.Body.Data)
+	// Prefer the plain-text body; receipts that only have HTML fall back to it
+	text, html := messageBodies(message.Payload)
+	msg.Body = text
+	if msg.Body == "" {
+		msg.Body = html
 	}
+	msg.HTMLBody = html
 
 	// Get labels
 	msg.Labels = message.LabelIds
 
-	return msg, nil
+	return msg
 }
 
 // SearchMessages searches for messages using a query
@@ -117,6 +318,38 @@ func (gs *GmailService) SearchMessages(ctx context.Context, query string) ([]*mo
 	return gs.GetMessages(ctx, query)
 }
 
+// wrapAPIError classifies Gmail API failures so callers can react with
+// errors.Is. Failures the user has to fix in their Google account or Cloud
+// project replace the raw API error, whose JSON body doesn't say how.
+func wrapAPIError(action string, err error) error {
+	if auth.IsRevoked(err) {
+		return fmt.Errorf("%s: %w", action, apperrors.ErrTokenRevoked)
+	}
+
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case http.StatusUnauthorized:
+			return fmt.Errorf("%s: %w: %w", action, apperrors.ErrAuthRequired, err)
+		case http.StatusTooManyRequests:
+			return fmt.Errorf("%s: %w: %w", action, apperrors.ErrQuotaExceeded, err)
+		case http.StatusForbidden:
+			for _, item := range apiErr.Errors {
+				switch item.Reason {
+				case "rateLimitExceeded", "userRateLimitExceeded", "quotaExceeded", "dailyLimitExceeded":
+					return fmt.Errorf("%s: %w: %w", action, apperrors.ErrQuotaExceeded, err)
+				case "accessNotConfigured", "SERVICE_DISABLED":
+					return fmt.Errorf("%s: %w", action, apperrors.ErrAPIDisabled)
+				case "insufficientPermissions", "ACCESS_TOKEN_SCOPE_INSUFFICIENT":
+					return fmt.Errorf("%s: %w", action, apperrors.ErrInsufficientScope)
+				}
+			}
+		}
+	}
+
+	return fmt.Errorf("%s: %w", action, err)
+}
+
 // parseDate parses email date header
 func parseDate(dateStr string) time.Time {
 	// Try RFC822 format first
@@ -127,29 +360,6 @@ func parseDate(dateStr string) time.Time {
 	return time.Now()
 }
 
-// decodeBase64 decodes base64 encoded data (URL-safe base64 from Gmail API)
-func decodeBase64(encoded string) string {
-	// Gmail API uses URL-safe base64 encoding (- and _ instead of + and /)
-	// First normalize the encoded string
-	encoded = strings.ReplaceAll(encoded, "-", "+")
-	encoded = strings.ReplaceAll(encoded, "_", "/")
-
-	// Add padding if necessary
-	remainder := len(encoded) % 4
-	if remainder != 0 {
-		encoded += strings.Repeat("=", 4-remainder)
-	}
-
-	// Decode from base64
-	decoded, err := base64.StdEncoding.DecodeString(encoded)
-	if err != nil {
-		log.Printf("Failed to decode base64: %v", err)
-		return encoded
-	}
-
-	return string(decoded)
-}
-
 // GetMessagesFromSender retrieves messages from a specific sender
 func (gs *GmailService) GetMessagesFromSender(ctx context.Context, sender string) ([]*models.Message, error) {
 	query := fmt.Sprintf("from:%s", sender)
@@ -163,7 +373,7 @@ func (gs *GmailService) GetMessagesWithLabel(ctx context.Context, label string)
 	// Get label ID
 	labels, err := gs.service.Users.Labels.List("me").Do()
 	if err != nil {
-		return nil, err
+		return nil, wrapAPIError("unable to list labels", err)
 	}
 
 	var labelID string
@@ -185,17 +395,13 @@ func (gs *GmailService) GetMessagesWithLabel(ctx context.Context, label string)
 
 	results, err := call.Do()
 	if err != nil {
-		return nil, err
+		return nil, wrapAPIError("unable to retrieve messages", err)
 	}
 
-	// Get full message details
+	ids := make([]string, 0, len(results.Messages))
 	for _, message := range results.Messages {
-		msg, err := gs.GetMessage(ctx, message.Id)
-		if err != nil {
-			continue
-		}
-		messages = append(messages, msg)
+		ids = append(ids, message.Id)
 	}
 
-	return messages, nil
+	return gs.fetchMessages(ctx, ids)
 }
@@ -0,0 +1,56 @@
+package gmail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	gmail "google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// ErrHistoryExpired means Gmail no longer keeps the mailbox changes since a
+// history ID, so a full search is needed instead
+var ErrHistoryExpired = errors.New("gmail history expired")
+
+// HistoryID returns the mailbox's current history ID, from which
+// AddedMessageIDs can list later changes
+func (gs *GmailService) HistoryID(ctx context.Context) (uint64, error) {
+	profile, err := gs.service.Users.GetProfile("me").Context(ctx).Do()
+	if err != nil {
+		return 0, wrapAPIError("unable to read mailbox profile", err)
+	}
+	return profile.HistoryId, nil
+}
+
+// AddedMessageIDs returns the IDs of messages added to the mailbox after
+// historyID, oldest first. Gmail keeps history for about a week; older IDs
+// return ErrHistoryExpired.
+func (gs *GmailService) AddedMessageIDs(ctx context.Context, historyID uint64) ([]string, error) {
+	var ids []string
+	seen := make(map[string]bool)
+
+	call := gs.service.Users.History.List("me").StartHistoryId(historyID).HistoryTypes("messageAdded").MaxResults(listPageSize)
+	err := call.Pages(ctx, func(page *gmail.ListHistoryResponse) error {
+		for _, record := range page.History {
+			for _, added := range record.MessagesAdded {
+				if added.Message == nil || seen[added.Message.Id] {
+					continue
+				}
+				seen[added.Message.Id] = true
+				ids = append(ids, added.Message.Id)
+			}
+		}
+		return nil
+	})
+
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %w", ErrHistoryExpired, err)
+	}
+	if err != nil {
+		return nil, wrapAPIError("unable to list mailbox history", err)
+	}
+	return ids, nil
+}
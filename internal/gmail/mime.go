@@ -0,0 +1,94 @@
+package gmail
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/mail"
+	gmail "google.golang.org/api/gmail/v1"
+)
+
+// messageBodies walks the MIME tree of a message, however deeply multipart
+// parts are nested, and returns its first text/plain and text/html bodies
+// decoded to UTF-8. Attachments are skipped.
+func messageBodies(part *gmail.MessagePart) (text, html string) {
+	if part == nil || isAttachment(part) {
+		return "", ""
+	}
+
+	mediaType, params := contentType(part)
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		for _, child := range part.Parts {
+			childText, childHTML := messageBodies(child)
+			if text == "" {
+				text = childText
+			}
+			if html == "" {
+				html = childHTML
+			}
+		}
+	case mediaType == "text/plain", mediaType == "text/html":
+		if part.Body == nil || part.Body.Data == "" {
+			return "", ""
+		}
+		body, err := decodePartBody(part.Body.Data, params["charset"])
+		if err != nil {
+			return "", ""
+		}
+		if mediaType == "text/plain" {
+			return body, ""
+		}
+		return "", body
+	}
+	return text, html
+}
+
+// contentType returns the part's media type and parameters, preferring the
+// Content-Type header, which carries the charset, over MimeType
+func contentType(part *gmail.MessagePart) (string, map[string]string) {
+	for _, header := range part.Headers {
+		if strings.EqualFold(header.Name, "Content-Type") {
+			if mediaType, params, err := mime.ParseMediaType(header.Value); err == nil {
+				return mediaType, params
+			}
+		}
+	}
+	return strings.ToLower(part.MimeType), map[string]string{}
+}
+
+// isAttachment reports whether the part is a file rather than a body
+func isAttachment(part *gmail.MessagePart) bool {
+	if part.Filename != "" {
+		return true
+	}
+	for _, header := range part.Headers {
+		if strings.EqualFold(header.Name, "Content-Disposition") {
+			disposition, _, err := mime.ParseMediaType(header.Value)
+			return err == nil && disposition == "attachment"
+		}
+	}
+	return false
+}
+
+// decodePartBody decodes a body from the Gmail API, which has already
+// undone the transfer encoding but still uses the part's own charset
+func decodePartBody(data, charsetLabel string) (string, error) {
+	raw, err := decodeBase64URL(data)
+	if err != nil {
+		return "", err
+	}
+	return mail.DecodeCharset(raw, charsetLabel)
+}
+
+// decodeBase64URL decodes the URL-safe base64 the Gmail API uses, with or
+// without padding
+func decodeBase64URL(data string) ([]byte, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(data, "="))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode message body: %w", err)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,114 @@
+package gmail
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+)
+
+// DefaultConcurrency is how many messages are fetched in parallel by default
+const DefaultConcurrency = 8
+
+// fatal reports whether err should stop every remaining request rather than
+// skip a single message
+func fatal(err error) bool {
+	return errors.Is(err, apperrors.ErrQuotaExceeded) ||
+		errors.Is(err, apperrors.ErrAuthRequired) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+// forEach calls fetch for every index in [0, n) using up to workers
+// goroutines, so results can be stored in order. Errors for single items
+// are collected and returned as skipped; a fatal error cancels the
+// remaining work and all fatal errors are returned joined.
+func forEach(ctx context.Context, n, workers int, fetch func(ctx context.Context, i int) error) (skipped []error, err error) {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan int)
+	var (
+		mu     sync.Mutex
+		fatals []error
+		wg     sync.WaitGroup
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				err := fetch(ctx, i)
+				if err == nil {
+					continue
+				}
+
+				mu.Lock()
+				if fatal(err) {
+					// Errors caused by our own cancellation add nothing
+					if ctx.Err() == nil || !errors.Is(err, context.Canceled) {
+						fatals = append(fatals, err)
+					}
+					cancel()
+				} else {
+					skipped = append(skipped, err)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if len(fatals) == 0 && ctx.Err() != nil {
+		// The parent context was cancelled
+		fatals = append(fatals, context.Cause(ctx))
+	}
+	return skipped, errors.Join(fatals...)
+}
+
+// forEach runs fetch for every index in [0, n) with the service's
+// concurrency, waiting the delay set with SetDelay before each request
+func (gs *GmailService) forEach(ctx context.Context, n int, fetch func(ctx context.Context, i int) error) (skipped []error, err error) {
+	if gs.delay <= 0 {
+		return forEach(ctx, n, gs.concurrency, fetch)
+	}
+	return forEach(ctx, n, gs.concurrency, func(ctx context.Context, i int) error {
+		if err := gs.wait(ctx); err != nil {
+			return err
+		}
+		return fetch(ctx, i)
+	})
+}
+
+// wait pauses for the delay set with SetDelay, or until ctx is done
+func (gs *GmailService) wait(ctx context.Context) error {
+	if gs.delay <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(gs.delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,73 @@
+package gmail
+
+import (
+	"regexp"
+	"strings"
+
+	gmail "google.golang.org/api/gmail/v1"
+)
+
+// metadataHeaders are the headers requested when pre-filtering messages
+var metadataHeaders = []string{"From", "Subject", "List-Unsubscribe", "Precedence"}
+
+// moneyHint detects an amount in a snippet or subject
+var moneyHint = regexp.MustCompile(`(?i)[$€£¥]\s*\d|\d[\d,.]*\s*(usd|mxn|eur|gbp|jpy|cad)\b`)
+
+// receiptTerms indicate the message is about a charge
+var receiptTerms = []string{
+	"receipt", "invoice", "payment", "charged", "order", "purchase", "total",
+	"subscription", "renewal", "booking", "recibo", "factura", "pago", "compra",
+}
+
+// shippingTerms indicate a delivery update, which rarely carries a new charge
+var shippingTerms = []string{
+	"has shipped", "shipped", "out for delivery", "delivered", "tracking number",
+	"on its way", "en camino", "enviado", "entregado",
+}
+
+// likelyReceipt applies cheap heuristics to a message fetched with
+// format=metadata to decide whether its full body is worth downloading.
+// Messages with a visible amount are always kept.
+func likelyReceipt(msg *gmail.Message) bool {
+	var subject string
+	bulk := false
+	if msg.Payload != nil {
+		for _, header := range msg.Payload.Headers {
+			switch strings.ToLower(header.Name) {
+			case "subject":
+				subject = header.Value
+			case "list-unsubscribe":
+				bulk = true
+			case "precedence":
+				v := strings.ToLower(header.Value)
+				bulk = bulk || v == "bulk" || v == "list"
+			}
+		}
+	}
+
+	text := strings.ToLower(subject + " " + msg.Snippet)
+	if moneyHint.MatchString(text) {
+		return true
+	}
+
+	// Shipping-only notices without an amount
+	if containsAny(text, shippingTerms) {
+		return false
+	}
+
+	// Newsletters and marketing mail that don't talk about a charge
+	if bulk && !containsAny(text, receiptTerms) {
+		return false
+	}
+
+	return true
+}
+
+func containsAny(text string, terms []string) bool {
+	for _, term := range terms {
+		if strings.Contains(text, term) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package gmail
+
+import "github.com/sazardev/go-money/internal/mail"
+
+// Progress describes how far a stage has advanced
+type Progress = mail.Progress
+
+// Stages reported to the progress callback
+const (
+	StageListing     = mail.StageListing
+	StageScreening   = mail.StageScreening
+	StageDownloading = mail.StageDownloading
+	StageAttachments = mail.StageAttachments
+)
+
+// SetProgress registers a callback for progress updates. Calls are
+// serialized, so the callback doesn't need to be safe for concurrent use.
+func (gs *GmailService) SetProgress(progress func(Progress)) {
+	gs.progress = progress
+}
+
+// report passes a progress update to the callback, if any
+func (gs *GmailService) report(p Progress) {
+	if gs.progress == nil {
+		return
+	}
+	gs.progressMu.Lock()
+	defer gs.progressMu.Unlock()
+	gs.progress(p)
+}
@@ -0,0 +1,102 @@
+package gmail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/mail"
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// GmailService is the Gmail mail provider and tracks changes through the
+// mailbox history
+var (
+	_ mail.Provider      = (*GmailService)(nil)
+	_ mail.ChangeTracker = (*GmailService)(nil)
+	_ mail.Identifier    = (*GmailService)(nil)
+)
+
+// Name returns the provider's display name
+func (gs *GmailService) Name() string {
+	return "Gmail"
+}
+
+// Search runs one Gmail search per term within the query's dates, or a
+// single OR search when the message limit is ignored
+func (gs *GmailService) Search(ctx context.Context, q mail.Query) ([]string, error) {
+	var window string
+	if !q.After.IsZero() {
+		window += fmt.Sprintf(" after:%d", q.After.Unix())
+	}
+	if !q.Before.IsZero() {
+		window += fmt.Sprintf(" before:%d", q.Before.Unix())
+	}
+
+	if q.All {
+		return gs.ListAllMessageIDs(ctx, orQuery(q.Terms)+window)
+	}
+	queries := q.Terms
+	if window != "" {
+		queries = make([]string, len(q.Terms))
+		for i, term := range q.Terms {
+			queries[i] = fmt.Sprintf("(%s)%s", term, window)
+		}
+	}
+	return gs.ListMessageIDsForQueries(ctx, queries)
+}
+
+// orQuery combines search terms into one Gmail OR query
+func orQuery(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		if strings.Contains(term, " ") {
+			term = `"` + term + `"`
+		}
+		quoted[i] = term
+	}
+	return "{" + strings.Join(quoted, " ") + "}"
+}
+
+// Fetch returns the full messages with the given IDs
+func (gs *GmailService) Fetch(ctx context.Context, ids []string) ([]*models.Message, error) {
+	return gs.GetMessagesByID(ctx, ids)
+}
+
+// Close does nothing; the Gmail API has no connection to release
+func (gs *GmailService) Close() error {
+	return nil
+}
+
+// Address returns the email address of the signed-in account
+func (gs *GmailService) Address(ctx context.Context) (string, error) {
+	profile, err := gs.service.Users.GetProfile("me").Context(ctx).Do()
+	if err != nil {
+		return "", wrapAPIError("unable to read mailbox profile", err)
+	}
+	return profile.EmailAddress, nil
+}
+
+// Cursor returns the mailbox's current history ID
+func (gs *GmailService) Cursor(ctx context.Context) (string, error) {
+	historyID, err := gs.HistoryID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(historyID, 10), nil
+}
+
+// AddedSince returns the IDs of messages added after the history ID in cursor
+func (gs *GmailService) AddedSince(ctx context.Context, cursor string) ([]string, error) {
+	historyID, err := strconv.ParseUint(cursor, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid history ID %q", mail.ErrChangesExpired, cursor)
+	}
+	ids, err := gs.AddedMessageIDs(ctx, historyID)
+	if errors.Is(err, ErrHistoryExpired) {
+		return nil, fmt.Errorf("%w: %w", mail.ErrChangesExpired, err)
+	}
+	return ids, err
+}
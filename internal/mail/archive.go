@@ -0,0 +1,301 @@
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// maxArchivedMessage skips archived emails too large to be a receipt
+const maxArchivedMessage = 25 << 20
+
+// Archive is a mail provider reading exported emails instead of a mailbox:
+// an mbox file, such as a Google Takeout export, or a directory of .eml
+// files. It needs no network access or login.
+type Archive struct {
+	path        string
+	mbox        bool
+	attachments bool
+	progress    func(Progress)
+	entries     map[string]archiveEntry // Emails found by Search, by ID
+}
+
+// archiveEntry locates one email of an archive
+type archiveEntry struct {
+	path   string
+	offset int64 // Start of the email in an mbox file
+	size   int64 // Length of the email in an mbox file
+}
+
+var _ Provider = (*Archive)(nil)
+
+// OpenMbox opens an mbox file. Emails are read as needed, so exports of
+// any size can be imported.
+func OpenMbox(path string) (*Archive, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory, not an mbox file", path)
+	}
+	return &Archive{path: path, mbox: true, attachments: true}, nil
+}
+
+// OpenEML opens a directory holding .eml files, searched recursively
+func OpenEML(dir string) (*Archive, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return &Archive{path: dir, attachments: true}, nil
+}
+
+// SetAttachments enables or disables keeping PDF attachments
+func (a *Archive) SetAttachments(enabled bool) {
+	a.attachments = enabled
+}
+
+// SetProgress registers a callback for progress updates
+func (a *Archive) SetProgress(progress func(Progress)) {
+	a.progress = progress
+}
+
+// report passes a progress update to the callback, if any
+func (a *Archive) report(p Progress) {
+	if a.progress != nil {
+		a.progress(p)
+	}
+}
+
+// Name returns the provider's display name
+func (a *Archive) Name() string {
+	return filepath.Base(a.path)
+}
+
+// Search reads every email of the archive and returns those matching any
+// term. Like Gmail, a term matches when all of its words appear in the
+// subject, sender or body, ignoring case. The limit on messages doesn't
+// apply: archives are always searched whole.
+func (a *Archive) Search(ctx context.Context, q Query) ([]string, error) {
+	terms := make([][]string, len(q.Terms))
+	for i, term := range q.Terms {
+		terms[i] = strings.Fields(strings.ToLower(term))
+	}
+
+	a.entries = make(map[string]archiveEntry)
+	var ids []string
+	scanned := 0
+	err := a.each(ctx, func(entry archiveEntry, raw []byte) {
+		scanned++
+		a.report(Progress{Stage: StageListing, Query: a.Name(), Done: scanned})
+
+		msg, err := ParseMessage(raw, false)
+		if err != nil || !inWindow(msg, q) || !matchesAny(msg, terms) {
+			return
+		}
+		id := archiveID(raw)
+		if _, ok := a.entries[id]; !ok {
+			a.entries[id] = entry
+			ids = append(ids, id)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	a.report(Progress{Stage: StageListing, Query: a.Name(), Done: scanned, Total: scanned, Finished: true})
+	return ids, nil
+}
+
+// Fetch parses the emails found by the last Search
+func (a *Archive) Fetch(ctx context.Context, ids []string) ([]*models.Message, error) {
+	messages := make([]*models.Message, 0, len(ids))
+	for i, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		entry, ok := a.entries[id]
+		if !ok {
+			return nil, fmt.Errorf("email %s wasn't found by a search of %s", id, a.Name())
+		}
+		raw, err := a.read(entry)
+		if err != nil {
+			return nil, err
+		}
+		msg, err := ParseMessage(raw, a.attachments)
+		if err != nil {
+			return nil, err
+		}
+		msg.ID = id
+		messages = append(messages, msg)
+		a.report(Progress{Stage: StageDownloading, Done: i + 1, Total: len(ids), Finished: i+1 == len(ids)})
+	}
+	return messages, nil
+}
+
+// Close does nothing; files are only open while they are read
+func (a *Archive) Close() error {
+	return nil
+}
+
+// each calls fn with every email of the archive, in order
+func (a *Archive) each(ctx context.Context, fn func(entry archiveEntry, raw []byte)) error {
+	if a.mbox {
+		return a.eachMbox(ctx, fn)
+	}
+	return filepath.WalkDir(a.path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".eml") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > maxArchivedMessage {
+			return err
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fn(archiveEntry{path: path}, raw)
+		return nil
+	})
+}
+
+// eachMbox splits an mbox file on its "From " lines, which start each email
+// after a blank line
+func (a *Archive) eachMbox(ctx context.Context, fn func(entry archiveEntry, raw []byte)) error {
+	f, err := os.Open(a.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var (
+		offset  int64 // Position of the next line
+		start   int64 = -1
+		current []byte
+		blank   = true
+	)
+	flush := func(end int64) {
+		if start >= 0 && end-start <= maxArchivedMessage {
+			fn(archiveEntry{path: a.path, offset: start, size: end - start}, unescapeMbox(current))
+		}
+		current = current[:0]
+	}
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			if blank && bytes.HasPrefix(line, []byte("From ")) {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				flush(offset)
+				start = offset + int64(len(line))
+			} else if start >= 0 && offset-start < maxArchivedMessage {
+				current = append(current, line...)
+			}
+			offset += int64(len(line))
+			blank = len(bytes.TrimRight(line, "\r\n")) == 0
+		}
+		if errors.Is(err, io.EOF) {
+			flush(offset)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// read returns the raw email an entry points to
+func (a *Archive) read(entry archiveEntry) ([]byte, error) {
+	if !a.mbox {
+		return os.ReadFile(entry.path)
+	}
+	f, err := os.Open(entry.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	raw := make([]byte, entry.size)
+	if _, err := f.ReadAt(raw, entry.offset); err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", a.Name(), err)
+	}
+	return unescapeMbox(raw), nil
+}
+
+// unescapeMbox removes the > that mbox files add in front of body lines
+// starting with "From "
+func unescapeMbox(raw []byte) []byte {
+	if !bytes.Contains(raw, []byte(">From ")) {
+		return raw
+	}
+	lines := bytes.SplitAfter(raw, []byte("\n"))
+	for i, line := range lines {
+		if quoted := bytes.TrimLeft(line, ">"); len(quoted) < len(line) && bytes.HasPrefix(quoted, []byte("From ")) {
+			lines[i] = line[1:]
+		}
+	}
+	return bytes.Join(lines, nil)
+}
+
+// archiveID identifies an archived email by its Message-Id, so importing
+// the same email from another export doesn't count it twice. Emails without
+// one are identified by their content.
+func archiveID(raw []byte) string {
+	key := raw
+	if m, err := netmail.ReadMessage(bytes.NewReader(raw)); err == nil {
+		if id := strings.TrimSpace(m.Header.Get("Message-Id")); id != "" {
+			key = []byte(id)
+		}
+	}
+	sum := sha256.Sum256(key)
+	return "archive:" + hex.EncodeToString(sum[:16])
+}
+
+// inWindow reports whether msg was sent within the query's dates
+func inWindow(msg *models.Message, q Query) bool {
+	return (q.After.IsZero() || !msg.Date.Before(q.After)) &&
+		(q.Before.IsZero() || msg.Date.Before(q.Before))
+}
+
+// matchesAny reports whether every word of at least one term appears in msg
+func matchesAny(msg *models.Message, terms [][]string) bool {
+	text := strings.ToLower(strings.Join([]string{msg.Subject, msg.From, msg.Body, msg.HTMLBody}, "\n"))
+	for _, words := range terms {
+		matched := len(words) > 0
+		for _, word := range words {
+			if !strings.Contains(text, word) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+// DecodeCharset converts text in the named charset to UTF-8. Unknown
+// charsets are assumed to be UTF-8 when valid and Windows-1252 otherwise,
+// the most common mislabeling in practice.
+func DecodeCharset(raw []byte, label string) (string, error) {
+	label = strings.ToLower(strings.TrimSpace(label))
+	if label == "" || label == "utf-8" || label == "us-ascii" {
+		if utf8.Valid(raw) {
+			return string(raw), nil
+		}
+		label = "windows-1252"
+	}
+
+	reader, err := charset.NewReaderLabel(label, bytes.NewReader(raw))
+	if err != nil {
+		if utf8.Valid(raw) {
+			return string(raw), nil
+		}
+		if reader, err = charset.NewReaderLabel("windows-1252", bytes.NewReader(raw)); err != nil {
+			return "", err
+		}
+	}
+
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("unable to decode %s message body: %w", label, err)
+	}
+	return string(decoded), nil
+}
@@ -0,0 +1,500 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// Connection security of an IMAP server
+const (
+	SecurityTLS      = "tls"      // Implicit TLS, usually on port 993
+	SecurityStartTLS = "starttls" // Plain connection upgraded with STARTTLS, usually on port 143
+	SecurityNone     = "none"     // Unencrypted, only for local bridges
+)
+
+const (
+	// imapTimeout bounds connecting to the server
+	imapTimeout = 30 * time.Second
+	// fetchBatchSize is how many emails are requested per UID FETCH
+	fetchBatchSize = 50
+	// maxLiteralSize rejects responses too large to be a single email
+	maxLiteralSize = 64 << 20
+)
+
+var (
+	// literalMarker ends a response line that is followed by n raw bytes
+	literalMarker = regexp.MustCompile(`\{(\d+)\}$`)
+	// fetchUID finds the UID in an untagged FETCH response
+	fetchUID = regexp.MustCompile(`(?i)^\* \d+ FETCH \(.*\bUID (\d+)`)
+	// responseCode finds a numeric response code such as [UIDNEXT 42]
+	responseCode = regexp.MustCompile(`(?i)\[(UIDVALIDITY|UIDNEXT) (\d+)\]`)
+)
+
+// IMAPConfig holds the connection settings of an IMAP mailbox
+type IMAPConfig struct {
+	Host     string
+	Port     int // 993 for SecurityTLS and 143 otherwise when zero
+	Username string
+	Password string
+	Mailbox  string // INBOX when empty
+	Security string // SecurityTLS when empty
+}
+
+// IMAP is a mail provider for any IMAP4rev1 mailbox. Emails are opened
+// read-only and never marked as seen. It isn't safe for concurrent use.
+type IMAP struct {
+	conn        net.Conn
+	r           *bufio.Reader
+	tag         int
+	mailbox     string
+	uidValidity uint32
+	uidNext     uint32
+	attachments bool
+	maxMessages int
+	progress    func(Progress)
+}
+
+var (
+	_ Provider      = (*IMAP)(nil)
+	_ ChangeTracker = (*IMAP)(nil)
+)
+
+// response is a server response line, with the literals it carried read
+// out and left as {n} markers in the text
+type response struct {
+	line     string
+	literals [][]byte
+}
+
+// DialIMAP connects and logs in to an IMAP server, then opens the mailbox
+func DialIMAP(ctx context.Context, cfg IMAPConfig) (*IMAP, error) {
+	if cfg.Host == "" || cfg.Username == "" {
+		return nil, fmt.Errorf("%w: IMAP needs a host and a username", apperrors.ErrInvalidInput)
+	}
+	security := strings.ToLower(cfg.Security)
+	if security == "" {
+		security = SecurityTLS
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = 143
+		if security == SecurityTLS {
+			port = 993
+		}
+	}
+	mailbox := cfg.Mailbox
+	if mailbox == "" {
+		mailbox = "INBOX"
+	}
+
+	address := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: cfg.Host}
+	dialer := &net.Dialer{Timeout: imapTimeout}
+	var conn net.Conn
+	var err error
+	switch security {
+	case SecurityTLS:
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", address)
+	case SecurityStartTLS, SecurityNone:
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	default:
+		return nil, fmt.Errorf("%w: IMAP security must be %s, %s or %s", apperrors.ErrInvalidInput, SecurityTLS, SecurityStartTLS, SecurityNone)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to %s: %w", address, err)
+	}
+
+	c := &IMAP{conn: conn, r: bufio.NewReader(conn), mailbox: mailbox, attachments: true}
+	if err := c.open(ctx, cfg, security, tlsConfig); err != nil {
+		c.conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// open reads the greeting, secures the connection, logs in and selects the
+// mailbox
+func (c *IMAP) open(ctx context.Context, cfg IMAPConfig, security string, tlsConfig *tls.Config) error {
+	greeting, err := c.readResponse()
+	if err != nil {
+		return fmt.Errorf("unable to read IMAP greeting: %w", err)
+	}
+	if !strings.HasPrefix(strings.ToUpper(greeting.line), "* OK") && !strings.HasPrefix(strings.ToUpper(greeting.line), "* PREAUTH") {
+		return fmt.Errorf("IMAP server refused the connection: %s", greeting.line)
+	}
+
+	if security == SecurityStartTLS {
+		if _, err := c.run(ctx, "STARTTLS"); err != nil {
+			return err
+		}
+		tlsConn := tls.Client(c.conn, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return fmt.Errorf("unable to start TLS: %w", err)
+		}
+		c.conn, c.r = tlsConn, bufio.NewReader(tlsConn)
+	}
+
+	if !strings.HasPrefix(strings.ToUpper(greeting.line), "* PREAUTH") {
+		username, err := quote(cfg.Username)
+		if err != nil {
+			return err
+		}
+		password, err := quote(cfg.Password)
+		if err != nil {
+			return err
+		}
+		if _, err := c.run(ctx, "LOGIN %s %s", username, password); err != nil {
+			return fmt.Errorf("%w: IMAP login failed: %w", apperrors.ErrAuthRequired, err)
+		}
+	}
+	return c.examine(ctx)
+}
+
+// examine opens the mailbox read-only and records its UIDVALIDITY and UIDNEXT
+func (c *IMAP) examine(ctx context.Context) error {
+	mailbox, err := quote(c.mailbox)
+	if err != nil {
+		return err
+	}
+	responses, err := c.run(ctx, "EXAMINE %s", mailbox)
+	if err != nil {
+		return fmt.Errorf("unable to open mailbox %q: %w", c.mailbox, err)
+	}
+	for _, resp := range responses {
+		for _, m := range responseCode.FindAllStringSubmatch(resp.line, -1) {
+			value, err := strconv.ParseUint(m[2], 10, 32)
+			if err != nil {
+				continue
+			}
+			if strings.EqualFold(m[1], "UIDVALIDITY") {
+				c.uidValidity = uint32(value)
+			} else {
+				c.uidNext = uint32(value)
+			}
+		}
+	}
+	return nil
+}
+
+// SetAttachments enables or disables keeping PDF attachments
+func (c *IMAP) SetAttachments(enabled bool) {
+	c.attachments = enabled
+}
+
+// SetMaxMessages limits how many emails Search returns, newest first;
+// 0 removes the limit
+func (c *IMAP) SetMaxMessages(limit int) {
+	c.maxMessages = limit
+}
+
+// SetProgress registers a callback for progress updates
+func (c *IMAP) SetProgress(progress func(Progress)) {
+	c.progress = progress
+}
+
+// report passes a progress update to the callback, if any
+func (c *IMAP) report(p Progress) {
+	if c.progress != nil {
+		c.progress(p)
+	}
+}
+
+// Name returns the provider's display name
+func (c *IMAP) Name() string {
+	return "IMAP"
+}
+
+// Search runs one IMAP TEXT search per term, which matches headers and
+// bodies. Dates are compared by day, as IMAP doesn't support times.
+func (c *IMAP) Search(ctx context.Context, q Query) ([]string, error) {
+	var criteria string
+	if !q.After.IsZero() {
+		criteria += " SINCE " + q.After.Format("2-Jan-2006")
+	}
+	if !q.Before.IsZero() {
+		// BEFORE excludes the whole day, so partial days are rounded up
+		before := q.Before
+		if !before.Equal(before.Truncate(24 * time.Hour)) {
+			before = before.AddDate(0, 0, 1)
+		}
+		criteria += " BEFORE " + before.Format("2-Jan-2006")
+	}
+
+	var uids []uint32
+	seen := make(map[uint32]bool)
+	for _, term := range q.Terms {
+		text, err := quote(term)
+		if err != nil {
+			return nil, err
+		}
+		found, err := c.search(ctx, "TEXT "+text+criteria)
+		if err != nil {
+			return nil, err
+		}
+		for _, uid := range found {
+			if !seen[uid] {
+				seen[uid] = true
+				uids = append(uids, uid)
+			}
+		}
+		c.report(Progress{Stage: StageListing, Query: term, Done: len(found), Total: len(found), Finished: true})
+	}
+
+	// UIDs grow as emails are delivered, so the highest are the newest
+	sort.Slice(uids, func(i, j int) bool { return uids[i] > uids[j] })
+	if !q.All && c.maxMessages > 0 && len(uids) > c.maxMessages {
+		log.Printf("Listed the newest %d matching emails; raise --max-messages to include older ones", c.maxMessages)
+		uids = uids[:c.maxMessages]
+	}
+
+	ids := make([]string, len(uids))
+	for i, uid := range uids {
+		ids[i] = c.messageID(uid)
+	}
+	return ids, nil
+}
+
+// search runs UID SEARCH and returns the matching UIDs
+func (c *IMAP) search(ctx context.Context, criteria string) ([]uint32, error) {
+	command := "UID SEARCH " + criteria
+	if !isASCII(criteria) {
+		command = "UID SEARCH CHARSET UTF-8 " + criteria
+	}
+	responses, err := c.run(ctx, "%s", command)
+	if err != nil {
+		return nil, fmt.Errorf("unable to search mailbox: %w", err)
+	}
+
+	var uids []uint32
+	for _, resp := range responses {
+		fields := strings.Fields(resp.line)
+		if len(fields) < 2 || fields[0] != "*" || !strings.EqualFold(fields[1], "SEARCH") {
+			continue
+		}
+		for _, field := range fields[2:] {
+			if uid, err := strconv.ParseUint(field, 10, 32); err == nil {
+				uids = append(uids, uint32(uid))
+			}
+		}
+	}
+	return uids, nil
+}
+
+// Fetch downloads the emails with the given IDs without marking them as
+// seen. IDs from another mailbox, or from before the server renumbered
+// this one, are skipped.
+func (c *IMAP) Fetch(ctx context.Context, ids []string) ([]*models.Message, error) {
+	var uids []uint32
+	for _, id := range ids {
+		if uid, ok := c.parseID(id); ok {
+			uids = append(uids, uid)
+		}
+	}
+	if len(uids) < len(ids) {
+		log.Printf("⚠️  Warning: Skipped %d emails that are no longer in %s", len(ids)-len(uids), c.mailbox)
+	}
+
+	raw := make(map[uint32][]byte, len(uids))
+	for start := 0; start < len(uids); start += fetchBatchSize {
+		end := min(start+fetchBatchSize, len(uids))
+		set := make([]string, 0, end-start)
+		for _, uid := range uids[start:end] {
+			set = append(set, strconv.FormatUint(uint64(uid), 10))
+		}
+		responses, err := c.run(ctx, "UID FETCH %s (UID BODY.PEEK[])", strings.Join(set, ","))
+		if err != nil {
+			return nil, fmt.Errorf("unable to retrieve emails: %w", err)
+		}
+		for _, resp := range responses {
+			m := fetchUID.FindStringSubmatch(resp.line)
+			if m == nil || len(resp.literals) == 0 {
+				continue
+			}
+			if uid, err := strconv.ParseUint(m[1], 10, 32); err == nil {
+				raw[uint32(uid)] = resp.literals[0]
+			}
+		}
+		c.report(Progress{Stage: StageDownloading, Done: end, Total: len(uids), Finished: end == len(uids)})
+	}
+
+	messages := make([]*models.Message, 0, len(uids))
+	var skipped []error
+	for _, uid := range uids {
+		data, ok := raw[uid]
+		if !ok {
+			skipped = append(skipped, fmt.Errorf("email %d was not returned", uid))
+			continue
+		}
+		msg, err := ParseMessage(data, c.attachments)
+		if err != nil {
+			skipped = append(skipped, err)
+			continue
+		}
+		msg.ID = c.messageID(uid)
+		msg.Labels = []string{c.mailbox}
+		messages = append(messages, msg)
+	}
+	if len(skipped) > 0 {
+		log.Printf("⚠️  Warning: Could not download %d of %d messages: %v", len(skipped), len(uids), skipped[0])
+	}
+	return messages, nil
+}
+
+// Cursor returns the mailbox's UIDVALIDITY and UIDNEXT; emails delivered
+// later get a UID of at least UIDNEXT
+func (c *IMAP) Cursor(ctx context.Context) (string, error) {
+	if err := c.examine(ctx); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d:%d", c.uidValidity, c.uidNext), nil
+}
+
+// AddedSince returns the IDs of emails delivered after cursor. A cursor from
+// before the server renumbered the mailbox returns ErrChangesExpired.
+func (c *IMAP) AddedSince(ctx context.Context, cursor string) ([]string, error) {
+	validity, next, ok := strings.Cut(cursor, ":")
+	uidValidity, err1 := strconv.ParseUint(validity, 10, 32)
+	uidNext, err2 := strconv.ParseUint(next, 10, 32)
+	if !ok || err1 != nil || err2 != nil || uint32(uidValidity) != c.uidValidity {
+		return nil, fmt.Errorf("%w: mailbox %s was renumbered", ErrChangesExpired, c.mailbox)
+	}
+
+	// n:* always includes the last email, even when its UID is below n
+	found, err := c.search(ctx, fmt.Sprintf("UID %d:*", uidNext))
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, uid := range found {
+		if uint64(uid) >= uidNext {
+			ids = append(ids, c.messageID(uid))
+		}
+	}
+	return ids, nil
+}
+
+// Close logs out and closes the connection
+func (c *IMAP) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), imapTimeout)
+	defer cancel()
+	c.run(ctx, "LOGOUT")
+	return c.conn.Close()
+}
+
+// messageID identifies an email by mailbox, UIDVALIDITY and UID, which
+// together never refer to another email
+func (c *IMAP) messageID(uid uint32) string {
+	return fmt.Sprintf("imap:%s:%d:%d", c.mailbox, c.uidValidity, uid)
+}
+
+// parseID returns the UID of an ID made by messageID for this mailbox
+func (c *IMAP) parseID(id string) (uint32, bool) {
+	prefix := fmt.Sprintf("imap:%s:%d:", c.mailbox, c.uidValidity)
+	rest, ok := strings.CutPrefix(id, prefix)
+	if !ok {
+		return 0, false
+	}
+	uid, err := strconv.ParseUint(rest, 10, 32)
+	return uint32(uid), err == nil
+}
+
+// run sends a command and returns its untagged responses, failing unless
+// the server completes it with OK. Cancelling ctx breaks the connection.
+func (c *IMAP) run(ctx context.Context, format string, args ...any) ([]*response, error) {
+	c.tag++
+	tag := fmt.Sprintf("g%d", c.tag)
+
+	stop := context.AfterFunc(ctx, func() { c.conn.SetDeadline(time.Unix(1, 0)) })
+	defer stop()
+
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, fmt.Sprintf(format, args...)); err != nil {
+		return nil, c.connErr(ctx, err)
+	}
+
+	var untagged []*response
+	for {
+		resp, err := c.readResponse()
+		if err != nil {
+			return nil, c.connErr(ctx, err)
+		}
+		status, tagged := strings.CutPrefix(resp.line, tag+" ")
+		if !tagged {
+			untagged = append(untagged, resp)
+			continue
+		}
+		if !strings.HasPrefix(strings.ToUpper(status), "OK") {
+			return nil, errors.New(status)
+		}
+		return untagged, nil
+	}
+}
+
+// readResponse reads one response line along with any literals in it
+func (c *IMAP) readResponse() (*response, error) {
+	resp := &response{}
+	var line strings.Builder
+	for {
+		text, err := c.r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		text = strings.TrimRight(text, "\r\n")
+		line.WriteString(text)
+
+		m := literalMarker.FindStringSubmatch(text)
+		if m == nil {
+			break
+		}
+		size, err := strconv.Atoi(m[1])
+		if err != nil || size > maxLiteralSize {
+			return nil, fmt.Errorf("IMAP literal of %s bytes is too large", m[1])
+		}
+		literal := make([]byte, size)
+		if _, err := io.ReadFull(c.r, literal); err != nil {
+			return nil, err
+		}
+		resp.literals = append(resp.literals, literal)
+	}
+	resp.line = line.String()
+	return resp, nil
+}
+
+// connErr reports a broken connection, or the cancellation that broke it
+func (c *IMAP) connErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return fmt.Errorf("IMAP connection failed: %w", err)
+}
+
+// quote makes s an IMAP quoted string
+func quote(s string) (string, error) {
+	if strings.ContainsAny(s, "\r\n") {
+		return "", fmt.Errorf("%w: IMAP strings can't contain line breaks", apperrors.ErrInvalidInput)
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`, nil
+}
+
+// isASCII reports whether s has only ASCII characters
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const imapReceipt = "From: shop@example.com\r\nSubject: Receipt\r\nDate: Mon, 03 Mar 2025 12:00:00 +0000\r\n\r\nTotal: $9.99\r\n"
+
+// serveIMAP answers the commands of a session on conn the way a server
+// holding UIDs 7, 12 and 15 in INBOX would, until LOGOUT
+func serveIMAP(t *testing.T, conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		tag, command, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+		var reply string
+		switch {
+		case strings.HasPrefix(command, "EXAMINE "):
+			reply = "* 3 EXISTS\r\n* OK [UIDVALIDITY 3857529045] UIDs valid\r\n* OK [UIDNEXT 16] Predicted next UID\r\n"
+		case command == "UID SEARCH UID 13:*":
+			reply = "* SEARCH 15\r\n"
+		case strings.HasPrefix(command, "UID SEARCH TEXT \"receipt\""):
+			reply = "* SEARCH 7 12\r\n"
+		case strings.HasPrefix(command, "UID SEARCH TEXT \"invoice\""):
+			reply = "* SEARCH 12 15\r\n"
+		case command == "UID FETCH 12,7 (UID BODY.PEEK[])":
+			reply = fmt.Sprintf("* 2 FETCH (UID 12 BODY[] {%d}\r\n%s)\r\n", len(imapReceipt), imapReceipt) +
+				"* 2 FETCH (FLAGS (\\Seen))\r\n"
+		case command == "LOGOUT":
+			fmt.Fprintf(conn, "* BYE\r\n%s OK LOGOUT completed\r\n", tag)
+			return
+		default:
+			t.Errorf("unexpected command %q", command)
+			fmt.Fprintf(conn, "%s BAD unknown command\r\n", tag)
+			continue
+		}
+		fmt.Fprintf(conn, "%s%s OK done\r\n", reply, tag)
+	}
+}
+
+func TestIMAPSession(t *testing.T) {
+	client, server := net.Pipe()
+	go serveIMAP(t, server)
+	c := &IMAP{conn: client, r: bufio.NewReader(client), mailbox: "INBOX"}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.examine(ctx); err != nil {
+		t.Fatal(err)
+	}
+	cursor, err := c.Cursor(ctx)
+	if err != nil || cursor != "3857529045:16" {
+		t.Fatalf("Cursor = %q, %v; want 3857529045:16", cursor, err)
+	}
+
+	ids, err := c.Search(ctx, Query{Terms: []string{"receipt", "invoice"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"imap:INBOX:3857529045:15", "imap:INBOX:3857529045:12", "imap:INBOX:3857529045:7"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("Search = %v; want %v", ids, want)
+	}
+
+	// UID 7 isn't returned by the server, and the ID of another mailbox is skipped
+	messages, err := c.Fetch(ctx, []string{ids[1], ids[2], "imap:Archive:1:12"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 || messages[0].ID != ids[1] || messages[0].Subject != "Receipt" || strings.TrimSpace(messages[0].Body) != "Total: $9.99" {
+		t.Errorf("Fetch = %+v; want the receipt with UID 12", messages)
+	}
+
+	added, err := c.AddedSince(ctx, "3857529045:13")
+	if err != nil || !reflect.DeepEqual(added, []string{"imap:INBOX:3857529045:15"}) {
+		t.Errorf("AddedSince = %v, %v; want UID 15", added, err)
+	}
+	if _, err := c.AddedSince(ctx, "1:13"); err == nil {
+		t.Error("AddedSince of a renumbered mailbox succeeded")
+	}
+}
+
+func TestReadResponseLiterals(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	go func() {
+		defer server.Close()
+		fmt.Fprint(server, "* 1 FETCH (UID 4 BODY[HEADER] {5}\r\nab\r\nc BODY[TEXT] {3}\r\nxyz)\r\n")
+	}()
+
+	c := &IMAP{conn: client, r: bufio.NewReader(client)}
+	resp, err := c.readResponse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "* 1 FETCH (UID 4 BODY[HEADER] {5} BODY[TEXT] {3})"; resp.line != want {
+		t.Errorf("line = %q; want %q", resp.line, want)
+	}
+	if len(resp.literals) != 2 || string(resp.literals[0]) != "ab\r\nc" || string(resp.literals[1]) != "xyz" {
+		t.Errorf("literals = %q; want ab\\r\\nc and xyz", resp.literals)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	if got, err := quote(`pa"ss\word`); err != nil || got != `"pa\"ss\\word"` {
+		t.Errorf("quote = %s, %v", got, err)
+	}
+	if _, err := quote("a\r\nb"); err == nil {
+		t.Error("quote accepted a line break")
+	}
+}
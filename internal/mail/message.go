@@ -0,0 +1,157 @@
+package mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/sazardev/go-money/internal/models"
+	"golang.org/x/net/html/charset"
+)
+
+// MaxAttachmentSize skips attachments too large to be an invoice
+const MaxAttachmentSize = 5 << 20
+
+// headerDecoder decodes RFC 2047 encoded words in any charset
+var headerDecoder = &mime.WordDecoder{CharsetReader: charset.NewReaderLabel}
+
+// ParseMessage converts a raw RFC 5322 email into a message, walking its
+// MIME tree for the first text/plain and text/html bodies. PDF attachments
+// are kept when attachments is true.
+func ParseMessage(raw []byte, attachments bool) (*models.Message, error) {
+	m, err := netmail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse email: %w", err)
+	}
+
+	msg := &models.Message{
+		ThreadID: threadID(m.Header),
+		From:     decodeHeader(m.Header.Get("From")),
+		To:       decodeHeader(m.Header.Get("To")),
+		Subject:  decodeHeader(m.Header.Get("Subject")),
+		Date:     time.Now(),
+	}
+	if date, err := m.Header.Date(); err == nil {
+		msg.Date = date
+	}
+
+	parts := &messageParts{attachments: attachments}
+	parts.walk(textproto.MIMEHeader(m.Header), m.Body)
+
+	// Prefer the plain-text body; receipts that only have HTML fall back to it
+	msg.Body = parts.text
+	if msg.Body == "" {
+		msg.Body = parts.html
+	}
+	msg.HTMLBody = parts.html
+	msg.Attachments = parts.pdfs
+	return msg, nil
+}
+
+// threadID groups replies with the email that started the conversation,
+// the first entry of References, like mail clients do
+func threadID(header netmail.Header) string {
+	if references := strings.Fields(header.Get("References")); len(references) > 0 {
+		return references[0]
+	}
+	if inReplyTo := strings.TrimSpace(header.Get("In-Reply-To")); inReplyTo != "" {
+		return inReplyTo
+	}
+	return strings.TrimSpace(header.Get("Message-Id"))
+}
+
+// decodeHeader decodes RFC 2047 encoded words, keeping the raw value when
+// they are malformed
+func decodeHeader(value string) string {
+	decoded, err := headerDecoder.DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+// messageParts collects the bodies and attachments of a MIME tree
+type messageParts struct {
+	attachments bool
+	text        string
+	html        string
+	pdfs        []models.Attachment
+}
+
+// walk visits a MIME part, recursing into multipart ones however deeply
+// they are nested. Parts that fail to decode are skipped.
+func (p *messageParts) walk(header textproto.MIMEHeader, body io.Reader) {
+	// Parts without a valid Content-Type are plain text (RFC 2045)
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err != nil {
+				return
+			}
+			p.walk(part.Header, part)
+		}
+	}
+
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dispositionParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	if disposition == "attachment" || filename != "" {
+		isPDF := mediaType == "application/pdf" || strings.HasSuffix(strings.ToLower(filename), ".pdf")
+		if !p.attachments || !isPDF {
+			return
+		}
+		data, err := io.ReadAll(io.LimitReader(transferDecoder(header, body), MaxAttachmentSize+1))
+		if err == nil && len(data) <= MaxAttachmentSize {
+			p.pdfs = append(p.pdfs, models.Attachment{Filename: filename, MimeType: mediaType, Data: data})
+		}
+		return
+	}
+
+	switch {
+	case mediaType == "text/plain" && p.text == "":
+		p.text = decodeText(header, body, params["charset"])
+	case mediaType == "text/html" && p.html == "":
+		p.html = decodeText(header, body, params["charset"])
+	}
+}
+
+// decodeText decodes a text part to UTF-8, returning "" when it is malformed
+func decodeText(header textproto.MIMEHeader, body io.Reader, charsetLabel string) string {
+	raw, err := io.ReadAll(transferDecoder(header, body))
+	if err != nil {
+		return ""
+	}
+	decoded, err := DecodeCharset(raw, charsetLabel)
+	if err != nil {
+		return ""
+	}
+	return decoded
+}
+
+// transferDecoder undoes the part's Content-Transfer-Encoding
+func transferDecoder(header textproto.MIMEHeader, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(header.Get("Content-Transfer-Encoding"))) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	default:
+		return body
+	}
+}
@@ -0,0 +1,87 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// nestedMessage has its bodies two multipart levels deep, in a legacy
+// charset and both transfer encodings, next to a PDF invoice
+const nestedMessage = "From: =?utf-8?q?Caf=C3=A9_Central?= <billing@cafe.example>\r\n" +
+	"To: customer@example.com\r\n" +
+	"Subject: =?iso-8859-1?q?Re=E7u_de_paiement?=\r\n" +
+	"Date: Wed, 05 Mar 2025 10:30:00 +0100\r\n" +
+	"Message-ID: <reply@cafe.example>\r\n" +
+	"In-Reply-To: <order@cafe.example>\r\n" +
+	"References: <start@cafe.example> <order@cafe.example>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=iso-8859-1\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Montant pay=E9 : 12,50 =80\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"PHA+TW9udGFudCBwYXnDqSA6IDEyLDUwIOKCrDwvcD4=\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: application/pdf; name=\"facture.pdf\"\r\n" +
+	"Content-Disposition: attachment; filename=\"facture.pdf\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"JVBERi0xLjQK\r\n" +
+	"--outer--\r\n"
+
+func TestParseMessage(t *testing.T) {
+	msg, err := ParseMessage([]byte(nestedMessage), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checks := []struct{ field, got, want string }{
+		{"From", msg.From, "Café Central <billing@cafe.example>"},
+		{"Subject", msg.Subject, "Reçu de paiement"},
+		{"ThreadID", msg.ThreadID, "<start@cafe.example>"},
+		{"Body", strings.TrimSpace(msg.Body), "Montant payé : 12,50 €"},
+		{"HTMLBody", msg.HTMLBody, "<p>Montant payé : 12,50 €</p>"},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %q; want %q", c.field, c.got, c.want)
+		}
+	}
+	if want := time.Date(2025, 3, 5, 9, 30, 0, 0, time.UTC); !msg.Date.Equal(want) {
+		t.Errorf("Date = %v; want %v", msg.Date, want)
+	}
+	if len(msg.Attachments) != 1 || msg.Attachments[0].Filename != "facture.pdf" || string(msg.Attachments[0].Data) != "%PDF-1.4\n" {
+		t.Errorf("Attachments = %+v; want facture.pdf", msg.Attachments)
+	}
+
+	// Attachments are only kept when asked for
+	msg, err = ParseMessage([]byte(nestedMessage), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.Attachments) != 0 {
+		t.Errorf("Attachments = %+v; want none", msg.Attachments)
+	}
+}
+
+func TestParseMessageWithoutContentType(t *testing.T) {
+	raw := "From: shop@example.com\r\nSubject: Receipt\r\n\r\nTotal: $9.99\r\n"
+	msg, err := ParseMessage([]byte(raw), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(msg.Body) != "Total: $9.99" || msg.HTMLBody != "" {
+		t.Errorf("Body = %q, HTMLBody = %q; want a plain-text body", msg.Body, msg.HTMLBody)
+	}
+}
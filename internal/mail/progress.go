@@ -0,0 +1,18 @@
+package mail
+
+// Stages reported to the progress callback
+const (
+	StageListing     = "listing"     // Searching for message IDs
+	StageScreening   = "screening"   // Fetching metadata to skip non-receipts
+	StageDownloading = "downloading" // Fetching full messages
+	StageAttachments = "attachments" // Fetching PDF attachments
+)
+
+// Progress describes how far a stage has advanced
+type Progress struct {
+	Stage    string
+	Query    string // Search being listed, for StageListing
+	Done     int
+	Total    int  // 0 while unknown
+	Finished bool // Last update of the stage
+}
@@ -0,0 +1,54 @@
+// Package mail defines the mailbox providers transaction emails are read
+// from, and implements the generic IMAP one
+package mail
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// Provider is a mailbox that can be searched for transaction emails
+type Provider interface {
+	// Name is the provider's display name, such as "Gmail"
+	Name() string
+	// Search returns the IDs of the emails matching any of the query's
+	// terms, newest first, without fetching them
+	Search(ctx context.Context, q Query) ([]string, error)
+	// Fetch returns the full emails with the given IDs, in the same order,
+	// skipping emails that fail on their own
+	Fetch(ctx context.Context, ids []string) ([]*models.Message, error)
+	// Close releases the connection to the mailbox
+	Close() error
+}
+
+// Query is a search over a mailbox
+type Query struct {
+	Terms  []string  // Keywords or phrases; emails matching any of them are returned
+	After  time.Time // Only emails received at or after this time, unless zero
+	Before time.Time // Only emails received before this time, unless zero
+	All    bool      // Ignore the provider's message limit
+}
+
+// ChangeTracker is implemented by providers that can list the emails added
+// since an earlier point, so syncs don't have to search the whole mailbox
+type ChangeTracker interface {
+	// Cursor returns the mailbox's current position
+	Cursor(ctx context.Context) (string, error)
+	// AddedSince returns the IDs of emails added after cursor, or
+	// ErrChangesExpired when the provider can no longer tell
+	AddedSince(ctx context.Context, cursor string) ([]string, error)
+}
+
+// Identifier is implemented by providers signed in to an account, which
+// can tell its email address
+type Identifier interface {
+	// Address returns the email address of the mailbox
+	Address(ctx context.Context) (string, error)
+}
+
+// ErrChangesExpired means the mailbox no longer knows the changes since a
+// cursor, so a full search is needed instead
+var ErrChangesExpired = errors.New("mailbox changes expired")
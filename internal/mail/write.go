@@ -0,0 +1,87 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"net/textproto"
+	"time"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// WriteEML writes msg as an RFC 5322 email: a text/plain body, a text/html
+// one, or both as alternatives
+func WriteEML(w io.Writer, msg *models.Message) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", encodeAddress(msg.From))
+	fmt.Fprintf(&buf, "To: %s\r\n", encodeAddress(msg.To))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", msg.Date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	// ParseMessage falls back to the HTML body when there is no text one
+	text := msg.Body
+	if text == msg.HTMLBody {
+		text = ""
+	}
+	switch {
+	case msg.HTMLBody == "":
+		if err := writePart(&buf, "text/plain", text); err != nil {
+			return err
+		}
+	case text == "":
+		if err := writePart(&buf, "text/html", msg.HTMLBody); err != nil {
+			return err
+		}
+	default:
+		parts := multipart.NewWriter(&buf)
+		fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+		for _, part := range []struct{ mediaType, body string }{{"text/plain", text}, {"text/html", msg.HTMLBody}} {
+			header := textproto.MIMEHeader{}
+			header.Set("Content-Type", part.mediaType+"; charset=utf-8")
+			header.Set("Content-Transfer-Encoding", "quoted-printable")
+			pw, err := parts.CreatePart(header)
+			if err != nil {
+				return err
+			}
+			if err := writeQuotedPrintable(pw, part.body); err != nil {
+				return err
+			}
+		}
+		if err := parts.Close(); err != nil {
+			return err
+		}
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writePart writes the headers and quoted-printable body of a single-part email
+func writePart(buf *bytes.Buffer, mediaType, body string) error {
+	fmt.Fprintf(buf, "Content-Type: %s; charset=utf-8\r\n", mediaType)
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	return writeQuotedPrintable(buf, body)
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// encodeAddress encodes the display name of an address header; headers
+// that aren't a single address are encoded whole
+func encodeAddress(value string) string {
+	if addr, err := netmail.ParseAddress(value); err == nil {
+		return addr.String()
+	}
+	return mime.QEncoding.Encode("utf-8", value)
+}
@@ -0,0 +1,35 @@
+package mail
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+func TestWriteEMLRoundTrip(t *testing.T) {
+	want := &models.Message{
+		From:     "Tienda Ñandú <ventas@tienda.example>",
+		To:       "cliente@example.com",
+		Subject:  "Tu recibo: total 1.234,56 €",
+		Date:     time.Date(2025, 1, 6, 18, 0, 0, 0, time.UTC),
+		Body:     "Total: 1.234,56 €\n",
+		HTMLBody: "<p>Total: <b>1.234,56 €</b></p>",
+	}
+	var buf bytes.Buffer
+	if err := WriteEML(&buf, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseMessage(buf.Bytes(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Lines of the text body come back ending in CRLF, as email writes them
+	body := strings.ReplaceAll(got.Body, "\r\n", "\n")
+	if got.Subject != want.Subject || body != want.Body || got.HTMLBody != want.HTMLBody || !got.Date.Equal(want.Date) {
+		t.Errorf("round trip = %q, %q, %q, %v; want %q, %q, %q, %v",
+			got.Subject, body, got.HTMLBody, got.Date, want.Subject, want.Body, want.HTMLBody, want.Date)
+	}
+}
@@ -1,41 +1,247 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // Transaction represents a financial transaction
 type Transaction struct {
-	ID             string    `json:"id"`
-	ServiceID      string    `json:"service_id"`
-	ServiceName    string    `json:"service_name"`
-	Category       string    `json:"category"`
-	Amount         float64   `json:"amount"`
-	Currency       string    `json:"currency"`        // USD, MXN, EUR, GBP, etc.
-	CurrencySymbol string    `json:"currency_symbol"` // $, €, £, ¥, etc.
-	Date           time.Time `json:"date"`
-	Description    string    `json:"description"`
-	Email          string    `json:"email"`
-	Subject        string    `json:"subject"`
-	Timestamp      time.Time `json:"timestamp"`
-	RawAmount      string    `json:"raw_amount"` // Original text extracted
+	ID             string    `json:"id" yaml:"id"`
+	ThreadID       string    `json:"thread_id" yaml:"thread_id"`
+	ServiceID      string    `json:"service_id" yaml:"service_id"`
+	ServiceName    string    `json:"service_name" yaml:"service_name"`
+	Category       string    `json:"category" yaml:"category"`
+	Amount         float64   `json:"amount" yaml:"amount"`
+	Currency       string    `json:"currency" yaml:"currency"`               // USD, MXN, EUR, GBP, etc.
+	CurrencySymbol string    `json:"currency_symbol" yaml:"currency_symbol"` // $, €, £, ¥, etc.
+	Date           time.Time `json:"date" yaml:"date"`
+	Description    string    `json:"description" yaml:"description"`
+	Email          string    `json:"email" yaml:"email"`
+	Subject        string    `json:"subject" yaml:"subject"`
+	Timestamp      time.Time `json:"timestamp" yaml:"timestamp"`
+	RawAmount      string    `json:"raw_amount" yaml:"raw_amount"`                       // Original text extracted
+	RelatedIDs     []string  `json:"related_ids,omitempty" yaml:"related_ids,omitempty"` // Other messages in the same thread
+	DueDate        time.Time `json:"due_date,omitzero" yaml:"due_date,omitempty"`        // Payment due date of bills
+	Tags           []string  `json:"tags,omitempty" yaml:"tags,omitempty"`               // User-assigned labels such as "work" or "shared"
+	Account        string    `json:"account,omitempty" yaml:"account,omitempty"`         // Address the receipt was sent to
+	Member         string    `json:"member,omitempty" yaml:"member,omitempty"`           // Household member the spending is attributed to
+	Confidence     float64   `json:"confidence,omitempty" yaml:"confidence,omitempty"`   // How reliable the extraction is, from 0.4 to 1; 0 when unscored
+	OrderID        string    `json:"order_id,omitempty" yaml:"order_id,omitempty"`       // Merchant order number shared by its confirmation and shipment emails
+	Partial        bool      `json:"partial,omitempty" yaml:"partial,omitempty"`         // Amount adds up shipment charges; the order total hasn't been seen
+	Status         string    `json:"status,omitempty" yaml:"status,omitempty"`           // pending, settled, refunded or cancelled; empty means settled
+	Excluded       bool      `json:"excluded,omitempty" yaml:"excluded,omitempty"`       // Left out of spending by the user in gm ui
+	Adjustment     string    `json:"adjustment,omitempty" yaml:"adjustment,omitempty"`   // "tip" or "total" for emails amending an earlier charge
+	InvoiceNumber  string    `json:"invoice_number,omitempty" yaml:"invoice_number,omitempty"`
+	Tax            float64   `json:"tax,omitempty" yaml:"tax,omitempty"` // Tax included in Amount, when the receipt shows it; negative for refunds
+}
+
+// Transaction statuses, following the emails about a charge: an
+// authorization is pending until its receipt settles it, and a full refund
+// or a cancellation closes it
+const (
+	StatusPending   = "pending"
+	StatusSettled   = "settled"
+	StatusRefunded  = "refunded"
+	StatusCancelled = "cancelled"
+)
+
+// EffectiveStatus returns the status of the transaction; transactions
+// saved before statuses were tracked are settled
+func (t *Transaction) EffectiveStatus() string {
+	if t.Status == "" {
+		return StatusSettled
+	}
+	return t.Status
+}
+
+// Counted reports whether the transaction counts toward spending: pending
+// authorizations may never be charged, cancelled charges never are and
+// excluded transactions were left out by the user
+func (t *Transaction) Counted() bool {
+	status := t.EffectiveStatus()
+	return !t.Excluded && status != StatusPending && status != StatusCancelled
 }
 
 // ExpenseSummary represents a summary of expenses
 type ExpenseSummary struct {
-	TotalAmount float64
-	TotalCount  int
-	ByCategory  map[string]float64
-	ByService   map[string]float64
-	DateRange   [2]time.Time
+	TotalAmount    float64             `json:"total_amount" yaml:"total_amount"`   // Net of refunds
+	GrossAmount    float64             `json:"gross_amount" yaml:"gross_amount"`   // Charges only
+	RefundAmount   float64             `json:"refund_amount" yaml:"refund_amount"` // Refunds, as a positive amount
+	TotalCount     int                 `json:"total_count" yaml:"total_count"`
+	CurrencySymbol string              `json:"currency_symbol" yaml:"currency_symbol"`
+	ByCategory     map[string]float64  `json:"by_category" yaml:"by_category"`
+	ByService      map[string]float64  `json:"by_service" yaml:"by_service"`
+	Categories     []GroupTotal        `json:"categories" yaml:"categories"`               // Sorted by total, largest first
+	Services       []GroupTotal        `json:"services" yaml:"services"`                   // Sorted by total, largest first
+	Members        []GroupTotal        `json:"members,omitempty" yaml:"members,omitempty"` // Household members; empty when nothing is attributed
+	DateRange      [2]time.Time        `json:"date_range" yaml:"date_range"`
+	Currency       string              `json:"currency,omitempty" yaml:"currency,omitempty"`   // Currency of the totals; empty when mixed
+	Currencies     []GroupTotal        `json:"currencies" yaml:"currencies"`                   // Unconverted subtotals per currency code
+	FXSource       string              `json:"fx_source,omitempty" yaml:"fx_source,omitempty"` // Rates used to convert to Currency
+	FXDate         string              `json:"fx_date,omitempty" yaml:"fx_date,omitempty"`
+	Budgets        []BudgetStatus      `json:"budgets,omitempty" yaml:"budgets,omitempty"`               // Burn-down of this month's budgets
+	PendingAmount  float64             `json:"pending_amount,omitempty" yaml:"pending_amount,omitempty"` // Authorizations left out of the totals until they settle
+	PendingCount   int                 `json:"pending_count,omitempty" yaml:"pending_count,omitempty"`
+	Warnings       []ExtractionWarning `json:"warnings,omitempty" yaml:"warnings,omitempty"` // Doubts about the emails extracted in this run, most severe first
+}
+
+// Severities of extraction warnings, from a detail worth knowing to a value
+// that is likely wrong
+const (
+	SeverityLow    = "low"
+	SeverityMedium = "medium"
+	SeverityHigh   = "high"
+)
+
+// Kinds of extraction warning
+const (
+	WarningCurrency = "ambiguous_currency" // No currency next to the amount, or several in the email
+	WarningAmount   = "ambiguous_amount"   // Other amounts scored about as well as the one picked
+	WarningDate     = "suspicious_date"    // No date in the email, or one far from when it was sent
+)
+
+// ExtractionWarning is a doubt about a value picked while extracting the
+// transaction of an email
+type ExtractionWarning struct {
+	MessageID string `json:"message_id" yaml:"message_id"`
+	Subject   string `json:"subject" yaml:"subject"`
+	Severity  string `json:"severity" yaml:"severity"`
+	Kind      string `json:"kind" yaml:"kind"`
+	Message   string `json:"message" yaml:"message"`
+}
+
+// Budget is a monthly spending limit for a category, saved in the local
+// store with gm budget set
+type Budget struct {
+	Category string  `json:"category" yaml:"category"`
+	Amount   float64 `json:"amount" yaml:"amount"`
+	Currency string  `json:"currency,omitempty" yaml:"currency,omitempty"` // Only transactions in this currency count; empty counts all
+}
+
+// MonthClose freezes a month closed with gm close: its transactions as they
+// were then, which reports use instead of the stored ones, and their summary
+type MonthClose struct {
+	Month        string          `json:"month" yaml:"month"` // YYYY-MM
+	ClosedAt     time.Time       `json:"closed_at" yaml:"closed_at"`
+	Summary      *ExpenseSummary `json:"summary" yaml:"summary"`
+	Transactions []*Transaction  `json:"transactions" yaml:"transactions"`
+}
+
+// SavedReport keeps the inputs of a report made with gm report, so gm report
+// --reproduce regenerates exactly the same report later
+type SavedReport struct {
+	ID        string    `json:"id" yaml:"id"`
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"` // The time the report was made for
+	Kind      string    `json:"kind" yaml:"kind"`             // month, year or pivot
+	Month     string    `json:"month,omitempty" yaml:"month,omitempty"`
+	Year      int       `json:"year,omitempty" yaml:"year,omitempty"`
+	Months    int       `json:"months,omitempty" yaml:"months,omitempty"`
+	By        string    `json:"by,omitempty" yaml:"by,omitempty"`
+	Flags     []string  `json:"flags,omitempty" yaml:"flags,omitempty"` // The flags given, such as the filters
+	// Rules holds the SHA-256 of each rule file when the report was made,
+	// by file name; "" when the file didn't exist
+	Rules        map[string]string `json:"rules" yaml:"rules"`
+	Budgets      []Budget          `json:"budgets,omitempty" yaml:"budgets,omitempty"`
+	Transactions []*Transaction    `json:"transactions" yaml:"transactions"` // Selected by the filters
+	// BudgetTransactions are the transactions budgets count, whatever the
+	// filters select
+	BudgetTransactions []*Transaction `json:"budget_transactions,omitempty" yaml:"budget_transactions,omitempty"`
+}
+
+// BudgetStatus tracks a category's spending this month against its budget
+type BudgetStatus struct {
+	Category       string  `json:"category" yaml:"category"`
+	Month          string  `json:"month" yaml:"month"` // YYYY-MM
+	Currency       string  `json:"currency,omitempty" yaml:"currency,omitempty"`
+	CurrencySymbol string  `json:"currency_symbol" yaml:"currency_symbol"`
+	Budget         float64 `json:"budget" yaml:"budget"`
+	Spent          float64 `json:"spent" yaml:"spent"`
+	Used           float64 `json:"used" yaml:"used"` // Percent of the budget spent so far
+	DaysLeft       int     `json:"days_left" yaml:"days_left"`
+	Projected      float64 `json:"projected" yaml:"projected"`                 // Spending by month end at the current pace
+	Overage        float64 `json:"overage,omitempty" yaml:"overage,omitempty"` // Projected spending above the budget
+}
+
+// AtRisk reports whether the budget is projected to be exceeded
+func (b BudgetStatus) AtRisk() bool {
+	return b.Overage > 0
+}
+
+// Over reports whether the budget is already exceeded
+func (b BudgetStatus) Over() bool {
+	return b.Spent > b.Budget
+}
+
+// Budget levels, shown green, yellow and red
+const (
+	BudgetOnTrack = "on_track"
+	BudgetAtRisk  = "at_risk" // Projected to be exceeded, or nearly used up
+	BudgetOver    = "over"
+)
+
+// budgetNearlyUsed is the percent of a budget past which it is at risk
+// whatever the projection
+const budgetNearlyUsed = 90
+
+// Level rates the budget by its spending so far and its projection
+func (b BudgetStatus) Level() string {
+	switch {
+	case b.Over():
+		return BudgetOver
+	case b.AtRisk() || b.Used >= budgetNearlyUsed:
+		return BudgetAtRisk
+	}
+	return BudgetOnTrack
+}
+
+// Message describes the burn-down in one sentence
+func (b BudgetStatus) Message() string {
+	if b.Over() {
+		return fmt.Sprintf("You're %s%.2f over your %s budget of %s%.2f for %s", b.CurrencySymbol, b.Spent-b.Budget, b.Category, b.CurrencySymbol, b.Budget, b.Month)
+	}
+	days := "days"
+	if b.DaysLeft == 1 {
+		days = "day"
+	}
+	message := fmt.Sprintf("You've used %.0f%% of your %s budget with %d %s left", b.Used, b.Category, b.DaysLeft, days)
+	if b.AtRisk() {
+		return fmt.Sprintf("%s, projected overage %s%.2f", message, b.CurrencySymbol, b.Overage)
+	}
+	return fmt.Sprintf("%s, projected %s%.2f of %s%.2f", message, b.CurrencySymbol, b.Projected, b.CurrencySymbol, b.Budget)
+}
+
+// Report bundles transactions with their summary for machine-readable output
+type Report struct {
+	Transactions []*Transaction  `json:"transactions" yaml:"transactions"`
+	Summary      *ExpenseSummary `json:"summary" yaml:"summary"`
+}
+
+// GroupTotal is the aggregate of the transactions sharing a category, service, etc.
+type GroupTotal struct {
+	Key     string  `json:"key" yaml:"key"`
+	Total   float64 `json:"total" yaml:"total"`
+	Count   int     `json:"count" yaml:"count"`
+	Percent float64 `json:"percent" yaml:"percent"` // Share of the grand total
 }
 
 // Message represents a Gmail message
 type Message struct {
-	ID       string
-	ThreadID string
-	From     string
-	To       string
-	Subject  string
-	Body     string
-	Date     time.Time
-	Labels   []string
+	ID          string
+	ThreadID    string
+	From        string
+	To          string
+	Subject     string
+	Body        string // Plain-text body, or the HTML one when there is none
+	HTMLBody    string // HTML alternative, if the email has one
+	Date        time.Time
+	Labels      []string
+	Attachments []Attachment // PDF attachments, such as invoices
+}
+
+// Attachment is a file attached to an email
+type Attachment struct {
+	Filename string
+	MimeType string
+	Data     []byte
 }
@@ -0,0 +1,381 @@
+// Package outlook reads Outlook.com and Microsoft 365 mailboxes through the
+// Microsoft Graph mail API
+package outlook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/auth"
+	"github.com/sazardev/go-money/internal/mail"
+	"github.com/sazardev/go-money/internal/models"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// graphURL is the Microsoft Graph API root
+	graphURL = "https://graph.microsoft.com/v1.0"
+	// listPageSize is the largest page of messages Graph returns
+	listPageSize = 1000
+	// maxMessageSize skips emails too large to be a receipt
+	maxMessageSize = 25 << 20
+	// cursorSkew allows for clock differences between here and Graph
+	cursorSkew = 5 * time.Minute
+	// MaxConcurrency is how many requests Graph allows in parallel per mailbox
+	MaxConcurrency = 4
+)
+
+// OutlookService is the mail provider for Outlook.com and Microsoft 365.
+// Messages are identified by immutable IDs, which survive moving them
+// between folders.
+type OutlookService struct {
+	client      *http.Client
+	attachments bool
+	concurrency int
+	maxMessages int
+	progress    func(mail.Progress)
+	progressMu  sync.Mutex
+}
+
+var (
+	_ mail.Provider      = (*OutlookService)(nil)
+	_ mail.ChangeTracker = (*OutlookService)(nil)
+	_ mail.Identifier    = (*OutlookService)(nil)
+)
+
+// NewOutlookService creates a Graph client for the signed-in account
+func NewOutlookService(ctx context.Context, token *oauth2.Token) *OutlookService {
+	authenticator := auth.NewMicrosoftAuthenticator()
+	return &OutlookService{
+		client:      authenticator.GetHTTPClient(ctx, token),
+		attachments: true,
+		concurrency: MaxConcurrency,
+	}
+}
+
+// SetAttachments enables or disables keeping PDF attachments
+func (s *OutlookService) SetAttachments(enabled bool) {
+	s.attachments = enabled
+}
+
+// SetConcurrency sets how many messages are downloaded in parallel, up to
+// MaxConcurrency
+func (s *OutlookService) SetConcurrency(workers int) {
+	s.concurrency = min(workers, MaxConcurrency)
+}
+
+// SetMaxMessages limits how many messages each search lists, newest
+// first; 0 removes the limit
+func (s *OutlookService) SetMaxMessages(limit int) {
+	s.maxMessages = limit
+}
+
+// SetProgress registers a callback for progress updates. Calls are
+// serialized, so the callback doesn't need to be safe for concurrent use.
+func (s *OutlookService) SetProgress(progress func(mail.Progress)) {
+	s.progress = progress
+}
+
+// report passes a progress update to the callback, if any
+func (s *OutlookService) report(p mail.Progress) {
+	if s.progress == nil {
+		return
+	}
+	s.progressMu.Lock()
+	defer s.progressMu.Unlock()
+	s.progress(p)
+}
+
+// Name returns the provider's display name
+func (s *OutlookService) Name() string {
+	return "Outlook"
+}
+
+// Search runs one Graph search per term. Graph searches use KQL, where
+// several words must all appear, like Gmail, and dates have day precision.
+func (s *OutlookService) Search(ctx context.Context, q mail.Query) ([]string, error) {
+	var window string
+	if !q.After.IsZero() {
+		window += " AND received>=" + q.After.UTC().Format("2006-01-02")
+	}
+	if !q.Before.IsZero() {
+		// Partial days are rounded up so no email is missed
+		before := q.Before.UTC()
+		if !before.Equal(before.Truncate(24 * time.Hour)) {
+			before = before.AddDate(0, 0, 1)
+		}
+		window += " AND received<" + before.Format("2006-01-02")
+	}
+
+	limit := s.maxMessages
+	if q.All {
+		limit = 0
+	}
+
+	var ids []string
+	seen := make(map[string]bool)
+	for _, term := range q.Terms {
+		if limit > 0 && len(ids) >= limit {
+			break
+		}
+		params := url.Values{
+			"$search": {`"` + strings.ReplaceAll(term, `"`, "") + window + `"`},
+			"$select": {"id"},
+			"$top":    {fmt.Sprint(listPageSize)},
+		}
+		found, err := s.listIDs(ctx, "/me/messages?"+params.Encode(), term, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range found {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	if limit > 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids, nil
+}
+
+// listIDs follows the pages of a message listing, stopping after limit
+// messages unless limit is 0
+func (s *OutlookService) listIDs(ctx context.Context, path, query string, limit int) ([]string, error) {
+	var ids []string
+	next := graphURL + path
+	for next != "" {
+		var page struct {
+			Value []struct {
+				ID string `json:"id"`
+			} `json:"value"`
+			NextLink string `json:"@odata.nextLink"`
+		}
+		if err := s.getJSON(ctx, next, &page); err != nil {
+			return nil, err
+		}
+		for _, message := range page.Value {
+			ids = append(ids, message.ID)
+		}
+		s.report(mail.Progress{Stage: mail.StageListing, Query: query, Done: len(ids)})
+
+		next = page.NextLink
+		if limit > 0 && len(ids) >= limit {
+			log.Printf("Listed the newest %d messages matching '%s'; raise --max-messages to include older ones", limit, query)
+			ids = ids[:limit]
+			break
+		}
+	}
+
+	s.report(mail.Progress{Stage: mail.StageListing, Query: query, Done: len(ids), Total: len(ids), Finished: true})
+	return ids, nil
+}
+
+// Fetch downloads the messages in MIME format, in parallel, keeping their
+// order and skipping messages that fail individually
+func (s *OutlookService) Fetch(ctx context.Context, ids []string) ([]*models.Message, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	fetched := make([]*models.Message, len(ids))
+	jobs := make(chan int)
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		done    int
+		skipped []error
+		fatal   error
+	)
+	for range max(1, min(s.concurrency, len(ids))) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				msg, err := s.fetchMessage(ctx, ids[i])
+
+				mu.Lock()
+				done++
+				s.report(mail.Progress{Stage: mail.StageDownloading, Done: done, Total: len(ids), Finished: done == len(ids)})
+				switch {
+				case err == nil:
+					fetched[i] = msg
+				case isFatal(err):
+					if fatal == nil {
+						fatal = err
+					}
+					cancel()
+				default:
+					skipped = append(skipped, err)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range ids {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if fatal != nil {
+		return nil, fatal
+	}
+	if len(skipped) > 0 {
+		log.Printf("⚠️  Warning: Could not download %d of %d messages: %v", len(skipped), len(ids), skipped[0])
+	}
+
+	messages := make([]*models.Message, 0, len(ids))
+	for _, msg := range fetched {
+		if msg != nil {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+// fetchMessage downloads and parses one message
+func (s *OutlookService) fetchMessage(ctx context.Context, id string) (*models.Message, error) {
+	res, err := s.get(ctx, graphURL+"/me/messages/"+url.PathEscape(id)+"/$value")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(res.Body, maxMessageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve message: %w", err)
+	}
+	if len(raw) > maxMessageSize {
+		return nil, fmt.Errorf("message %s is larger than %d MB", id, maxMessageSize>>20)
+	}
+
+	msg, err := mail.ParseMessage(raw, s.attachments)
+	if err != nil {
+		return nil, err
+	}
+	msg.ID = id
+	return msg, nil
+}
+
+// Close does nothing; Graph has no connection to release
+func (s *OutlookService) Close() error {
+	return nil
+}
+
+// Cursor returns the current time; Graph delta queries would have to list
+// the whole mailbox once to get a starting point
+func (s *OutlookService) Cursor(ctx context.Context) (string, error) {
+	return time.Now().UTC().Add(-cursorSkew).Format(time.RFC3339), nil
+}
+
+// AddedSince returns the IDs of messages received after the time in cursor
+func (s *OutlookService) AddedSince(ctx context.Context, cursor string) ([]string, error) {
+	since, err := time.Parse(time.RFC3339, cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid cursor %q", mail.ErrChangesExpired, cursor)
+	}
+	params := url.Values{
+		"$filter": {"receivedDateTime ge " + since.Format(time.RFC3339)},
+		"$select": {"id"},
+		"$top":    {fmt.Sprint(listPageSize)},
+	}
+	return s.listIDs(ctx, "/me/messages?"+params.Encode(), "new messages", 0)
+}
+
+// Address returns the email address of the signed-in account. Work
+// accounts without a mailbox address fall back to their sign-in name.
+func (s *OutlookService) Address(ctx context.Context) (string, error) {
+	var user struct {
+		Mail              string `json:"mail"`
+		UserPrincipalName string `json:"userPrincipalName"`
+	}
+	if err := s.getJSON(ctx, graphURL+"/me?$select=mail,userPrincipalName", &user); err != nil {
+		return "", err
+	}
+	if user.Mail != "" {
+		return user.Mail, nil
+	}
+	return user.UserPrincipalName, nil
+}
+
+// getJSON requests a Graph URL and decodes the JSON response into v
+func (s *OutlookService) getJSON(ctx context.Context, rawURL string, v any) error {
+	res, err := s.get(ctx, rawURL)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return fmt.Errorf("unable to decode Graph response: %w", err)
+	}
+	return nil
+}
+
+// get requests a Graph URL, failing on any status other than 200
+func (s *OutlookService) get(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Prefer", `IdType="ImmutableId"`)
+	// $search requires eventual consistency on some mailboxes
+	req.Header.Set("ConsistencyLevel", "eventual")
+
+	res, err := s.client.Do(req)
+	if auth.IsRevoked(err) {
+		return nil, fmt.Errorf("graph request failed: %w", apperrors.ErrTokenRevoked)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("graph request failed: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, graphError(res)
+	}
+	return res, nil
+}
+
+// graphError converts a failed Graph response into an error, classifying
+// authentication and throttling failures
+func graphError(res *http.Response) error {
+	var body struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&body)
+	err := fmt.Errorf("graph API error %d: %s %s", res.StatusCode, body.Error.Code, body.Error.Message)
+
+	switch res.StatusCode {
+	case http.StatusUnauthorized:
+		return fmt.Errorf("%w: %w", apperrors.ErrAuthRequired, err)
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return fmt.Errorf("%w: %w", apperrors.ErrQuotaExceeded, err)
+	}
+	return err
+}
+
+// isFatal reports whether err should stop every remaining download rather
+// than skip a single message
+func isFatal(err error) bool {
+	return errors.Is(err, apperrors.ErrQuotaExceeded) ||
+		errors.Is(err, apperrors.ErrAuthRequired) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
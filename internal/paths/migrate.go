@@ -0,0 +1,175 @@
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Move is a file Migrate moved, or copied, out of the working directory
+type Move struct {
+	From   string
+	To     string
+	Copied bool
+}
+
+// legacyFile is a file earlier versions kept in the working directory
+type legacyFile struct {
+	name  string // In the working directory
+	dir   func(Dirs) string
+	to    string // Name in dir
+	copy  bool   // Copy rather than move
+	store bool   // Part of the default store
+}
+
+func configDir(d Dirs) string { return d.Config }
+func dataDir(d Dirs) string   { return d.Data }
+
+// legacyFiles are the files earlier versions kept in the working directory.
+// tracker-mails.json is copied, since it usually belongs to a checkout of
+// the sources. The old .cache is left alone: run from the home directory it
+// is the user's own ~/.cache, and gm's entries in it are only a cache.
+var legacyFiles = []legacyFile{
+	{name: "tracker-mails.json", dir: configDir, to: "tracker-mails.json", copy: true},
+	{name: "category-rules.json", dir: configDir, to: "category-rules.json"},
+	{name: "accounts.json", dir: configDir, to: "accounts.json"},
+	{name: ".credentials", dir: dataDir, to: credentialsDir},
+	{name: "go-money.db", dir: dataDir, to: "go-money.db", store: true},
+	{name: "go-money.db-wal", dir: dataDir, to: "go-money.db-wal", store: true},
+	{name: "go-money.db-shm", dir: dataDir, to: "go-money.db-shm", store: true},
+}
+
+// Migrate moves the files earlier versions kept in the working directory
+// into the directories in use, leaving any already there alone. The
+// default store is only moved with store, when no other one was chosen.
+// Files it fails to move are still used where they are. They belong to the
+// default profile, so nothing is moved into a named one.
+func Migrate(store bool) ([]Move, error) {
+	if current.Profile != "" {
+		return nil, nil
+	}
+	var moves []Move
+	var errs []error
+	for _, file := range legacyFiles {
+		if file.store && !store {
+			continue
+		}
+		from, to := file.name, filepath.Join(file.dir(current), file.to)
+		if !exists(from) || exists(to) || samePath(from, to) {
+			continue
+		}
+
+		var err error
+		if file.copy {
+			err = copyPath(from, to)
+		} else {
+			err = movePath(from, to)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to move %s to %s: %w", from, to, err))
+			continue
+		}
+		moves = append(moves, Move{From: from, To: to, Copied: file.copy})
+	}
+	return moves, errors.Join(errs...)
+}
+
+// samePath reports whether a and b name the same file, such as when gm runs
+// from its own configuration directory
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// movePath renames from to to, copying it instead across file systems
+func movePath(from, to string) error {
+	if err := checkOutside(from, to); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0700); err != nil {
+		return err
+	}
+	if os.Rename(from, to) == nil {
+		return nil
+	}
+	if err := copyPath(from, to); err != nil {
+		return err
+	}
+	return os.RemoveAll(from)
+}
+
+// copyPath copies the file or directory from to to, keeping permissions, so
+// saved sign-ins stay readable only by the user. Nothing is left at to when
+// it fails.
+func copyPath(from, to string) error {
+	if err := checkOutside(from, to); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0700); err != nil {
+		return err
+	}
+	err := filepath.WalkDir(from, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(to, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+		return copyFile(path, target, info.Mode().Perm())
+	})
+	if err != nil {
+		os.RemoveAll(to)
+	}
+	return err
+}
+
+// checkOutside refuses to move or copy from into itself, such as the
+// working directory's .cache into .cache/go-money, which would copy its own
+// copies until the path is too long
+func checkOutside(from, to string) error {
+	absFrom, err := filepath.Abs(from)
+	if err != nil {
+		return err
+	}
+	absTo, err := filepath.Abs(to)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(absFrom, absTo)
+	if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%s is inside %s", to, from)
+	}
+	return nil
+}
+
+func copyFile(from, to string, perm fs.FileMode) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
@@ -0,0 +1,137 @@
+// Package paths resolves where gm keeps its files, so it behaves the same
+// from any working directory: settings in the configuration directory, the
+// store and sign-ins in the data directory, and external lookups in the
+// cache directory
+package paths
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// appDir names gm's directory inside each of the user's directories
+const appDir = "go-money"
+
+// credentialsDir holds saved sign-ins in the data directory
+const credentialsDir = "credentials"
+
+// Dirs are the directories gm keeps its files in
+type Dirs struct {
+	Config string // tracker-mails.json, category-rules.json and accounts.json
+	Data   string // The store and saved sign-ins
+	Cache  string // Exchange rates and LLM replies
+
+	Profile string // Named profile the directories belong to, see Dirs.ForProfile
+}
+
+// current are the directories in use, see Set
+var current = Default()
+
+// Default returns the platform's directories for gm, each of which can be
+// overridden with GM_CONFIG_DIR, GM_DATA_DIR and GM_CACHE_DIR. On Linux
+// they follow XDG: ~/.config/go-money, ~/.local/share/go-money and
+// ~/.cache/go-money. On macOS they are in ~/Library, and on Windows in
+// %AppData% and %LocalAppData%.
+func Default() Dirs {
+	dirs := Dirs{
+		Config: os.Getenv("GM_CONFIG_DIR"),
+		Data:   os.Getenv("GM_DATA_DIR"),
+		Cache:  os.Getenv("GM_CACHE_DIR"),
+	}
+	if dirs.Config == "" {
+		dirs.Config = userDir(os.UserConfigDir, ".")
+	}
+	if dirs.Data == "" {
+		dirs.Data = userDir(userDataDir, ".")
+	}
+	if dirs.Cache == "" {
+		dirs.Cache = userDir(os.UserCacheDir, filepath.Join(".cache", appDir))
+		// gm cache clear empties the cache directory, so it can't be the
+		// data directory, as on Windows
+		if dirs.Cache == dirs.Data {
+			dirs.Cache = filepath.Join(dirs.Cache, "cache")
+		}
+	}
+	return dirs
+}
+
+// userDir returns gm's directory inside the user's directory returned by
+// base, or fallback in the working directory, where earlier versions kept
+// its files, when there is none, such as without HOME
+func userDir(base func() (string, error), fallback string) string {
+	dir, err := base()
+	if err != nil {
+		return fallback
+	}
+	return filepath.Join(dir, appDir)
+}
+
+// userDataDir returns the user's data directory: XDG_DATA_HOME or
+// ~/.local/share on Unix. Windows keeps the store out of the roaming
+// profile, in %LocalAppData%; macOS has a single directory for both.
+func userDataDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		return os.UserCacheDir()
+	case "darwin", "ios", "plan9":
+		return os.UserConfigDir()
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share"), nil
+}
+
+// Set makes gm keep its files in dirs
+func Set(dirs Dirs) {
+	current = dirs
+}
+
+// Current returns the directories in use
+func Current() Dirs {
+	return current
+}
+
+// Config returns the path of a file in the configuration directory
+func Config(name string) string {
+	return resolve(filepath.Join(current.Config, name), name)
+}
+
+// Data returns the path of a file in the data directory
+func Data(name string) string {
+	return resolve(filepath.Join(current.Data, name), name)
+}
+
+// Credentials returns the directory saved sign-ins are kept in
+func Credentials() string {
+	return resolve(filepath.Join(current.Data, credentialsDir), ".credentials")
+}
+
+// Cache returns the directory external lookups are cached in. Unlike the
+// other files, it is never the working directory's .cache, which gm cache
+// clear would empty of whatever else it holds.
+func Cache() string {
+	return current.Cache
+}
+
+// resolve returns path, or legacy in the working directory when only that
+// exists: where earlier versions kept it, and where it stays until Migrate
+// moves it. Named profiles never use the working directory's files.
+func resolve(path, legacy string) string {
+	if current.Profile != "" || exists(path) || !exists(legacy) {
+		return path
+	}
+	return legacy
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return !errors.Is(err, fs.ErrNotExist)
+}
@@ -0,0 +1,28 @@
+package paths
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+)
+
+// profilesDir holds the directories of named profiles inside each of gm's
+// directories
+const profilesDir = "profiles"
+
+// profileName is the form of a profile name, which names its directories
+var profileName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ForProfile returns the directories of the named profile: one of its own
+// inside each of d, so profiles share no settings, store, sign-ins or cache
+func (d Dirs) ForProfile(name string) (Dirs, error) {
+	if !profileName.MatchString(name) {
+		return Dirs{}, fmt.Errorf("invalid profile name %q: use letters, digits, - and _", name)
+	}
+	return Dirs{
+		Config:  filepath.Join(d.Config, profilesDir, name),
+		Data:    filepath.Join(d.Data, profilesDir, name),
+		Cache:   filepath.Join(d.Cache, profilesDir, name),
+		Profile: name,
+	}, nil
+}
@@ -0,0 +1,171 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sazardev/go-money/internal/apperrors"
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// Path is where gm serve exchanges its store with remote instances
+const Path = "/api/store"
+
+// MaxBundleSize bounds the bundles gm serve accepts
+const MaxBundleSize = 64 << 20
+
+// Bundle is the content of a store exchanged between gm instances
+type Bundle struct {
+	Transactions []*models.Transaction `json:"transactions"`
+	Processed    []string              `json:"processed"` // IDs of the emails already processed
+}
+
+// Result counts what merging a bundle changed
+type Result struct {
+	Added     int `json:"added"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Skipped   int `json:"skipped"` // Dated in months closed on the receiving side
+}
+
+// Replica is a store that can be exchanged with remote instances
+type Replica interface {
+	// Bundle returns the whole content of the store
+	Bundle(ctx context.Context) (*Bundle, error)
+	// Merge saves the transactions of a bundle that win over the stored
+	// ones, see Merge, and marks its emails processed
+	Merge(ctx context.Context, bundle *Bundle) (Result, error)
+}
+
+// Hash identifies the content of a transaction; copies with the same hash
+// are identical
+func Hash(tx *models.Transaction) string {
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Merge returns the transactions of incoming to save over stored: those
+// missing from it and those that win over the stored copy with the same ID.
+// The winner of two copies is decided the same way on both sides, so
+// stores that push and pull each other end up identical: the copy extracted
+// last, then the one merging more emails, then the one with the greater
+// hash. Transactions for which locked reports true, such as those dated in
+// closed months, are skipped; locked may be nil.
+func Merge(stored, incoming []*models.Transaction, locked func(tx *models.Transaction) bool) ([]*models.Transaction, Result) {
+	byID := make(map[string]*models.Transaction, len(stored))
+	for _, tx := range stored {
+		byID[tx.ID] = tx
+	}
+
+	var result Result
+	var toSave []*models.Transaction
+	for _, tx := range incoming {
+		if locked != nil && locked(tx) {
+			result.Skipped++
+			continue
+		}
+		existing, ok := byID[tx.ID]
+		if !ok {
+			result.Added++
+			toSave = append(toSave, tx)
+			continue
+		}
+		existingHash, hash := Hash(existing), Hash(tx)
+		if hash == existingHash || !wins(tx, existing, hash, existingHash) {
+			result.Unchanged++
+			continue
+		}
+		result.Updated++
+		toSave = append(toSave, tx)
+	}
+	return toSave, result
+}
+
+// wins reports whether a, with hash aHash, replaces b
+func wins(a, b *models.Transaction, aHash, bHash string) bool {
+	switch {
+	case !a.Timestamp.Equal(b.Timestamp):
+		return a.Timestamp.After(b.Timestamp)
+	case len(a.RelatedIDs) != len(b.RelatedIDs):
+		return len(a.RelatedIDs) > len(b.RelatedIDs)
+	default:
+		return aHash > bHash
+	}
+}
+
+// Client exchanges bundles with a gm serve instance
+type Client struct {
+	URL    string // Base URL, such as http://homeserver:8090
+	Token  string
+	Client *http.Client
+}
+
+// NewClient returns a client for the gm serve instance at url
+func NewClient(url, token string) *Client {
+	return &Client{URL: strings.TrimSuffix(url, "/"), Token: token, Client: &http.Client{Timeout: 5 * time.Minute}}
+}
+
+// Pull downloads the remote store
+func (c *Client) Pull(ctx context.Context) (*Bundle, error) {
+	var bundle Bundle
+	if err := c.do(ctx, http.MethodGet, nil, &bundle); err != nil {
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+// Push merges bundle into the remote store and returns what it changed
+func (c *Client) Push(ctx context.Context, bundle *Bundle) (Result, error) {
+	var result Result
+	body, err := json.Marshal(bundle)
+	if err != nil {
+		return result, err
+	}
+	err = c.do(ctx, http.MethodPost, body, &result)
+	return result, err
+}
+
+// do sends a request to Path and decodes the JSON response into out
+func (c *Client) do(ctx context.Context, method string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.URL+Path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: invalid remote URL %q: %w", apperrors.ErrInvalidInput, c.URL, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return fmt.Errorf("%w: %s refused the token", apperrors.ErrAuthRequired, c.URL)
+	case http.StatusNotFound:
+		return fmt.Errorf("%s doesn't accept store syncs; upgrade gm there, and don't run gm serve with --read-only", c.URL)
+	default:
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: unexpected status %s: %s", c.URL, resp.Status, strings.TrimSpace(string(message)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s: invalid response: %w", c.URL, err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// Package sanitize strips the personal details out of receipt emails so
+// they can be shared in bug reports and fixtures, keeping their structure
+// and amounts so they are still read the same way
+package sanitize
+
+import (
+	netmail "net/mail"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/pkg/redact"
+)
+
+// Replacements for what is removed
+const (
+	Recipient   = "you@example.com"
+	nameMask    = "Customer"
+	addressMask = "[address]"
+	phoneMask   = "[phone]"
+)
+
+// minNameLength keeps initials and short words out of the recipient's names
+const minNameLength = 3
+
+var (
+	// greetingPattern finds the capitalized name after a greeting, such as
+	// "Hi Jane Doe,"
+	greetingPattern = regexp.MustCompile(`\b((?i:hi|hello|hey|dear|hola|estimad[oa]|querid[oa]))(,?\s+)(\p{Lu}[\p{L}'-]+(?:\s+\p{Lu}[\p{L}'-]+)?)`)
+	// streetPattern finds street addresses: a number followed by a few
+	// words ending in a street type, or a street type followed by a number
+	streetPattern = regexp.MustCompile(`(?i)\b\d{1,6}\s+(?:[\p{L}.'-]+\s+){1,4}(?:street|st|avenue|ave|road|rd|boulevard|blvd|lane|ln|drive|dr|suite|apt)\b\.?|\b(?:calle|avenida|av\.|col\.|colonia|carrera|rua)\s+[\p{L}\d .'#-]{2,40}?\d{1,6}\b`)
+	// phonePattern finds phone numbers of at least ten digits in groups
+	phonePattern = regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?\(?\d{2,4}\)?[\s.-]\d{3,4}[\s.-]\d{4}\b`)
+	// queryPattern finds the query strings of links, which often carry
+	// tokens tied to the account
+	queryPattern = regexp.MustCompile(`(https?://[^\s"'<>?]+)\?[^\s"'<>]*`)
+)
+
+// Message returns a copy of msg that is safe to share: sent to
+// you@example.com, with the recipient's names, street addresses, phone
+// numbers, link query strings, email addresses, card and account numbers
+// and tokens removed from its subject and bodies. The sender is kept, since
+// it identifies the merchant, and attachments are dropped, since PDFs can't
+// be sanitized.
+func Message(msg *models.Message) *models.Message {
+	names := recipientNames(msg.To)
+	return &models.Message{
+		ID:       msg.ID,
+		From:     msg.From,
+		To:       Recipient,
+		Subject:  Text(msg.Subject, names),
+		Body:     Text(msg.Body, names),
+		HTMLBody: Text(msg.HTMLBody, names),
+		Date:     msg.Date,
+	}
+}
+
+// Text removes personal details from s, including the given names of the
+// recipient. Amounts are left alone.
+func Text(s string, names []string) string {
+	s = queryPattern.ReplaceAllString(s, "$1")
+	s = redact.All(s)
+	s = streetPattern.ReplaceAllString(s, addressMask)
+	s = phonePattern.ReplaceAllString(s, phoneMask)
+	s = greetingPattern.ReplaceAllString(s, "${1}${2}"+nameMask)
+	for _, name := range names {
+		s = regexp.MustCompile(`(?i)\b`+regexp.QuoteMeta(name)+`\b`).ReplaceAllString(s, nameMask)
+	}
+	return s
+}
+
+// recipientNames returns the words of the recipient's display name, such
+// as "jane" and "doe" for "Jane Doe <jane.doe@example.com>", or of the local
+// part of their address when it has none
+func recipientNames(to string) []string {
+	addr, err := netmail.ParseAddress(to)
+	if err != nil {
+		return nil
+	}
+	words := strings.Fields(addr.Name)
+	if len(words) == 0 {
+		local, _, _ := strings.Cut(addr.Address, "@")
+		words = strings.FieldsFunc(local, func(r rune) bool {
+			return strings.ContainsRune("._-+0123456789", r)
+		})
+	}
+
+	var names []string
+	for _, word := range words {
+		word = strings.ToLower(strings.Trim(word, `"',.`))
+		if len(word) >= minNameLength && !slices.Contains(names, word) {
+			names = append(names, word)
+		}
+	}
+	return names
+}
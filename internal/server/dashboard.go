@@ -0,0 +1,83 @@
+package server
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/summary"
+)
+
+// static holds the web dashboard served at /
+//
+//go:embed static
+var static embed.FS
+
+// dashboardData is what the dashboard shows: the counted transactions of
+// the selected category and month, their summary and the spending per month
+type dashboardData struct {
+	Updated      time.Time              `json:"updated"`
+	Categories   []string               `json:"categories"` // Every category, for the filter
+	Months       []summary.Group        `json:"months"`     // Oldest first
+	Summary      *models.ExpenseSummary `json:"summary"`
+	Transactions []*models.Transaction  `json:"transactions"` // Newest first
+}
+
+// staticHandler serves the dashboard's files. They hold no data, so they
+// don't need the token; the page asks for it and sends it to /api.
+func staticHandler() http.Handler {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	return http.FileServer(http.FS(files))
+}
+
+// handleDashboard serves the dashboard data as JSON. The category and month
+// (YYYY-MM) query parameters narrow it down.
+func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	all, updated := s.snapshot()
+	counted := summary.Apply(all, summary.Counted())
+
+	// The month chart follows the category filter but shows every month
+	inCategory := counted
+	if category := r.URL.Query().Get("category"); category != "" {
+		inCategory = summary.Apply(counted, summary.InCategory(category))
+	}
+	selected := inCategory
+	if month := r.URL.Query().Get("month"); month != "" {
+		start, err := time.ParseInLocation("2006-01", month, time.Local)
+		if err != nil {
+			http.Error(w, "invalid month, use YYYY-MM", http.StatusBadRequest)
+			return
+		}
+		selected = summary.Apply(inCategory, summary.Between(start, start.AddDate(0, 1, 0).Add(-time.Nanosecond)))
+	}
+
+	data := dashboardData{
+		Updated:      updated,
+		Categories:   []string{},
+		Months:       summary.GroupBy(inCategory, summary.ByMonth),
+		Summary:      summary.Build(selected),
+		Transactions: make([]*models.Transaction, len(selected)),
+	}
+	for _, group := range summary.GroupBy(counted, summary.ByCategory) {
+		data.Categories = append(data.Categories, group.Key)
+	}
+	sort.Strings(data.Categories)
+	sort.Slice(data.Months, func(i, j int) bool { return data.Months[i].Key < data.Months[j].Key })
+	copy(data.Transactions, selected)
+	sort.SliceStable(data.Transactions, func(i, j int) bool { return data.Transactions[i].Date.After(data.Transactions[j].Date) })
+	if s.budgets != nil {
+		data.Summary.Budgets = s.budgets(all, time.Now())
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := summary.WriteJSON(w, data); err != nil {
+		s.log.Error("Failed to write dashboard data: " + err.Error())
+	}
+}
@@ -0,0 +1,223 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sazardev/go-money/internal/export"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/remote"
+	"github.com/sazardev/go-money/pkg/logger"
+)
+
+// LoadFunc produces the current list of transactions, e.g. by syncing Gmail
+type LoadFunc func(ctx context.Context) ([]*models.Transaction, error)
+
+// BudgetFunc reports the budget burn-down of the given transactions at now
+type BudgetFunc func(transactions []*models.Transaction, now time.Time) []models.BudgetStatus
+
+// Server serves extracted transactions over HTTP and keeps them fresh by
+// calling its LoadFunc periodically
+type Server struct {
+	addr    string
+	token   string
+	refresh time.Duration
+	load    LoadFunc
+	budgets BudgetFunc
+	replica remote.Replica
+	log     logger.Logger
+
+	mu           sync.RWMutex
+	transactions []*models.Transaction
+	updated      time.Time
+}
+
+// NewServer creates a server listening on addr. Every request must present
+// token as a Bearer Authorization header.
+// budgets may be nil when no budgets are tracked, and replica when remote
+// instances may not push or pull the store.
+func NewServer(addr, token string, refresh time.Duration, load LoadFunc, budgets BudgetFunc, replica remote.Replica) *Server {
+	return &Server{
+		addr:    addr,
+		token:   token,
+		refresh: refresh,
+		load:    load,
+		budgets: budgets,
+		replica: replica,
+		log:     logger.GetLogger(),
+	}
+}
+
+// Run syncs once, starts serving and keeps syncing until ctx is cancelled
+func (s *Server) Run(ctx context.Context) error {
+	if err := s.sync(ctx); err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:    s.addr,
+		Handler: s.routes(),
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- srv.ListenAndServe()
+	}()
+	s.log.Info(fmt.Sprintf("Serving on %s", s.addr))
+
+	ticker := time.NewTicker(s.refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-errChan:
+			return err
+		case <-ticker.C:
+			if err := s.sync(ctx); err != nil {
+				s.log.Error(fmt.Sprintf("Sync failed: %v", err))
+			}
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		}
+	}
+}
+
+// sync reloads transactions and swaps them in atomically
+func (s *Server) sync(ctx context.Context) error {
+	transactions, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.transactions = transactions
+	s.updated = time.Now()
+	s.mu.Unlock()
+
+	s.log.Info(fmt.Sprintf("Synced %d transactions", len(transactions)))
+	return nil
+}
+
+// snapshot returns the current transactions and when they were loaded
+func (s *Server) snapshot() ([]*models.Transaction, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.transactions, s.updated
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feed.atom", s.requireToken(s.handleFeed))
+	mux.HandleFunc("/api/dashboard", s.requireToken(s.handleDashboard))
+	if s.replica != nil {
+		mux.HandleFunc(remote.Path, s.requireToken(s.handleStore))
+	}
+	mux.Handle("/", staticHandler())
+	return mux
+}
+
+// requireToken rejects requests that don't carry the configured token
+func (s *Server) requireToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only the header: a token in the URL ends up in logs and history
+		header := r.Header.Get("Authorization")
+		given, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			given = ""
+		}
+
+		if subtle.ConstantTimeCompare([]byte(given), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="go-money"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// handleFeed serves the Atom feed of recent transactions and budget alerts
+func (s *Server) handleFeed(w http.ResponseWriter, r *http.Request) {
+	transactions, updated := s.snapshot()
+
+	var budgets []models.BudgetStatus
+	if s.budgets != nil {
+		budgets = s.budgets(transactions, time.Now())
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	selfURL := fmt.Sprintf("%s://%s%s", scheme, r.Host, r.URL.Path)
+
+	var buf bytes.Buffer
+	if err := export.WriteAtom(&buf, transactions, budgets, selfURL, updated); err != nil {
+		http.Error(w, "failed to render feed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// handleStore lets remote instances pull the store with GET and push
+// theirs with POST, see gm remote
+func (s *Server) handleStore(w http.ResponseWriter, r *http.Request) {
+	var response any
+	switch r.Method {
+	case http.MethodGet:
+		bundle, err := s.replica.Bundle(r.Context())
+		if err != nil {
+			s.log.Error("Failed to read the store: " + err.Error())
+			http.Error(w, "failed to read the store", http.StatusInternalServerError)
+			return
+		}
+		response = bundle
+	case http.MethodPost:
+		var bundle remote.Bundle
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, remote.MaxBundleSize)).Decode(&bundle); err != nil {
+			http.Error(w, "invalid bundle: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		result, err := s.replica.Merge(r.Context(), &bundle)
+		if err != nil {
+			s.log.Error("Failed to merge a pushed store: " + err.Error())
+			http.Error(w, "failed to merge the store", http.StatusInternalServerError)
+			return
+		}
+		s.log.Info(fmt.Sprintf("Merged a pushed store: %d added, %d updated", result.Added, result.Updated))
+		if result.Added+result.Updated > 0 {
+			// Show the pushed transactions without waiting for the next refresh
+			go func() {
+				if err := s.sync(context.Background()); err != nil {
+					s.log.Error(fmt.Sprintf("Sync failed: %v", err))
+				}
+			}()
+		}
+		response = result
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		s.log.Error("Failed to write the store: " + err.Error())
+	}
+}
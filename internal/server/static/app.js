@@ -0,0 +1,169 @@
+// GO Money dashboard: reads /api/dashboard with the token of gm serve and
+// draws it. Email data is only ever inserted as text, never as HTML.
+"use strict";
+
+const tokenKey = "gm-token";
+const svgNS = "http://www.w3.org/2000/svg";
+
+const $ = (id) => document.getElementById(id);
+
+function el(tag, text, className) {
+  const node = document.createElement(tag);
+  if (text !== undefined) node.textContent = text;
+  if (className) node.className = className;
+  return node;
+}
+
+function money(symbol, amount) {
+  return symbol + amount.toFixed(2);
+}
+
+async function load() {
+  const query = new URLSearchParams();
+  if ($("category").value) query.set("category", $("category").value);
+  if ($("month").value) query.set("month", $("month").value);
+
+  const response = await fetch("api/dashboard?" + query, {
+    headers: { Authorization: "Bearer " + (sessionStorage.getItem(tokenKey) || "") },
+  });
+  if (response.status === 401) {
+    sessionStorage.removeItem(tokenKey);
+    $("dashboard").hidden = true;
+    $("login").hidden = false;
+    return;
+  }
+  if (!response.ok) {
+    $("error").textContent = await response.text();
+    return;
+  }
+
+  $("login").hidden = true;
+  $("dashboard").hidden = false;
+  render(await response.json());
+}
+
+// last is the data drawn last, redrawn when the window is resized
+let last;
+
+function render(data) {
+  last = data;
+  const s = data.summary;
+  const symbol = s.currency_symbol;
+
+  fillCategories(data.categories);
+  $("updated").textContent = "Synced " + new Date(data.updated).toLocaleString();
+  $("total").textContent = s.currencies.length > 1
+    ? s.currencies.map((c) => c.key + " " + c.total.toFixed(2)).join(" · ")
+    : money(symbol, s.total_amount);
+  $("count").textContent = s.total_count;
+  $("period").textContent = s.total_count
+    ? s.date_range[0].slice(0, 10) + " – " + s.date_range[1].slice(0, 10)
+    : "–";
+
+  drawMonths(data.months, symbol);
+  drawCategories(s.categories, symbol);
+  drawBudgets(s.budgets || []);
+  fillTable(data.transactions);
+}
+
+function fillCategories(categories) {
+  const select = $("category");
+  if (select.options.length > 1) return;
+  for (const category of categories) {
+    select.append(new Option(category, category));
+  }
+}
+
+// drawMonths draws a bar per month; clicking one selects that month
+function drawMonths(months, symbol) {
+  const svg = $("months");
+  svg.replaceChildren();
+  const width = svg.clientWidth || 800;
+  const height = svg.clientHeight || 220;
+  const top = Math.max(...months.map((m) => m.total), 0);
+  if (top <= 0) return;
+
+  const slot = width / months.length;
+  months.forEach((m, i) => {
+    const barHeight = Math.max(m.total / top * (height - 40), 1);
+    const rect = document.createElementNS(svgNS, "rect");
+    rect.setAttribute("x", i * slot + slot * 0.15);
+    rect.setAttribute("y", height - 20 - barHeight);
+    rect.setAttribute("width", slot * 0.7);
+    rect.setAttribute("height", barHeight);
+    if (m.key === $("month").value) rect.classList.add("selected");
+    const title = document.createElementNS(svgNS, "title");
+    title.textContent = m.key + ": " + money(symbol, m.total);
+    rect.append(title);
+    rect.addEventListener("click", () => {
+      $("month").value = $("month").value === m.key ? "" : m.key;
+      load();
+    });
+
+    const label = document.createElementNS(svgNS, "text");
+    label.setAttribute("x", i * slot + slot / 2);
+    label.setAttribute("y", height - 5);
+    label.setAttribute("text-anchor", "middle");
+    label.textContent = m.key;
+    svg.append(rect, label);
+  });
+}
+
+// drawCategories draws a bar per category; clicking one filters by it
+function drawCategories(categories, symbol) {
+  const box = $("categories");
+  box.replaceChildren();
+  const top = Math.max(...categories.map((c) => c.total), 0);
+  for (const c of categories) {
+    const row = el("div", undefined, "bar");
+    const fill = el("div", undefined, "fill");
+    fill.style.width = (top > 0 ? Math.max(c.total / top * 100, 0) : 0) + "%";
+    const track = el("div");
+    track.append(fill);
+    row.append(el("span", c.key), track, el("span", money(symbol, c.total) + " (" + c.percent.toFixed(1) + "%)", "value"));
+    row.addEventListener("click", () => {
+      $("category").value = $("category").value === c.key ? "" : c.key;
+      load();
+    });
+    box.append(row);
+  }
+}
+
+function drawBudgets(budgets) {
+  $("budgets-section").hidden = budgets.length === 0;
+  const box = $("budgets");
+  box.replaceChildren();
+  for (const b of budgets) {
+    const row = el("div", undefined, "bar");
+    const fill = el("div", undefined, b.spent > b.budget || b.overage > 0 ? "fill over" : "fill");
+    fill.style.width = Math.min(b.used, 100) + "%";
+    const track = el("div");
+    track.append(fill);
+    row.append(el("span", b.category), track,
+      el("span", money(b.currency_symbol, b.spent) + " of " + money(b.currency_symbol, b.budget), "value"));
+    box.append(row);
+  }
+}
+
+function fillTable(transactions) {
+  const body = $("transactions");
+  body.replaceChildren();
+  for (const tx of transactions) {
+    const row = el("tr");
+    const amount = el("td", money(tx.currency_symbol, tx.amount) + " " + tx.currency, tx.amount < 0 ? "amount refund" : "amount");
+    row.append(el("td", tx.date.slice(0, 10)), el("td", tx.service_name), el("td", tx.category), amount, el("td", tx.subject));
+    body.append(row);
+  }
+}
+
+$("login").addEventListener("submit", (event) => {
+  event.preventDefault();
+  sessionStorage.setItem(tokenKey, $("token").value);
+  $("error").textContent = "";
+  load();
+});
+$("category").addEventListener("change", load);
+$("month").addEventListener("change", load);
+window.addEventListener("resize", () => last && render(last));
+
+load();
@@ -0,0 +1,169 @@
+package settle
+
+import (
+	"math"
+	"sort"
+
+	"github.com/sazardev/go-money/internal/categories"
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// Balance is what a member paid for shared expenses and what their share
+// of them is; a positive Net means the member is owed money
+type Balance struct {
+	Member string  `json:"member" yaml:"member"`
+	Paid   float64 `json:"paid" yaml:"paid"`
+	Share  float64 `json:"share" yaml:"share"`
+	Net    float64 `json:"net" yaml:"net"`
+}
+
+// Transfer is a payment that settles part of the balances
+type Transfer struct {
+	From   string  `json:"from" yaml:"from"`
+	To     string  `json:"to" yaml:"to"`
+	Amount float64 `json:"amount" yaml:"amount"`
+}
+
+// Settlement settles the shared expenses paid in one currency
+type Settlement struct {
+	Currency       string     `json:"currency" yaml:"currency"`
+	CurrencySymbol string     `json:"currency_symbol" yaml:"currency_symbol"`
+	Count          int        `json:"count" yaml:"count"`
+	Total          float64    `json:"total" yaml:"total"`
+	Balances       []Balance  `json:"balances" yaml:"balances"`
+	Transfers      []Transfer `json:"transfers" yaml:"transfers"`
+}
+
+// Compute splits the shared transactions between members by their
+// configured shares and returns the transfers that settle them, one
+// settlement per currency. Shared transactions that aren't attributed to a
+// member have no known payer; they are skipped and counted in unassigned.
+func Compute(transactions []*models.Transaction, members []categories.Member) (settlements []Settlement, unassigned int) {
+	weights := shareWeights(members)
+
+	byCurrency := make(map[string][]*models.Transaction)
+	var currencies []string
+	for _, tx := range transactions {
+		if !isShared(tx) {
+			continue
+		}
+		if _, known := weights[tx.Member]; !known {
+			unassigned++
+			continue
+		}
+		if _, ok := byCurrency[tx.Currency]; !ok {
+			currencies = append(currencies, tx.Currency)
+		}
+		byCurrency[tx.Currency] = append(byCurrency[tx.Currency], tx)
+	}
+	sort.Strings(currencies)
+
+	for _, currency := range currencies {
+		settlements = append(settlements, settleCurrency(currency, byCurrency[currency], members, weights))
+	}
+	return settlements, unassigned
+}
+
+// settleCurrency computes the balances and transfers of one currency
+func settleCurrency(currency string, shared []*models.Transaction, members []categories.Member, weights map[string]float64) Settlement {
+	s := Settlement{
+		Currency:       currency,
+		CurrencySymbol: shared[0].CurrencySymbol,
+		Count:          len(shared),
+		Transfers:      []Transfer{},
+	}
+
+	paid := make(map[string]float64)
+	for _, tx := range shared {
+		paid[tx.Member] += tx.Amount
+		s.Total += tx.Amount
+	}
+
+	for _, member := range members {
+		share := s.Total * weights[member.Name]
+		s.Balances = append(s.Balances, Balance{
+			Member: member.Name,
+			Paid:   round(paid[member.Name]),
+			Share:  round(share),
+			Net:    round(paid[member.Name] - share),
+		})
+	}
+	s.Total = round(s.Total)
+	s.Transfers = transfers(s.Balances)
+	return s
+}
+
+// transfers pairs the largest debtor with the largest creditor until every
+// balance is settled, which needs at most one transfer fewer than members
+func transfers(balances []Balance) []Transfer {
+	type party struct {
+		name   string
+		amount float64
+	}
+	var debtors, creditors []party
+	for _, b := range balances {
+		switch {
+		case b.Net < 0:
+			debtors = append(debtors, party{b.Member, -b.Net})
+		case b.Net > 0:
+			creditors = append(creditors, party{b.Member, b.Net})
+		}
+	}
+	largestFirst := func(parties []party) {
+		sort.SliceStable(parties, func(i, j int) bool { return parties[i].amount > parties[j].amount })
+	}
+	largestFirst(debtors)
+	largestFirst(creditors)
+
+	result := []Transfer{}
+	for len(debtors) > 0 && len(creditors) > 0 {
+		amount := round(math.Min(debtors[0].amount, creditors[0].amount))
+		if amount > 0 {
+			result = append(result, Transfer{From: debtors[0].name, To: creditors[0].name, Amount: amount})
+		}
+
+		debtors[0].amount = round(debtors[0].amount - amount)
+		creditors[0].amount = round(creditors[0].amount - amount)
+		if debtors[0].amount <= 0 {
+			debtors = debtors[1:]
+		}
+		if creditors[0].amount <= 0 {
+			creditors = creditors[1:]
+		}
+	}
+	return result
+}
+
+// shareWeights normalizes the members' shares to fractions adding up to 1,
+// splitting equally when no member has a share
+func shareWeights(members []categories.Member) map[string]float64 {
+	total := 0.0
+	for _, member := range members {
+		total += member.Share
+	}
+
+	weights := make(map[string]float64, len(members))
+	for _, member := range members {
+		if total == 0 {
+			weights[member.Name] = 1 / float64(len(members))
+		} else {
+			weights[member.Name] = member.Share / total
+		}
+	}
+	return weights
+}
+
+// isShared reports whether the transaction carries the shared tag
+func isShared(tx *models.Transaction) bool {
+	for _, tag := range tx.Tags {
+		if tag == categories.SharedTag {
+			return true
+		}
+	}
+	return false
+}
+
+// round rounds an amount to cents
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
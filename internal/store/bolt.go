@@ -0,0 +1,342 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sazardev/go-money/internal/models"
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltSchemaVersion is the layout version of bolt stores this version of gm
+// reads and writes. Values are JSON, so new fields need no migration; bump
+// it only when existing values must be rewritten.
+const BoltSchemaVersion = 1
+
+// boltLockTimeout bounds the wait for another gm holding the file open
+const boltLockTimeout = 10 * time.Second
+
+// Buckets of a bolt store, one per table of a SQLite store
+var (
+	boltMeta         = []byte("meta")
+	boltTransactions = []byte("transactions")
+	boltProcessed    = []byte("processed_messages")
+	boltSyncState    = []byte("sync_state")
+	boltBudgets      = []byte("budgets") // By lower-case category
+	boltClosedMonths = []byte("closed_months")
+	boltReports      = []byte("reports")
+)
+
+// boltVersionKey holds the layout version in the meta bucket
+var boltVersionKey = []byte("schema_version")
+
+var _ Store = (*BoltStore)(nil)
+
+// BoltStore is a Store kept in a bbolt file, which needs neither cgo nor
+// SQLite, so gm runs on platforms SQLite isn't available for, such as MIPS
+// routers. Only one gm can have the file open for writing at a time.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// IsBolt reports whether a store location is a bolt file, by its .bolt
+// extension
+func IsBolt(location string) bool {
+	return strings.EqualFold(filepath.Ext(location), ".bolt")
+}
+
+// OpenBolt opens (creating if needed) the bolt store at path
+func OpenBolt(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: boltLockTimeout})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("unable to open store %s: another gm is using it", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to open store %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltMeta, boltTransactions, boltProcessed, boltSyncState, boltBudgets, boltClosedMonths, boltReports} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		meta := tx.Bucket(boltMeta)
+		version, err := boltVersion(meta)
+		if err != nil {
+			return err
+		}
+		if version > BoltSchemaVersion {
+			return fmt.Errorf("schema v%d is newer than the v%d this version of gm supports; upgrade gm", version, BoltSchemaVersion)
+		}
+		return meta.Put(boltVersionKey, []byte(strconv.Itoa(BoltSchemaVersion)))
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("unable to open store %s: %w", path, err)
+	}
+	return &BoltStore{db: db}, nil
+}
+
+// openBoltReadOnly opens the bolt store at path without writing to it; nil
+// when there is no store yet
+func openBoltReadOnly(path string) (*BoltStore, error) {
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: boltLockTimeout, ReadOnly: true})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("unable to open store %s: another gm is writing to it", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to open store %s: %w", path, err)
+	}
+	return &BoltStore{db: db}, nil
+}
+
+// boltVersion returns the layout version recorded in the meta bucket; a
+// new store is at version 0
+func boltVersion(meta *bolt.Bucket) (int, error) {
+	if meta == nil || meta.Get(boltVersionKey) == nil {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(string(meta.Get(boltVersionKey)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema version %q", meta.Get(boltVersionKey))
+	}
+	return version, nil
+}
+
+// checkBoltSchema reports the differences between the bolt store at path
+// and the layout this version expects
+func checkBoltSchema(path string) ([]SchemaIssue, error) {
+	st, err := openBoltReadOnly(path)
+	if err != nil || st == nil {
+		return nil, err
+	}
+	defer st.Close()
+
+	var issues []SchemaIssue
+	err = st.db.View(func(tx *bolt.Tx) error {
+		version, err := boltVersion(tx.Bucket(boltMeta))
+		if err != nil {
+			return err
+		}
+		if version > BoltSchemaVersion {
+			issues = append(issues, SchemaIssue{Message: fmt.Sprintf("schema is v%d, from a newer version of gm that supports more than v%d", version, BoltSchemaVersion)})
+		}
+		for _, name := range [][]byte{boltTransactions, boltProcessed, boltSyncState, boltBudgets, boltClosedMonths, boltReports} {
+			if tx.Bucket(name) == nil {
+				issues = append(issues, SchemaIssue{Message: fmt.Sprintf("bucket %s is missing", name), Missing: true})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the schema of %s: %w", path, err)
+	}
+	return issues, nil
+}
+
+// put stores value as JSON under key in bucket
+func put(tx *bolt.Tx, bucket []byte, key string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucket).Put([]byte(key), data)
+}
+
+// each calls fn with every value of bucket, in key order. Buckets missing
+// from a store opened read-only have no values.
+func each(tx *bolt.Tx, bucket []byte, fn func(key string, value []byte) error) error {
+	b := tx.Bucket(bucket)
+	if b == nil {
+		return nil
+	}
+	return b.ForEach(func(k, v []byte) error { return fn(string(k), v) })
+}
+
+// SaveTransactions inserts or replaces transactions by ID. Like SQLite
+// stores, only the extracted fields are kept; tags and the other user
+// changes live in category-rules.json.
+func (s *BoltStore) SaveTransactions(ctx context.Context, transactions []*models.Transaction) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, t := range transactions {
+			stored := *t
+			stored.Tags, stored.Member, stored.Excluded, stored.Adjustment = nil, "", false, ""
+			stored.Status = t.EffectiveStatus()
+			stored.Date, stored.Timestamp = t.Date.UTC(), t.Timestamp.UTC()
+			if !t.DueDate.IsZero() {
+				stored.DueDate = t.DueDate.UTC()
+			}
+			if err := put(tx, boltTransactions, t.ID, &stored); err != nil {
+				return fmt.Errorf("unable to save transaction %s: %w", t.ID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Transactions returns every stored transaction ordered by date
+func (s *BoltStore) Transactions(ctx context.Context) ([]*models.Transaction, error) {
+	var transactions []*models.Transaction
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return each(tx, boltTransactions, func(id string, value []byte) error {
+			var t models.Transaction
+			if err := json.Unmarshal(value, &t); err != nil {
+				return fmt.Errorf("transaction %s is invalid: %w", id, err)
+			}
+			transactions = append(transactions, &t)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(transactions, func(i, j int) bool { return transactions[i].Date.Before(transactions[j].Date) })
+	return transactions, nil
+}
+
+// MarkProcessed records message IDs that don't need to be fetched again
+func (s *BoltStore) MarkProcessed(ctx context.Context, messageIDs []string) error {
+	now := []byte(formatTime(time.Now()))
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, id := range messageIDs {
+			if err := tx.Bucket(boltProcessed).Put([]byte(id), now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ProcessedIDs returns the set of message IDs already processed
+func (s *BoltStore) ProcessedIDs(ctx context.Context) (map[string]bool, error) {
+	ids := make(map[string]bool)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return each(tx, boltProcessed, func(id string, _ []byte) error {
+			ids[id] = true
+			return nil
+		})
+	})
+	return ids, err
+}
+
+// SyncState returns the value stored under key, or "" if unset
+func (s *BoltStore) SyncState(ctx context.Context, key string) (string, error) {
+	var value string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket(boltSyncState); b != nil {
+			value = string(b.Get([]byte(key)))
+		}
+		return nil
+	})
+	return value, err
+}
+
+// SetSyncState stores value under key
+func (s *BoltStore) SetSyncState(ctx context.Context, key, value string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltSyncState).Put([]byte(key), []byte(value))
+	})
+}
+
+// Budgets returns the saved budgets ordered by category
+func (s *BoltStore) Budgets(ctx context.Context) ([]models.Budget, error) {
+	var budgets []models.Budget
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return each(tx, boltBudgets, func(category string, value []byte) error {
+			var b models.Budget
+			if err := json.Unmarshal(value, &b); err != nil {
+				return fmt.Errorf("budget %s is invalid: %w", category, err)
+			}
+			budgets = append(budgets, b)
+			return nil
+		})
+	})
+	return budgets, err
+}
+
+// SetBudget inserts or replaces the budget of a category, matched ignoring
+// case
+func (s *BoltStore) SetBudget(ctx context.Context, budget models.Budget) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return put(tx, boltBudgets, strings.ToLower(budget.Category), budget)
+	})
+}
+
+// DeleteBudget removes the budget of a category, matched ignoring case
+func (s *BoltStore) DeleteBudget(ctx context.Context, category string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBudgets).Delete([]byte(strings.ToLower(category)))
+	})
+}
+
+// ClosedMonths returns the closed months, oldest first
+func (s *BoltStore) ClosedMonths(ctx context.Context) ([]models.MonthClose, error) {
+	var closed []models.MonthClose
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return each(tx, boltClosedMonths, func(month string, value []byte) error {
+			var c models.MonthClose
+			if err := json.Unmarshal(value, &c); err != nil {
+				return fmt.Errorf("closed month %s has an invalid snapshot: %w", month, err)
+			}
+			closed = append(closed, c)
+			return nil
+		})
+	})
+	return closed, err
+}
+
+// CloseMonth saves the snapshot of a closed month
+func (s *BoltStore) CloseMonth(ctx context.Context, closed models.MonthClose) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return put(tx, boltClosedMonths, closed.Month, closed)
+	})
+}
+
+// ReopenMonth drops the snapshot of a closed month
+func (s *BoltStore) ReopenMonth(ctx context.Context, month string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltClosedMonths).Delete([]byte(month))
+	})
+}
+
+// SaveReport saves the inputs of a report
+func (s *BoltStore) SaveReport(ctx context.Context, report models.SavedReport) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return put(tx, boltReports, report.ID, report)
+	})
+}
+
+// Report returns the saved report with the given ID, or nil
+func (s *BoltStore) Report(ctx context.Context, id string) (*models.SavedReport, error) {
+	var report *models.SavedReport
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltReports)
+		if b == nil || b.Get([]byte(id)) == nil {
+			return nil
+		}
+		report = &models.SavedReport{}
+		if err := json.Unmarshal(b.Get([]byte(id)), report); err != nil {
+			return fmt.Errorf("report %s has invalid inputs: %w", id, err)
+		}
+		return nil
+	})
+	return report, err
+}
+
+// Close closes the file
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,168 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// migration upgrades the schema by one version
+type migration struct {
+	version     int
+	description string
+	up          func(ctx context.Context, tx *sql.Tx) error
+}
+
+// migrations upgrade a database to SchemaVersion, in order. Databases from
+// before schema versioning are at version 0, with or without the columns
+// added since, so every step must be safe to run on them. Append new steps;
+// never change released ones.
+var migrations = []migration{
+	{1, "create tables", func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, sqliteSchema)
+		return err
+	}},
+	{2, "add due dates", func(ctx context.Context, tx *sql.Tx) error {
+		return ensureColumn(ctx, tx, "transactions", "due_date", "TEXT NOT NULL DEFAULT ''")
+	}},
+	{3, "add accounts", func(ctx context.Context, tx *sql.Tx) error {
+		return ensureColumn(ctx, tx, "transactions", "account", "TEXT NOT NULL DEFAULT ''")
+	}},
+	{4, "add extraction confidence", func(ctx context.Context, tx *sql.Tx) error {
+		return ensureColumn(ctx, tx, "transactions", "confidence", "REAL NOT NULL DEFAULT 0")
+	}},
+	{5, "add budgets", func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS budgets (
+	category TEXT PRIMARY KEY COLLATE NOCASE,
+	amount   REAL NOT NULL,
+	currency TEXT NOT NULL DEFAULT ''
+)`)
+		return err
+	}},
+	{6, "add orders", func(ctx context.Context, tx *sql.Tx) error {
+		if err := ensureColumn(ctx, tx, "transactions", "order_id", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		return ensureColumn(ctx, tx, "transactions", "partial", "INTEGER NOT NULL DEFAULT 0")
+	}},
+	{7, "add transaction statuses", func(ctx context.Context, tx *sql.Tx) error {
+		return ensureColumn(ctx, tx, "transactions", "status", "TEXT NOT NULL DEFAULT 'settled'")
+	}},
+	{8, "add closed months", func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS closed_months (
+	month     TEXT PRIMARY KEY,
+	closed_at TEXT NOT NULL,
+	snapshot  TEXT NOT NULL
+)`)
+		return err
+	}},
+	{9, "add saved reports", func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS reports (
+	id         TEXT PRIMARY KEY,
+	created_at TEXT NOT NULL,
+	inputs     TEXT NOT NULL
+)`)
+		return err
+	}},
+	{10, "add invoice numbers and taxes", func(ctx context.Context, tx *sql.Tx) error {
+		if err := ensureColumn(ctx, tx, "transactions", "invoice_number", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		return ensureColumn(ctx, tx, "transactions", "tax", "REAL NOT NULL DEFAULT 0")
+	}},
+}
+
+// SchemaVersion is the schema version this version of gm reads and writes
+var SchemaVersion = migrations[len(migrations)-1].version
+
+// dbConn is satisfied by both *sql.DB and *sql.Tx
+type dbConn interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// schemaVersion returns the version recorded in the database
+func schemaVersion(ctx context.Context, db dbConn) (int, error) {
+	rows, err := db.QueryContext(ctx, "PRAGMA user_version")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var version int
+	if rows.Next() {
+		if err := rows.Scan(&version); err != nil {
+			return 0, err
+		}
+	}
+	return version, rows.Err()
+}
+
+// migrate upgrades the database at path to SchemaVersion, one transaction
+// per step. Existing data is first copied next to the database, so a failed
+// or unwanted upgrade can be undone by restoring the copy. Databases from a
+// newer version of gm are refused rather than modified.
+func migrate(ctx context.Context, db *sql.DB, path string) error {
+	version, err := schemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	if version > SchemaVersion {
+		return fmt.Errorf("schema v%d is newer than the v%d this version of gm supports; upgrade gm", version, SchemaVersion)
+	}
+	if version == SchemaVersion {
+		return nil
+	}
+
+	hasData, err := hasTables(ctx, db)
+	if err != nil {
+		return err
+	}
+	if hasData && path != ":memory:" {
+		backup := fmt.Sprintf("%s.v%d-%s.bak", path, version, time.Now().Format("20060102-150405"))
+		if _, err := db.ExecContext(ctx, "VACUUM INTO ?", backup); err != nil {
+			return fmt.Errorf("unable to back up before migrating: %w", err)
+		}
+		log.Printf("Upgrading store %s from schema v%d to v%d; the previous version is saved as %s", path, version, SchemaVersion, backup)
+	}
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		if err := runMigration(ctx, db, m); err != nil {
+			return fmt.Errorf("migration to v%d (%s) failed: %w", m.version, m.description, err)
+		}
+	}
+	return nil
+}
+
+// runMigration applies one step and records its version atomically
+func runMigration(ctx context.Context, db *sql.DB, m migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.up(ctx, tx); err != nil {
+		return err
+	}
+	// PRAGMA doesn't accept bound parameters
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", m.version)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// hasTables reports whether the database has any tables yet
+func hasTables(ctx context.Context, db dbConn) (bool, error) {
+	rows, err := db.QueryContext(ctx, "SELECT name FROM sqlite_master WHERE type = 'table' LIMIT 1")
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	return rows.Next(), rows.Err()
+}
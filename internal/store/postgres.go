@@ -0,0 +1,510 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// postgresSchema creates the tables of a PostgreSQL store, with the same
+// columns as sqliteSchema. Adding a column here also needs a step in
+// postgresMigrations.
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS transactions (
+	id              TEXT PRIMARY KEY,
+	thread_id       TEXT NOT NULL DEFAULT '',
+	service_id      TEXT NOT NULL,
+	service_name    TEXT NOT NULL,
+	category        TEXT NOT NULL,
+	amount          DOUBLE PRECISION NOT NULL,
+	currency        TEXT NOT NULL,
+	currency_symbol TEXT NOT NULL,
+	date            TEXT NOT NULL,
+	description     TEXT NOT NULL DEFAULT '',
+	email           TEXT NOT NULL DEFAULT '',
+	subject         TEXT NOT NULL DEFAULT '',
+	timestamp       TEXT NOT NULL,
+	raw_amount      TEXT NOT NULL DEFAULT '',
+	related_ids     TEXT NOT NULL DEFAULT '[]',
+	due_date        TEXT NOT NULL DEFAULT '',
+	account         TEXT NOT NULL DEFAULT '',
+	confidence      DOUBLE PRECISION NOT NULL DEFAULT 0,
+	order_id        TEXT NOT NULL DEFAULT '',
+	partial         BOOLEAN NOT NULL DEFAULT FALSE,
+	status          TEXT NOT NULL DEFAULT 'settled',
+	invoice_number  TEXT NOT NULL DEFAULT '',
+	tax             DOUBLE PRECISION NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS transactions_date ON transactions (date);
+CREATE INDEX IF NOT EXISTS transactions_thread ON transactions (thread_id);
+
+CREATE TABLE IF NOT EXISTS processed_messages (
+	id           TEXT PRIMARY KEY,
+	processed_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS sync_state (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS budgets (
+	category TEXT PRIMARY KEY,
+	amount   DOUBLE PRECISION NOT NULL,
+	currency TEXT NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS budgets_category ON budgets (lower(category));
+
+CREATE TABLE IF NOT EXISTS closed_months (
+	month     TEXT PRIMARY KEY,
+	closed_at TEXT NOT NULL,
+	snapshot  TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS reports (
+	id         TEXT PRIMARY KEY,
+	created_at TEXT NOT NULL,
+	inputs     TEXT NOT NULL
+);
+`
+
+// postgresMigrations upgrade a PostgreSQL store to PostgresSchemaVersion,
+// like migrations do for SQLite. PostgreSQL stores started at the schema
+// of SQLite v10, so the versions of the two don't match. Append new steps;
+// never change released ones.
+var postgresMigrations = []migration{
+	{1, "create tables", func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, postgresSchema)
+		return err
+	}},
+}
+
+// PostgresSchemaVersion is the PostgreSQL schema version this version of
+// gm reads and writes
+var PostgresSchemaVersion = postgresMigrations[len(postgresMigrations)-1].version
+
+var _ Store = (*PostgresStore)(nil)
+
+// PostgresStore is a Store backed by a PostgreSQL database, which several
+// gm instances can share
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// IsPostgres reports whether a store location is a PostgreSQL connection
+// URL rather than the path of a SQLite database
+func IsPostgres(location string) bool {
+	return strings.HasPrefix(location, "postgres://") || strings.HasPrefix(location, "postgresql://")
+}
+
+// Redacted returns a store location safe to show, without the password of
+// a connection URL
+func Redacted(location string) string {
+	if !IsPostgres(location) {
+		return location
+	}
+	u, err := url.Parse(location)
+	if err != nil {
+		return "PostgreSQL store"
+	}
+	return u.Redacted()
+}
+
+// OpenPostgres connects to the PostgreSQL database at a postgres:// URL,
+// creating the tables or migrating them as needed
+func OpenPostgres(location string) (*PostgresStore, error) {
+	db, err := openPostgresDB(location)
+	if err != nil {
+		return nil, err
+	}
+	if err := migratePostgres(context.Background(), db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("unable to upgrade store %s: %w", Redacted(location), err)
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+// openPostgresDB connects to the database at location without changing it
+func openPostgresDB(location string) (*sql.DB, error) {
+	db, err := sql.Open("pgx", location)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open store %s: %w", Redacted(location), err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("unable to open store %s: %w", Redacted(location), err)
+	}
+	return db, nil
+}
+
+// migratePostgres upgrades the database to PostgresSchemaVersion in a
+// single transaction, since PostgreSQL can roll back schema changes. The
+// version table stays locked meanwhile, so gm instances starting together
+// don't migrate twice. Databases from a newer version of gm are refused.
+func migratePostgres(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL)`); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE schema_version IN ACCESS EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+	version, err := postgresVersion(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if version > PostgresSchemaVersion {
+		return fmt.Errorf("schema v%d is newer than the v%d this version of gm supports; upgrade gm", version, PostgresSchemaVersion)
+	}
+	if version == PostgresSchemaVersion {
+		return nil
+	}
+
+	for _, m := range postgresMigrations {
+		if m.version <= version {
+			continue
+		}
+		if err := m.up(ctx, tx); err != nil {
+			return fmt.Errorf("migration to v%d (%s) failed: %w", m.version, m.description, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_version`); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_version (version) VALUES ($1)`, PostgresSchemaVersion); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// postgresVersion returns the version recorded in the database; one
+// without the version table is at version 0
+func postgresVersion(ctx context.Context, db dbConn) (int, error) {
+	columns, err := postgresColumns(ctx, db, "schema_version")
+	if err != nil || len(columns) == 0 {
+		return 0, err
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT version FROM schema_version`)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var version int
+	if rows.Next() {
+		if err := rows.Scan(&version); err != nil {
+			return 0, err
+		}
+	}
+	return version, rows.Err()
+}
+
+// postgresColumns returns the column names of table; a missing table has
+// none
+func postgresColumns(ctx context.Context, db dbConn, table string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT column_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1
+		ORDER BY ordinal_position`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns = append(columns, name)
+	}
+	return columns, rows.Err()
+}
+
+// upsertQuery returns an INSERT of columns into table that replaces the row
+// with the same key
+func upsertQuery(table, key string, columns []string) string {
+	placeholders := make([]string, len(columns))
+	var updates []string
+	for i, column := range columns {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		if column != key {
+			updates = append(updates, column+" = EXCLUDED."+column)
+		}
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO UPDATE SET %s",
+		table, strings.Join(columns, ", "), strings.Join(placeholders, ", "), key, strings.Join(updates, ", "))
+}
+
+// SaveTransactions inserts or replaces transactions by ID
+func (s *PostgresStore) SaveTransactions(ctx context.Context, transactions []*models.Transaction) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, upsertQuery("transactions", "id", schemaColumns["transactions"]))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, t := range transactions {
+		related, err := json.Marshal(t.RelatedIDs)
+		if err != nil {
+			return err
+		}
+
+		_, err = stmt.ExecContext(ctx,
+			t.ID, t.ThreadID, t.ServiceID, t.ServiceName, t.Category, t.Amount, t.Currency,
+			t.CurrencySymbol, formatTime(t.Date), t.Description, t.Email, t.Subject,
+			formatTime(t.Timestamp), t.RawAmount, string(related), formatOptionalTime(t.DueDate), t.Account,
+			t.Confidence, t.OrderID, t.Partial, t.EffectiveStatus(), t.InvoiceNumber, t.Tax,
+		)
+		if err != nil {
+			return fmt.Errorf("unable to save transaction %s: %w", t.ID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Transactions returns every stored transaction ordered by date
+func (s *PostgresStore) Transactions(ctx context.Context) ([]*models.Transaction, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, thread_id, service_id, service_name, category, amount, currency,
+			currency_symbol, date, description, email, subject, timestamp,
+			raw_amount, related_ids, due_date, account, confidence, order_id, partial, status,
+			invoice_number, tax
+		FROM transactions
+		ORDER BY date`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []*models.Transaction
+	for rows.Next() {
+		var t models.Transaction
+		var date, timestamp, related, dueDate string
+
+		err := rows.Scan(
+			&t.ID, &t.ThreadID, &t.ServiceID, &t.ServiceName, &t.Category, &t.Amount, &t.Currency,
+			&t.CurrencySymbol, &date, &t.Description, &t.Email, &t.Subject, &timestamp,
+			&t.RawAmount, &related, &dueDate, &t.Account, &t.Confidence, &t.OrderID, &t.Partial, &t.Status,
+			&t.InvoiceNumber, &t.Tax,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if t.Date, err = parseTime(date); err != nil {
+			return nil, fmt.Errorf("transaction %s has an invalid date: %w", t.ID, err)
+		}
+		if t.Timestamp, err = parseTime(timestamp); err != nil {
+			return nil, fmt.Errorf("transaction %s has an invalid timestamp: %w", t.ID, err)
+		}
+		if dueDate != "" {
+			if t.DueDate, err = parseTime(dueDate); err != nil {
+				return nil, fmt.Errorf("transaction %s has an invalid due date: %w", t.ID, err)
+			}
+		}
+		if err := json.Unmarshal([]byte(related), &t.RelatedIDs); err != nil {
+			return nil, fmt.Errorf("transaction %s has invalid related IDs: %w", t.ID, err)
+		}
+
+		transactions = append(transactions, &t)
+	}
+
+	return transactions, rows.Err()
+}
+
+// MarkProcessed records message IDs that don't need to be fetched again
+func (s *PostgresStore) MarkProcessed(ctx context.Context, messageIDs []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := formatTime(time.Now())
+	query := upsertQuery("processed_messages", "id", schemaColumns["processed_messages"])
+	for _, id := range messageIDs {
+		if _, err := tx.ExecContext(ctx, query, id, now); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ProcessedIDs returns the set of message IDs already processed
+func (s *PostgresStore) ProcessedIDs(ctx context.Context) (map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM processed_messages`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+
+	return ids, rows.Err()
+}
+
+// SyncState returns the value stored under key, or "" if unset
+func (s *PostgresStore) SyncState(ctx context.Context, key string) (string, error) {
+	var value string
+	err := s.db.QueryRowContext(ctx, `SELECT value FROM sync_state WHERE key = $1`, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return value, err
+}
+
+// SetSyncState stores value under key
+func (s *PostgresStore) SetSyncState(ctx context.Context, key, value string) error {
+	_, err := s.db.ExecContext(ctx, upsertQuery("sync_state", "key", schemaColumns["sync_state"]), key, value)
+	return err
+}
+
+// Budgets returns the saved budgets ordered by category
+func (s *PostgresStore) Budgets(ctx context.Context) ([]models.Budget, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT category, amount, currency FROM budgets ORDER BY lower(category)`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var budgets []models.Budget
+	for rows.Next() {
+		var b models.Budget
+		if err := rows.Scan(&b.Category, &b.Amount, &b.Currency); err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, b)
+	}
+	return budgets, rows.Err()
+}
+
+// SetBudget inserts or replaces the budget of a category, matched ignoring
+// case like SQLite stores do
+func (s *PostgresStore) SetBudget(ctx context.Context, budget models.Budget) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM budgets WHERE lower(category) = lower($1)`, budget.Category); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO budgets (category, amount, currency) VALUES ($1, $2, $3)`,
+		budget.Category, budget.Amount, budget.Currency); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteBudget removes the budget of a category
+func (s *PostgresStore) DeleteBudget(ctx context.Context, category string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM budgets WHERE lower(category) = lower($1)`, category)
+	return err
+}
+
+// ClosedMonths returns the closed months, oldest first
+func (s *PostgresStore) ClosedMonths(ctx context.Context) ([]models.MonthClose, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT month, snapshot FROM closed_months ORDER BY month`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var closed []models.MonthClose
+	for rows.Next() {
+		var month, snapshot string
+		if err := rows.Scan(&month, &snapshot); err != nil {
+			return nil, err
+		}
+		var c models.MonthClose
+		if err := json.Unmarshal([]byte(snapshot), &c); err != nil {
+			return nil, fmt.Errorf("closed month %s has an invalid snapshot: %w", month, err)
+		}
+		closed = append(closed, c)
+	}
+	return closed, rows.Err()
+}
+
+// CloseMonth saves the snapshot of a closed month
+func (s *PostgresStore) CloseMonth(ctx context.Context, closed models.MonthClose) error {
+	snapshot, err := json.Marshal(closed)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, upsertQuery("closed_months", "month", schemaColumns["closed_months"]),
+		closed.Month, formatTime(closed.ClosedAt), string(snapshot))
+	return err
+}
+
+// ReopenMonth drops the snapshot of a closed month
+func (s *PostgresStore) ReopenMonth(ctx context.Context, month string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM closed_months WHERE month = $1`, month)
+	return err
+}
+
+// SaveReport saves the inputs of a report
+func (s *PostgresStore) SaveReport(ctx context.Context, report models.SavedReport) error {
+	inputs, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, upsertQuery("reports", "id", schemaColumns["reports"]),
+		report.ID, formatTime(report.CreatedAt), string(inputs))
+	return err
+}
+
+// Report returns the saved report with the given ID, or nil
+func (s *PostgresStore) Report(ctx context.Context, id string) (*models.SavedReport, error) {
+	var inputs string
+	err := s.db.QueryRowContext(ctx, `SELECT inputs FROM reports WHERE id = $1`, id).Scan(&inputs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var report models.SavedReport
+	if err := json.Unmarshal([]byte(inputs), &report); err != nil {
+		return nil, fmt.Errorf("report %s has invalid inputs: %w", id, err)
+	}
+	return &report, nil
+}
+
+// Close closes the connections to the database
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
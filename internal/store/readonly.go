@@ -0,0 +1,320 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+var _ Store = (*readOnlyStore)(nil)
+
+// readOnlyStore reads from an underlying store but keeps every change in
+// memory, so a run behaves as usual without modifying the database. Without
+// an underlying store it is a plain in-memory store.
+type readOnlyStore struct {
+	base         Store // nil when there is no database yet
+	mu           sync.Mutex
+	transactions map[string]*models.Transaction
+	processed    map[string]bool
+	state        map[string]string
+	budgets      map[string]*models.Budget     // By lower-case category; nil when deleted
+	closed       map[string]*models.MonthClose // By month; nil when reopened
+	reports      map[string]*models.SavedReport
+}
+
+// OpenReadOnly opens the SQLite database or bolt file at path, or the
+// PostgreSQL database at a postgres:// URL, without ever writing to it; a missing or empty
+// database behaves as an empty one and isn't created. Changes made through
+// the returned store last until it is closed.
+func OpenReadOnly(path string) (Store, error) {
+	st := newReadOnlyStore()
+
+	if IsPostgres(path) {
+		if err := st.openPostgres(path); err != nil {
+			return nil, err
+		}
+		return st, nil
+	}
+	if IsBolt(path) {
+		base, err := openBoltReadOnly(path)
+		if err != nil {
+			return nil, err
+		}
+		if base != nil {
+			st.base = base
+		}
+		return st, nil
+	}
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return st, nil
+	}
+
+	if err := requireSQLite(); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("unable to open store %s: %w", path, err)
+	}
+	db.SetMaxOpenConns(1)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("unable to open store %s: %w", path, err)
+	}
+
+	st.base = &SQLiteStore{db: db}
+	return st, nil
+}
+
+// OpenMemory returns an empty store kept in memory only, for runs that
+// shouldn't read or write anything on disk and for tests. Everything saved
+// is lost when it is closed.
+func OpenMemory() Store {
+	return newReadOnlyStore()
+}
+
+// newReadOnlyStore returns a store with no changes and no underlying store
+func newReadOnlyStore() *readOnlyStore {
+	return &readOnlyStore{
+		transactions: make(map[string]*models.Transaction),
+		processed:    make(map[string]bool),
+		state:        make(map[string]string),
+		budgets:      make(map[string]*models.Budget),
+		closed:       make(map[string]*models.MonthClose),
+		reports:      make(map[string]*models.SavedReport),
+	}
+}
+
+// openPostgres reads from the PostgreSQL database at location. Unlike
+// SQLite stores, which are read at any version, it must be migrated first.
+func (s *readOnlyStore) openPostgres(location string) error {
+	db, err := openPostgresDB(location)
+	if err != nil {
+		return err
+	}
+	version, err := postgresVersion(context.Background(), db)
+	switch {
+	case err != nil:
+		err = fmt.Errorf("unable to read the schema version of %s: %w", Redacted(location), err)
+	case version == 0:
+		// Nothing was stored yet
+	case version < PostgresSchemaVersion:
+		err = fmt.Errorf("store %s is at schema v%d; run gm once without --read-only to migrate it to v%d", Redacted(location), version, PostgresSchemaVersion)
+	case version > PostgresSchemaVersion:
+		err = fmt.Errorf("store %s is at schema v%d, newer than the v%d this version of gm supports; upgrade gm", Redacted(location), version, PostgresSchemaVersion)
+	default:
+		s.base = &PostgresStore{db: db}
+		return nil
+	}
+	db.Close()
+	return err
+}
+
+// SaveTransactions keeps transactions in memory
+func (s *readOnlyStore) SaveTransactions(ctx context.Context, transactions []*models.Transaction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tx := range transactions {
+		s.transactions[tx.ID] = tx
+	}
+	return nil
+}
+
+// Transactions returns the stored transactions with the in-memory changes
+// applied, ordered by date
+func (s *readOnlyStore) Transactions(ctx context.Context) ([]*models.Transaction, error) {
+	var stored []*models.Transaction
+	if s.base != nil {
+		var err error
+		if stored, err = s.base.Transactions(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transactions := make([]*models.Transaction, 0, len(stored)+len(s.transactions))
+	for _, tx := range stored {
+		if _, changed := s.transactions[tx.ID]; !changed {
+			transactions = append(transactions, tx)
+		}
+	}
+	for _, tx := range s.transactions {
+		transactions = append(transactions, tx)
+	}
+	sort.SliceStable(transactions, func(i, j int) bool { return transactions[i].Date.Before(transactions[j].Date) })
+	return transactions, nil
+}
+
+// MarkProcessed records message IDs in memory
+func (s *readOnlyStore) MarkProcessed(ctx context.Context, messageIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range messageIDs {
+		s.processed[id] = true
+	}
+	return nil
+}
+
+// ProcessedIDs returns the stored and in-memory processed message IDs
+func (s *readOnlyStore) ProcessedIDs(ctx context.Context) (map[string]bool, error) {
+	processed := make(map[string]bool)
+	if s.base != nil {
+		var err error
+		if processed, err = s.base.ProcessedIDs(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id := range s.processed {
+		processed[id] = true
+	}
+	return processed, nil
+}
+
+// SyncState returns the in-memory value of key, or the stored one
+func (s *readOnlyStore) SyncState(ctx context.Context, key string) (string, error) {
+	s.mu.Lock()
+	value, ok := s.state[key]
+	s.mu.Unlock()
+	if ok || s.base == nil {
+		return value, nil
+	}
+	return s.base.SyncState(ctx, key)
+}
+
+// SetSyncState keeps value in memory
+func (s *readOnlyStore) SetSyncState(ctx context.Context, key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state[key] = value
+	return nil
+}
+
+// Budgets returns the stored budgets with the in-memory changes applied,
+// ordered by category
+func (s *readOnlyStore) Budgets(ctx context.Context) ([]models.Budget, error) {
+	var stored []models.Budget
+	if s.base != nil {
+		var err error
+		if stored, err = s.base.Budgets(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var budgets []models.Budget
+	for _, b := range stored {
+		if _, changed := s.budgets[strings.ToLower(b.Category)]; !changed {
+			budgets = append(budgets, b)
+		}
+	}
+	for _, b := range s.budgets {
+		if b != nil {
+			budgets = append(budgets, *b)
+		}
+	}
+	sort.Slice(budgets, func(i, j int) bool { return budgets[i].Category < budgets[j].Category })
+	return budgets, nil
+}
+
+// SetBudget keeps the budget in memory
+func (s *readOnlyStore) SetBudget(ctx context.Context, budget models.Budget) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.budgets[strings.ToLower(budget.Category)] = &budget
+	return nil
+}
+
+// DeleteBudget removes the budget in memory
+func (s *readOnlyStore) DeleteBudget(ctx context.Context, category string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.budgets[strings.ToLower(category)] = nil
+	return nil
+}
+
+// ClosedMonths returns the stored closed months with the in-memory changes
+// applied, oldest first
+func (s *readOnlyStore) ClosedMonths(ctx context.Context) ([]models.MonthClose, error) {
+	var stored []models.MonthClose
+	if s.base != nil {
+		var err error
+		if stored, err = s.base.ClosedMonths(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var closed []models.MonthClose
+	for _, c := range stored {
+		if _, changed := s.closed[c.Month]; !changed {
+			closed = append(closed, c)
+		}
+	}
+	for _, c := range s.closed {
+		if c != nil {
+			closed = append(closed, *c)
+		}
+	}
+	sort.Slice(closed, func(i, j int) bool { return closed[i].Month < closed[j].Month })
+	return closed, nil
+}
+
+// CloseMonth keeps the snapshot in memory
+func (s *readOnlyStore) CloseMonth(ctx context.Context, closed models.MonthClose) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed[closed.Month] = &closed
+	return nil
+}
+
+// ReopenMonth drops the snapshot in memory
+func (s *readOnlyStore) ReopenMonth(ctx context.Context, month string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed[month] = nil
+	return nil
+}
+
+// SaveReport keeps the report in memory
+func (s *readOnlyStore) SaveReport(ctx context.Context, report models.SavedReport) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reports[report.ID] = &report
+	return nil
+}
+
+// Report returns the in-memory report with the given ID, or the stored one
+func (s *readOnlyStore) Report(ctx context.Context, id string) (*models.SavedReport, error) {
+	s.mu.Lock()
+	report, ok := s.reports[id]
+	s.mu.Unlock()
+	if ok || s.base == nil {
+		return report, nil
+	}
+	return s.base.Report(ctx, id)
+}
+
+// Close releases the database and drops the in-memory changes
+func (s *readOnlyStore) Close() error {
+	if s.base == nil {
+		return nil
+	}
+	return s.base.Close()
+}
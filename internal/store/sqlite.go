@@ -0,0 +1,557 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// sqliteSchema creates the tables at SchemaVersion. Adding a column here
+// also needs a migration for existing databases, and the same column in
+// postgresSchema.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS transactions (
+	id              TEXT PRIMARY KEY,
+	thread_id       TEXT NOT NULL DEFAULT '',
+	service_id      TEXT NOT NULL,
+	service_name    TEXT NOT NULL,
+	category        TEXT NOT NULL,
+	amount          REAL NOT NULL,
+	currency        TEXT NOT NULL,
+	currency_symbol TEXT NOT NULL,
+	date            TEXT NOT NULL,
+	description     TEXT NOT NULL DEFAULT '',
+	email           TEXT NOT NULL DEFAULT '',
+	subject         TEXT NOT NULL DEFAULT '',
+	timestamp       TEXT NOT NULL,
+	raw_amount      TEXT NOT NULL DEFAULT '',
+	related_ids     TEXT NOT NULL DEFAULT '[]',
+	due_date        TEXT NOT NULL DEFAULT '',
+	account         TEXT NOT NULL DEFAULT '',
+	confidence      REAL NOT NULL DEFAULT 0,
+	order_id        TEXT NOT NULL DEFAULT '',
+	partial         INTEGER NOT NULL DEFAULT 0,
+	status          TEXT NOT NULL DEFAULT 'settled',
+	invoice_number  TEXT NOT NULL DEFAULT '',
+	tax             REAL NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS transactions_date ON transactions (date);
+CREATE INDEX IF NOT EXISTS transactions_thread ON transactions (thread_id);
+
+CREATE TABLE IF NOT EXISTS processed_messages (
+	id           TEXT PRIMARY KEY,
+	processed_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS sync_state (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS budgets (
+	category TEXT PRIMARY KEY COLLATE NOCASE,
+	amount   REAL NOT NULL,
+	currency TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS closed_months (
+	month     TEXT PRIMARY KEY,
+	closed_at TEXT NOT NULL,
+	snapshot  TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS reports (
+	id         TEXT PRIMARY KEY,
+	created_at TEXT NOT NULL,
+	inputs     TEXT NOT NULL
+);
+`
+
+var _ Store = (*SQLiteStore)(nil)
+
+// SQLiteStore is a Store backed by a SQLite database file
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens (creating if needed) the SQLite database at path,
+// migrating databases written by older versions
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	if err := requireSQLite(); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open store %s: %w", path, err)
+	}
+
+	// SQLite allows a single writer; serialize access through one connection
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(context.Background(), db, path); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("unable to upgrade store %s: %w", path, err)
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// SaveTransactions inserts or replaces transactions by ID
+func (s *SQLiteStore) SaveTransactions(ctx context.Context, transactions []*models.Transaction) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT OR REPLACE INTO transactions (
+			id, thread_id, service_id, service_name, category, amount, currency,
+			currency_symbol, date, description, email, subject, timestamp,
+			raw_amount, related_ids, due_date, account, confidence, order_id, partial, status,
+			invoice_number, tax
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, t := range transactions {
+		related, err := json.Marshal(t.RelatedIDs)
+		if err != nil {
+			return err
+		}
+
+		_, err = stmt.ExecContext(ctx,
+			t.ID, t.ThreadID, t.ServiceID, t.ServiceName, t.Category, t.Amount, t.Currency,
+			t.CurrencySymbol, formatTime(t.Date), t.Description, t.Email, t.Subject,
+			formatTime(t.Timestamp), t.RawAmount, string(related), formatOptionalTime(t.DueDate), t.Account,
+			t.Confidence, t.OrderID, t.Partial, t.EffectiveStatus(), t.InvoiceNumber, t.Tax,
+		)
+		if err != nil {
+			return fmt.Errorf("unable to save transaction %s: %w", t.ID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Transactions returns every stored transaction ordered by date
+func (s *SQLiteStore) Transactions(ctx context.Context) ([]*models.Transaction, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, thread_id, service_id, service_name, category, amount, currency,
+			currency_symbol, date, description, email, subject, timestamp,
+			raw_amount, related_ids, due_date, account, confidence, order_id, partial, status,
+			invoice_number, tax
+		FROM transactions
+		ORDER BY date`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []*models.Transaction
+	for rows.Next() {
+		var t models.Transaction
+		var date, timestamp, related, dueDate string
+
+		err := rows.Scan(
+			&t.ID, &t.ThreadID, &t.ServiceID, &t.ServiceName, &t.Category, &t.Amount, &t.Currency,
+			&t.CurrencySymbol, &date, &t.Description, &t.Email, &t.Subject, &timestamp,
+			&t.RawAmount, &related, &dueDate, &t.Account, &t.Confidence, &t.OrderID, &t.Partial, &t.Status,
+			&t.InvoiceNumber, &t.Tax,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if t.Date, err = parseTime(date); err != nil {
+			return nil, fmt.Errorf("transaction %s has an invalid date: %w", t.ID, err)
+		}
+		if t.Timestamp, err = parseTime(timestamp); err != nil {
+			return nil, fmt.Errorf("transaction %s has an invalid timestamp: %w", t.ID, err)
+		}
+		if dueDate != "" {
+			if t.DueDate, err = parseTime(dueDate); err != nil {
+				return nil, fmt.Errorf("transaction %s has an invalid due date: %w", t.ID, err)
+			}
+		}
+		if err := json.Unmarshal([]byte(related), &t.RelatedIDs); err != nil {
+			return nil, fmt.Errorf("transaction %s has invalid related IDs: %w", t.ID, err)
+		}
+
+		transactions = append(transactions, &t)
+	}
+
+	return transactions, rows.Err()
+}
+
+// MarkProcessed records message IDs that don't need to be fetched again
+func (s *SQLiteStore) MarkProcessed(ctx context.Context, messageIDs []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := formatTime(time.Now())
+	for _, id := range messageIDs {
+		if _, err := tx.ExecContext(ctx, `INSERT OR REPLACE INTO processed_messages (id, processed_at) VALUES (?, ?)`, id, now); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ProcessedIDs returns the set of message IDs already processed
+func (s *SQLiteStore) ProcessedIDs(ctx context.Context) (map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM processed_messages`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+
+	return ids, rows.Err()
+}
+
+// SyncState returns the value stored under key, or "" if unset
+func (s *SQLiteStore) SyncState(ctx context.Context, key string) (string, error) {
+	var value string
+	err := s.db.QueryRowContext(ctx, `SELECT value FROM sync_state WHERE key = ?`, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return value, err
+}
+
+// SetSyncState stores value under key
+func (s *SQLiteStore) SetSyncState(ctx context.Context, key, value string) error {
+	_, err := s.db.ExecContext(ctx, `INSERT OR REPLACE INTO sync_state (key, value) VALUES (?, ?)`, key, value)
+	return err
+}
+
+// Budgets returns the saved budgets ordered by category. A database opened
+// read-only before budgets were added has none.
+func (s *SQLiteStore) Budgets(ctx context.Context) ([]models.Budget, error) {
+	columns, err := tableColumns(ctx, s.db, "budgets")
+	if err != nil || len(columns) == 0 {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT category, amount, currency FROM budgets ORDER BY category`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var budgets []models.Budget
+	for rows.Next() {
+		var b models.Budget
+		if err := rows.Scan(&b.Category, &b.Amount, &b.Currency); err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, b)
+	}
+	return budgets, rows.Err()
+}
+
+// SetBudget inserts or replaces the budget of a category
+func (s *SQLiteStore) SetBudget(ctx context.Context, budget models.Budget) error {
+	_, err := s.db.ExecContext(ctx, `INSERT OR REPLACE INTO budgets (category, amount, currency) VALUES (?, ?, ?)`,
+		budget.Category, budget.Amount, budget.Currency)
+	return err
+}
+
+// DeleteBudget removes the budget of a category
+func (s *SQLiteStore) DeleteBudget(ctx context.Context, category string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM budgets WHERE category = ?`, category)
+	return err
+}
+
+// ClosedMonths returns the closed months, oldest first. A database opened
+// read-only before months could be closed has none.
+func (s *SQLiteStore) ClosedMonths(ctx context.Context) ([]models.MonthClose, error) {
+	columns, err := tableColumns(ctx, s.db, "closed_months")
+	if err != nil || len(columns) == 0 {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT month, snapshot FROM closed_months ORDER BY month`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var closed []models.MonthClose
+	for rows.Next() {
+		var month, snapshot string
+		if err := rows.Scan(&month, &snapshot); err != nil {
+			return nil, err
+		}
+		var c models.MonthClose
+		if err := json.Unmarshal([]byte(snapshot), &c); err != nil {
+			return nil, fmt.Errorf("closed month %s has an invalid snapshot: %w", month, err)
+		}
+		closed = append(closed, c)
+	}
+	return closed, rows.Err()
+}
+
+// CloseMonth saves the snapshot of a closed month
+func (s *SQLiteStore) CloseMonth(ctx context.Context, closed models.MonthClose) error {
+	snapshot, err := json.Marshal(closed)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `INSERT OR REPLACE INTO closed_months (month, closed_at, snapshot) VALUES (?, ?, ?)`,
+		closed.Month, formatTime(closed.ClosedAt), string(snapshot))
+	return err
+}
+
+// ReopenMonth drops the snapshot of a closed month
+func (s *SQLiteStore) ReopenMonth(ctx context.Context, month string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM closed_months WHERE month = ?`, month)
+	return err
+}
+
+// SaveReport saves the inputs of a report
+func (s *SQLiteStore) SaveReport(ctx context.Context, report models.SavedReport) error {
+	inputs, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `INSERT OR REPLACE INTO reports (id, created_at, inputs) VALUES (?, ?, ?)`,
+		report.ID, formatTime(report.CreatedAt), string(inputs))
+	return err
+}
+
+// Report returns the saved report with the given ID, or nil. A database
+// opened read-only before reports were saved has none.
+func (s *SQLiteStore) Report(ctx context.Context, id string) (*models.SavedReport, error) {
+	columns, err := tableColumns(ctx, s.db, "reports")
+	if err != nil || len(columns) == 0 {
+		return nil, err
+	}
+
+	var inputs string
+	err = s.db.QueryRowContext(ctx, `SELECT inputs FROM reports WHERE id = ?`, id).Scan(&inputs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var report models.SavedReport
+	if err := json.Unmarshal([]byte(inputs), &report); err != nil {
+		return nil, fmt.Errorf("report %s has invalid inputs: %w", id, err)
+	}
+	return &report, nil
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// SQLiteAvailable reports whether gm was built with SQLite, which builds
+// with -tags nosqlite leave out
+func SQLiteAvailable() bool {
+	return slices.Contains(sql.Drivers(), "sqlite")
+}
+
+// requireSQLite fails with a hint when gm was built without SQLite
+func requireSQLite() error {
+	if SQLiteAvailable() {
+		return nil
+	}
+	return errors.New("this gm was built without SQLite (-tags nosqlite); use a bolt store such as --store go-money.bolt, or a postgres:// URL")
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseTime(s string) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// formatOptionalTime stores the zero time as an empty string
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return formatTime(t)
+}
+
+// ensureColumn adds a column to table unless it already exists
+func ensureColumn(ctx context.Context, db dbConn, table, column, definition string) error {
+	columns, err := tableColumns(ctx, db, table)
+	if err != nil {
+		return err
+	}
+	for _, name := range columns {
+		if name == column {
+			return nil
+		}
+	}
+
+	_, err = db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
+// tableColumns returns the column names of table; a missing table has none
+func tableColumns(ctx context.Context, db dbConn, table string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return nil, err
+		}
+		columns = append(columns, name)
+	}
+	return columns, rows.Err()
+}
+
+// schemaColumns lists the columns of each table this version uses
+var schemaColumns = map[string][]string{
+	"transactions": {
+		"id", "thread_id", "service_id", "service_name", "category", "amount", "currency",
+		"currency_symbol", "date", "description", "email", "subject", "timestamp",
+		"raw_amount", "related_ids", "due_date", "account", "confidence", "order_id", "partial", "status",
+		"invoice_number", "tax",
+	},
+	"processed_messages": {"id", "processed_at"},
+	"sync_state":         {"key", "value"},
+	"budgets":            {"category", "amount", "currency"},
+	"closed_months":      {"month", "closed_at", "snapshot"},
+	"reports":            {"id", "created_at", "inputs"},
+}
+
+// SchemaIssue is a difference between a database and the schema this
+// version expects
+type SchemaIssue struct {
+	Message string
+	Missing bool // A table, column or migration OpenSQLite adds
+}
+
+// CheckSchema compares the database or bolt file at path, or the database
+// at a postgres:// URL, with
+// the schema this version expects without modifying it. A missing database
+// has no issues.
+func CheckSchema(ctx context.Context, path string) ([]SchemaIssue, error) {
+	if IsPostgres(path) {
+		db, err := openPostgresDB(path)
+		if err != nil {
+			return nil, err
+		}
+		defer db.Close()
+		version, err := postgresVersion(ctx, db)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the schema version of %s: %w", Redacted(path), err)
+		}
+		if version == 0 {
+			return nil, nil
+		}
+		return schemaIssues(Redacted(path), version, PostgresSchemaVersion, func(table string) ([]string, error) {
+			return postgresColumns(ctx, db, table)
+		})
+	}
+
+	if IsBolt(path) {
+		return checkBoltSchema(path)
+	}
+
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err := requireSQLite(); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("unable to open store %s: %w", path, err)
+	}
+	defer db.Close()
+
+	version, err := schemaVersion(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the schema version of %s: %w", path, err)
+	}
+	return schemaIssues(path, version, SchemaVersion, func(table string) ([]string, error) {
+		return tableColumns(ctx, db, table)
+	})
+}
+
+// schemaIssues compares a database at version, whose tables have the
+// columns columnsOf returns, with the current version of its schema
+func schemaIssues(name string, version, current int, columnsOf func(table string) ([]string, error)) ([]SchemaIssue, error) {
+	tables := make([]string, 0, len(schemaColumns))
+	for table := range schemaColumns {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	var issues []SchemaIssue
+	switch {
+	case version < current:
+		issues = append(issues, SchemaIssue{Message: fmt.Sprintf("schema is v%d; opening the store for writing migrates it to v%d", version, current), Missing: true})
+	case version > current:
+		issues = append(issues, SchemaIssue{Message: fmt.Sprintf("schema is v%d, from a newer version of gm that supports more than v%d", version, current)})
+	}
+	for _, table := range tables {
+		columns, err := columnsOf(table)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the schema of %s: %w", name, err)
+		}
+		if len(columns) == 0 {
+			issues = append(issues, SchemaIssue{Message: fmt.Sprintf("table %s is missing", table), Missing: true})
+			continue
+		}
+
+		present := make(map[string]bool, len(columns))
+		for _, column := range columns {
+			present[column] = true
+		}
+		expected := make(map[string]bool, len(schemaColumns[table]))
+		for _, column := range schemaColumns[table] {
+			expected[column] = true
+			if !present[column] {
+				issues = append(issues, SchemaIssue{Message: fmt.Sprintf("table %s is missing column %s", table, column), Missing: true})
+			}
+		}
+		for _, column := range columns {
+			if !expected[column] {
+				issues = append(issues, SchemaIssue{Message: fmt.Sprintf("table %s has unknown column %s, likely from a newer version of gm", table, column)})
+			}
+		}
+	}
+	return issues, nil
+}
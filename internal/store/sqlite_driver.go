@@ -0,0 +1,7 @@
+//go:build !nosqlite
+
+package store
+
+// The SQLite driver is left out of builds with -tags nosqlite, for
+// platforms it doesn't support; those use bolt or PostgreSQL stores
+import _ "modernc.org/sqlite"
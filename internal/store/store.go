@@ -0,0 +1,105 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// DefaultPath is the name of the database file in the data directory used
+// when no other store is configured
+const DefaultPath = "go-money.db"
+
+// Sync state keys
+const (
+	// LastSyncKey holds the RFC 3339 time of the last successful sync
+	LastSyncKey = "last_sync"
+	// BackfillFromKey holds the --from date of the current backfill
+	BackfillFromKey = "backfill_from"
+	// BackfillCursorKey holds the start date of the next month to backfill
+	BackfillCursorKey = "backfill_cursor"
+	// HistoryIDKey holds the mailbox cursor, such as the Gmail history ID,
+	// the next sync lists changes from
+	HistoryIDKey = "history_id"
+	// HistorySinceKey holds the RFC 3339 time HistoryIDKey was read
+	HistorySinceKey = "history_since"
+)
+
+// AccountKey scopes a sync state key to an account from accounts.json; the
+// single mailbox used without one keeps the plain key
+func AccountKey(key, account string) string {
+	if account == "" {
+		return key
+	}
+	return key + ":" + account
+}
+
+// Open opens the store at location: a postgres:// URL, the path of a bolt
+// file or that of a SQLite database
+func Open(location string) (Store, error) {
+	if IsPostgres(location) {
+		return OpenPostgres(location)
+	}
+	// The data directory may not exist yet
+	if err := os.MkdirAll(filepath.Dir(location), 0700); err != nil {
+		return nil, fmt.Errorf("unable to open store %s: %w", location, err)
+	}
+	switch {
+	case IsBolt(location):
+		return OpenBolt(location)
+	}
+	return OpenSQLite(location)
+}
+
+// Backend names the kind of store Open opens at location: postgres, bolt or
+// sqlite
+func Backend(location string) string {
+	switch {
+	case IsPostgres(location):
+		return "postgres"
+	case IsBolt(location):
+		return "bolt"
+	}
+	return "sqlite"
+}
+
+// Store persists extracted transactions, the messages already processed and
+// sync state between runs so syncs can be incremental
+type Store interface {
+	// SaveTransactions inserts or replaces transactions by ID
+	SaveTransactions(ctx context.Context, transactions []*models.Transaction) error
+	// Transactions returns every stored transaction ordered by date
+	Transactions(ctx context.Context) ([]*models.Transaction, error)
+	// MarkProcessed records message IDs that don't need to be fetched again
+	MarkProcessed(ctx context.Context, messageIDs []string) error
+	// ProcessedIDs returns the set of message IDs already processed
+	ProcessedIDs(ctx context.Context) (map[string]bool, error)
+	// SyncState returns the value stored under key, or "" if unset
+	SyncState(ctx context.Context, key string) (string, error)
+	// SetSyncState stores value under key
+	SetSyncState(ctx context.Context, key, value string) error
+	// Budgets returns the saved budgets ordered by category
+	Budgets(ctx context.Context) ([]models.Budget, error)
+	// SetBudget inserts or replaces the budget of a category, matched
+	// ignoring case
+	SetBudget(ctx context.Context, budget models.Budget) error
+	// DeleteBudget removes the budget of a category, matched ignoring case
+	DeleteBudget(ctx context.Context, category string) error
+	// ClosedMonths returns the months closed with gm close, oldest first
+	ClosedMonths(ctx context.Context) ([]models.MonthClose, error)
+	// CloseMonth saves the snapshot of a closed month, replacing any
+	// earlier one
+	CloseMonth(ctx context.Context, closed models.MonthClose) error
+	// ReopenMonth drops the snapshot of a closed month (YYYY-MM)
+	ReopenMonth(ctx context.Context, month string) error
+	// SaveReport saves the inputs of a report under its ID
+	SaveReport(ctx context.Context, report models.SavedReport) error
+	// Report returns the saved report with the given ID, or nil if there
+	// is none
+	Report(ctx context.Context, id string) (*models.SavedReport, error)
+	// Close releases the underlying resources
+	Close() error
+}
@@ -0,0 +1,193 @@
+package subscriptions
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// Cadence names for the billing periods that can be detected
+const (
+	Weekly    = "weekly"
+	Monthly   = "monthly"
+	Quarterly = "quarterly"
+	Yearly    = "yearly"
+)
+
+// amountTolerance is how far (as a fraction) a charge may deviate from the
+// typical amount and still count as the same subscription
+const amountTolerance = 0.10
+
+// Subscription represents a recurring charge detected from past transactions
+type Subscription struct {
+	ServiceID      string    `json:"service_id" yaml:"service_id"`
+	ServiceName    string    `json:"service_name" yaml:"service_name"`
+	Category       string    `json:"category" yaml:"category"`
+	Amount         float64   `json:"amount" yaml:"amount"`
+	Currency       string    `json:"currency" yaml:"currency"`
+	CurrencySymbol string    `json:"currency_symbol" yaml:"currency_symbol"`
+	Cadence        string    `json:"cadence" yaml:"cadence"`
+	BillingDay     int       `json:"billing_day" yaml:"billing_day"`   // Day of the month of the last charge
+	MonthlyCost    float64   `json:"monthly_cost" yaml:"monthly_cost"` // Amount spread over a month, whatever the cadence
+	AnnualCost     float64   `json:"annual_cost" yaml:"annual_cost"`
+	Charges        int       `json:"charges" yaml:"charges"`
+	LastCharge     time.Time `json:"last_charge" yaml:"last_charge"`
+	NextCharge     time.Time `json:"next_charge" yaml:"next_charge"`
+	Active         bool      `json:"active" yaml:"active"`
+}
+
+// cadence describes a billing period and the interval range (in days) that maps to it
+type cadence struct {
+	name    string
+	minDays float64
+	maxDays float64
+	perYear float64 // Charges in a year
+	next    func(time.Time) time.Time
+}
+
+var cadences = []cadence{
+	{Weekly, 6, 8, 52, func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }},
+	{Monthly, 26, 35, 12, func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }},
+	{Quarterly, 84, 98, 4, func(t time.Time) time.Time { return t.AddDate(0, 3, 0) }},
+	{Yearly, 350, 380, 1, func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }},
+}
+
+// Detect groups transactions by service and currency and returns the groups
+// that look like recurring charges: a similar amount billed on a regular cadence.
+// A subscription is Active when its next expected charge hasn't been missed by
+// more than half a period as of now. Refunds are ignored.
+func Detect(transactions []*models.Transaction, now time.Time) []Subscription {
+	groups := make(map[string][]*models.Transaction)
+	var keys []string
+	for _, tx := range transactions {
+		if tx.Amount < 0 {
+			continue
+		}
+		key := tx.ServiceID + "|" + tx.Currency
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], tx)
+	}
+
+	var subs []Subscription
+	for _, key := range keys {
+		if sub, ok := detectGroup(groups[key], now); ok {
+			subs = append(subs, sub)
+		}
+	}
+
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].NextCharge.Before(subs[j].NextCharge)
+	})
+
+	return subs
+}
+
+// detectGroup checks whether the transactions of one service form a subscription
+func detectGroup(group []*models.Transaction, now time.Time) (Subscription, bool) {
+	if len(group) < 2 {
+		return Subscription{}, false
+	}
+
+	sorted := make([]*models.Transaction, len(group))
+	copy(sorted, group)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	// Every charge must be close to the typical amount
+	amounts := make([]float64, len(sorted))
+	for i, tx := range sorted {
+		amounts[i] = tx.Amount
+	}
+	typical := median(amounts)
+	for _, amount := range amounts {
+		if math.Abs(amount-typical) > typical*amountTolerance {
+			return Subscription{}, false
+		}
+	}
+
+	intervals := make([]float64, 0, len(sorted)-1)
+	for i := 1; i < len(sorted); i++ {
+		intervals = append(intervals, sorted[i].Date.Sub(sorted[i-1].Date).Hours()/24)
+	}
+	interval := median(intervals)
+
+	for _, c := range cadences {
+		if interval < c.minDays || interval > c.maxDays {
+			continue
+		}
+
+		last := sorted[len(sorted)-1]
+		next := c.next(last.Date)
+		grace := time.Duration(interval / 2 * float64(24*time.Hour))
+
+		return Subscription{
+			ServiceID:      last.ServiceID,
+			ServiceName:    last.ServiceName,
+			Category:       last.Category,
+			Amount:         last.Amount,
+			Currency:       last.Currency,
+			CurrencySymbol: last.CurrencySymbol,
+			Cadence:        c.name,
+			BillingDay:     last.Date.Day(),
+			MonthlyCost:    round(last.Amount * c.perYear / 12),
+			AnnualCost:     round(last.Amount * c.perYear),
+			Charges:        len(sorted),
+			LastCharge:     last.Date,
+			NextCharge:     next,
+			Active:         now.Before(next.Add(grace)),
+		}, true
+	}
+
+	return Subscription{}, false
+}
+
+// median returns the median of values; values is reordered
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
+
+// round rounds an amount to cents
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// Cost is what the active subscriptions in one currency add up to
+type Cost struct {
+	Currency       string  `json:"currency" yaml:"currency"`
+	CurrencySymbol string  `json:"currency_symbol" yaml:"currency_symbol"`
+	Subscriptions  int     `json:"subscriptions" yaml:"subscriptions"`
+	Monthly        float64 `json:"monthly" yaml:"monthly"`
+	Annual         float64 `json:"annual" yaml:"annual"`
+}
+
+// Costs totals the monthly and annual cost of the active subscriptions per
+// currency, in the order currencies first appear
+func Costs(subs []Subscription) []Cost {
+	var costs []Cost
+	index := make(map[string]int)
+	for _, sub := range subs {
+		if !sub.Active {
+			continue
+		}
+		i, ok := index[sub.Currency]
+		if !ok {
+			i = len(costs)
+			index[sub.Currency] = i
+			costs = append(costs, Cost{Currency: sub.Currency, CurrencySymbol: sub.CurrencySymbol})
+		}
+		costs[i].Subscriptions++
+		costs[i].Monthly = round(costs[i].Monthly + sub.MonthlyCost)
+		costs[i].Annual = round(costs[i].Annual + sub.AnnualCost)
+	}
+	return costs
+}
@@ -0,0 +1,86 @@
+package summary
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sazardev/go-money/internal/categories"
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// BurnDown compares this month's spending in each budgeted category with
+// its budget and projects month-end spending at the current daily pace.
+// Statuses are sorted by category.
+func BurnDown(transactions []*models.Transaction, budgets map[string]categories.Budget, now time.Time) []models.BudgetStatus {
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	return monthBudgets(transactions, budgets, monthStart, now)
+}
+
+// BudgetByMonth compares the spending in each budgeted category with its
+// budget in each of months (YYYY-MM, as from LastMonths). Budgets reset
+// every month; the current month is projected like BurnDown, and months
+// that have ended are complete. Statuses are sorted by month, then category.
+func BudgetByMonth(transactions []*models.Transaction, budgets map[string]categories.Budget, months []string, now time.Time) []models.BudgetStatus {
+	var statuses []models.BudgetStatus
+	for _, month := range months {
+		monthStart, err := time.ParseInLocation("2006-01", month, now.Location())
+		if err != nil || monthStart.After(now) {
+			continue
+		}
+		asOf := now
+		if monthEnd := monthStart.AddDate(0, 1, 0).Add(-time.Nanosecond); monthEnd.Before(now) {
+			asOf = monthEnd
+		}
+		statuses = append(statuses, monthBudgets(transactions, budgets, monthStart, asOf)...)
+	}
+	return statuses
+}
+
+// monthBudgets compares the spending from monthStart to asOf in each
+// budgeted category with its budget, sorted by category
+func monthBudgets(transactions []*models.Transaction, budgets map[string]categories.Budget, monthStart, asOf time.Time) []models.BudgetStatus {
+	daysInMonth := monthStart.AddDate(0, 1, -1).Day()
+	elapsed := asOf.Day()
+	thisMonth := Apply(transactions, Counted(), Between(monthStart, asOf))
+
+	var statuses []models.BudgetStatus
+	for category, budget := range budgets {
+		filters := []Filter{InCategory(category)}
+		if budget.Currency != "" {
+			filters = append(filters, InCurrency(budget.Currency))
+		}
+		matching := Apply(thisMonth, filters...)
+		spent := Total(matching)
+		projected := spent / float64(elapsed) * float64(daysInMonth)
+
+		status := models.BudgetStatus{
+			Category:       category,
+			Month:          monthStart.Format("2006-01"),
+			Currency:       budget.Currency,
+			CurrencySymbol: budgetSymbol(budget.Currency, matching),
+			Budget:         budget.Amount,
+			Spent:          spent,
+			Used:           spent / budget.Amount * 100,
+			DaysLeft:       daysInMonth - elapsed,
+			Projected:      projected,
+		}
+		if projected > budget.Amount {
+			status.Overage = projected - budget.Amount
+		}
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Category < statuses[j].Category })
+	return statuses
+}
+
+// budgetSymbol returns the symbol amounts of a budget are shown with
+func budgetSymbol(currency string, transactions []*models.Transaction) string {
+	if len(transactions) > 0 {
+		return transactions[0].CurrencySymbol
+	}
+	if currency != "" {
+		return currency + " "
+	}
+	return ""
+}
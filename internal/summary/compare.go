@@ -0,0 +1,58 @@
+package summary
+
+import (
+	"time"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// Span is a named span of time, such as the month "2024-05"
+type Span struct {
+	Name string    `json:"name" yaml:"name"`
+	From time.Time `json:"from" yaml:"from"`
+	To   time.Time `json:"to" yaml:"to"` // Exclusive
+}
+
+// Comparison sets the spending of period B against period A. In its
+// deltas, Previous is the amount of A and Total that of B.
+type Comparison struct {
+	A           Span     `json:"a" yaml:"a"`
+	B           Span     `json:"b" yaml:"b"`
+	Total       Delta    `json:"total" yaml:"total"`
+	Categories  []Delta  `json:"categories" yaml:"categories"`   // Largest total in B first, then categories only A has
+	Appeared    []string `json:"appeared" yaml:"appeared"`       // Categories with spending in B but none in A
+	Disappeared []string `json:"disappeared" yaml:"disappeared"` // Categories with spending in A but none in B
+}
+
+// Compare compares the spending of two periods, per category
+func Compare(transactions []*models.Transaction, a, b Span) *Comparison {
+	inA := Apply(transactions, Between(a.From, a.To.Add(-time.Nanosecond)))
+	inB := Apply(transactions, Between(b.From, b.To.Add(-time.Nanosecond)))
+
+	c := &Comparison{
+		A:           a,
+		B:           b,
+		Total:       delta("total", Total(inB), Total(inA)),
+		Categories:  categoryDeltas(inB, inA),
+		Appeared:    []string{},
+		Disappeared: []string{},
+	}
+	counts := make(map[string][2]int)
+	for _, group := range GroupBy(inA, ByCategory) {
+		counts[group.Key] = [2]int{group.Count, 0}
+	}
+	for _, group := range GroupBy(inB, ByCategory) {
+		count := counts[group.Key]
+		count[1] = group.Count
+		counts[group.Key] = count
+	}
+	for _, d := range c.Categories {
+		switch count := counts[d.Key]; {
+		case count[0] == 0:
+			c.Appeared = append(c.Appeared, d.Key)
+		case count[1] == 0:
+			c.Disappeared = append(c.Disappeared, d.Key)
+		}
+	}
+	return c
+}
@@ -0,0 +1,85 @@
+package summary
+
+import (
+	"sort"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// histogramEdges are the lower bounds of the transaction size buckets
+var histogramEdges = []float64{0, 5, 10, 25, 50, 100, 250, 500, 1000}
+
+// Stats describes the transaction sizes of a group
+type Stats struct {
+	Key   string  `json:"key" yaml:"key"`
+	Count int     `json:"count" yaml:"count"`
+	Total float64 `json:"total" yaml:"total"`
+	P50   float64 `json:"p50" yaml:"p50"`
+	P90   float64 `json:"p90" yaml:"p90"`
+	Max   float64 `json:"max" yaml:"max"`
+}
+
+// Bucket counts the transactions whose amount is in [Min, Max); the last
+// bucket has no upper bound and Max is 0
+type Bucket struct {
+	Min   float64 `json:"min" yaml:"min"`
+	Max   float64 `json:"max,omitempty" yaml:"max,omitempty"`
+	Count int     `json:"count" yaml:"count"`
+	Total float64 `json:"total" yaml:"total"`
+}
+
+// StatsBy returns the size statistics of each group, sorted by total
+// (largest first)
+func StatsBy(transactions []*models.Transaction, key Key) []Stats {
+	members := make(map[string][]*models.Transaction)
+	for _, tx := range transactions {
+		members[key(tx)] = append(members[key(tx)], tx)
+	}
+
+	var stats []Stats
+	for _, group := range GroupBy(transactions, key) {
+		stats = append(stats, statsOf(group.Key, members[group.Key]))
+	}
+	return stats
+}
+
+// Overall returns the size statistics of all transactions
+func Overall(transactions []*models.Transaction) Stats {
+	return statsOf("all", transactions)
+}
+
+func statsOf(key string, transactions []*models.Transaction) Stats {
+	return Stats{
+		Key:   key,
+		Count: len(transactions),
+		Total: Total(transactions),
+		P50:   Percentile(transactions, 50),
+		P90:   Percentile(transactions, 90),
+		Max:   Percentile(transactions, 100),
+	}
+}
+
+// Histogram counts transactions per size bucket
+func Histogram(transactions []*models.Transaction) []Bucket {
+	buckets := make([]Bucket, len(histogramEdges))
+	for i, edge := range histogramEdges {
+		buckets[i].Min = edge
+		if i+1 < len(histogramEdges) {
+			buckets[i].Max = histogramEdges[i+1]
+		}
+	}
+
+	for _, tx := range transactions {
+		i := sort.SearchFloat64s(histogramEdges, tx.Amount)
+		if i == len(histogramEdges) || histogramEdges[i] != tx.Amount {
+			i--
+		}
+		if i < 0 {
+			i = 0
+		}
+		buckets[i].Count++
+		buckets[i].Total += tx.Amount
+	}
+
+	return buckets
+}
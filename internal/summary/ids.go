@@ -0,0 +1,98 @@
+package summary
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// Lengths of short transaction IDs: the shortest one printed, lengthened
+// while two transactions would share it, and the shortest prefix accepted
+const (
+	ShortIDLength    = 7
+	MinShortIDPrefix = 4
+)
+
+var (
+	// ErrUnknownID means no transaction has the ID or short ID given
+	ErrUnknownID = errors.New("no stored transaction with ID")
+	// ErrAmbiguousID means a short ID prefix matches several transactions
+	ErrAmbiguousID = errors.New("ambiguous short ID")
+)
+
+// idHash is the hex SHA-256 of a transaction ID, which short IDs are
+// prefixes of. Mailbox IDs differ by provider and those of one period share
+// their first characters, so their hash is abbreviated instead.
+func idHash(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
+// ShortIDs returns the short ID of each of transactions by transaction ID:
+// the first ShortIDLength characters of the hash of the ID, or more when
+// that isn't enough to tell two of them apart, like git's abbreviated
+// hashes
+func ShortIDs(transactions []*models.Transaction) map[string]string {
+	hashes := make(map[string]string, len(transactions))
+	for _, tx := range transactions {
+		hashes[tx.ID] = idHash(tx.ID)
+	}
+
+	length := ShortIDLength
+	for ; length < sha256.Size*2; length++ {
+		seen := make(map[string]bool, len(hashes))
+		for _, hash := range hashes {
+			seen[hash[:length]] = true
+		}
+		if len(seen) == len(hashes) {
+			break
+		}
+	}
+
+	short := make(map[string]string, len(hashes))
+	for id, hash := range hashes {
+		short[id] = hash[:length]
+	}
+	return short
+}
+
+// ResolveID returns the transaction of transactions with the full ID ref,
+// or whose short ID starts with ref. Prefixes shorter than MinShortIDPrefix
+// aren't accepted, and those matching several transactions are reported
+// with the candidates.
+func ResolveID(transactions []*models.Transaction, ref string) (*models.Transaction, error) {
+	ref = strings.TrimSpace(ref)
+	for _, tx := range transactions {
+		if tx.ID == ref {
+			return tx, nil
+		}
+	}
+
+	prefix := strings.ToLower(ref)
+	if len(prefix) < MinShortIDPrefix {
+		return nil, fmt.Errorf("%w %q; short IDs need at least %d characters", ErrUnknownID, ref, MinShortIDPrefix)
+	}
+	var matches []*models.Transaction
+	for _, tx := range transactions {
+		if strings.HasPrefix(idHash(tx.ID), prefix) {
+			matches = append(matches, tx)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%w %q", ErrUnknownID, ref)
+	case 1:
+		return matches[0], nil
+	}
+	short := ShortIDs(matches)
+	candidates := make([]string, len(matches))
+	for i, tx := range matches {
+		candidates[i] = fmt.Sprintf("%s (%s %s %.2f %s)", short[tx.ID], tx.Date.Format("2006-01-02"), tx.ServiceName, tx.Amount, tx.Currency)
+	}
+	return nil, fmt.Errorf("%w %q matches %d transactions: %s", ErrAmbiguousID, ref, len(matches), strings.Join(candidates, ", "))
+}
@@ -0,0 +1,101 @@
+package summary
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// Pivot is a table of spending per category (rows) and month or
+// household member (columns)
+type Pivot struct {
+	Columns []string   `json:"columns" yaml:"columns"` // Months as YYYY-MM, oldest first, or members
+	Rows    []PivotRow `json:"rows" yaml:"rows"`       // Sorted by total, largest first
+	Totals  []float64  `json:"totals" yaml:"totals"`   // Per column, across categories
+	Total   float64    `json:"total" yaml:"total"`
+	// Budgets compares each month's spending with the monthly budgets
+	Budgets []models.BudgetStatus `json:"budgets,omitempty" yaml:"budgets,omitempty"`
+}
+
+// PivotRow holds one category's spending in each column of a Pivot
+type PivotRow struct {
+	Category string    `json:"category" yaml:"category"`
+	Values   []float64 `json:"values" yaml:"values"`
+	Total    float64   `json:"total" yaml:"total"`
+}
+
+// LastMonths returns the n months up to and including the month of now,
+// oldest first, as YYYY-MM
+func LastMonths(now time.Time, n int) []string {
+	first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, 1-n, 0)
+	months := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		months = append(months, first.AddDate(0, i, 0).Format("2006-01"))
+	}
+	return months
+}
+
+// Members returns the household members transactions are attributed to,
+// sorted, with Unassigned last
+func Members(transactions []*models.Transaction) []string {
+	seen := make(map[string]bool)
+	var members []string
+	unassigned := false
+	for _, tx := range transactions {
+		switch {
+		case tx.Member == "":
+			unassigned = true
+		case !seen[tx.Member]:
+			seen[tx.Member] = true
+			members = append(members, tx.Member)
+		}
+	}
+
+	sort.Strings(members)
+	if unassigned {
+		members = append(members, Unassigned)
+	}
+	return members
+}
+
+// BuildPivot tabulates spending per category over the given columns, such
+// as LastMonths with ByMonth or Members with ByMember; transactions outside
+// those columns are ignored
+func BuildPivot(transactions []*models.Transaction, columns []string, key Key) *Pivot {
+	column := make(map[string]int, len(columns))
+	for i, c := range columns {
+		column[c] = i
+	}
+
+	var inRange []*models.Transaction
+	for _, tx := range transactions {
+		if _, ok := column[key(tx)]; ok {
+			inRange = append(inRange, tx)
+		}
+	}
+
+	pivot := &Pivot{
+		Columns: columns,
+		Rows:    []PivotRow{},
+		Totals:  make([]float64, len(columns)),
+	}
+	row := make(map[string]int)
+	for _, group := range GroupBy(inRange, ByCategory) {
+		row[group.Key] = len(pivot.Rows)
+		pivot.Rows = append(pivot.Rows, PivotRow{
+			Category: group.Key,
+			Values:   make([]float64, len(columns)),
+			Total:    group.Total,
+		})
+	}
+
+	for _, tx := range inRange {
+		i := column[key(tx)]
+		pivot.Rows[row[ByCategory(tx)]].Values[i] += tx.Amount
+		pivot.Totals[i] += tx.Amount
+		pivot.Total += tx.Amount
+	}
+
+	return pivot
+}
@@ -0,0 +1,112 @@
+package summary
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// rollupTop is how many merchants and transactions a Rollup lists
+const rollupTop = 5
+
+// Rollup summarizes a calendar month or year and compares it with the
+// period before it
+type Rollup struct {
+	Period       string                 `json:"period" yaml:"period"`     // YYYY-MM or YYYY
+	Previous     string                 `json:"previous" yaml:"previous"` // The period compared with
+	From         time.Time              `json:"from" yaml:"from"`
+	To           time.Time              `json:"to" yaml:"to"` // Exclusive
+	Summary      *models.ExpenseSummary `json:"summary" yaml:"summary"`
+	TopMerchants []Group                `json:"top_merchants" yaml:"top_merchants"` // Largest total first
+	Largest      []*models.Transaction  `json:"largest" yaml:"largest"`             // Largest amount first
+	Change       Delta                  `json:"change" yaml:"change"`               // Of the total
+	Categories   []Delta                `json:"categories" yaml:"categories"`       // Largest total first
+}
+
+// Delta compares a total with the same total in the previous period
+type Delta struct {
+	Key      string  `json:"key" yaml:"key"`
+	Total    float64 `json:"total" yaml:"total"`
+	Previous float64 `json:"previous" yaml:"previous"`
+	Change   float64 `json:"change" yaml:"change"`
+	Percent  float64 `json:"percent" yaml:"percent"` // Change relative to Previous; 0 when Previous is 0
+}
+
+// MonthRollup summarizes the calendar month of month
+func MonthRollup(transactions []*models.Transaction, month time.Time) *Rollup {
+	from := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	previous := from.AddDate(0, -1, 0)
+	return buildRollup(transactions, from, from.AddDate(0, 1, 0), previous, "2006-01")
+}
+
+// YearRollup summarizes a calendar year
+func YearRollup(transactions []*models.Transaction, year int, loc *time.Location) *Rollup {
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	previous := from.AddDate(-1, 0, 0)
+	return buildRollup(transactions, from, from.AddDate(1, 0, 0), previous, "2006")
+}
+
+// buildRollup summarizes [from, to) and compares it with the period of the
+// same length starting at previous; layout formats the period names
+func buildRollup(transactions []*models.Transaction, from, to, previous time.Time, layout string) *Rollup {
+	current := Apply(transactions, Between(from, to.Add(-time.Nanosecond)))
+	before := Apply(transactions, Between(previous, from.Add(-time.Nanosecond)))
+
+	r := &Rollup{
+		Period:   from.Format(layout),
+		Previous: previous.Format(layout),
+		From:     from,
+		To:       to,
+		Summary:  Build(current),
+		Change:   delta("total", Total(current), Total(before)),
+	}
+
+	r.TopMerchants = r.Summary.Services
+	if len(r.TopMerchants) > rollupTop {
+		r.TopMerchants = r.TopMerchants[:rollupTop]
+	}
+
+	r.Largest = make([]*models.Transaction, len(current))
+	copy(r.Largest, current)
+	sort.SliceStable(r.Largest, func(i, j int) bool { return r.Largest[i].Amount > r.Largest[j].Amount })
+	if len(r.Largest) > rollupTop {
+		r.Largest = r.Largest[:rollupTop]
+	}
+
+	r.Categories = categoryDeltas(current, before)
+	return r
+}
+
+// categoryDeltas compares the category totals of current with those of
+// before: categories of current, largest total first, then the ones only
+// before has by name, so categories with no spending left show their drop
+func categoryDeltas(current, before []*models.Transaction) []Delta {
+	previousTotals := make(map[string]float64)
+	for _, group := range GroupBy(before, ByCategory) {
+		previousTotals[group.Key] = group.Total
+	}
+	deltas := []Delta{}
+	for _, group := range GroupBy(current, ByCategory) {
+		deltas = append(deltas, delta(group.Key, group.Total, previousTotals[group.Key]))
+		delete(previousTotals, group.Key)
+	}
+	var dropped []string
+	for category := range previousTotals {
+		dropped = append(dropped, category)
+	}
+	sort.Strings(dropped)
+	for _, category := range dropped {
+		deltas = append(deltas, delta(category, 0, previousTotals[category]))
+	}
+	return deltas
+}
+
+// delta compares total with previous
+func delta(key string, total, previous float64) Delta {
+	d := Delta{Key: key, Total: total, Previous: previous, Change: total - previous}
+	if previous != 0 {
+		d.Percent = d.Change / previous * 100
+	}
+	return d
+}
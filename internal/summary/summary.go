@@ -0,0 +1,290 @@
+package summary
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// Filter selects the transactions to include in a summary
+type Filter func(tx *models.Transaction) bool
+
+// Key extracts the value transactions are grouped by
+type Key func(tx *models.Transaction) string
+
+// Group is an aggregate of transactions sharing the same key
+type Group = models.GroupTotal
+
+// Common grouping keys
+var (
+	ByCategory Key = func(tx *models.Transaction) string { return tx.Category }
+	ByService  Key = func(tx *models.Transaction) string { return tx.ServiceName }
+	ByCurrency Key = func(tx *models.Transaction) string { return tx.Currency }
+	ByMonth    Key = func(tx *models.Transaction) string { return tx.Date.Format("2006-01") }
+	ByMember   Key = func(tx *models.Transaction) string {
+		if tx.Member == "" {
+			return Unassigned
+		}
+		return tx.Member
+	}
+)
+
+// Unassigned is the member of transactions not attributed to anyone
+const Unassigned = "Unassigned"
+
+// Between keeps transactions dated within [from, to]; a zero bound is open
+func Between(from, to time.Time) Filter {
+	return func(tx *models.Transaction) bool {
+		if !from.IsZero() && tx.Date.Before(from) {
+			return false
+		}
+		if !to.IsZero() && tx.Date.After(to) {
+			return false
+		}
+		return true
+	}
+}
+
+// InCurrency keeps transactions in the given currency code
+func InCurrency(code string) Filter {
+	return func(tx *models.Transaction) bool {
+		return strings.EqualFold(tx.Currency, code)
+	}
+}
+
+// InCategory keeps transactions in the given category
+func InCategory(category string) Filter {
+	return func(tx *models.Transaction) bool {
+		return strings.EqualFold(tx.Category, category)
+	}
+}
+
+// FromService keeps transactions of the given service, matched by ID or name
+func FromService(service string) Filter {
+	return func(tx *models.Transaction) bool {
+		return strings.EqualFold(tx.ServiceID, service) || strings.EqualFold(tx.ServiceName, service)
+	}
+}
+
+// AmountBetween keeps transactions whose amount is within [min, max]; a zero bound is open.
+// Refunds are compared by their size, so they stay with the charges they refund.
+func AmountBetween(min, max float64) Filter {
+	return func(tx *models.Transaction) bool {
+		amount := math.Abs(tx.Amount)
+		if min != 0 && amount < min {
+			return false
+		}
+		if max != 0 && amount > max {
+			return false
+		}
+		return true
+	}
+}
+
+// MinConfidence keeps transactions extracted with at least the given
+// confidence. Transactions stored before confidence was scored are kept.
+func MinConfidence(min float64) Filter {
+	return func(tx *models.Transaction) bool {
+		return tx.Confidence == 0 || tx.Confidence >= min
+	}
+}
+
+// WithTag keeps transactions carrying the given tag
+func WithTag(tag string) Filter {
+	return func(tx *models.Transaction) bool {
+		for _, t := range tx.Tags {
+			if strings.EqualFold(t, tag) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ForMember keeps transactions attributed to the given household member;
+// Unassigned selects the transactions attributed to no one
+func ForMember(member string) Filter {
+	return func(tx *models.Transaction) bool {
+		return strings.EqualFold(ByMember(tx), member)
+	}
+}
+
+// WithStatus keeps transactions in any of the given statuses that the user
+// didn't exclude
+func WithStatus(statuses ...string) Filter {
+	return func(tx *models.Transaction) bool {
+		if tx.Excluded {
+			return false
+		}
+		for _, status := range statuses {
+			if strings.EqualFold(tx.EffectiveStatus(), status) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Counted keeps the transactions that count toward spending, leaving out
+// pending authorizations, cancelled charges and excluded transactions
+func Counted() Filter {
+	return func(tx *models.Transaction) bool {
+		return tx.Counted()
+	}
+}
+
+// Apply returns the transactions accepted by every filter
+func Apply(transactions []*models.Transaction, filters ...Filter) []*models.Transaction {
+	if len(filters) == 0 {
+		return transactions
+	}
+
+	var result []*models.Transaction
+	for _, tx := range transactions {
+		keep := true
+		for _, filter := range filters {
+			if !filter(tx) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			result = append(result, tx)
+		}
+	}
+	return result
+}
+
+// Total returns the sum of all amounts, net of refunds
+func Total(transactions []*models.Transaction) float64 {
+	total := 0.0
+	for _, tx := range transactions {
+		total += tx.Amount
+	}
+	return total
+}
+
+// Split returns the gross spend, the sum of the charges, and the refunds,
+// as a positive amount. Their difference is the Total.
+func Split(transactions []*models.Transaction) (gross, refunds float64) {
+	for _, tx := range transactions {
+		if tx.Amount < 0 {
+			refunds -= tx.Amount
+		} else {
+			gross += tx.Amount
+		}
+	}
+	return gross, refunds
+}
+
+// GroupBy aggregates transactions by key, sorted by total (largest first)
+func GroupBy(transactions []*models.Transaction, key Key) []Group {
+	index := make(map[string]int)
+	var groups []Group
+	for _, tx := range transactions {
+		k := key(tx)
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, Group{Key: k})
+		}
+		groups[i].Total += tx.Amount
+		groups[i].Count++
+	}
+
+	if total := Total(transactions); total != 0 {
+		for i := range groups {
+			groups[i].Percent = groups[i].Total / total * 100
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Total > groups[j].Total
+	})
+
+	return groups
+}
+
+// Percentile returns the p-th percentile (0-100) of the transaction amounts
+// using linear interpolation between closest ranks
+func Percentile(transactions []*models.Transaction, p float64) float64 {
+	if len(transactions) == 0 {
+		return 0
+	}
+
+	amounts := make([]float64, len(transactions))
+	for i, tx := range transactions {
+		amounts[i] = tx.Amount
+	}
+	sort.Float64s(amounts)
+
+	rank := p / 100 * float64(len(amounts)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower < 0 {
+		return amounts[0]
+	}
+	if upper >= len(amounts) {
+		return amounts[len(amounts)-1]
+	}
+	return amounts[lower] + (amounts[upper]-amounts[lower])*(rank-float64(lower))
+}
+
+// Period returns the earliest and latest transaction dates
+func Period(transactions []*models.Transaction) (time.Time, time.Time) {
+	if len(transactions) == 0 {
+		return time.Time{}, time.Time{}
+	}
+
+	earliest, latest := transactions[0].Date, transactions[0].Date
+	for _, tx := range transactions {
+		if tx.Date.Before(earliest) {
+			earliest = tx.Date
+		}
+		if tx.Date.After(latest) {
+			latest = tx.Date
+		}
+	}
+	return earliest, latest
+}
+
+// Build computes the expense summary consumed by every renderer
+func Build(transactions []*models.Transaction) *models.ExpenseSummary {
+	s := &models.ExpenseSummary{
+		TotalAmount:    Total(transactions),
+		TotalCount:     len(transactions),
+		CurrencySymbol: "$",
+		ByCategory:     make(map[string]float64),
+		ByService:      make(map[string]float64),
+		Categories:     GroupBy(transactions, ByCategory),
+		Services:       GroupBy(transactions, ByService),
+		Currencies:     GroupBy(transactions, ByCurrency),
+	}
+
+	if len(transactions) > 0 && transactions[0].CurrencySymbol != "" {
+		s.CurrencySymbol = transactions[0].CurrencySymbol
+	}
+	if len(s.Currencies) == 1 {
+		s.Currency = s.Currencies[0].Key
+	}
+	for _, tx := range transactions {
+		if tx.Member != "" {
+			s.Members = GroupBy(transactions, ByMember)
+			break
+		}
+	}
+	for _, group := range s.Categories {
+		s.ByCategory[group.Key] = group.Total
+	}
+	for _, group := range s.Services {
+		s.ByService[group.Key] = group.Total
+	}
+	s.GrossAmount, s.RefundAmount = Split(transactions)
+	s.DateRange[0], s.DateRange[1] = Period(transactions)
+
+	return s
+}
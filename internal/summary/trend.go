@@ -0,0 +1,64 @@
+package summary
+
+import (
+	"time"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// Rolling average windows, in days
+const (
+	WeekWindow  = 7
+	MonthWindow = 30
+)
+
+// Daily returns the total spent on each day from the first to the last
+// transaction, oldest first; days without transactions are 0
+func Daily(transactions []*models.Transaction) ([]time.Time, []float64) {
+	if len(transactions) == 0 {
+		return nil, nil
+	}
+
+	totals := make(map[time.Time]float64)
+	first, last := day(transactions[0].Date), day(transactions[0].Date)
+	for _, tx := range transactions {
+		d := day(tx.Date)
+		totals[d] += tx.Amount
+		if d.Before(first) {
+			first = d
+		}
+		if d.After(last) {
+			last = d
+		}
+	}
+
+	var days []time.Time
+	var values []float64
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		days = append(days, d)
+		values = append(values, totals[d])
+	}
+	return days, values
+}
+
+// RollingAverage returns the trailing average of values over window
+// entries; the first entries average over the values seen so far
+func RollingAverage(values []float64, window int) []float64 {
+	averages := make([]float64, len(values))
+	sum := 0.0
+	for i, v := range values {
+		sum += v
+		if i >= window {
+			sum -= values[i-window]
+		}
+		averages[i] = sum / float64(min(i+1, window))
+	}
+	return averages
+}
+
+// day returns the calendar date of t, in its own location, as midnight UTC
+// so that transactions from different time zones share days
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,59 @@
+package summary
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/sazardev/go-money/internal/models"
+)
+
+// Vendor is the spending with one vendor in one currency, with what
+// bookkeeping needs to match it to invoices
+type Vendor struct {
+	Vendor         string   `json:"vendor" yaml:"vendor"`
+	Currency       string   `json:"currency" yaml:"currency"`
+	CurrencySymbol string   `json:"currency_symbol" yaml:"currency_symbol"`
+	Total          float64  `json:"total" yaml:"total"`
+	Tax            float64  `json:"tax" yaml:"tax"` // Tax shown on the receipts, included in Total
+	Count          int      `json:"count" yaml:"count"`
+	Invoices       []string `json:"invoices" yaml:"invoices"`     // Invoice numbers, oldest first
+	Uninvoiced     int      `json:"uninvoiced" yaml:"uninvoiced"` // Transactions without an invoice number
+}
+
+// Vendors totals transactions per vendor and currency, by currency and
+// then largest total first
+func Vendors(transactions []*models.Transaction) []Vendor {
+	sorted := make([]*models.Transaction, len(transactions))
+	copy(sorted, transactions)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+
+	index := make(map[[2]string]int)
+	var vendors []Vendor
+	for _, tx := range sorted {
+		key := [2]string{tx.ServiceName, tx.Currency}
+		i, ok := index[key]
+		if !ok {
+			i = len(vendors)
+			index[key] = i
+			vendors = append(vendors, Vendor{Vendor: tx.ServiceName, Currency: tx.Currency, CurrencySymbol: tx.CurrencySymbol, Invoices: []string{}})
+		}
+		v := &vendors[i]
+		v.Total += tx.Amount
+		v.Tax += tx.Tax
+		v.Count++
+		switch {
+		case tx.InvoiceNumber == "":
+			v.Uninvoiced++
+		case !slices.Contains(v.Invoices, tx.InvoiceNumber):
+			v.Invoices = append(v.Invoices, tx.InvoiceNumber)
+		}
+	}
+
+	sort.SliceStable(vendors, func(i, j int) bool {
+		if vendors[i].Currency != vendors[j].Currency {
+			return vendors[i].Currency < vendors[j].Currency
+		}
+		return vendors[i].Total > vendors[j].Total
+	})
+	return vendors
+}
@@ -0,0 +1,190 @@
+package verify
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/sazardev/go-money/internal/categories"
+	"github.com/sazardev/go-money/internal/extractor"
+	"github.com/sazardev/go-money/internal/models"
+	"github.com/sazardev/go-money/internal/store"
+)
+
+// Kinds of issues
+const (
+	KindOrphan    = "orphan-correction" // Category or tags for a transaction that isn't stored
+	KindDuplicate = "duplicate"         // Same charge stored under several IDs
+	KindCurrency  = "currency"          // Unknown currency or mismatched symbol
+	KindAmount    = "amount"            // Amount that can't be a charge
+	KindSchema    = "schema"            // Store columns differ from what this version expects
+)
+
+// Issue is one problem found in the store or the category overrides
+type Issue struct {
+	Kind          string `json:"kind" yaml:"kind"`
+	TransactionID string `json:"transaction_id,omitempty" yaml:"transaction_id,omitempty"`
+	Message       string `json:"message" yaml:"message"`
+	Repairable    bool   `json:"repairable" yaml:"repairable"`
+}
+
+// Report lists the issues found by Check
+type Report struct {
+	Transactions int     `json:"transactions" yaml:"transactions"`
+	Issues       []Issue `json:"issues" yaml:"issues"`
+}
+
+// Check looks for orphan corrections, duplicate transactions and currency
+// and amount inconsistencies, after the given schema issues
+func Check(transactions []*models.Transaction, overrides *categories.Overrides, schema []store.SchemaIssue) *Report {
+	report := &Report{Transactions: len(transactions), Issues: []Issue{}}
+	for _, problem := range schema {
+		// Opening the store for writing adds missing tables and columns
+		report.Issues = append(report.Issues, Issue{Kind: KindSchema, Message: problem.Message, Repairable: problem.Missing})
+	}
+	report.Issues = append(report.Issues, orphans(transactions, overrides)...)
+	report.Issues = append(report.Issues, duplicates(transactions)...)
+	for _, tx := range transactions {
+		report.Issues = append(report.Issues, inconsistencies(tx)...)
+	}
+	return report
+}
+
+// Repairable counts the issues Repair can fix
+func (r *Report) Repairable() int {
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Repairable {
+			n++
+		}
+	}
+	return n
+}
+
+// Repair fixes what it can: it drops orphan corrections from overrides and
+// corrects currency symbols in transactions. It returns the transactions
+// that changed, which need saving, and whether overrides changed.
+func Repair(transactions []*models.Transaction, overrides *categories.Overrides) (changed []*models.Transaction, overridesChanged bool) {
+	known := knownIDs(transactions)
+	for id := range overrides.Transactions {
+		if !known[id] {
+			delete(overrides.Transactions, id)
+			overridesChanged = true
+		}
+	}
+	for id := range overrides.Tags {
+		if !known[id] {
+			delete(overrides.Tags, id)
+			overridesChanged = true
+		}
+	}
+
+	for _, tx := range transactions {
+		if symbol, ok := extractor.CurrencySymbol(tx.Currency); ok && tx.CurrencySymbol != symbol {
+			tx.CurrencySymbol = symbol
+			changed = append(changed, tx)
+		}
+	}
+	return changed, overridesChanged
+}
+
+// knownIDs returns the IDs of stored transactions, including the follow-up
+// emails merged into them
+func knownIDs(transactions []*models.Transaction) map[string]bool {
+	known := make(map[string]bool, len(transactions))
+	for _, tx := range transactions {
+		known[tx.ID] = true
+		for _, id := range tx.RelatedIDs {
+			known[id] = true
+		}
+	}
+	return known
+}
+
+// orphans finds categories and tags set for transactions that aren't stored
+func orphans(transactions []*models.Transaction, overrides *categories.Overrides) []Issue {
+	known := knownIDs(transactions)
+
+	var ids []string
+	for id := range overrides.Transactions {
+		if !known[id] {
+			ids = append(ids, id)
+		}
+	}
+	for id := range overrides.Tags {
+		if _, categorized := overrides.Transactions[id]; !known[id] && !categorized {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	issues := make([]Issue, 0, len(ids))
+	for _, id := range ids {
+		issues = append(issues, Issue{
+			Kind:          KindOrphan,
+			TransactionID: id,
+			Message:       fmt.Sprintf("%s has a category or tags for a transaction that isn't stored", categories.DefaultFile),
+			Repairable:    true,
+		})
+	}
+	return issues
+}
+
+// duplicates finds transactions with the same service, amount, currency,
+// day and subject stored under different IDs
+func duplicates(transactions []*models.Transaction) []Issue {
+	first := make(map[string]*models.Transaction)
+	var issues []Issue
+	for _, tx := range transactions {
+		key := strings.Join([]string{
+			tx.ServiceID,
+			fmt.Sprintf("%.2f", tx.Amount),
+			tx.Currency,
+			tx.Date.Format("2006-01-02"),
+			strings.ToLower(strings.TrimSpace(tx.Subject)),
+		}, "|")
+		original, seen := first[key]
+		if !seen {
+			first[key] = tx
+			continue
+		}
+		issues = append(issues, Issue{
+			Kind:          KindDuplicate,
+			TransactionID: tx.ID,
+			Message:       fmt.Sprintf("same %s charge of %.2f %s on %s as %s", tx.ServiceName, tx.Amount, tx.Currency, tx.Date.Format("2006-01-02"), original.ID),
+		})
+	}
+	return issues
+}
+
+// inconsistencies checks a transaction's amount and currency
+func inconsistencies(tx *models.Transaction) []Issue {
+	var issues []Issue
+	// Refunds are negative, so only a zero amount is neither a charge nor a refund
+	if math.IsNaN(tx.Amount) || math.IsInf(tx.Amount, 0) || tx.Amount == 0 {
+		issues = append(issues, Issue{
+			Kind:          KindAmount,
+			TransactionID: tx.ID,
+			Message:       fmt.Sprintf("amount %v isn't a charge or a refund", tx.Amount),
+		})
+	}
+
+	symbol, known := extractor.CurrencySymbol(tx.Currency)
+	switch {
+	case !known:
+		issues = append(issues, Issue{
+			Kind:          KindCurrency,
+			TransactionID: tx.ID,
+			Message:       fmt.Sprintf("unknown currency %q", tx.Currency),
+		})
+	case tx.CurrencySymbol != symbol:
+		issues = append(issues, Issue{
+			Kind:          KindCurrency,
+			TransactionID: tx.ID,
+			Message:       fmt.Sprintf("%s amount stored with symbol %q instead of %q", tx.Currency, tx.CurrencySymbol, symbol),
+			Repairable:    true,
+		})
+	}
+	return issues
+}
@@ -4,6 +4,8 @@ import (
 	"log"
 	"os"
 	"sync"
+
+	"github.com/sazardev/go-money/pkg/redact"
 )
 
 var (
@@ -31,10 +33,10 @@ type simpleLogger struct {
 func GetLogger() Logger {
 	once.Do(func() {
 		defaultLogger = &simpleLogger{
-			infoLog:  log.New(os.Stdout, "[INFO] ", log.LstdFlags),
-			warnLog:  log.New(os.Stdout, "[WARN] ", log.LstdFlags),
-			errorLog: log.New(os.Stderr, "[ERROR] ", log.LstdFlags),
-			debugLog: log.New(os.Stdout, "[DEBUG] ", log.LstdFlags),
+			infoLog:  log.New(redact.NewWriter(os.Stdout), "[INFO] ", log.LstdFlags),
+			warnLog:  log.New(redact.NewWriter(os.Stdout), "[WARN] ", log.LstdFlags),
+			errorLog: log.New(redact.NewWriter(os.Stderr), "[ERROR] ", log.LstdFlags),
+			debugLog: log.New(redact.NewWriter(os.Stdout), "[DEBUG] ", log.LstdFlags),
 		}
 	})
 	return defaultLogger
@@ -57,7 +57,7 @@ func ParseDate(dateStr string) time.Time {
 		"Jan 02 2006",
 		"January 02, 2006",
 		time.RFC822,
-		time.RFC2822,
+		time.RFC1123Z,
 	}
 
 	for _, format := range formats {